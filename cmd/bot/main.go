@@ -3,9 +3,13 @@ package main
 import (
 	"context"
 	"fmt"
+	"got/internal/alert"
 	"got/internal/app"
 	"got/internal/app/model"
 	"got/internal/groq"
+	"got/internal/imagegen"
+	"got/internal/llm"
+	"got/internal/moderation"
 	"got/internal/redis"
 	"got/internal/repository/postgres"
 	"got/internal/scheduler"
@@ -13,6 +17,7 @@ import (
 	"got/internal/tts"
 	"got/pkg/config"
 	"got/pkg/i18n"
+	"got/pkg/logger"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -21,74 +26,119 @@ import (
 
 func main() {
 	cfg := config.Load()
+	logger.Setup(logger.Options{
+		Format:   cfg.Log.Format,
+		Level:    cfg.Log.Level,
+		Packages: cfg.Log.Packages,
+	})
+
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
 	dbPool, err := postgres.NewDB(ctx, cfg.DBURL)
 	if err != nil {
-		slog.Error("Failed to connect to database", "error", err)
+		slog.ErrorContext(ctx, "Failed to connect to database", "error", err)
 		os.Exit(1)
 	}
 	defer dbPool.Close()
 
-	chatRepo := postgres.NewChatRepository(dbPool)
-	userRepo := postgres.NewUserRepository(dbPool)
-	reminderRepo := postgres.NewReminderRepository(dbPool)
-	factRepo := postgres.NewFactRepository(dbPool)
-	stickerRepo := postgres.NewStickerRepository(dbPool)
-	subredditRepo := postgres.NewSubredditRepository(dbPool)
-	statRepo := postgres.NewStatRepository(dbPool)
-
-	svc := app.NewService(chatRepo, userRepo, reminderRepo, factRepo, stickerRepo, subredditRepo, statRepo)
+	svc := app.NewService(app.Repositories{
+		Chats:      postgres.NewChatRepository(dbPool),
+		Users:      postgres.NewUserRepository(dbPool),
+		Reminders:  postgres.NewReminderRepository(dbPool),
+		Facts:      postgres.NewFactRepository(dbPool),
+		Stickers:   postgres.NewStickerRepository(dbPool),
+		Subreddits: postgres.NewSubredditRepository(dbPool),
+		Stats:      postgres.NewStatRepository(dbPool),
+		Bans:       postgres.NewBanRepository(dbPool),
+		Usage:      postgres.NewUsageRepository(dbPool),
+		Tokens:     postgres.NewTokenRepository(dbPool),
+		Messages:   postgres.NewMessageRepository(dbPool),
+		Moderation: postgres.NewModerationRepository(dbPool),
+	})
+	svc.SetDefaultTokenQuota(model.QuotaTargetChat, cfg.Quotas.Chat.Daily, cfg.Quotas.Chat.Monthly)
+	svc.SetDefaultTokenQuota(model.QuotaTargetUser, cfg.Quotas.User.Daily, cfg.Quotas.User.Monthly)
+	svc.SetMessageLogPolicy(cfg.MessageLog.Retention, cfg.MessageLog.MaxPerChat)
+	svc.SetLurkerDefaults(cfg.Lurker.Chance, cfg.Lurker.Cooldown)
 
 	client := telegram.NewClient(cfg.BotToken)
 	translator := i18n.New(cfg.Bot.Language)
 
-	var gptClient *groq.Client
-	if cfg.GptKey != "" {
-		gptClient = groq.NewClient(cfg.GptKey)
-	}
-
 	var redisClient *redis.Client
 	if cfg.RedisAddr != "" {
 		redisClient = redis.NewClient(cfg.RedisAddr)
 	}
 
+	llmRegistry := newLLMRegistry(ctx, cfg)
+	if llmRegistry != nil {
+		var modelCache llm.ModelCache
+		if redisClient != nil {
+			modelCache = redisClient
+		}
+		llmRegistry.SetModelCache(modelCache, cfg.LLM.ModelsTTL)
+	}
+
 	ttsClient := tts.NewClient()
 
+	var reporter *alert.Reporter
+	if cfg.Alerts.ChatID != 0 {
+		reporter = alert.NewReporter(client, cfg.Alerts.ChatID, cfg.Alerts.DedupWindow)
+	}
+
+	menu := telegram.NewCommandMenu(client, cfg, translator.Lang())
+
 	router := telegram.NewRouter()
-	handlers := telegram.NewBotHandlers(client, svc, gptClient, redisClient, translator, ttsClient, &cfg.Commands, cfg.AdminPass)
+	handlers := telegram.NewBotHandlers(client, svc, llmRegistry, redisClient, translator, ttsClient, &cfg.Commands, cfg.AdminPass, menu)
+	if moderator := newModerator(ctx, cfg, llmRegistry); moderator != nil {
+		handlers.SetModerator(moderator)
+	}
+	handlers.SetImageGenerator(newImageChain(ctx, cfg))
+	handlers.SetLurker(telegram.NewLurker(cfg.Lurker.Context))
 
 	cmds := &cfg.Commands
-	registerCommand(router, cfg, cmds.Start, telegram.WithRecover(telegram.WithLogging(handlers.HandleStart)))
-	registerCommand(router, cfg, cmds.Help, telegram.WithRecover(telegram.WithLogging(handlers.HandleHelp)))
-	registerCommand(router, cfg, cmds.Gpt, telegram.WithRecover(telegram.WithLogging(handlers.HandleGPT)))
-	registerCommand(router, cfg, cmds.Remind, telegram.WithRecover(telegram.WithLogging(handlers.HandleRemind)))
-	registerCommand(router, cfg, cmds.Meme, telegram.WithRecover(telegram.WithLogging(handlers.HandleMeme)))
-	registerCommand(router, cfg, cmds.Sticker, telegram.WithRecover(telegram.WithLogging(handlers.HandleSticker)))
-	registerCommand(router, cfg, cmds.Fact, telegram.WithRecover(telegram.WithLogging(handlers.HandleFact)))
-	registerCommand(router, cfg, cmds.Roulette, telegram.WithRecover(telegram.WithLogging(handlers.HandleRoulette)))
-	registerCommand(router, cfg, cmds.Tts, telegram.WithRecover(telegram.WithLogging(handlers.HandleTTS)))
-	registerCommand(router, cfg, cmds.Admin, telegram.WithRecover(telegram.WithLogging(handlers.HandleAdmin)))
-	registerCommand(router, cfg, cmds.Lang, telegram.WithRecover(telegram.WithLogging(handlers.HandleLang)))
+	recoverMw := telegram.WithReportedRecover(reporter)
+	usageMw := telegram.WithUsageTracking(svc)
+	registerCommand(router, cfg, cmds.Start, recoverMw(usageMw(telegram.WithLogging(handlers.HandleStart))))
+	registerCommand(router, cfg, cmds.Help, recoverMw(usageMw(telegram.WithLogging(handlers.HandleHelp))))
+	registerCommand(router, cfg, cmds.Gpt, recoverMw(usageMw(telegram.WithLogging(handlers.HandleGPT))))
+	registerCommand(router, cfg, cmds.Remind, recoverMw(usageMw(telegram.WithLogging(handlers.HandleRemind))))
+	registerCommand(router, cfg, cmds.Meme, recoverMw(usageMw(telegram.WithLogging(handlers.HandleMeme))))
+	registerCommand(router, cfg, cmds.Sticker, recoverMw(usageMw(telegram.WithLogging(handlers.HandleSticker))))
+	registerCommand(router, cfg, cmds.Fact, recoverMw(usageMw(telegram.WithLogging(handlers.HandleFact))))
+	registerCommand(router, cfg, cmds.Roulette, recoverMw(usageMw(telegram.WithLogging(handlers.HandleRoulette))))
+	registerCommand(router, cfg, cmds.Tts, recoverMw(usageMw(telegram.WithLogging(handlers.HandleTTS))))
+	registerCommand(router, cfg, cmds.Transcribe, recoverMw(usageMw(telegram.WithLogging(handlers.HandleTranscribe))))
+	registerCommand(router, cfg, cmds.Translate, recoverMw(usageMw(telegram.WithLogging(handlers.HandleTranslate))))
+	registerCommand(router, cfg, cmds.Assistant, recoverMw(usageMw(telegram.WithLogging(handlers.HandleAssistant))))
+	registerCommand(router, cfg, cmds.Admin, recoverMw(usageMw(telegram.WithLogging(handlers.HandleAdmin))))
+	registerCommand(router, cfg, cmds.Lang, recoverMw(usageMw(telegram.WithLogging(handlers.HandleLang))))
+	registerCommand(router, cfg, cmds.Usage, recoverMw(usageMw(telegram.WithLogging(handlers.HandleUsage))))
+	router.SetFallback(recoverMw(handlers.HandleMessage))
+	router.SetCallbackHandler(recoverMw(handlers.HandleCallback))
 
 	autoRegister := telegram.NewAutoRegisterMiddleware(svc, router)
-	bot := telegram.NewBot(client, autoRegister)
+	banFilter := telegram.NewBanFilterMiddleware(svc, autoRegister)
+	bot := telegram.NewBot(client, banFilter, reporter)
 
 	sentences := telegram.NewSentenceProvider()
 
-	registerBotCommands(client, cfg, translator, &cfg.Commands)
-	sched := startScheduler(cfg, svc, client, translator, sentences)
+	go registerCommandMenus(ctx, menu, svc)
+	sched := startScheduler(cfg, svc, client, translator, sentences, reporter)
 	defer sched.Stop()
 
 	go runReminderChecker(ctx, svc, client, translator)
 
-	slog.Info("Bot started", "language", translator.Lang())
+	slog.InfoContext(ctx, "Bot started", "language", translator.Lang())
 	bot.Start(ctx)
 }
 
-func startScheduler(cfg *config.Config, svc *app.Service, client *telegram.Client, t *i18n.Translator, sentences *telegram.SentenceProvider) *scheduler.Scheduler {
+func startScheduler(cfg *config.Config, svc *app.Service, client *telegram.Client, t *i18n.Translator, sentences *telegram.SentenceProvider, reporter *alert.Reporter) *scheduler.Scheduler {
 	sched := scheduler.New()
+	if reporter != nil {
+		sched.OnError(func(ctx context.Context, job string, err error) {
+			reporter.Report(ctx, alert.Report{Source: alert.SourceJob, Command: job, Err: err})
+		})
+	}
 
 	_ = sched.Register(scheduler.Job{
 		Name:     "reset_daily_winners",
@@ -102,6 +152,12 @@ func startScheduler(cfg *config.Config, svc *app.Service, client *telegram.Clien
 		Func:     autoRouletteJob(svc, client, t, sentences, cfg.Commands.Roulette),
 	})
 
+	_ = sched.Register(scheduler.Job{
+		Name:     "prune_message_log",
+		Schedule: cfg.Schedule.PruneMessages,
+		Func:     svc.PruneMessageLog,
+	})
+
 	sched.Start()
 	return sched
 }
@@ -132,8 +188,8 @@ func autoRouletteJob(svc *app.Service, client *telegram.Client, defaultT *i18n.T
 			}
 			winnerName := formatUserLink(r.Winner.User)
 			fallbackMsg := fmt.Sprintf(t.Get(i18n.KeyRouletteAutoWinner), cmdName, winnerName)
-			if err := sentences.SendSequence(client, r.ChatID, lang, cmdName, winnerName, fallbackMsg); err != nil {
-				slog.Error("Failed to send auto roulette result", "chat", r.ChatID, "error", err)
+			if err := sentences.SendSequence(ctx, client, r.ChatID, lang, cmdName, winnerName, fallbackMsg); err != nil {
+				slog.ErrorContext(ctx, "Failed to send auto roulette result", "chat", r.ChatID, "error", err)
 			}
 		}
 
@@ -158,7 +214,7 @@ func runReminderChecker(ctx context.Context, svc *app.Service, client *telegram.
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			checkReminders(ctx, svc, client, t)
+			checkReminders(logger.WithCorrelationID(ctx, logger.NewCorrelationID()), svc, client, t)
 		}
 	}
 }
@@ -166,16 +222,94 @@ func runReminderChecker(ctx context.Context, svc *app.Service, client *telegram.
 func checkReminders(ctx context.Context, svc *app.Service, client *telegram.Client, t *i18n.Translator) {
 	reminders, err := svc.CheckReminders(ctx)
 	if err != nil {
-		slog.Error("Failed to check reminders", "error", err)
+		slog.ErrorContext(ctx, "Failed to check reminders", "error", err)
 		return
 	}
 
 	for _, r := range reminders {
 		msg := fmt.Sprintf(t.Get(i18n.KeyReminderNotify), r.Message)
-		if err := client.SendMessage(r.Chat.ChatID, msg); err != nil {
-			slog.Error("Failed to send reminder", "id", r.ReminderID, "error", err)
+		if err := client.SendMessage(ctx, r.Chat.ChatID, msg); err != nil {
+			slog.ErrorContext(ctx, "Failed to send reminder", "id", r.ReminderID, "error", err)
+		}
+	}
+}
+
+func newLLMRegistry(ctx context.Context, cfg *config.Config) *llm.Registry {
+	retry := groq.RetryPolicy{
+		MaxAttempts: cfg.LLM.MaxAttempts,
+		BaseDelay:   cfg.LLM.RetryDelay,
+		MaxDelay:    16 * cfg.LLM.RetryDelay,
+	}
+
+	registry := llm.NewRegistry()
+	if cfg.GptKey != "" {
+		client := groq.NewClient(cfg.GptKey)
+		client.SetRetryPolicy(retry)
+		registry.Add(client)
+	}
+	for _, p := range cfg.LLM.Providers {
+		if p.Name == "" || p.BaseURL == "" {
+			slog.WarnContext(ctx, "Skipping LLM provider without name or base URL", "name", p.Name)
+			continue
 		}
+		client := groq.NewCompatibleClient(p.Name, p.BaseURL, p.APIKey, p.Model, p.Models)
+		client.SetRetryPolicy(retry)
+		client.SetVisionModel(p.VisionModel)
+		client.SetTranscriptionModel(p.AudioModel)
+		registry.Add(client)
+	}
+	registry.SetFallbacks(cfg.LLM.Fallbacks)
+
+	if registry.Len() == 0 {
+		return nil
 	}
+	if cfg.LLM.DefaultProvider != "" {
+		if err := registry.SetDefault(cfg.LLM.DefaultProvider); err != nil {
+			slog.WarnContext(ctx, "Invalid default LLM provider, using first configured", "error", err)
+		}
+	}
+	return registry
+}
+
+func newImageChain(ctx context.Context, cfg *config.Config) *imagegen.Chain {
+	var generators []imagegen.Generator
+	for _, p := range cfg.Image.Providers {
+		switch {
+		case p.Name == imagegen.PollinationsName:
+			generators = append(generators, imagegen.NewPollinations(p.BaseURL, cfg.Image.Timeout))
+		case p.Name == "" || p.BaseURL == "":
+			slog.WarnContext(ctx, "Skipping image provider without name or base URL", "name", p.Name)
+		default:
+			generators = append(generators, imagegen.NewCompatible(p.Name, p.BaseURL, p.APIKey, p.Model, cfg.Image.Timeout))
+		}
+	}
+
+	chain := imagegen.NewChain(generators...)
+	chain.SetRetryPolicy(cfg.Image.MaxAttempts, cfg.Image.RetryDelay)
+	return chain
+}
+
+func newModerator(ctx context.Context, cfg *config.Config, registry *llm.Registry) *moderation.Moderator {
+	if !cfg.Moderation.Enabled {
+		return nil
+	}
+
+	var classifier moderation.Classifier
+	if cfg.Moderation.Classifier {
+		if registry != nil {
+			classifier = moderation.NewLLMClassifier(registry, cfg.Moderation.Model)
+		} else {
+			slog.WarnContext(ctx, "Moderation classifier enabled without an LLM provider, using local rules only")
+		}
+	}
+
+	moderator, err := moderation.New(cfg.Moderation.Keywords, cfg.Moderation.Patterns, classifier)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to configure moderation", "error", err)
+		os.Exit(1)
+	}
+	moderator.SetFailOpen(cfg.Moderation.FailOpen)
+	return moderator
 }
 
 func registerCommand(router *telegram.Router, cfg *config.Config, cmd string, handler telegram.HandlerFunc) {
@@ -185,31 +319,15 @@ func registerCommand(router *telegram.Router, cfg *config.Config, cmd string, ha
 	router.Register(cmd, handler)
 }
 
-func registerBotCommands(client *telegram.Client, cfg *config.Config, t *i18n.Translator, cmds *config.CommandsConfig) {
-	allCommands := []telegram.BotCommand{
-		{Command: cmds.Start, Description: t.Get(i18n.KeyCmdStart)},
-		{Command: cmds.Help, Description: t.Get(i18n.KeyCmdHelp)},
-		{Command: cmds.Gpt, Description: t.Get(i18n.KeyCmdGpt)},
-		{Command: cmds.Remind, Description: t.Get(i18n.KeyCmdRemind)},
-		{Command: cmds.Meme, Description: t.Get(i18n.KeyCmdMeme)},
-		{Command: cmds.Sticker, Description: t.Get(i18n.KeyCmdSticker)},
-		{Command: cmds.Fact, Description: t.Get(i18n.KeyCmdFact)},
-		{Command: cmds.Roulette, Description: t.Get(i18n.KeyCmdRoulette)},
-		{Command: cmds.Tts, Description: t.Get(i18n.KeyCmdTts)},
-		{Command: cmds.Lang, Description: t.Get(i18n.KeyCmdLang)},
-	}
-
-	var commands []telegram.BotCommand
-	for _, cmd := range allCommands {
-		if !cfg.IsDisabled(cmd.Command) {
-			commands = append(commands, cmd)
-		}
+func registerCommandMenus(ctx context.Context, menu *telegram.CommandMenu, svc *app.Service) {
+	if err := menu.Register(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to register bot commands", "error", err)
 	}
 
-	if err := client.SetMyCommands(commands); err != nil {
-		slog.Error("Failed to register bot commands", "error", err)
+	chats, err := svc.ListChats(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list chats for command menus", "error", err)
 		return
 	}
-
-	slog.Info("Bot commands registered", "count", len(commands))
+	menu.SyncChats(ctx, chats)
 }
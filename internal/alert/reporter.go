@@ -0,0 +1,183 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"got/pkg/logger"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	SourceUpdate = "update"
+	SourcePanic  = "panic"
+	SourceJob    = "job"
+
+	defaultWindow = 10 * time.Minute
+	maxStackLines = 40
+	maxErrorLen   = 1000
+	maxFieldLen   = 200
+	maxMessageLen = 4000
+	codeFence     = "\n```\n"
+)
+
+type Sender interface {
+	SendMessage(ctx context.Context, chatID int64, text string) error
+}
+
+type Report struct {
+	Source   string
+	Command  string
+	ChatID   int64
+	UserID   int64
+	Username string
+	Err      error
+	Stack    []byte
+
+	CorrelationID string
+}
+
+type Reporter struct {
+	sender Sender
+	chatID int64
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]*entry
+}
+
+type entry struct {
+	sentAt     time.Time
+	suppressed int
+}
+
+var log = logger.For("alert")
+
+func NewReporter(sender Sender, chatID int64, window time.Duration) *Reporter {
+	if window <= 0 {
+		window = defaultWindow
+	}
+	return &Reporter{
+		sender: sender,
+		chatID: chatID,
+		window: window,
+		now:    time.Now,
+		seen:   make(map[string]*entry),
+	}
+}
+
+func (r *Reporter) Report(ctx context.Context, report Report) {
+	if report.CorrelationID == "" {
+		report.CorrelationID = logger.CorrelationID(ctx)
+	}
+
+	suppressed, ok := r.admit(report.fingerprint())
+	if !ok {
+		log.DebugContext(ctx, "Duplicate error report suppressed", "source", report.Source, "command", report.Command)
+		return
+	}
+
+	if err := r.sender.SendMessage(ctx, r.chatID, report.format(suppressed)); err != nil {
+		log.ErrorContext(ctx, "Failed to send error report", "chat", r.chatID, "error", err)
+	}
+}
+
+func (r *Reporter) admit(key string) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if e, ok := r.seen[key]; ok && now.Sub(e.sentAt) < r.window {
+		e.suppressed++
+		return 0, false
+	}
+
+	suppressed := 0
+	if e, ok := r.seen[key]; ok {
+		suppressed = e.suppressed
+	}
+	r.seen[key] = &entry{sentAt: now}
+	r.evict(now)
+
+	return suppressed, true
+}
+
+func (r *Reporter) evict(now time.Time) {
+	for key, e := range r.seen {
+		age := now.Sub(e.sentAt)
+		if age >= r.window && (e.suppressed == 0 || age >= 2*r.window) {
+			delete(r.seen, key)
+		}
+	}
+}
+
+func (rep Report) fingerprint() string {
+	errText := ""
+	if rep.Err != nil {
+		errText = rep.Err.Error()
+	}
+	return rep.Source + "|" + rep.Command + "|" + errText
+}
+
+func (rep Report) format(suppressed int) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚠️ *Error report* (%s)\n\n", rep.Source))
+
+	if rep.Command != "" {
+		sb.WriteString(fmt.Sprintf("Command: `%s`\n", truncate(sanitize(rep.Command), maxFieldLen)))
+	}
+	if rep.ChatID != 0 {
+		sb.WriteString(fmt.Sprintf("Chat: `%d`\n", rep.ChatID))
+	}
+	if rep.UserID != 0 {
+		sb.WriteString(fmt.Sprintf("User: `%s` (`%d`)\n", truncate(sanitize(rep.Username), maxFieldLen), rep.UserID))
+	}
+	if rep.CorrelationID != "" {
+		sb.WriteString(fmt.Sprintf("Correlation ID: `%s`\n", truncate(sanitize(rep.CorrelationID), maxFieldLen)))
+	}
+	if suppressed > 0 {
+		sb.WriteString(fmt.Sprintf("Suppressed duplicates: %d\n", suppressed))
+	}
+
+	errText := "unknown error"
+	if rep.Err != nil {
+		errText = rep.Err.Error()
+	}
+	sb.WriteString(codeBlock(truncate(sanitize(errText), maxErrorLen)))
+
+	if len(rep.Stack) > 0 {
+		budget := maxMessageLen - utf8.RuneCountInString(sb.String()) - 2*utf8.RuneCountInString(codeFence)
+		if budget > 0 {
+			sb.WriteString(codeBlock(truncate(trimStack(sanitize(string(rep.Stack))), budget)))
+		}
+	}
+
+	return sb.String()
+}
+
+func codeBlock(text string) string {
+	return codeFence + text + strings.TrimSuffix(codeFence, "\n")
+}
+
+func trimStack(stack string) string {
+	lines := strings.Split(strings.TrimSpace(stack), "\n")
+	if len(lines) > maxStackLines {
+		lines = append(lines[:maxStackLines], "...")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sanitize(s string) string {
+	return strings.ReplaceAll(s, "`", "'")
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,134 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockSender struct {
+	chatIDs  []int64
+	messages []string
+	err      error
+}
+
+func (m *mockSender) SendMessage(ctx context.Context, chatID int64, text string) error {
+	m.chatIDs = append(m.chatIDs, chatID)
+	m.messages = append(m.messages, text)
+	return m.err
+}
+
+func TestReporterReport(t *testing.T) {
+	sender := &mockSender{}
+	reporter := NewReporter(sender, -100, time.Minute)
+
+	reporter.Report(context.Background(), Report{
+		Source:   SourcePanic,
+		Command:  "gpt",
+		ChatID:   123,
+		UserID:   456,
+		Username: "test_user",
+		Err:      errors.New("boom"),
+		Stack:    []byte("goroutine 1 [running]:\nmain.main()"),
+	})
+
+	if len(sender.messages) != 1 {
+		t.Fatalf("want 1 message, got %d", len(sender.messages))
+	}
+	if sender.chatIDs[0] != -100 {
+		t.Errorf("want chat ID -100, got %d", sender.chatIDs[0])
+	}
+
+	msg := sender.messages[0]
+	for _, want := range []string{"panic", "`gpt`", "`123`", "`test_user`", "`456`", "boom", "main.main()"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("report should contain %q, got: %s", want, msg)
+		}
+	}
+}
+
+func TestReporterSuppressesDuplicates(t *testing.T) {
+	sender := &mockSender{}
+	reporter := NewReporter(sender, -100, time.Minute)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+
+	report := Report{Source: SourceUpdate, Command: "meme", Err: errors.New("timeout")}
+
+	reporter.Report(context.Background(), report)
+	reporter.Report(context.Background(), report)
+	reporter.Report(context.Background(), report)
+
+	if len(sender.messages) != 1 {
+		t.Fatalf("want 1 message within window, got %d", len(sender.messages))
+	}
+
+	reporter.Report(context.Background(), Report{Source: SourceUpdate, Command: "fact", Err: errors.New("timeout")})
+	if len(sender.messages) != 2 {
+		t.Fatalf("different command should not be suppressed, got %d messages", len(sender.messages))
+	}
+
+	now = now.Add(2 * time.Minute)
+	reporter.Report(context.Background(), report)
+
+	if len(sender.messages) != 3 {
+		t.Fatalf("want report after window expires, got %d messages", len(sender.messages))
+	}
+	if !strings.Contains(sender.messages[2], "Suppressed duplicates: 2") {
+		t.Errorf("report should mention suppressed duplicates, got: %s", sender.messages[2])
+	}
+}
+
+func TestReportFormatSanitizesAndTruncates(t *testing.T) {
+	stack := strings.Repeat("frame `x`\n", maxStackLines*2)
+	msg := Report{Source: SourceJob, Command: "auto_roulette", Err: errors.New("bad `input`"), Stack: []byte(stack)}.format(0)
+
+	if strings.Contains(msg, "`input`") || strings.Contains(msg, "`x`") {
+		t.Errorf("backticks in content should be sanitized, got: %s", msg)
+	}
+	if strings.Count(msg, "frame") != maxStackLines {
+		t.Errorf("want %d stack lines, got %d", maxStackLines, strings.Count(msg, "frame"))
+	}
+	if len([]rune(msg)) > maxMessageLen {
+		t.Errorf("message length %d exceeds limit %d", len([]rune(msg)), maxMessageLen)
+	}
+}
+
+func TestReportFormatKeepsFencesClosed(t *testing.T) {
+	stack := strings.Repeat(strings.Repeat("x", 300)+"\n", maxStackLines)
+	msg := Report{Source: SourcePanic, Err: errors.New(strings.Repeat("e", maxErrorLen*2)), Stack: []byte(stack)}.format(0)
+
+	if n := len([]rune(msg)); n > maxMessageLen {
+		t.Errorf("message length %d exceeds limit %d", n, maxMessageLen)
+	}
+	if strings.Count(msg, "```")%2 != 0 || !strings.HasSuffix(msg, "```") {
+		t.Errorf("code fences should stay balanced and closed, got tail %q", msg[len(msg)-20:])
+	}
+}
+
+func TestReporterEvictsSuppressedEntries(t *testing.T) {
+	reporter := NewReporter(&mockSender{}, -100, time.Minute)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return now }
+
+	report := Report{Source: SourceUpdate, Command: "meme", Err: errors.New("timeout")}
+	reporter.Report(context.Background(), report)
+	reporter.Report(context.Background(), report)
+
+	now = now.Add(3 * time.Minute)
+	reporter.Report(context.Background(), Report{Source: SourceUpdate, Command: "fact", Err: errors.New("timeout")})
+
+	if _, ok := reporter.seen[report.fingerprint()]; ok || len(reporter.seen) != 1 {
+		t.Errorf("seen = %d entries, want the stale suppressed entry evicted", len(reporter.seen))
+	}
+}
+
+func TestNewReporterDefaultWindow(t *testing.T) {
+	reporter := NewReporter(&mockSender{}, 1, 0)
+	if reporter.window != defaultWindow {
+		t.Errorf("want default window %v, got %v", defaultWindow, reporter.window)
+	}
+}
@@ -0,0 +1,21 @@
+package app
+
+import "context"
+
+func (s *Service) IsAssistantMode(ctx context.Context, chatID int64) (bool, error) {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return false, err
+	}
+	return settings.AssistantMode, nil
+}
+
+func (s *Service) SetAssistantMode(ctx context.Context, chatID int64, enabled bool) error {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	settings.AssistantMode = enabled
+	return s.chats.SaveSettings(ctx, settings)
+}
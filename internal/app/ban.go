@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"got/internal/app/model"
+	"time"
+)
+
+func (s *Service) Ban(ctx context.Context, targetType string, targetID int64, reason string, bannedBy int64, duration time.Duration) (*model.Ban, error) {
+	if targetType != model.BanTargetUser && targetType != model.BanTargetChat {
+		return nil, fmt.Errorf("invalid ban target: %s", targetType)
+	}
+
+	ban := &model.Ban{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		BannedBy:   bannedBy,
+		CreatedAt:  time.Now(),
+	}
+	if duration > 0 {
+		expiresAt := ban.CreatedAt.Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	if err := s.bans.Save(ctx, ban); err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
+
+func (s *Service) Unban(ctx context.Context, targetType string, targetID int64) error {
+	return s.bans.Delete(ctx, targetType, targetID)
+}
+
+func (s *Service) IsBlocked(ctx context.Context, userID, chatID int64) (bool, error) {
+	ban, err := s.bans.FindActive(ctx, userID, chatID)
+	if err != nil {
+		return false, err
+	}
+	return ban != nil, nil
+}
+
+func (s *Service) ListBans(ctx context.Context) ([]*model.Ban, error) {
+	return s.bans.ListActive(ctx)
+}
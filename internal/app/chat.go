@@ -20,3 +20,7 @@ func (s *Service) SetChatLanguage(ctx context.Context, chatID int64, language st
 func (s *Service) GetChatLanguage(ctx context.Context, chatID int64) (string, error) {
 	return s.chats.GetLanguage(ctx, chatID)
 }
+
+func (s *Service) GetChatSettings(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+	return s.chats.GetSettings(ctx, chatID)
+}
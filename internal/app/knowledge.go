@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"got/internal/app/model"
+	"sort"
+	"strings"
+)
+
+func (s *Service) SearchKnowledge(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || limit <= 0 {
+		return nil, nil
+	}
+
+	snippets, err := s.facts.Search(ctx, chatID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.IsMessageLogEnabled(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		messages, err := s.messages.Search(ctx, chatID, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, messages...)
+	}
+
+	sort.SliceStable(snippets, func(i, j int) bool {
+		return snippets[i].Rank > snippets[j].Rank
+	})
+	if len(snippets) > limit {
+		snippets = snippets[:limit]
+	}
+	return snippets, nil
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"got/internal/app/model"
+	"time"
+)
+
+const (
+	maxLurkerChance   = 100
+	minLurkerCooldown = time.Minute
+	maxLurkerCooldown = 7 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidLurkerChance   = errors.New("invalid lurker chance")
+	ErrInvalidLurkerCooldown = errors.New("invalid lurker cooldown")
+)
+
+func (s *Service) SetLurkerDefaults(chance int, cooldown time.Duration) {
+	s.lurkerDefaults = model.LurkerSettings{Chance: chance, Cooldown: cooldown}
+}
+
+func (s *Service) GetLurkerSettings(ctx context.Context, chatID int64) (model.LurkerSettings, error) {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return model.LurkerSettings{}, err
+	}
+	return s.LurkerSettings(settings), nil
+}
+
+func (s *Service) LurkerSettings(settings *model.ChatSettings) model.LurkerSettings {
+	lurker := model.LurkerSettings{
+		Enabled:    settings.Lurker,
+		Chance:     settings.LurkerChance,
+		Cooldown:   settings.LurkerCooldown,
+		QuietUntil: settings.LurkerQuietUntil,
+	}
+	if lurker.Chance <= 0 {
+		lurker.Chance = s.lurkerDefaults.Chance
+	}
+	if lurker.Cooldown <= 0 {
+		lurker.Cooldown = s.lurkerDefaults.Cooldown
+	}
+	return lurker
+}
+
+func (s *Service) SetLurker(ctx context.Context, chatID int64, enabled bool) error {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	settings.Lurker = enabled
+	if !enabled {
+		settings.LurkerQuietUntil = time.Time{}
+	}
+	return s.chats.SaveSettings(ctx, settings)
+}
+
+func (s *Service) SetLurkerChance(ctx context.Context, chatID int64, chance int) error {
+	if chance <= 0 || chance > maxLurkerChance {
+		return ErrInvalidLurkerChance
+	}
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	settings.LurkerChance = chance
+	return s.chats.SaveSettings(ctx, settings)
+}
+
+func (s *Service) SetLurkerCooldown(ctx context.Context, chatID int64, cooldown time.Duration) error {
+	if cooldown < minLurkerCooldown || cooldown > maxLurkerCooldown {
+		return ErrInvalidLurkerCooldown
+	}
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	settings.LurkerCooldown = cooldown
+	return s.chats.SaveSettings(ctx, settings)
+}
+
+func (s *Service) SetLurkerQuiet(ctx context.Context, chatID int64, until time.Time) error {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	settings.LurkerQuietUntil = until
+	return s.chats.SaveSettings(ctx, settings)
+}
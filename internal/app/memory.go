@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"got/internal/app/model"
+)
+
+var ErrInvalidMemoryScope = errors.New("invalid memory scope")
+
+var memoryScopes = map[string]bool{
+	model.MemoryScopeChat:   true,
+	model.MemoryScopeUser:   true,
+	model.MemoryScopeThread: true,
+}
+
+func (s *Service) GetMemoryScope(ctx context.Context, chatID int64) (string, error) {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return model.MemoryScopeChat, err
+	}
+	if !memoryScopes[settings.MemoryScope] {
+		return model.MemoryScopeChat, nil
+	}
+	return settings.MemoryScope, nil
+}
+
+func (s *Service) SetMemoryScope(ctx context.Context, chatID int64, scope string) error {
+	if !memoryScopes[scope] {
+		return ErrInvalidMemoryScope
+	}
+
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	settings.MemoryScope = scope
+	return s.chats.SaveSettings(ctx, settings)
+}
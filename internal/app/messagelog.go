@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"got/internal/app/model"
+	"time"
+)
+
+type messageLogPolicy struct {
+	retention  time.Duration
+	maxPerChat int
+}
+
+func (s *Service) SetMessageLogPolicy(retention time.Duration, maxPerChat int) {
+	s.logPolicy = messageLogPolicy{retention: retention, maxPerChat: maxPerChat}
+}
+
+func (s *Service) IsMessageLogEnabled(ctx context.Context, chatID int64) (bool, error) {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return false, err
+	}
+	return settings.MessageLog, nil
+}
+
+func (s *Service) SetMessageLog(ctx context.Context, chatID int64, enabled bool) error {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	settings.MessageLog = enabled
+	if err := s.chats.SaveSettings(ctx, settings); err != nil {
+		return err
+	}
+	if enabled {
+		return nil
+	}
+
+	deleted, err := s.messages.DeleteByChat(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	log.InfoContext(ctx, "Message log disabled, deleted stored messages", "chat_id", chatID, "deleted", deleted)
+	return nil
+}
+
+func (s *Service) LogMessage(ctx context.Context, settings *model.ChatSettings, msg *model.ChatMessage) error {
+	if !settings.MessageLog {
+		return nil
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	return s.messages.Save(ctx, msg)
+}
+
+func (s *Service) RecentMessages(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.ChatMessage, error) {
+	return s.messages.ListRecent(ctx, chatID, since, limit)
+}
+
+func (s *Service) PruneMessageLog(ctx context.Context) error {
+	if s.logPolicy.retention <= 0 || s.logPolicy.maxPerChat <= 0 {
+		return nil
+	}
+
+	deleted, err := s.messages.Prune(ctx, time.Now().Add(-s.logPolicy.retention), s.logPolicy.maxPerChat)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.InfoContext(ctx, "Pruned message log", "deleted", deleted)
+	}
+	return nil
+}
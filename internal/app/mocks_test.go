@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	"got/internal/app/model"
+	"time"
 )
 
 type MockChatRepository struct {
-	SaveFunc        func(ctx context.Context, chat *model.Chat) error
-	GetFunc         func(ctx context.Context, chatID int64) (*model.Chat, error)
-	ListAllFunc     func(ctx context.Context) ([]*model.Chat, error)
-	SetLanguageFunc func(ctx context.Context, chatID int64, language string) error
-	GetLanguageFunc func(ctx context.Context, chatID int64) (string, error)
+	SaveFunc         func(ctx context.Context, chat *model.Chat) error
+	GetFunc          func(ctx context.Context, chatID int64) (*model.Chat, error)
+	ListAllFunc      func(ctx context.Context) ([]*model.Chat, error)
+	SetLanguageFunc  func(ctx context.Context, chatID int64, language string) error
+	GetLanguageFunc  func(ctx context.Context, chatID int64) (string, error)
+	GetSettingsFunc  func(ctx context.Context, chatID int64) (*model.ChatSettings, error)
+	SaveSettingsFunc func(ctx context.Context, settings *model.ChatSettings) error
 }
 
 type MockUserRepository struct {
@@ -33,6 +36,7 @@ type MockFactRepository struct {
 	SaveFunc            func(ctx context.Context, fact *model.Fact) error
 	GetRandomByChatFunc func(ctx context.Context, chatID int64) (*model.Fact, error)
 	ListByChatFunc      func(ctx context.Context, chatID int64) ([]*model.Fact, error)
+	SearchFunc          func(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error)
 }
 
 type MockStickerRepository struct {
@@ -61,8 +65,46 @@ type MockStatRepository struct {
 	UpdateFunc             func(ctx context.Context, statID int64, score int64, isWinner bool) error
 }
 
+type MockBanRepository struct {
+	SaveFunc       func(ctx context.Context, ban *model.Ban) error
+	DeleteFunc     func(ctx context.Context, targetType string, targetID int64) error
+	FindActiveFunc func(ctx context.Context, userID, chatID int64) (*model.Ban, error)
+	ListActiveFunc func(ctx context.Context) ([]*model.Ban, error)
+}
+
 var errMock = errors.New("mock error")
 
+type MockUsageRepository struct {
+	SaveFunc        func(ctx context.Context, usage *model.CommandUsage) error
+	SummaryFunc     func(ctx context.Context, chatID int64, since time.Time) (*model.UsageSummary, error)
+	TopCommandsFunc func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	TopUsersFunc    func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	TopChatsFunc    func(ctx context.Context, since time.Time, limit int) ([]*model.UsageCount, error)
+}
+
+type MockTokenRepository struct {
+	SaveFunc        func(ctx context.Context, usage *model.TokenUsage) error
+	TotalsFunc      func(ctx context.Context, targetType string, targetID int64, since time.Time) (*model.TokenTotals, error)
+	TopModelsFunc   func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	TopUsersFunc    func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	GetQuotaFunc    func(ctx context.Context, targetType string, targetID int64) (*model.TokenQuota, error)
+	SaveQuotaFunc   func(ctx context.Context, quota *model.TokenQuota) error
+	DeleteQuotaFunc func(ctx context.Context, targetType string, targetID int64) error
+}
+
+type MockMessageRepository struct {
+	SaveFunc         func(ctx context.Context, msg *model.ChatMessage) error
+	ListRecentFunc   func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.ChatMessage, error)
+	DeleteByChatFunc func(ctx context.Context, chatID int64) (int64, error)
+	PruneFunc        func(ctx context.Context, before time.Time, maxPerChat int) (int64, error)
+	SearchFunc       func(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error)
+}
+
+type MockModerationRepository struct {
+	SaveFunc       func(ctx context.Context, event *model.ModerationEvent) error
+	ListRecentFunc func(ctx context.Context, limit int) ([]*model.ModerationEvent, error)
+}
+
 func (m *MockChatRepository) Save(ctx context.Context, chat *model.Chat) error {
 	return m.SaveFunc(ctx, chat)
 }
@@ -88,6 +130,20 @@ func (m *MockChatRepository) GetLanguage(ctx context.Context, chatID int64) (str
 	return "", nil
 }
 
+func (m *MockChatRepository) GetSettings(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+	if m.GetSettingsFunc != nil {
+		return m.GetSettingsFunc(ctx, chatID)
+	}
+	return &model.ChatSettings{ChatID: chatID}, nil
+}
+
+func (m *MockChatRepository) SaveSettings(ctx context.Context, settings *model.ChatSettings) error {
+	if m.SaveSettingsFunc != nil {
+		return m.SaveSettingsFunc(ctx, settings)
+	}
+	return nil
+}
+
 func (m *MockUserRepository) Save(ctx context.Context, user *model.User) error {
 	return m.SaveFunc(ctx, user)
 }
@@ -141,6 +197,12 @@ func (m *MockFactRepository) ListByChat(ctx context.Context, chatID int64) ([]*m
 	}
 	return nil, nil
 }
+func (m *MockFactRepository) Search(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+	if m.SearchFunc != nil {
+		return m.SearchFunc(ctx, chatID, query, limit)
+	}
+	return nil, nil
+}
 
 func (m *MockStickerRepository) Save(ctx context.Context, sticker *model.Sticker) error {
 	return m.SaveFunc(ctx, sticker)
@@ -243,3 +305,164 @@ func (m *MockStatRepository) Update(ctx context.Context, statID int64, score int
 	}
 	return nil
 }
+
+func (m *MockBanRepository) Save(ctx context.Context, ban *model.Ban) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(ctx, ban)
+	}
+	return nil
+}
+
+func (m *MockBanRepository) Delete(ctx context.Context, targetType string, targetID int64) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, targetType, targetID)
+	}
+	return nil
+}
+
+func (m *MockBanRepository) FindActive(ctx context.Context, userID, chatID int64) (*model.Ban, error) {
+	if m.FindActiveFunc != nil {
+		return m.FindActiveFunc(ctx, userID, chatID)
+	}
+	return nil, nil
+}
+
+func (m *MockBanRepository) ListActive(ctx context.Context) ([]*model.Ban, error) {
+	if m.ListActiveFunc != nil {
+		return m.ListActiveFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockUsageRepository) Save(ctx context.Context, usage *model.CommandUsage) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(ctx, usage)
+	}
+	return nil
+}
+
+func (m *MockUsageRepository) Summary(ctx context.Context, chatID int64, since time.Time) (*model.UsageSummary, error) {
+	if m.SummaryFunc != nil {
+		return m.SummaryFunc(ctx, chatID, since)
+	}
+	return nil, nil
+}
+
+func (m *MockUsageRepository) TopCommands(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.TopCommandsFunc != nil {
+		return m.TopCommandsFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockUsageRepository) TopUsers(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.TopUsersFunc != nil {
+		return m.TopUsersFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockUsageRepository) TopChats(ctx context.Context, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.TopChatsFunc != nil {
+		return m.TopChatsFunc(ctx, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockTokenRepository) Save(ctx context.Context, usage *model.TokenUsage) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(ctx, usage)
+	}
+	return nil
+}
+
+func (m *MockTokenRepository) Totals(ctx context.Context, targetType string, targetID int64, since time.Time) (*model.TokenTotals, error) {
+	if m.TotalsFunc != nil {
+		return m.TotalsFunc(ctx, targetType, targetID, since)
+	}
+	return &model.TokenTotals{}, nil
+}
+
+func (m *MockTokenRepository) TopModels(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.TopModelsFunc != nil {
+		return m.TopModelsFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockTokenRepository) TopUsers(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.TopUsersFunc != nil {
+		return m.TopUsersFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockTokenRepository) GetQuota(ctx context.Context, targetType string, targetID int64) (*model.TokenQuota, error) {
+	if m.GetQuotaFunc != nil {
+		return m.GetQuotaFunc(ctx, targetType, targetID)
+	}
+	return nil, nil
+}
+
+func (m *MockTokenRepository) SaveQuota(ctx context.Context, quota *model.TokenQuota) error {
+	if m.SaveQuotaFunc != nil {
+		return m.SaveQuotaFunc(ctx, quota)
+	}
+	return nil
+}
+
+func (m *MockTokenRepository) DeleteQuota(ctx context.Context, targetType string, targetID int64) error {
+	if m.DeleteQuotaFunc != nil {
+		return m.DeleteQuotaFunc(ctx, targetType, targetID)
+	}
+	return nil
+}
+
+func (m *MockMessageRepository) Save(ctx context.Context, msg *model.ChatMessage) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(ctx, msg)
+	}
+	return nil
+}
+
+func (m *MockMessageRepository) ListRecent(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.ChatMessage, error) {
+	if m.ListRecentFunc != nil {
+		return m.ListRecentFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockMessageRepository) DeleteByChat(ctx context.Context, chatID int64) (int64, error) {
+	if m.DeleteByChatFunc != nil {
+		return m.DeleteByChatFunc(ctx, chatID)
+	}
+	return 0, nil
+}
+
+func (m *MockMessageRepository) Prune(ctx context.Context, before time.Time, maxPerChat int) (int64, error) {
+	if m.PruneFunc != nil {
+		return m.PruneFunc(ctx, before, maxPerChat)
+	}
+	return 0, nil
+}
+
+func (m *MockMessageRepository) Search(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+	if m.SearchFunc != nil {
+		return m.SearchFunc(ctx, chatID, query, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockModerationRepository) Save(ctx context.Context, event *model.ModerationEvent) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(ctx, event)
+	}
+	return nil
+}
+
+func (m *MockModerationRepository) ListRecent(ctx context.Context, limit int) ([]*model.ModerationEvent, error) {
+	if m.ListRecentFunc != nil {
+		return m.ListRecentFunc(ctx, limit)
+	}
+	return nil, nil
+}
@@ -2,6 +2,32 @@ package model
 
 import "time"
 
+const (
+	BanTargetUser = "user"
+	BanTargetChat = "chat"
+
+	QuotaTargetUser = "user"
+	QuotaTargetChat = "chat"
+
+	QuotaPeriodDay   = "day"
+	QuotaPeriodMonth = "month"
+
+	PersonaCustom = "custom"
+
+	MemoryScopeChat   = "chat"
+	MemoryScopeUser   = "user"
+	MemoryScopeThread = "thread"
+
+	SnippetSourceFact    = "fact"
+	SnippetSourceMessage = "message"
+
+	ModerationActionBlock = "block"
+	ModerationActionFlag  = "flag"
+
+	ModerationSourceGPT   = "gpt"
+	ModerationSourceImage = "image"
+)
+
 type Chat struct {
 	ChatID   int64   `json:"chat_id"`
 	ChatName string  `json:"chat_name"`
@@ -9,6 +35,33 @@ type Chat struct {
 	Users    []*User `json:"users"`
 }
 
+type ChatSettings struct {
+	ChatID           int64         `json:"chat_id"`
+	Persona          string        `json:"persona"`
+	SystemPrompt     string        `json:"system_prompt"`
+	AutoTranscribe   bool          `json:"auto_transcribe"`
+	MemoryScope      string        `json:"memory_scope"`
+	MessageLog       bool          `json:"message_log"`
+	AutoTranslate    bool          `json:"auto_translate"`
+	AssistantMode    bool          `json:"assistant_mode"`
+	Lurker           bool          `json:"lurker"`
+	LurkerChance     int           `json:"lurker_chance"`
+	LurkerCooldown   time.Duration `json:"lurker_cooldown"`
+	LurkerQuietUntil time.Time     `json:"lurker_quiet_until"`
+}
+
+type LurkerSettings struct {
+	Enabled    bool          `json:"enabled"`
+	Chance     int           `json:"chance"`
+	Cooldown   time.Duration `json:"cooldown"`
+	QuietUntil time.Time     `json:"quiet_until"`
+}
+
+type Persona struct {
+	Name   string `json:"name"`
+	Prompt string `json:"prompt"`
+}
+
 type User struct {
 	UserID   int64  `json:"user_id"`
 	Username string `json:"username"`
@@ -52,6 +105,115 @@ type Stat struct {
 	IsWinner bool  `json:"is_winner"`
 }
 
+type Ban struct {
+	BanID      int64      `json:"ban_id"`
+	TargetType string     `json:"target_type"`
+	TargetID   int64      `json:"target_id"`
+	Reason     string     `json:"reason"`
+	BannedBy   int64      `json:"banned_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type CommandUsage struct {
+	UsageID    int64         `json:"usage_id"`
+	ChatID     int64         `json:"chat_id"`
+	UserID     int64         `json:"user_id"`
+	Command    string        `json:"command"`
+	SubCommand string        `json:"sub_command"`
+	Latency    time.Duration `json:"latency"`
+	Success    bool          `json:"success"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type UsageCount struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type UsageSummary struct {
+	Total      int64         `json:"total"`
+	Failed     int64         `json:"failed"`
+	Chats      int64         `json:"chats"`
+	Users      int64         `json:"users"`
+	AvgLatency time.Duration `json:"avg_latency"`
+}
+
+type UsageReport struct {
+	Summary  *UsageSummary `json:"summary"`
+	Commands []*UsageCount `json:"commands"`
+	Users    []*UsageCount `json:"users"`
+	Chats    []*UsageCount `json:"chats"`
+}
+
+type ChatMessage struct {
+	ID        int64     `json:"id"`
+	ChatID    int64     `json:"chat_id"`
+	MessageID int       `json:"message_id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type KnowledgeSnippet struct {
+	Source    string    `json:"source"`
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	Rank      float64   `json:"rank"`
+}
+
+type ModerationEvent struct {
+	EventID   int64     `json:"event_id"`
+	ChatID    int64     `json:"chat_id"`
+	UserID    int64     `json:"user_id"`
+	Source    string    `json:"source"`
+	Action    string    `json:"action"`
+	Rule      string    `json:"rule"`
+	Detail    string    `json:"detail"`
+	Excerpt   string    `json:"excerpt"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TokenUsage struct {
+	UsageID          int64     `json:"usage_id"`
+	ChatID           int64     `json:"chat_id"`
+	UserID           int64     `json:"user_id"`
+	Model            string    `json:"model"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type TokenTotals struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+}
+
+type TokenQuota struct {
+	TargetType string `json:"target_type"`
+	TargetID   int64  `json:"target_id"`
+	Daily      int64  `json:"daily"`
+	Monthly    int64  `json:"monthly"`
+}
+
+type TokenLimitUsage struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
+
+type TokenUsageReport struct {
+	ChatDay   TokenLimitUsage `json:"chat_day"`
+	ChatMonth TokenLimitUsage `json:"chat_month"`
+	UserDay   TokenLimitUsage `json:"user_day"`
+	UserMonth TokenLimitUsage `json:"user_month"`
+	Models    []*UsageCount   `json:"models"`
+	Users     []*UsageCount   `json:"users"`
+}
+
 type RedditResponse struct {
 	Memes []RedditMeme `json:"memes"`
 }
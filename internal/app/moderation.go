@@ -0,0 +1,14 @@
+package app
+
+import (
+	"context"
+	"got/internal/app/model"
+)
+
+func (s *Service) RecordModeration(ctx context.Context, event *model.ModerationEvent) error {
+	return s.moderation.Save(ctx, event)
+}
+
+func (s *Service) ListModerationEvents(ctx context.Context, limit int) ([]*model.ModerationEvent, error) {
+	return s.moderation.ListRecent(ctx, limit)
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"got/internal/app/model"
+	"strings"
+)
+
+const (
+	PersonaDefault = "default"
+
+	maxSystemPromptLen = 2000
+)
+
+var ErrSystemPromptTooLong = errors.New("system prompt is too long")
+
+var builtinPersonas = []model.Persona{
+	{Name: PersonaDefault, Prompt: "You are a helpful assistant in a Telegram chat. Keep responses concise and friendly."},
+	{Name: "concise", Prompt: "You are a terse assistant in a Telegram chat. Answer in as few words as possible, without pleasantries."},
+	{Name: "teacher", Prompt: "You are a patient teacher in a Telegram chat. Explain things step by step with simple examples and check for understanding."},
+	{Name: "coder", Prompt: "You are a senior software engineer in a Telegram chat. Give precise technical answers and prefer short code snippets over prose."},
+	{Name: "pirate", Prompt: "You are a cheerful pirate in a Telegram chat. Stay helpful, but talk like a pirate."},
+	{Name: "sarcastic", Prompt: "You are a witty, mildly sarcastic assistant in a Telegram chat. Be funny but never rude, and still answer the question."},
+}
+
+var LanguageNames = map[string]string{
+	"en": "English",
+	"ru": "Russian",
+	"lt": "Lithuanian",
+	"ja": "Japanese",
+	"be": "Belarusian",
+}
+
+func (s *Service) ListPersonas() []model.Persona {
+	return append([]model.Persona{}, builtinPersonas...)
+}
+
+func (s *Service) GetPersona(ctx context.Context, chatID int64) (*model.Persona, error) {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.Persona == model.PersonaCustom && settings.SystemPrompt != "" {
+		return &model.Persona{Name: model.PersonaCustom, Prompt: settings.SystemPrompt}, nil
+	}
+	if p, ok := findPersona(settings.Persona); ok {
+		return &p, nil
+	}
+
+	p, _ := findPersona(PersonaDefault)
+	return &p, nil
+}
+
+func (s *Service) SetPersona(ctx context.Context, chatID int64, nameOrPrompt string) (*model.Persona, error) {
+	nameOrPrompt = strings.TrimSpace(nameOrPrompt)
+	if len([]rune(nameOrPrompt)) > maxSystemPromptLen {
+		return nil, ErrSystemPromptTooLong
+	}
+
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	persona := model.Persona{Name: model.PersonaCustom, Prompt: nameOrPrompt}
+	if p, ok := findPersona(strings.ToLower(nameOrPrompt)); ok {
+		persona = p
+		settings.SystemPrompt = ""
+	} else {
+		settings.SystemPrompt = nameOrPrompt
+	}
+	settings.Persona = persona.Name
+
+	if err := s.chats.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return &persona, nil
+}
+
+func (s *Service) ResetPersona(ctx context.Context, chatID int64) error {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	settings.Persona = ""
+	settings.SystemPrompt = ""
+	return s.chats.SaveSettings(ctx, settings)
+}
+
+func (s *Service) BuildSystemPrompt(ctx context.Context, chatID int64) (string, error) {
+	persona, err := s.GetPersona(ctx, chatID)
+	if err != nil {
+		return "", err
+	}
+
+	lang, err := s.chats.GetLanguage(ctx, chatID)
+	if err != nil {
+		return persona.Prompt, err
+	}
+
+	return withLanguageInstruction(persona.Prompt, lang), nil
+}
+
+func withLanguageInstruction(prompt, lang string) string {
+	name, ok := LanguageNames[lang]
+	if !ok {
+		return prompt
+	}
+	return fmt.Sprintf("%s\n\nAlways reply in %s unless the user explicitly asks for another language.", prompt, name)
+}
+
+func findPersona(name string) (model.Persona, bool) {
+	for _, p := range builtinPersonas {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return model.Persona{}, false
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"got/internal/app/model"
-	"log/slog"
 	"time"
 )
 
@@ -49,7 +48,7 @@ func (s *Service) CheckReminders(ctx context.Context) ([]*model.Reminder, error)
 
 	for _, r := range pending {
 		if err := s.reminders.MarkSent(ctx, r.ReminderID); err != nil {
-			slog.Error("failed to mark reminder as sent", "id", r.ReminderID, "error", err)
+			log.ErrorContext(ctx, "failed to mark reminder as sent", "id", r.ReminderID, "error", err)
 		}
 	}
 
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"got/internal/app/model"
+	"time"
 )
 
 type ChatRepository interface {
@@ -11,6 +12,8 @@ type ChatRepository interface {
 	ListAll(ctx context.Context) ([]*model.Chat, error)
 	SetLanguage(ctx context.Context, chatID int64, language string) error
 	GetLanguage(ctx context.Context, chatID int64) (string, error)
+	GetSettings(ctx context.Context, chatID int64) (*model.ChatSettings, error)
+	SaveSettings(ctx context.Context, settings *model.ChatSettings) error
 }
 
 type UserRepository interface {
@@ -32,6 +35,7 @@ type FactRepository interface {
 	Save(ctx context.Context, fact *model.Fact) error
 	GetRandomByChat(ctx context.Context, chatID int64) (*model.Fact, error)
 	ListByChat(ctx context.Context, chatID int64) ([]*model.Fact, error)
+	Search(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error)
 }
 
 type StickerRepository interface {
@@ -59,3 +63,41 @@ type StatRepository interface {
 	ResetWinnerByChat(ctx context.Context, chatID int64, year int) error
 	Update(ctx context.Context, statID int64, score int64, isWinner bool) error
 }
+
+type BanRepository interface {
+	Save(ctx context.Context, ban *model.Ban) error
+	Delete(ctx context.Context, targetType string, targetID int64) error
+	FindActive(ctx context.Context, userID, chatID int64) (*model.Ban, error)
+	ListActive(ctx context.Context) ([]*model.Ban, error)
+}
+
+type UsageRepository interface {
+	Save(ctx context.Context, usage *model.CommandUsage) error
+	Summary(ctx context.Context, chatID int64, since time.Time) (*model.UsageSummary, error)
+	TopCommands(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	TopUsers(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	TopChats(ctx context.Context, since time.Time, limit int) ([]*model.UsageCount, error)
+}
+
+type TokenRepository interface {
+	Save(ctx context.Context, usage *model.TokenUsage) error
+	Totals(ctx context.Context, targetType string, targetID int64, since time.Time) (*model.TokenTotals, error)
+	TopModels(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	TopUsers(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	GetQuota(ctx context.Context, targetType string, targetID int64) (*model.TokenQuota, error)
+	SaveQuota(ctx context.Context, quota *model.TokenQuota) error
+	DeleteQuota(ctx context.Context, targetType string, targetID int64) error
+}
+
+type MessageRepository interface {
+	Save(ctx context.Context, msg *model.ChatMessage) error
+	ListRecent(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.ChatMessage, error)
+	DeleteByChat(ctx context.Context, chatID int64) (int64, error)
+	Prune(ctx context.Context, before time.Time, maxPerChat int) (int64, error)
+	Search(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error)
+}
+
+type ModerationRepository interface {
+	Save(ctx context.Context, event *model.ModerationEvent) error
+	ListRecent(ctx context.Context, limit int) ([]*model.ModerationEvent, error)
+}
@@ -1,31 +1,59 @@
 package app
 
+import (
+	"got/internal/app/model"
+	"got/pkg/logger"
+)
+
 type Service struct {
-	chats      ChatRepository
-	users      UserRepository
-	reminders  ReminderRepository
-	facts      FactRepository
-	stickers   StickerRepository
-	subreddits SubredditRepository
-	stats      StatRepository
+	chats          ChatRepository
+	users          UserRepository
+	reminders      ReminderRepository
+	facts          FactRepository
+	stickers       StickerRepository
+	subreddits     SubredditRepository
+	stats          StatRepository
+	bans           BanRepository
+	usage          UsageRepository
+	tokens         TokenRepository
+	messages       MessageRepository
+	moderation     ModerationRepository
+	quotas         map[string]model.TokenQuota
+	logPolicy      messageLogPolicy
+	lurkerDefaults model.LurkerSettings
+}
+
+type Repositories struct {
+	Chats      ChatRepository
+	Users      UserRepository
+	Reminders  ReminderRepository
+	Facts      FactRepository
+	Stickers   StickerRepository
+	Subreddits SubredditRepository
+	Stats      StatRepository
+	Bans       BanRepository
+	Usage      UsageRepository
+	Tokens     TokenRepository
+	Messages   MessageRepository
+	Moderation ModerationRepository
 }
 
-func NewService(
-	chats ChatRepository,
-	users UserRepository,
-	reminders ReminderRepository,
-	facts FactRepository,
-	stickers StickerRepository,
-	subreddits SubredditRepository,
-	stats StatRepository,
-) *Service {
+var log = logger.For("app")
+
+func NewService(repos Repositories) *Service {
 	return &Service{
-		chats:      chats,
-		users:      users,
-		reminders:  reminders,
-		facts:      facts,
-		stickers:   stickers,
-		subreddits: subreddits,
-		stats:      stats,
+		chats:      repos.Chats,
+		users:      repos.Users,
+		reminders:  repos.Reminders,
+		facts:      repos.Facts,
+		stickers:   repos.Stickers,
+		subreddits: repos.Subreddits,
+		stats:      repos.Stats,
+		bans:       repos.Bans,
+		usage:      repos.Usage,
+		tokens:     repos.Tokens,
+		messages:   repos.Messages,
+		moderation: repos.Moderation,
+		quotas:     make(map[string]model.TokenQuota),
 	}
 }
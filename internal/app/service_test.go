@@ -2,14 +2,57 @@ package app
 
 import (
 	"context"
+	"errors"
 	"got/internal/app/model"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
 
+func newMockService(repos Repositories) *Service {
+	if repos.Chats == nil {
+		repos.Chats = &MockChatRepository{}
+	}
+	if repos.Users == nil {
+		repos.Users = &MockUserRepository{}
+	}
+	if repos.Reminders == nil {
+		repos.Reminders = &MockReminderRepository{}
+	}
+	if repos.Facts == nil {
+		repos.Facts = &MockFactRepository{}
+	}
+	if repos.Stickers == nil {
+		repos.Stickers = &MockStickerRepository{}
+	}
+	if repos.Subreddits == nil {
+		repos.Subreddits = &MockSubredditRepository{}
+	}
+	if repos.Stats == nil {
+		repos.Stats = &MockStatRepository{}
+	}
+	if repos.Bans == nil {
+		repos.Bans = &MockBanRepository{}
+	}
+	if repos.Usage == nil {
+		repos.Usage = &MockUsageRepository{}
+	}
+	if repos.Tokens == nil {
+		repos.Tokens = &MockTokenRepository{}
+	}
+	if repos.Messages == nil {
+		repos.Messages = &MockMessageRepository{}
+	}
+	if repos.Moderation == nil {
+		repos.Moderation = &MockModerationRepository{}
+	}
+	return NewService(repos)
+}
+
 func TestServiceRegisterChat(t *testing.T) {
 	chatRepo := &MockChatRepository{}
-	svc := NewService(chatRepo, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Chats: chatRepo})
 
 	chat := &model.Chat{ChatID: 1, ChatName: "test"}
 
@@ -35,7 +78,7 @@ func TestServiceRegisterChat(t *testing.T) {
 
 func TestServiceRegisterUser(t *testing.T) {
 	userRepo := &MockUserRepository{}
-	svc := NewService(&MockChatRepository{}, userRepo, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Users: userRepo})
 
 	user := &model.User{UserID: 1, Username: "test"}
 
@@ -54,7 +97,7 @@ func TestServiceRegisterUser(t *testing.T) {
 func TestServiceAddFact(t *testing.T) {
 	chatRepo := &MockChatRepository{}
 	factRepo := &MockFactRepository{}
-	svc := NewService(chatRepo, &MockUserRepository{}, &MockReminderRepository{}, factRepo, &MockStickerRepository{}, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Chats: chatRepo, Facts: factRepo})
 
 	chat := &model.Chat{ChatID: 1}
 	text := "interesting fact"
@@ -82,7 +125,7 @@ func TestServiceAddReminder(t *testing.T) {
 	chatRepo := &MockChatRepository{}
 	userRepo := &MockUserRepository{}
 	reminderRepo := &MockReminderRepository{}
-	svc := NewService(chatRepo, userRepo, reminderRepo, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Chats: chatRepo, Users: userRepo, Reminders: reminderRepo})
 
 	chat := &model.Chat{ChatID: 1}
 	user := &model.User{UserID: 1}
@@ -117,7 +160,7 @@ func TestServiceAddReminder(t *testing.T) {
 
 func TestServiceCheckReminders(t *testing.T) {
 	reminderRepo := &MockReminderRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, reminderRepo, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Reminders: reminderRepo})
 
 	reminders := []*model.Reminder{
 		{ReminderID: 1},
@@ -185,7 +228,7 @@ func TestServiceAddSticker(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			chatRepo := &MockChatRepository{}
 			stickerRepo := &MockStickerRepository{}
-			svc := NewService(chatRepo, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, stickerRepo, &MockSubredditRepository{}, &MockStatRepository{})
+			svc := newMockService(Repositories{Chats: chatRepo, Stickers: stickerRepo})
 
 			chatRepo.GetFunc = func(ctx context.Context, id int64) (*model.Chat, error) {
 				if tt.chatFound {
@@ -211,7 +254,7 @@ func TestServiceAddSticker(t *testing.T) {
 
 func TestServiceGetRandomSticker(t *testing.T) {
 	stickerRepo := &MockStickerRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, stickerRepo, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Stickers: stickerRepo})
 
 	expected := &model.Sticker{FileID: "random123"}
 	stickerRepo.GetRandomByChatFunc = func(ctx context.Context, chatID int64) (*model.Sticker, error) {
@@ -229,7 +272,7 @@ func TestServiceGetRandomSticker(t *testing.T) {
 
 func TestServiceListStickers(t *testing.T) {
 	stickerRepo := &MockStickerRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, stickerRepo, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Stickers: stickerRepo})
 
 	expected := []*model.Sticker{{FileID: "a"}, {FileID: "b"}}
 	stickerRepo.ListByChatFunc = func(ctx context.Context, chatID int64) ([]*model.Sticker, error) {
@@ -248,7 +291,7 @@ func TestServiceListStickers(t *testing.T) {
 func TestServiceSubredditOperations(t *testing.T) {
 	t.Run("addSubreddit", func(t *testing.T) {
 		subRepo := &MockSubredditRepository{}
-		svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, subRepo, &MockStatRepository{})
+		svc := newMockService(Repositories{Subreddits: subRepo})
 
 		subRepo.SaveFunc = func(ctx context.Context, s *model.Subreddit) error {
 			if s.Name != "golang" {
@@ -267,7 +310,7 @@ func TestServiceSubredditOperations(t *testing.T) {
 
 	t.Run("getRandomSubreddit", func(t *testing.T) {
 		subRepo := &MockSubredditRepository{}
-		svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, subRepo, &MockStatRepository{})
+		svc := newMockService(Repositories{Subreddits: subRepo})
 
 		expected := &model.Subreddit{Name: "programmerhumor"}
 		subRepo.GetRandomByChatFunc = func(ctx context.Context, chatID int64) (*model.Subreddit, error) {
@@ -285,7 +328,7 @@ func TestServiceSubredditOperations(t *testing.T) {
 
 	t.Run("listSubreddits", func(t *testing.T) {
 		subRepo := &MockSubredditRepository{}
-		svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, subRepo, &MockStatRepository{})
+		svc := newMockService(Repositories{Subreddits: subRepo})
 
 		expected := []*model.Subreddit{{Name: "golang"}, {Name: "rust"}}
 		subRepo.ListByChatFunc = func(ctx context.Context, chatID int64) ([]*model.Subreddit, error) {
@@ -303,7 +346,7 @@ func TestServiceSubredditOperations(t *testing.T) {
 
 	t.Run("removeSubreddit", func(t *testing.T) {
 		subRepo := &MockSubredditRepository{}
-		svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, subRepo, &MockStatRepository{})
+		svc := newMockService(Repositories{Subreddits: subRepo})
 
 		deleteCalled := false
 		subRepo.DeleteFunc = func(ctx context.Context, name string, chatID int64) error {
@@ -364,7 +407,7 @@ func TestServiceGetOrCreateStat(t *testing.T) {
 			chatRepo := &MockChatRepository{}
 			userRepo := &MockUserRepository{}
 			statRepo := &MockStatRepository{}
-			svc := NewService(chatRepo, userRepo, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, statRepo)
+			svc := newMockService(Repositories{Chats: chatRepo, Users: userRepo, Stats: statRepo})
 
 			statRepo.FindByUserChatYearFunc = func(ctx context.Context, userID, chatID int64, year int) (*model.Stat, error) {
 				return tt.existingStat, nil
@@ -402,7 +445,7 @@ func TestServiceGetOrCreateStat(t *testing.T) {
 
 func TestServiceGetTodayWinner(t *testing.T) {
 	statRepo := &MockStatRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, statRepo)
+	svc := newMockService(Repositories{Stats: statRepo})
 
 	expected := &model.Stat{StatID: 1, IsWinner: true, User: &model.User{Username: "winner"}}
 	statRepo.FindWinnerByChatFunc = func(ctx context.Context, chatID int64, year int) (*model.Stat, error) {
@@ -444,7 +487,7 @@ func TestServiceSelectRandomWinner(t *testing.T) {
 			chatRepo := &MockChatRepository{}
 			userRepo := &MockUserRepository{}
 			statRepo := &MockStatRepository{}
-			svc := NewService(chatRepo, userRepo, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, statRepo)
+			svc := newMockService(Repositories{Chats: chatRepo, Users: userRepo, Stats: statRepo})
 
 			userRepo.GetRandomByChatFunc = func(ctx context.Context, chatID int64) (*model.User, error) {
 				if tt.userFound {
@@ -498,7 +541,7 @@ func TestServiceSelectRandomWinner(t *testing.T) {
 
 func TestServiceGetStatsByYear(t *testing.T) {
 	statRepo := &MockStatRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, statRepo)
+	svc := newMockService(Repositories{Stats: statRepo})
 
 	expected := []*model.Stat{
 		{StatID: 1, Score: 10, Year: 2025},
@@ -523,7 +566,7 @@ func TestServiceGetStatsByYear(t *testing.T) {
 
 func TestServiceGetAllStats(t *testing.T) {
 	statRepo := &MockStatRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, statRepo)
+	svc := newMockService(Repositories{Stats: statRepo})
 
 	expected := []*model.Stat{
 		{StatID: 1, Score: 10, Year: 2024},
@@ -545,7 +588,7 @@ func TestServiceGetAllStats(t *testing.T) {
 
 func TestServiceResetDailyWinners(t *testing.T) {
 	statRepo := &MockStatRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, statRepo)
+	svc := newMockService(Repositories{Stats: statRepo})
 
 	resetCalled := false
 	statRepo.ResetDailyWinnersFunc = func(ctx context.Context) error {
@@ -563,7 +606,7 @@ func TestServiceResetDailyWinners(t *testing.T) {
 
 func TestServiceGetRandomFact(t *testing.T) {
 	factRepo := &MockFactRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, factRepo, &MockStickerRepository{}, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Facts: factRepo})
 
 	expected := &model.Fact{ID: 1, Comment: "interesting"}
 	factRepo.GetRandomByChatFunc = func(ctx context.Context, chatID int64) (*model.Fact, error) {
@@ -581,7 +624,7 @@ func TestServiceGetRandomFact(t *testing.T) {
 
 func TestServiceListFacts(t *testing.T) {
 	factRepo := &MockFactRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, &MockReminderRepository{}, factRepo, &MockStickerRepository{}, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Facts: factRepo})
 
 	expected := []*model.Fact{{Comment: "fact1"}, {Comment: "fact2"}}
 	factRepo.ListByChatFunc = func(ctx context.Context, chatID int64) ([]*model.Fact, error) {
@@ -599,7 +642,7 @@ func TestServiceListFacts(t *testing.T) {
 
 func TestServiceGetPendingReminders(t *testing.T) {
 	reminderRepo := &MockReminderRepository{}
-	svc := NewService(&MockChatRepository{}, &MockUserRepository{}, reminderRepo, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Reminders: reminderRepo})
 
 	expected := []*model.Reminder{{ReminderID: 1}, {ReminderID: 2}}
 	reminderRepo.ListByChatFunc = func(ctx context.Context, chatID int64) ([]*model.Reminder, error) {
@@ -667,7 +710,7 @@ func TestServiceRunAutoRoulette(t *testing.T) {
 			chatRepo := &MockChatRepository{}
 			userRepo := &MockUserRepository{}
 			statRepo := &MockStatRepository{}
-			svc := NewService(chatRepo, userRepo, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, statRepo)
+			svc := newMockService(Repositories{Chats: chatRepo, Users: userRepo, Stats: statRepo})
 
 			chatRepo.ListAllFunc = func(ctx context.Context) ([]*model.Chat, error) {
 				return tt.chats, nil
@@ -722,7 +765,7 @@ func TestServiceRunAutoRoulette(t *testing.T) {
 
 func TestServiceRunAutoRouletteListAllError(t *testing.T) {
 	chatRepo := &MockChatRepository{}
-	svc := NewService(chatRepo, &MockUserRepository{}, &MockReminderRepository{}, &MockFactRepository{}, &MockStickerRepository{}, &MockSubredditRepository{}, &MockStatRepository{})
+	svc := newMockService(Repositories{Chats: chatRepo})
 
 	chatRepo.ListAllFunc = func(ctx context.Context) ([]*model.Chat, error) {
 		return nil, errMock
@@ -733,3 +776,565 @@ func TestServiceRunAutoRouletteListAllError(t *testing.T) {
 		t.Error("expected error, got nil")
 	}
 }
+
+func TestServiceBan(t *testing.T) {
+	banRepo := &MockBanRepository{}
+	svc := newMockService(Repositories{Bans: banRepo})
+
+	var saved *model.Ban
+	banRepo.SaveFunc = func(ctx context.Context, ban *model.Ban) error {
+		saved = ban
+		return nil
+	}
+
+	ban, err := svc.Ban(context.Background(), model.BanTargetUser, 42, "spam", 1, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved != ban {
+		t.Error("ban was not saved")
+	}
+	if ban.ExpiresAt == nil || ban.ExpiresAt.Sub(ban.CreatedAt) != time.Hour {
+		t.Errorf("want expiry after 1h, got %v", ban.ExpiresAt)
+	}
+
+	ban, err = svc.Ban(context.Background(), model.BanTargetChat, -100, "", 1, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ban.ExpiresAt != nil {
+		t.Errorf("want permanent ban, got expiry %v", ban.ExpiresAt)
+	}
+
+	if _, err := svc.Ban(context.Background(), "group", 1, "", 1, 0); err == nil {
+		t.Error("expected error for invalid target type")
+	}
+}
+
+func TestServiceIsBlocked(t *testing.T) {
+	banRepo := &MockBanRepository{}
+	svc := newMockService(Repositories{Bans: banRepo})
+
+	banRepo.FindActiveFunc = func(ctx context.Context, userID, chatID int64) (*model.Ban, error) {
+		if userID == 42 {
+			return &model.Ban{TargetType: model.BanTargetUser, TargetID: 42}, nil
+		}
+		return nil, nil
+	}
+
+	blocked, err := svc.IsBlocked(context.Background(), 42, 1)
+	if err != nil || !blocked {
+		t.Errorf("want blocked, got %v (err %v)", blocked, err)
+	}
+
+	blocked, err = svc.IsBlocked(context.Background(), 7, 1)
+	if err != nil || blocked {
+		t.Errorf("want not blocked, got %v (err %v)", blocked, err)
+	}
+
+	banRepo.FindActiveFunc = func(ctx context.Context, userID, chatID int64) (*model.Ban, error) {
+		return nil, errMock
+	}
+	if _, err := svc.IsBlocked(context.Background(), 7, 1); err != errMock {
+		t.Errorf("want error %v, got %v", errMock, err)
+	}
+}
+
+func TestServiceGetGlobalUsage(t *testing.T) {
+	usageRepo := &MockUsageRepository{}
+	svc := newMockService(Repositories{Usage: usageRepo})
+
+	usageRepo.SummaryFunc = func(ctx context.Context, chatID int64, since time.Time) (*model.UsageSummary, error) {
+		if chatID != 0 {
+			t.Errorf("want all chats (0), got %d", chatID)
+		}
+		return &model.UsageSummary{Total: 3}, nil
+	}
+	usageRepo.TopChatsFunc = func(ctx context.Context, since time.Time, limit int) ([]*model.UsageCount, error) {
+		return []*model.UsageCount{{ID: 1, Count: 3}}, nil
+	}
+
+	report, err := svc.GetGlobalUsage(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Summary.Total != 3 || len(report.Chats) != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	usageRepo.SummaryFunc = func(ctx context.Context, chatID int64, since time.Time) (*model.UsageSummary, error) {
+		return nil, errMock
+	}
+	if _, err := svc.GetGlobalUsage(context.Background(), time.Time{}); err != errMock {
+		t.Errorf("want error %v, got %v", errMock, err)
+	}
+}
+
+func TestServiceRecordCommandUsage(t *testing.T) {
+	usageRepo := &MockUsageRepository{}
+	svc := newMockService(Repositories{Usage: usageRepo})
+
+	usageRepo.SaveFunc = func(ctx context.Context, usage *model.CommandUsage) error {
+		if usage.CreatedAt.IsZero() {
+			t.Error("want created_at to be set")
+		}
+		return nil
+	}
+
+	if err := svc.RecordCommandUsage(context.Background(), &model.CommandUsage{Command: "gpt"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestServiceSetPersona(t *testing.T) {
+	chatRepo := &MockChatRepository{}
+	svc := newMockService(Repositories{Chats: chatRepo})
+
+	var saved *model.ChatSettings
+	chatRepo.SaveSettingsFunc = func(ctx context.Context, settings *model.ChatSettings) error {
+		saved = settings
+		return nil
+	}
+
+	persona, err := svc.SetPersona(context.Background(), 1, "Pirate")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if persona.Name != "pirate" || saved.Persona != "pirate" || saved.SystemPrompt != "" {
+		t.Errorf("want built-in pirate persona, got %+v (saved %+v)", persona, saved)
+	}
+
+	persona, err = svc.SetPersona(context.Background(), 1, "You are a grumpy cat.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if persona.Name != model.PersonaCustom || saved.SystemPrompt != "You are a grumpy cat." {
+		t.Errorf("want custom persona, got %+v (saved %+v)", persona, saved)
+	}
+
+	long := make([]rune, maxSystemPromptLen+1)
+	for i := range long {
+		long[i] = 'a'
+	}
+	if _, err := svc.SetPersona(context.Background(), 1, string(long)); err != ErrSystemPromptTooLong {
+		t.Errorf("want error %v, got %v", ErrSystemPromptTooLong, err)
+	}
+}
+
+func TestServiceSetAutoTranscribe(t *testing.T) {
+	chatRepo := &MockChatRepository{}
+	svc := newMockService(Repositories{Chats: chatRepo})
+
+	stored := &model.ChatSettings{ChatID: 1, Persona: "pirate"}
+	chatRepo.GetSettingsFunc = func(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+		return stored, nil
+	}
+	chatRepo.SaveSettingsFunc = func(ctx context.Context, settings *model.ChatSettings) error {
+		stored = settings
+		return nil
+	}
+
+	if err := svc.SetAutoTranscribe(context.Background(), 1, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	enabled, err := svc.IsAutoTranscribe(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !enabled || stored.Persona != "pirate" {
+		t.Errorf("want auto-transcribe enabled with persona kept, got %+v", stored)
+	}
+}
+
+func TestServiceLurkerSettings(t *testing.T) {
+	chatRepo := &MockChatRepository{}
+	svc := newMockService(Repositories{Chats: chatRepo})
+	svc.SetLurkerDefaults(10, time.Hour)
+
+	stored := &model.ChatSettings{ChatID: 1, Persona: "pirate"}
+	chatRepo.GetSettingsFunc = func(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+		copied := *stored
+		return &copied, nil
+	}
+	chatRepo.SaveSettingsFunc = func(ctx context.Context, settings *model.ChatSettings) error {
+		stored = settings
+		return nil
+	}
+
+	got, err := svc.GetLurkerSettings(context.Background(), 1)
+	if err != nil || got != (model.LurkerSettings{Chance: 10, Cooldown: time.Hour}) {
+		t.Errorf("GetLurkerSettings() = %+v, %v, want configured defaults", got, err)
+	}
+
+	if err := svc.SetLurkerChance(context.Background(), 1, 101); !errors.Is(err, ErrInvalidLurkerChance) {
+		t.Errorf("SetLurkerChance(101) error = %v, want ErrInvalidLurkerChance", err)
+	}
+	if err := svc.SetLurkerCooldown(context.Background(), 1, time.Second); !errors.Is(err, ErrInvalidLurkerCooldown) {
+		t.Errorf("SetLurkerCooldown(1s) error = %v, want ErrInvalidLurkerCooldown", err)
+	}
+	_ = svc.SetLurker(context.Background(), 1, true)
+	_ = svc.SetLurkerChance(context.Background(), 1, 25)
+	_ = svc.SetLurkerCooldown(context.Background(), 1, 5*time.Minute)
+
+	got, err = svc.GetLurkerSettings(context.Background(), 1)
+	want := model.LurkerSettings{Enabled: true, Chance: 25, Cooldown: 5 * time.Minute}
+	if err != nil || got != want || stored.Persona != "pirate" {
+		t.Errorf("GetLurkerSettings() = %+v, %v, want %+v with persona kept", got, err, want)
+	}
+
+	quietUntil := time.Date(2024, 5, 3, 18, 0, 0, 0, time.UTC)
+	_ = svc.SetLurkerQuiet(context.Background(), 1, quietUntil)
+	if got, _ = svc.GetLurkerSettings(context.Background(), 1); !got.QuietUntil.Equal(quietUntil) {
+		t.Errorf("GetLurkerSettings().QuietUntil = %v, want %v", got.QuietUntil, quietUntil)
+	}
+	_ = svc.SetLurker(context.Background(), 1, false)
+	if got, _ = svc.GetLurkerSettings(context.Background(), 1); !got.QuietUntil.IsZero() {
+		t.Errorf("GetLurkerSettings().QuietUntil = %v after disabling, want zero", got.QuietUntil)
+	}
+}
+
+func TestServiceMemoryScope(t *testing.T) {
+	chatRepo := &MockChatRepository{}
+	svc := newMockService(Repositories{Chats: chatRepo})
+
+	stored := &model.ChatSettings{ChatID: 1, AutoTranscribe: true}
+	chatRepo.GetSettingsFunc = func(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+		return stored, nil
+	}
+	chatRepo.SaveSettingsFunc = func(ctx context.Context, settings *model.ChatSettings) error {
+		stored = settings
+		return nil
+	}
+
+	scope, err := svc.GetMemoryScope(context.Background(), 1)
+	if err != nil || scope != model.MemoryScopeChat {
+		t.Fatalf("GetMemoryScope() = %q, %v, want %q", scope, err, model.MemoryScopeChat)
+	}
+
+	if err := svc.SetMemoryScope(context.Background(), 1, "everyone"); !errors.Is(err, ErrInvalidMemoryScope) {
+		t.Errorf("SetMemoryScope(everyone) error = %v, want %v", err, ErrInvalidMemoryScope)
+	}
+	if err := svc.SetMemoryScope(context.Background(), 1, model.MemoryScopeThread); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scope, _ = svc.GetMemoryScope(context.Background(), 1)
+	if scope != model.MemoryScopeThread || !stored.AutoTranscribe {
+		t.Errorf("want thread scope with other settings kept, got %+v", stored)
+	}
+}
+
+func TestServiceLogMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		wantSaved bool
+	}{
+		{name: "DisabledByDefault", enabled: false, wantSaved: false},
+		{name: "Enabled", enabled: true, wantSaved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *model.ChatMessage
+			messageRepo := &MockMessageRepository{
+				SaveFunc: func(ctx context.Context, msg *model.ChatMessage) error {
+					saved = msg
+					return nil
+				},
+			}
+			svc := newMockService(Repositories{Messages: messageRepo})
+
+			settings := &model.ChatSettings{ChatID: 1, MessageLog: tt.enabled}
+			err := svc.LogMessage(context.Background(), settings, &model.ChatMessage{ChatID: 1, UserID: 42, Text: "hello"})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (saved != nil) != tt.wantSaved {
+				t.Errorf("saved = %+v, want saved %v", saved, tt.wantSaved)
+			}
+			if saved != nil && saved.CreatedAt.IsZero() {
+				t.Error("saved message has no timestamp")
+			}
+		})
+	}
+}
+
+func TestServiceSetMessageLogOffDeletesMessages(t *testing.T) {
+	stored := &model.ChatSettings{ChatID: 1, MessageLog: true}
+	chatRepo := &MockChatRepository{
+		GetSettingsFunc: func(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+			return stored, nil
+		},
+		SaveSettingsFunc: func(ctx context.Context, settings *model.ChatSettings) error {
+			stored = settings
+			return nil
+		},
+	}
+	var deletedChat int64
+	messageRepo := &MockMessageRepository{
+		DeleteByChatFunc: func(ctx context.Context, chatID int64) (int64, error) {
+			deletedChat = chatID
+			return 3, nil
+		},
+	}
+	svc := newMockService(Repositories{Chats: chatRepo, Messages: messageRepo})
+
+	if err := svc.SetMessageLog(context.Background(), 1, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.MessageLog || deletedChat != 1 {
+		t.Errorf("want logging off and messages deleted, got settings %+v, deleted chat %d", stored, deletedChat)
+	}
+}
+
+func TestServicePruneMessageLog(t *testing.T) {
+	var gotBefore time.Time
+	var gotMax int
+	messageRepo := &MockMessageRepository{
+		PruneFunc: func(ctx context.Context, before time.Time, maxPerChat int) (int64, error) {
+			gotBefore, gotMax = before, maxPerChat
+			return 0, nil
+		},
+	}
+	svc := newMockService(Repositories{Messages: messageRepo})
+	if err := svc.PruneMessageLog(context.Background()); err != nil || gotMax != 0 {
+		t.Fatalf("PruneMessageLog() without a policy = %v, pruned with max %d, want no pruning", err, gotMax)
+	}
+
+	svc.SetMessageLogPolicy(24*time.Hour, 100)
+
+	if err := svc.PruneMessageLog(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMax != 100 || time.Since(gotBefore) < 24*time.Hour || time.Since(gotBefore) > 25*time.Hour {
+		t.Errorf("Prune(%v, %d), want cutoff 24h ago and 100 per chat", gotBefore, gotMax)
+	}
+}
+
+func TestServiceBuildSystemPrompt(t *testing.T) {
+	tests := []struct {
+		name         string
+		settings     *model.ChatSettings
+		language     string
+		wantContains []string
+	}{
+		{
+			name:         "DefaultPersona",
+			settings:     &model.ChatSettings{ChatID: 1},
+			wantContains: []string{"helpful assistant"},
+		},
+		{
+			name:         "CustomPromptWithLanguage",
+			settings:     &model.ChatSettings{ChatID: 1, Persona: model.PersonaCustom, SystemPrompt: "You are a grumpy cat."},
+			language:     "ru",
+			wantContains: []string{"grumpy cat", "Russian"},
+		},
+		{
+			name:         "BuiltinPersona",
+			settings:     &model.ChatSettings{ChatID: 1, Persona: "pirate"},
+			language:     "ja",
+			wantContains: []string{"pirate", "Japanese"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatRepo := &MockChatRepository{
+				GetSettingsFunc: func(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+					return tt.settings, nil
+				},
+				GetLanguageFunc: func(ctx context.Context, chatID int64) (string, error) {
+					return tt.language, nil
+				},
+			}
+			svc := newMockService(Repositories{Chats: chatRepo})
+
+			prompt, err := svc.BuildSystemPrompt(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("prompt %q missing %q", prompt, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceCheckTokenQuota(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		userDaily  int64
+		chatMonth  int64
+		override   *model.TokenQuota
+		used       map[string]int64
+		wantTarget string
+		wantPeriod string
+	}{
+		{
+			name:      "Unlimited",
+			used:      map[string]int64{"user:day": 1 << 40},
+			userDaily: 0,
+		},
+		{
+			name:      "UnderLimit",
+			userDaily: 1000,
+			chatMonth: 5000,
+			used:      map[string]int64{"user:day": 999, "chat:month": 4999},
+		},
+		{
+			name:       "UserDailyExceeded",
+			userDaily:  1000,
+			used:       map[string]int64{"user:day": 1000},
+			wantTarget: model.QuotaTargetUser,
+			wantPeriod: model.QuotaPeriodDay,
+		},
+		{
+			name:       "ChatMonthlyExceeded",
+			chatMonth:  5000,
+			used:       map[string]int64{"chat:month": 6000},
+			wantTarget: model.QuotaTargetChat,
+			wantPeriod: model.QuotaPeriodMonth,
+		},
+		{
+			name:      "OverrideLiftsLimit",
+			userDaily: 1000,
+			override:  &model.TokenQuota{TargetType: model.QuotaTargetUser, TargetID: 42},
+			used:      map[string]int64{"user:day": 5000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := &MockTokenRepository{
+				TotalsFunc: func(ctx context.Context, targetType string, targetID int64, since time.Time) (*model.TokenTotals, error) {
+					period := model.QuotaPeriodDay
+					if since.Day() == 1 {
+						period = model.QuotaPeriodMonth
+					}
+					return &model.TokenTotals{PromptTokens: tt.used[targetType+":"+period]}, nil
+				},
+				GetQuotaFunc: func(ctx context.Context, targetType string, targetID int64) (*model.TokenQuota, error) {
+					if tt.override != nil && tt.override.TargetType == targetType {
+						return tt.override, nil
+					}
+					return nil, nil
+				},
+			}
+			svc := newMockService(Repositories{Tokens: tokens})
+			svc.SetDefaultTokenQuota(model.QuotaTargetUser, tt.userDaily, 0)
+			svc.SetDefaultTokenQuota(model.QuotaTargetChat, 0, tt.chatMonth)
+
+			err := svc.CheckTokenQuota(context.Background(), -100, 42, now)
+
+			if tt.wantTarget == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var exceeded *QuotaExceededError
+			if !errors.As(err, &exceeded) || !errors.Is(err, ErrQuotaExceeded) {
+				t.Fatalf("want QuotaExceededError, got %v", err)
+			}
+			if exceeded.TargetType != tt.wantTarget || exceeded.Period != tt.wantPeriod {
+				t.Errorf("exceeded %s %s, want %s %s", exceeded.TargetType, exceeded.Period, tt.wantTarget, tt.wantPeriod)
+			}
+		})
+	}
+}
+
+func TestServiceSetTokenQuota(t *testing.T) {
+	var saved *model.TokenQuota
+	tokens := &MockTokenRepository{
+		SaveQuotaFunc: func(ctx context.Context, quota *model.TokenQuota) error {
+			saved = quota
+			return nil
+		},
+	}
+	svc := newMockService(Repositories{Tokens: tokens})
+
+	if _, err := svc.SetTokenQuota(context.Background(), model.QuotaTargetChat, -100, 1000, 20000); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saved == nil || saved.Daily != 1000 || saved.Monthly != 20000 {
+		t.Errorf("saved quota = %+v, want 1000/20000", saved)
+	}
+
+	if _, err := svc.SetTokenQuota(context.Background(), model.QuotaTargetUser, 42, -1, 0); !errors.Is(err, ErrInvalidQuota) {
+		t.Errorf("want %v for negative limit, got %v", ErrInvalidQuota, err)
+	}
+	if _, err := svc.SetTokenQuota(context.Background(), "group", 42, 1, 1); !errors.Is(err, ErrInvalidQuota) {
+		t.Errorf("want %v for unknown target, got %v", ErrInvalidQuota, err)
+	}
+}
+
+func TestQuotaPeriodStart(t *testing.T) {
+	now := time.Date(2026, 3, 15, 23, 30, 0, 0, time.FixedZone("UTC+3", 3*3600))
+
+	if got := QuotaPeriodStart(model.QuotaPeriodDay, now); !got.Equal(time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("day start = %v", got)
+	}
+	if got := QuotaPeriodStart(model.QuotaPeriodMonth, now); !got.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("month start = %v", got)
+	}
+}
+
+func TestServiceSearchKnowledge(t *testing.T) {
+	tests := []struct {
+		name       string
+		messageLog bool
+		wantIDs    []int64
+	}{
+		{name: "FactsOnly", messageLog: false, wantIDs: []int64{1, 2}},
+		{name: "MergedByRank", messageLog: true, wantIDs: []int64{10, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatRepo := &MockChatRepository{
+				GetSettingsFunc: func(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+					return &model.ChatSettings{ChatID: chatID, MessageLog: tt.messageLog}, nil
+				},
+			}
+			factRepo := &MockFactRepository{
+				SearchFunc: func(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+					return []*model.KnowledgeSnippet{
+						{Source: model.SnippetSourceFact, ID: 1, Rank: 0.5},
+						{Source: model.SnippetSourceFact, ID: 2, Rank: 0.1},
+					}, nil
+				},
+			}
+			messageRepo := &MockMessageRepository{
+				SearchFunc: func(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+					if !tt.messageLog {
+						t.Error("message log searched while disabled")
+					}
+					return []*model.KnowledgeSnippet{
+						{Source: model.SnippetSourceMessage, ID: 10, Rank: 0.9},
+						{Source: model.SnippetSourceMessage, ID: 11, Rank: 0.05},
+					}, nil
+				},
+			}
+			svc := newMockService(Repositories{Chats: chatRepo, Facts: factRepo, Messages: messageRepo})
+
+			snippets, err := svc.SearchKnowledge(context.Background(), 1, "what did Tomas say about the server", 3)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var ids []int64
+			for _, s := range snippets {
+				ids = append(ids, s.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("snippet IDs = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
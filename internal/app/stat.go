@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"got/internal/app/model"
-	"log/slog"
 	"time"
 )
 
@@ -110,7 +109,7 @@ func (s *Service) RunAutoRoulette(ctx context.Context) ([]RouletteResult, error)
 func (s *Service) runRouletteForChat(ctx context.Context, chat *model.Chat, year int) (RouletteResult, bool) {
 	existing, err := s.GetTodayWinner(ctx, chat.ChatID, year)
 	if err != nil {
-		slog.Error("failed to check existing winner", "chat", chat.ChatID, "error", err)
+		log.ErrorContext(ctx, "failed to check existing winner", "chat", chat.ChatID, "error", err)
 		return RouletteResult{}, false
 	}
 	if existing != nil {
@@ -119,7 +118,7 @@ func (s *Service) runRouletteForChat(ctx context.Context, chat *model.Chat, year
 
 	winner, err := s.SelectRandomWinner(ctx, chat.ChatID, year)
 	if err != nil {
-		slog.Error("failed to select winner", "chat", chat.ChatID, "error", err)
+		log.ErrorContext(ctx, "failed to select winner", "chat", chat.ChatID, "error", err)
 		return RouletteResult{}, false
 	}
 	if winner == nil {
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"got/internal/app/model"
+	"time"
+)
+
+const tokenTopLimit = 5
+
+type QuotaExceededError struct {
+	TargetType string
+	Period     string
+	Used       int64
+	Limit      int64
+}
+
+var (
+	ErrQuotaExceeded = errors.New("token quota exceeded")
+	ErrInvalidQuota  = errors.New("invalid token quota")
+)
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s %s token quota exceeded: %d/%d", e.TargetType, e.Period, e.Used, e.Limit)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+func (s *Service) SetDefaultTokenQuota(targetType string, daily, monthly int64) {
+	s.quotas[targetType] = model.TokenQuota{TargetType: targetType, Daily: daily, Monthly: monthly}
+}
+
+func (s *Service) RecordTokenUsage(ctx context.Context, usage *model.TokenUsage) error {
+	if usage.CreatedAt.IsZero() {
+		usage.CreatedAt = time.Now()
+	}
+	return s.tokens.Save(ctx, usage)
+}
+
+func (s *Service) GetTokenQuota(ctx context.Context, targetType string, targetID int64) (*model.TokenQuota, bool, error) {
+	override, err := s.tokens.GetQuota(ctx, targetType, targetID)
+	if err != nil {
+		return nil, false, err
+	}
+	if override != nil {
+		return override, true, nil
+	}
+
+	quota := s.quotas[targetType]
+	quota.TargetType = targetType
+	quota.TargetID = targetID
+	return &quota, false, nil
+}
+
+func (s *Service) SetTokenQuota(ctx context.Context, targetType string, targetID, daily, monthly int64) (*model.TokenQuota, error) {
+	if targetType != model.QuotaTargetUser && targetType != model.QuotaTargetChat {
+		return nil, fmt.Errorf("%w: target %s", ErrInvalidQuota, targetType)
+	}
+	if daily < 0 || monthly < 0 {
+		return nil, fmt.Errorf("%w: negative limit", ErrInvalidQuota)
+	}
+
+	quota := &model.TokenQuota{TargetType: targetType, TargetID: targetID, Daily: daily, Monthly: monthly}
+	if err := s.tokens.SaveQuota(ctx, quota); err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+func (s *Service) ResetTokenQuota(ctx context.Context, targetType string, targetID int64) error {
+	return s.tokens.DeleteQuota(ctx, targetType, targetID)
+}
+
+func (s *Service) CheckTokenQuota(ctx context.Context, chatID, userID int64, now time.Time) error {
+	targets := []struct {
+		targetType string
+		targetID   int64
+	}{
+		{model.QuotaTargetUser, userID},
+		{model.QuotaTargetChat, chatID},
+	}
+
+	for _, target := range targets {
+		if target.targetID == 0 {
+			continue
+		}
+
+		quota, _, err := s.GetTokenQuota(ctx, target.targetType, target.targetID)
+		if err != nil {
+			return err
+		}
+
+		limits := []struct {
+			period string
+			limit  int64
+		}{
+			{model.QuotaPeriodDay, quota.Daily},
+			{model.QuotaPeriodMonth, quota.Monthly},
+		}
+		for _, l := range limits {
+			if l.limit <= 0 {
+				continue
+			}
+			used, err := s.tokensUsed(ctx, target.targetType, target.targetID, QuotaPeriodStart(l.period, now))
+			if err != nil {
+				return err
+			}
+			if used >= l.limit {
+				return &QuotaExceededError{TargetType: target.targetType, Period: l.period, Used: used, Limit: l.limit}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) GetTokenUsageReport(ctx context.Context, chatID, userID int64, now time.Time) (*model.TokenUsageReport, error) {
+	report := &model.TokenUsageReport{}
+
+	entries := []struct {
+		targetType string
+		targetID   int64
+		day        *model.TokenLimitUsage
+		month      *model.TokenLimitUsage
+	}{
+		{model.QuotaTargetChat, chatID, &report.ChatDay, &report.ChatMonth},
+		{model.QuotaTargetUser, userID, &report.UserDay, &report.UserMonth},
+	}
+	for _, e := range entries {
+		quota, _, err := s.GetTokenQuota(ctx, e.targetType, e.targetID)
+		if err != nil {
+			return nil, err
+		}
+		if e.day.Used, err = s.tokensUsed(ctx, e.targetType, e.targetID, QuotaPeriodStart(model.QuotaPeriodDay, now)); err != nil {
+			return nil, err
+		}
+		if e.month.Used, err = s.tokensUsed(ctx, e.targetType, e.targetID, QuotaPeriodStart(model.QuotaPeriodMonth, now)); err != nil {
+			return nil, err
+		}
+		e.day.Limit, e.month.Limit = quota.Daily, quota.Monthly
+	}
+
+	monthStart := QuotaPeriodStart(model.QuotaPeriodMonth, now)
+	models, err := s.tokens.TopModels(ctx, chatID, monthStart, tokenTopLimit)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.tokens.TopUsers(ctx, chatID, monthStart, tokenTopLimit)
+	if err != nil {
+		return nil, err
+	}
+	report.Models, report.Users = models, users
+
+	return report, nil
+}
+
+func QuotaPeriodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	if period == model.QuotaPeriodMonth {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *Service) tokensUsed(ctx context.Context, targetType string, targetID int64, since time.Time) (int64, error) {
+	totals, err := s.tokens.Totals(ctx, targetType, targetID, since)
+	if err != nil {
+		return 0, err
+	}
+	return totals.PromptTokens + totals.CompletionTokens, nil
+}
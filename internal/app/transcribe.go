@@ -0,0 +1,21 @@
+package app
+
+import "context"
+
+func (s *Service) IsAutoTranscribe(ctx context.Context, chatID int64) (bool, error) {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return false, err
+	}
+	return settings.AutoTranscribe, nil
+}
+
+func (s *Service) SetAutoTranscribe(ctx context.Context, chatID int64, enabled bool) error {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	settings.AutoTranscribe = enabled
+	return s.chats.SaveSettings(ctx, settings)
+}
@@ -0,0 +1,21 @@
+package app
+
+import "context"
+
+func (s *Service) IsAutoTranslate(ctx context.Context, chatID int64) (bool, error) {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return false, err
+	}
+	return settings.AutoTranslate, nil
+}
+
+func (s *Service) SetAutoTranslate(ctx context.Context, chatID int64, enabled bool) error {
+	settings, err := s.chats.GetSettings(ctx, chatID)
+	if err != nil {
+		return err
+	}
+
+	settings.AutoTranslate = enabled
+	return s.chats.SaveSettings(ctx, settings)
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"got/internal/app/model"
+	"time"
+)
+
+const usageTopLimit = 5
+
+func (s *Service) RecordCommandUsage(ctx context.Context, usage *model.CommandUsage) error {
+	if usage.CreatedAt.IsZero() {
+		usage.CreatedAt = time.Now()
+	}
+	return s.usage.Save(ctx, usage)
+}
+
+func (s *Service) GetChatUsage(ctx context.Context, chatID int64, since time.Time) (*model.UsageReport, error) {
+	summary, err := s.usage.Summary(ctx, chatID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	commands, err := s.usage.TopCommands(ctx, chatID, since, usageTopLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.usage.TopUsers(ctx, chatID, since, usageTopLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UsageReport{Summary: summary, Commands: commands, Users: users}, nil
+}
+
+func (s *Service) GetGlobalUsage(ctx context.Context, since time.Time) (*model.UsageReport, error) {
+	report, err := s.GetChatUsage(ctx, 0, since)
+	if err != nil {
+		return nil, err
+	}
+
+	chats, err := s.usage.TopChats(ctx, since, usageTopLimit)
+	if err != nil {
+		return nil, err
+	}
+	report.Chats = chats
+
+	return report, nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"got/pkg/logger"
 	"io"
 	"net/http"
 	"sort"
@@ -13,39 +14,102 @@ import (
 )
 
 const (
-	baseURL        = "https://api.groq.com/openai/v1/chat/completions"
-	modelsURL      = "https://api.groq.com/openai/v1/models"
+	ProviderName   = "groq"
+	baseURL        = "https://api.groq.com/openai/v1"
+	completionPath = "/chat/completions"
+	modelsPath     = "/models"
+	audioPath      = "/audio/transcriptions"
 	defaultTimeout = 30 * time.Second
 	defaultModel   = "llama-3.3-70b-versatile"
 	roleSystem     = "system"
 	roleUser       = "user"
+	roleTool       = "tool"
+	toolTypeFunc   = "function"
+	maxToolRounds  = 3
 	systemPrompt   = "You are a helpful assistant in a Telegram chat. Keep responses concise and friendly."
 )
 
 type Client struct {
-	apiKey     string
-	httpClient *http.Client
-	model      string
-	baseURL    string
-	modelsURL  string
+	name        string
+	apiKey      string
+	httpClient  *http.Client
+	model       string
+	visionModel string
+	audioModel  string
+	models      []string
+	baseURL     string
+	modelsURL   string
+	audioURL    string
+	retry       RetryPolicy
+	sleep       func(ctx context.Context, d time.Duration) error
 }
 
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	ImageRefs  []string   `json:"image_refs,omitempty"`
+	Images     []string   `json:"-"`
+}
+
+type Tool struct {
+	Type     string      `json:"type"`
+	Function FunctionDef `json:"function"`
+}
+
+type FunctionDef struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+type ToolExecutor func(ctx context.Context, call ToolCall) (string, error)
+
+type UsageHandler func(usage Usage)
+
+type ChatRequest struct {
+	Model        string
+	SystemPrompt string
+	History      []Message
+	Prompt       string
+	Images       []string
+	Tools        []Tool
+	ExecuteTool  ToolExecutor
+	OnUsage      UsageHandler
 }
 
 type Request struct {
 	Model    string    `json:"model"`
 	Messages []Message `json:"messages"`
+	Tools    []Tool    `json:"tools,omitempty"`
+	Stream   bool      `json:"stream,omitempty"`
 }
 
 type Response struct {
 	ID      string   `json:"id"`
 	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"`
 	Error   *Error   `json:"error,omitempty"`
 }
 
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
 type Choice struct {
 	Message Message `json:"message"`
 }
@@ -60,49 +124,131 @@ type ModelsResponse struct {
 }
 
 type ModelInfo struct {
-	ID      string `json:"id"`
-	OwnedBy string `json:"owned_by"`
+	ID            string `json:"id"`
+	OwnedBy       string `json:"owned_by,omitempty"`
+	Active        *bool  `json:"active,omitempty"`
+	ContextWindow int    `json:"context_window,omitempty"`
+	Vision        bool   `json:"vision,omitempty"`
+	Tools         bool   `json:"tools,omitempty"`
 }
 
+var log = logger.For("groq")
+
 func NewClient(apiKey string) *Client {
+	client := NewCompatibleClient(ProviderName, baseURL, apiKey, defaultModel, []string{
+		"llama-3.3-70b-versatile",
+		"llama-3.1-8b-instant",
+		"mixtral-8x7b-32768",
+		"gemma2-9b-it",
+	})
+	client.visionModel = defaultVisionModel
+	client.audioModel = defaultTranscriptionModel
+	return client
+}
+
+func NewCompatibleClient(name, endpoint, apiKey, model string, models []string) *Client {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if model == "" && len(models) > 0 {
+		model = models[0]
+	}
 	return &Client{
+		name:   name,
 		apiKey: apiKey,
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
-		model:     defaultModel,
-		baseURL:   baseURL,
-		modelsURL: modelsURL,
+		model:     model,
+		models:    models,
+		baseURL:   endpoint + completionPath,
+		modelsURL: endpoint + modelsPath,
+		audioURL:  endpoint + audioPath,
+		retry:     DefaultRetryPolicy(),
+		sleep:     sleepContext,
 	}
 }
 
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = defaultBaseDelay
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+	c.retry = policy
+}
+
+func (c *Client) Name() string {
+	return c.name
+}
+
 func (c *Client) Chat(ctx context.Context, prompt string, history []Message) (string, error) {
 	return c.ChatWithModel(ctx, prompt, history, c.model)
 }
 
 func (c *Client) ChatWithModel(ctx context.Context, prompt string, history []Message, model string) (string, error) {
+	return c.Complete(ctx, ChatRequest{Model: model, History: history, Prompt: prompt})
+}
+
+func (c *Client) Complete(ctx context.Context, chatReq ChatRequest) (string, error) {
+	model := chatReq.Model
 	if model == "" {
 		model = c.model
 	}
 
-	messages := c.buildMessages(prompt, history)
+	messages := c.requestMessages(chatReq)
 
-	reqBody := Request{
-		Model:    model,
-		Messages: messages,
-	}
+	for round := 0; ; round++ {
+		reqBody := Request{
+			Model:    model,
+			Messages: messages,
+		}
+		if chatReq.ExecuteTool != nil && round < maxToolRounds {
+			reqBody.Tools = chatReq.Tools
+		}
 
-	data, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		msg, usage, err := c.send(ctx, reqBody)
+		if err != nil {
+			return "", err
+		}
+		if chatReq.OnUsage != nil && usage != nil {
+			chatReq.OnUsage(*usage)
+		}
+
+		if len(msg.ToolCalls) == 0 || len(reqBody.Tools) == 0 {
+			return msg.Content, nil
+		}
+
+		messages = append(messages, msg)
+		for _, call := range msg.ToolCalls {
+			messages = append(messages, Message{
+				Role:       roleTool,
+				ToolCallID: call.ID,
+				Content:    c.runTool(ctx, chatReq.ExecuteTool, call),
+			})
+		}
 	}
+}
 
-	resp, err := c.doRequest(ctx, data)
-	if err != nil {
-		return "", err
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
 	}
+}
 
-	return c.parseResponse(resp)
+func NewFunctionTool(name, description string, parameters map[string]any) Tool {
+	return Tool{
+		Type: toolTypeFunc,
+		Function: FunctionDef{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	}
 }
 
 func (c *Client) ValidateModel(model string) error {
@@ -115,15 +261,10 @@ func (c *Client) ValidateModel(model string) error {
 }
 
 func (c *Client) ListModels() []string {
-	return []string{
-		"llama-3.3-70b-versatile",
-		"llama-3.1-8b-instant",
-		"mixtral-8x7b-32768",
-		"gemma2-9b-it",
-	}
+	return append([]string(nil), c.models...)
 }
 
-func (c *Client) FetchModels(ctx context.Context) ([]string, error) {
+func (c *Client) FetchModels(ctx context.Context) ([]ModelInfo, error) {
 	data, err := c.doGetRequest(ctx, c.modelsURL)
 	if err != nil {
 		return nil, err
@@ -131,6 +272,10 @@ func (c *Client) FetchModels(ctx context.Context) ([]string, error) {
 	return c.parseModelsResponse(data)
 }
 
+func (c *Client) Model() string {
+	return c.model
+}
+
 func (c *Client) SetModel(model string) error {
 	for _, m := range c.ListModels() {
 		if m == model {
@@ -141,7 +286,7 @@ func (c *Client) SetModel(model string) error {
 	return fmt.Errorf("invalid model: %s", model)
 }
 
-func (c *Client) parseModelsResponse(data []byte) ([]string, error) {
+func (c *Client) parseModelsResponse(data []byte) ([]ModelInfo, error) {
 	var resp ModelsResponse
 	if err := json.Unmarshal(data, &resp); err != nil {
 		return nil, fmt.Errorf("failed to parse models response: %w", err)
@@ -149,20 +294,28 @@ func (c *Client) parseModelsResponse(data []byte) ([]string, error) {
 	return c.filterChatModels(resp.Data), nil
 }
 
-func (c *Client) filterChatModels(models []ModelInfo) []string {
-	var result []string
+func (c *Client) filterChatModels(models []ModelInfo) []ModelInfo {
+	var result []ModelInfo
 	for _, m := range models {
+		if m.Active != nil && !*m.Active {
+			continue
+		}
 		if c.isChatModel(m.ID) {
-			result = append(result, m.ID)
+			result = append(result, c.describeModel(m))
 		}
 	}
-	sort.Strings(result)
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
 	return result
 }
 
 func (c *Client) isChatModel(modelID string) bool {
 	lower := strings.ToLower(modelID)
-	return !strings.Contains(lower, "whisper")
+	for _, hint := range nonChatModelHints {
+		if strings.Contains(lower, hint) {
+			return false
+		}
+	}
+	return true
 }
 
 func (c *Client) doGetRequest(ctx context.Context, url string) ([]byte, error) {
@@ -171,7 +324,7 @@ func (c *Client) doGetRequest(ctx context.Context, url string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	c.setAuth(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -191,25 +344,56 @@ func (c *Client) doGetRequest(ctx context.Context, url string) ([]byte, error) {
 	return body, nil
 }
 
-func (c *Client) buildMessages(prompt string, history []Message) []Message {
+func (c *Client) buildMessages(system, prompt string, history []Message) []Message {
+	if system == "" {
+		system = systemPrompt
+	}
+
 	messages := make([]Message, 0, len(history)+2)
-	messages = append(messages, Message{Role: roleSystem, Content: systemPrompt})
+	messages = append(messages, Message{Role: roleSystem, Content: system})
 	messages = append(messages, history...)
 	messages = append(messages, Message{Role: roleUser, Content: prompt})
 	return messages
 }
 
+func (c *Client) requestMessages(chatReq ChatRequest) []Message {
+	messages := c.buildMessages(chatReq.SystemPrompt, chatReq.Prompt, chatReq.History)
+	messages[len(messages)-1].Images = chatReq.Images
+	return messages
+}
+
 func (c *Client) doRequest(ctx context.Context, data []byte) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := c.doRequestOnce(ctx, data)
+		if err == nil || !IsRetryable(err) || attempt+1 >= c.retry.MaxAttempts {
+			return body, err
+		}
+
+		wait, ok := c.retry.delay(attempt, err)
+		if !ok {
+			return nil, err
+		}
+
+		log.InfoContext(ctx, "Retrying LLM request", "provider", c.name, "attempt", attempt+1, "wait", wait, "error", err)
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) doRequestOnce(ctx context.Context, data []byte) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL, bytes.NewBuffer(data))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	c.setAuth(req)
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		log.ErrorContext(ctx, "LLM request failed", "provider", c.name, "error", err)
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
@@ -219,26 +403,68 @@ func (c *Client) doRequest(ctx context.Context, data []byte) ([]byte, error) {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	log.DebugContext(ctx, "LLM request completed", "provider", c.name, "status", resp.StatusCode, "duration", time.Since(start))
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("api error: %s", string(body))
+		apiErr := classifyError(resp, body)
+		log.WarnContext(ctx, "LLM API error", "provider", c.name, "status", resp.StatusCode, "kind", apiErr.Kind)
+		return nil, apiErr
 	}
 
 	return body, nil
 }
 
+func (c *Client) setAuth(req *http.Request) {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+}
+
+func (c *Client) send(ctx context.Context, reqBody Request) (Message, *Usage, error) {
+	data, err := json.Marshal(reqBody)
+	if err != nil {
+		return Message{}, nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, data)
+	if err != nil {
+		return Message{}, nil, err
+	}
+
+	return c.parseMessage(resp)
+}
+
+func (c *Client) runTool(ctx context.Context, execute ToolExecutor, call ToolCall) string {
+	log.DebugContext(ctx, "Executing tool call", "tool", call.Function.Name)
+	result, err := execute(ctx, call)
+	if err != nil {
+		log.WarnContext(ctx, "Tool call failed", "tool", call.Function.Name, "error", err)
+		return "error: " + err.Error()
+	}
+	return result
+}
+
 func (c *Client) parseResponse(data []byte) (string, error) {
+	msg, _, err := c.parseMessage(data)
+	if err != nil {
+		return "", err
+	}
+	return msg.Content, nil
+}
+
+func (c *Client) parseMessage(data []byte) (Message, *Usage, error) {
 	var resp Response
 	if err := json.Unmarshal(data, &resp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return Message{}, nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if resp.Error != nil {
-		return "", fmt.Errorf("groq error: %s", resp.Error.Message)
+		return Message{}, nil, fmt.Errorf("groq error: %s", resp.Error.Message)
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response choices")
+		return Message{}, resp.Usage, fmt.Errorf("no response choices")
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	return resp.Choices[0].Message, resp.Usage, nil
 }
@@ -3,9 +3,12 @@ package groq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 )
 
 const (
@@ -139,7 +142,7 @@ func TestClientBuildMessages(t *testing.T) {
 		{Role: "assistant", Content: "hello"},
 	}
 
-	messages := client.buildMessages("new prompt", history)
+	messages := client.buildMessages("", "new prompt", history)
 
 	if len(messages) != 4 {
 		t.Fatalf("want 4 messages, got %d", len(messages))
@@ -149,6 +152,10 @@ func TestClientBuildMessages(t *testing.T) {
 		t.Errorf("first message role = %s, want system", messages[0].Role)
 	}
 
+	if messages[0].Content != systemPrompt {
+		t.Errorf("system prompt = %s, want default", messages[0].Content)
+	}
+
 	if messages[1].Content != "hi" {
 		t.Errorf("second message content = %s, want hi", messages[1].Content)
 	}
@@ -206,6 +213,7 @@ func TestClientParseResponse(t *testing.T) {
 func newTestGroqClient(serverURL string) *Client {
 	client := NewClient(testAPIKey)
 	client.baseURL = serverURL
+	client.sleep = func(ctx context.Context, d time.Duration) error { return nil }
 	return client
 }
 
@@ -346,8 +354,40 @@ func TestClientFetchModelsSorted(t *testing.T) {
 
 	assertNoError(t, err)
 
-	if models[0] != "alpha-model" {
-		t.Errorf("models not sorted, first = %s, want alpha-model", models[0])
+	if models[0].ID != "alpha-model" {
+		t.Errorf("models not sorted, first = %s, want alpha-model", models[0].ID)
+	}
+}
+
+func TestClientFetchModelsMetadata(t *testing.T) {
+	inactive := false
+	response := ModelsResponse{
+		Data: []ModelInfo{
+			{ID: "llama-3.3-70b-versatile", ContextWindow: 131072},
+			{ID: "meta-llama/llama-4-scout-17b-16e-instruct"},
+			{ID: "gemma2-9b-it"},
+			{ID: "llama-guard-3-8b"},
+			{ID: "playai-tts"},
+			{ID: "mixtral-8x7b-32768", Active: &inactive},
+		},
+	}
+
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(response)
+	})
+
+	client := newTestGroqClientWithModelsURL(server.URL)
+	models, err := client.FetchModels(context.Background())
+
+	assertNoError(t, err)
+
+	want := []ModelInfo{
+		{ID: "gemma2-9b-it", ContextWindow: 8192, Tools: true},
+		{ID: "llama-3.3-70b-versatile", ContextWindow: 131072, Tools: true},
+		{ID: "meta-llama/llama-4-scout-17b-16e-instruct", ContextWindow: defaultContextWindow, Vision: true, Tools: true},
+	}
+	if !reflect.DeepEqual(models, want) {
+		t.Errorf("FetchModels() = %+v, want %+v", models, want)
 	}
 }
 
@@ -356,3 +396,159 @@ func newTestGroqClientWithModelsURL(serverURL string) *Client {
 	client.modelsURL = serverURL
 	return client
 }
+
+func TestClientBuildMessagesCustomSystemPrompt(t *testing.T) {
+	client := NewClient(testAPIKey)
+
+	messages := client.buildMessages("You are a pirate.", "hello", nil)
+
+	if len(messages) != 2 {
+		t.Fatalf("want 2 messages, got %d", len(messages))
+	}
+
+	if messages[0].Content != "You are a pirate." {
+		t.Errorf("system prompt = %s, want custom prompt", messages[0].Content)
+	}
+}
+
+func TestClientCompleteWithTools(t *testing.T) {
+	var requests []Request
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		if len(requests) == 1 {
+			_ = json.NewEncoder(w).Encode(Response{Choices: []Choice{{Message: Message{
+				Role: "assistant",
+				ToolCalls: []ToolCall{{
+					ID:       "call_1",
+					Type:     "function",
+					Function: FunctionCall{Name: "get_time", Arguments: `{"zone":"UTC"}`},
+				}},
+			}}}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(newSuccessResponse("It is noon."))
+	})
+
+	client := newTestGroqClient(server.URL)
+	var executed ToolCall
+	got, err := client.Complete(context.Background(), ChatRequest{
+		Prompt: "what time is it?",
+		Tools:  []Tool{NewFunctionTool("get_time", "Current time", map[string]any{"type": "object"})},
+		ExecuteTool: func(ctx context.Context, call ToolCall) (string, error) {
+			executed = call
+			return "12:00", nil
+		},
+	})
+
+	assertNoError(t, err)
+	if got != "It is noon." {
+		t.Errorf("got %q, want final answer", got)
+	}
+	if executed.Function.Arguments != `{"zone":"UTC"}` {
+		t.Errorf("tool executed with %q", executed.Function.Arguments)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("want 2 requests, got %d", len(requests))
+	}
+	if len(requests[0].Tools) != 1 {
+		t.Errorf("first request should advertise tools")
+	}
+
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	if last.Role != "tool" || last.ToolCallID != "call_1" || last.Content != "12:00" {
+		t.Errorf("unexpected tool result message: %+v", last)
+	}
+}
+
+func TestClientCompleteStopsAfterMaxToolRounds(t *testing.T) {
+	calls := 0
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Tools) == 0 {
+			_ = json.NewEncoder(w).Encode(newSuccessResponse("done"))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Response{Choices: []Choice{{Message: Message{
+			Role:      "assistant",
+			ToolCalls: []ToolCall{{ID: "call", Type: "function", Function: FunctionCall{Name: "loop"}}},
+		}}}})
+	})
+
+	client := newTestGroqClient(server.URL)
+	got, err := client.Complete(context.Background(), ChatRequest{
+		Prompt:      "loop forever",
+		Tools:       []Tool{NewFunctionTool("loop", "Loops", nil)},
+		ExecuteTool: func(ctx context.Context, call ToolCall) (string, error) { return "", errors.New("nope") },
+	})
+
+	assertNoError(t, err)
+	if got != "done" || calls != maxToolRounds+1 {
+		t.Errorf("got %q after %d calls, want done after %d", got, calls, maxToolRounds+1)
+	}
+}
+
+func TestClientCompleteReportsUsage(t *testing.T) {
+	calls := 0
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		resp := newSuccessResponse("done")
+		if calls == 1 {
+			resp = Response{Choices: []Choice{{Message: Message{
+				Role:      "assistant",
+				ToolCalls: []ToolCall{{ID: "call", Type: "function", Function: FunctionCall{Name: "noop"}}},
+			}}}}
+		}
+		resp.Usage = &Usage{PromptTokens: 100 * calls, CompletionTokens: 10 * calls, TotalTokens: 110 * calls}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+
+	client := newTestGroqClient(server.URL)
+	var total Usage
+	_, err := client.Complete(context.Background(), ChatRequest{
+		Prompt:      "hi",
+		Tools:       []Tool{NewFunctionTool("noop", "Does nothing", nil)},
+		ExecuteTool: func(ctx context.Context, call ToolCall) (string, error) { return "ok", nil },
+		OnUsage: func(usage Usage) {
+			total.PromptTokens += usage.PromptTokens
+			total.CompletionTokens += usage.CompletionTokens
+		},
+	})
+
+	assertNoError(t, err)
+	if total.PromptTokens != 300 || total.CompletionTokens != 30 {
+		t.Errorf("usage = %+v, want 300 prompt and 30 completion tokens", total)
+	}
+}
+
+func TestNewCompatibleClient(t *testing.T) {
+	var gotPath, gotAuth string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewEncoder(w).Encode(newSuccessResponse("local answer"))
+	})
+
+	client := NewCompatibleClient("ollama", server.URL+"/v1/", "", "", []string{"llama3.1"})
+	got, err := client.Chat(context.Background(), "hi", nil)
+
+	assertNoError(t, err)
+	if got != "local answer" {
+		t.Errorf("got %q, want %q", got, "local answer")
+	}
+	if gotPath != "/v1/chat/completions" {
+		t.Errorf("path = %q, want %q", gotPath, "/v1/chat/completions")
+	}
+	if gotAuth != "" {
+		t.Errorf("Authorization = %q, want none without an API key", gotAuth)
+	}
+	if client.Name() != "ollama" || client.model != "llama3.1" {
+		t.Errorf("client = %q/%q, want ollama/llama3.1", client.Name(), client.model)
+	}
+}
@@ -0,0 +1,153 @@
+package groq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxAttempts   = 3
+	defaultBaseDelay     = 500 * time.Millisecond
+	defaultMaxDelay      = 8 * time.Second
+	maxRetryAfter        = 30 * time.Second
+	maxErrorMessageBytes = 500
+)
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+type APIError struct {
+	Kind       error
+	StatusCode int
+	RetryAfter time.Duration
+	Message    string
+}
+
+var (
+	ErrRateLimited      = errors.New("rate limited")
+	ErrOverloaded       = errors.New("provider overloaded")
+	ErrContextTooLong   = errors.New("context too long")
+	ErrAuth             = errors.New("authentication failed")
+	ErrModelUnavailable = errors.New("model unavailable")
+	ErrRequest          = errors.New("request failed")
+)
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: defaultMaxAttempts,
+		BaseDelay:   defaultBaseDelay,
+		MaxDelay:    defaultMaxDelay,
+	}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error (%d, %v): %s", e.StatusCode, e.Kind, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrOverloaded)
+}
+
+func ShouldFallback(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrContextTooLong) {
+		return false
+	}
+	return true
+}
+
+func classifyError(resp *http.Response, body []byte) *APIError {
+	message := strings.TrimSpace(string(body))
+	if len(message) > maxErrorMessageBytes {
+		message = message[:maxErrorMessageBytes]
+	}
+
+	apiErr := &APIError{
+		Kind:       ErrRequest,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Message:    message,
+	}
+
+	lower := strings.ToLower(message)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		apiErr.Kind = ErrRateLimited
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		apiErr.Kind = ErrAuth
+	case resp.StatusCode == http.StatusRequestEntityTooLarge,
+		strings.Contains(lower, "context_length"),
+		strings.Contains(lower, "context length"),
+		strings.Contains(lower, "reduce the length"):
+		apiErr.Kind = ErrContextTooLong
+	case resp.StatusCode == http.StatusNotFound,
+		strings.Contains(lower, "model_not_found"),
+		strings.Contains(lower, "model_decommissioned"),
+		strings.Contains(lower, "does not exist"):
+		apiErr.Kind = ErrModelUnavailable
+	case resp.StatusCode >= http.StatusInternalServerError:
+		apiErr.Kind = ErrOverloaded
+	}
+
+	return apiErr
+}
+
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
+
+func (p RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > maxRetryAfter {
+			return 0, false
+		}
+		return apiErr.RetryAfter, true
+	}
+
+	backoff := p.BaseDelay << attempt
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	return backoff/2 + rand.N(backoff/2+1), true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
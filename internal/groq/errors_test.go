@@ -0,0 +1,194 @@
+package groq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		retryAfter string
+		wantKind   error
+		wantWait   time.Duration
+	}{
+		{
+			name:       "RateLimited",
+			status:     http.StatusTooManyRequests,
+			body:       `{"error":{"message":"Rate limit reached"}}`,
+			retryAfter: "2",
+			wantKind:   ErrRateLimited,
+			wantWait:   2 * time.Second,
+		},
+		{
+			name:     "Overloaded",
+			status:   http.StatusServiceUnavailable,
+			body:     "over capacity",
+			wantKind: ErrOverloaded,
+		},
+		{
+			name:     "Unauthorized",
+			status:   http.StatusUnauthorized,
+			body:     "invalid api key",
+			wantKind: ErrAuth,
+		},
+		{
+			name:     "ContextTooLong",
+			status:   http.StatusBadRequest,
+			body:     `{"error":{"code":"context_length_exceeded"}}`,
+			wantKind: ErrContextTooLong,
+		},
+		{
+			name:     "ModelDecommissioned",
+			status:   http.StatusBadRequest,
+			body:     `{"error":{"code":"model_decommissioned"}}`,
+			wantKind: ErrModelUnavailable,
+		},
+		{
+			name:     "OtherBadRequest",
+			status:   http.StatusBadRequest,
+			body:     "bad request",
+			wantKind: ErrRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			err := classifyError(resp, []byte(tt.body))
+
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("kind = %v, want %v", err.Kind, tt.wantKind)
+			}
+			if err.RetryAfter != tt.wantWait {
+				t.Errorf("RetryAfter = %v, want %v", err.RetryAfter, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "Empty", value: "", want: 0},
+		{name: "Seconds", value: "3", want: 3 * time.Second},
+		{name: "Fractional", value: "0.5", want: 500 * time.Millisecond},
+		{name: "HTTPDate", value: now.Add(10 * time.Second).Format(http.TimeFormat), want: 10 * time.Second},
+		{name: "PastDate", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "Garbage", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientRetriesRetryableErrors(t *testing.T) {
+	calls := 0
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(newSuccessResponse("finally"))
+	})
+	client := newTestGroqClient(server.URL)
+
+	var waited []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		waited = append(waited, d)
+		return nil
+	}
+
+	got, err := client.Chat(context.Background(), "hi", nil)
+
+	assertNoError(t, err)
+	if got != "finally" {
+		t.Errorf("got %q, want %q", got, "finally")
+	}
+	if len(waited) != 1 || waited[0] != time.Second {
+		t.Errorf("waited %v, want [1s] from Retry-After", waited)
+	}
+}
+
+func TestClientRetryLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantCalls int
+		wantKind  error
+	}{
+		{
+			name:      "GivesUpAfterMaxAttempts",
+			status:    http.StatusServiceUnavailable,
+			wantCalls: defaultMaxAttempts,
+			wantKind:  ErrOverloaded,
+		},
+		{
+			name:      "DoesNotRetryAuth",
+			status:    http.StatusUnauthorized,
+			wantCalls: 1,
+			wantKind:  ErrAuth,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tt.status)
+			})
+			client := newTestGroqClient(server.URL)
+
+			_, err := client.Chat(context.Background(), "hi", nil)
+
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("error = %v, want %v", err, tt.wantKind)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	for attempt := range 4 {
+		wait, ok := policy.delay(attempt, ErrOverloaded)
+		if !ok {
+			t.Fatalf("attempt %d: delay refused", attempt)
+		}
+		ceiling := min(policy.BaseDelay<<attempt, policy.MaxDelay)
+		if wait < ceiling/2 || wait > ceiling {
+			t.Errorf("attempt %d: wait %v outside [%v, %v]", attempt, wait, ceiling/2, ceiling)
+		}
+	}
+
+	if _, ok := policy.delay(0, &APIError{Kind: ErrRateLimited, RetryAfter: time.Hour}); ok {
+		t.Error("delay should refuse a Retry-After above the cap")
+	}
+}
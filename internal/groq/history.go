@@ -0,0 +1,203 @@
+package groq
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultContextWindow  = 8192
+	defaultCharsPerToken  = 4.0
+	nonASCIICharsPerToken = 1.5
+	messageOverheadTokens = 4
+	replyReserveTokens    = 1024
+	toolsReserveTokens    = 512
+	maxHistoryTokens      = 16000
+	MaxHistoryMessages    = 200
+	maxKeptTurns          = MaxHistoryMessages - 3
+	summaryReserveTokens  = 1024
+	summaryPrefix         = "Summary of the earlier conversation: "
+	summarizePrompt       = "You maintain a running summary of a Telegram chat with an assistant. " +
+		"Merge the previous summary and the new messages into one short summary in plain text. " +
+		"Keep names, facts, decisions and open questions; drop greetings and small talk. " +
+		"Reply with the summary only."
+)
+
+type modelLimits struct {
+	contextWindow int
+	charsPerToken float64
+}
+
+var knownModels = map[string]modelLimits{
+	"llama-3.3-70b-versatile": {contextWindow: 131072, charsPerToken: 4.0},
+	"llama-3.1-8b-instant":    {contextWindow: 131072, charsPerToken: 4.0},
+	"mixtral-8x7b-32768":      {contextWindow: 32768, charsPerToken: 3.5},
+	"gemma2-9b-it":            {contextWindow: 8192, charsPerToken: 3.8},
+}
+
+func ContextWindow(model string) int {
+	return limitsFor(model).contextWindow
+}
+
+func EstimateTokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+
+	limits := limitsFor(model)
+	tokens := float64(ascii)/limits.charsPerToken + float64(other)/nonASCIICharsPerToken
+	return int(tokens) + 1
+}
+
+func EstimateMessagesTokens(model string, messages []Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += messageOverheadTokens + EstimateTokens(model, msg.Content)
+		for _, call := range msg.ToolCalls {
+			total += EstimateTokens(model, call.Function.Name+call.Function.Arguments)
+		}
+	}
+	return total
+}
+
+func HistoryBudget(model string, window int, system, prompt string, withTools bool) int {
+	if window <= 0 {
+		window = ContextWindow(model)
+	}
+	budget := window - replyReserveTokens
+	budget -= EstimateMessagesTokens(model, []Message{
+		{Role: roleSystem, Content: system},
+		{Role: roleUser, Content: prompt},
+	})
+	if withTools {
+		budget -= toolsReserveTokens
+	}
+	budget = min(budget, maxHistoryTokens)
+	return max(budget, 0)
+}
+
+func FitHistory(model string, history []Message, budget int) (kept, dropped []Message) {
+	summary, turns := SplitSummary(history)
+	if summary != "" {
+		budget -= EstimateMessagesTokens(model, []Message{SummaryMessage(summary)})
+	}
+
+	start := len(turns)
+	used := 0
+	for i := len(turns) - 1; i >= 0; i-- {
+		cost := EstimateMessagesTokens(model, turns[i:i+1])
+		if used+cost > budget || len(turns)-i > maxKeptTurns {
+			break
+		}
+		used += cost
+		start = i
+	}
+
+	for start < len(turns) && turns[start].Role != roleUser {
+		start++
+	}
+
+	return turns[start:], turns[:start]
+}
+
+func SplitSummary(history []Message) (string, []Message) {
+	if len(history) > 0 && history[0].Role == roleSystem && strings.HasPrefix(history[0].Content, summaryPrefix) {
+		return strings.TrimPrefix(history[0].Content, summaryPrefix), history[1:]
+	}
+	return "", history
+}
+
+func SummaryMessage(summary string) Message {
+	return Message{Role: roleSystem, Content: summaryPrefix + summary}
+}
+
+func WithSummary(summary string, turns []Message) []Message {
+	if summary == "" {
+		return turns
+	}
+	return append([]Message{SummaryMessage(summary)}, turns...)
+}
+
+func (c *Client) Summarize(ctx context.Context, model, previous string, messages []Message) (string, Usage, error) {
+	if model == "" {
+		model = c.model
+	}
+
+	summary := previous
+	var total Usage
+	for _, chunk := range summaryChunks(model, messages) {
+		var sb strings.Builder
+		if summary != "" {
+			sb.WriteString("Previous summary:\n")
+			sb.WriteString(summary)
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString("New messages:\n")
+		for _, line := range chunk {
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+
+		msg, usage, err := c.send(ctx, Request{
+			Model:    model,
+			Messages: c.buildMessages(summarizePrompt, sb.String(), nil),
+		})
+		if usage != nil {
+			total = total.Add(*usage)
+		}
+		if err != nil {
+			return "", total, err
+		}
+		summary = strings.TrimSpace(msg.Content)
+	}
+
+	return summary, total, nil
+}
+
+func summaryChunks(model string, messages []Message) [][]string {
+	budget := ContextWindow(model) - replyReserveTokens - summaryReserveTokens - EstimateTokens(model, summarizePrompt)
+	budget = max(min(budget, maxHistoryTokens), summaryReserveTokens)
+	maxRunes := int(float64(budget) * nonASCIICharsPerToken)
+
+	var chunks [][]string
+	var current []string
+	used := 0
+	for _, msg := range messages {
+		line := []rune(fmt.Sprintf("%s: %s", msg.Role, msg.Content))
+		for len(line) > 0 {
+			n := min(len(line), maxRunes)
+			piece := string(line[:n])
+			line = line[n:]
+
+			cost := EstimateTokens(model, piece) + 1
+			if len(current) > 0 && used+cost > budget {
+				chunks = append(chunks, current)
+				current, used = nil, 0
+			}
+			current = append(current, piece)
+			used += cost
+		}
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+func limitsFor(model string) modelLimits {
+	if limits, ok := knownModels[model]; ok {
+		return limits
+	}
+	return modelLimits{contextWindow: defaultContextWindow, charsPerToken: defaultCharsPerToken}
+}
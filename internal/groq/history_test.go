@@ -0,0 +1,252 @@
+package groq
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		text  string
+		want  int
+	}{
+		{
+			name:  "Empty",
+			model: defaultModel,
+			text:  "",
+			want:  0,
+		},
+		{
+			name:  "ASCII",
+			model: defaultModel,
+			text:  strings.Repeat("a", 400),
+			want:  101,
+		},
+		{
+			name:  "NonASCII",
+			model: defaultModel,
+			text:  strings.Repeat("я", 300),
+			want:  201,
+		},
+		{
+			name:  "DenserTokenizer",
+			model: "mixtral-8x7b-32768",
+			text:  strings.Repeat("a", 350),
+			want:  101,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.model, tt.text); got != tt.want {
+				t.Errorf("EstimateTokens() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	if got := ContextWindow("gemma2-9b-it"); got != 8192 {
+		t.Errorf("ContextWindow(gemma2-9b-it) = %d, want 8192", got)
+	}
+	if got := ContextWindow("unknown-model"); got != defaultContextWindow {
+		t.Errorf("ContextWindow(unknown) = %d, want %d", got, defaultContextWindow)
+	}
+}
+
+func TestHistoryBudget(t *testing.T) {
+	small := HistoryBudget("gemma2-9b-it", 0, "system", "prompt", false)
+	if small <= 0 || small >= 8192-replyReserveTokens {
+		t.Errorf("budget for gemma = %d, want between 0 and %d", small, 8192-replyReserveTokens)
+	}
+
+	large := HistoryBudget(defaultModel, 0, "system", "prompt", false)
+	if large != maxHistoryTokens {
+		t.Errorf("budget for %s = %d, want cap %d", defaultModel, large, maxHistoryTokens)
+	}
+
+	huge := HistoryBudget("gemma2-9b-it", 0, "system", strings.Repeat("a", 40000), false)
+	if huge != 0 {
+		t.Errorf("budget with oversized prompt = %d, want 0", huge)
+	}
+
+	reported := HistoryBudget("unknown-model", 131072, "system", "prompt", false)
+	if reported != maxHistoryTokens {
+		t.Errorf("budget with a reported 128k window = %d, want cap %d", reported, maxHistoryTokens)
+	}
+}
+
+func TestFitHistory(t *testing.T) {
+	history := []Message{
+		{Role: "user", Content: strings.Repeat("a", 400)},
+		{Role: "assistant", Content: strings.Repeat("b", 400)},
+		{Role: "user", Content: "short"},
+		{Role: "assistant", Content: "reply"},
+	}
+
+	tests := []struct {
+		name        string
+		history     []Message
+		budget      int
+		wantKept    int
+		wantDropped int
+	}{
+		{
+			name:        "EverythingFits",
+			history:     history,
+			budget:      1000,
+			wantKept:    4,
+			wantDropped: 0,
+		},
+		{
+			name:        "DropsOldestTurn",
+			history:     history,
+			budget:      150,
+			wantKept:    2,
+			wantDropped: 2,
+		},
+		{
+			name:        "NeverStartsWithAssistant",
+			history:     history,
+			budget:      120,
+			wantKept:    2,
+			wantDropped: 2,
+		},
+		{
+			name:        "NothingFits",
+			history:     history,
+			budget:      0,
+			wantKept:    0,
+			wantDropped: 4,
+		},
+		{
+			name:        "CapsTurnCount",
+			history:     manyTurns(MaxHistoryMessages),
+			budget:      100000,
+			wantKept:    maxKeptTurns - 1,
+			wantDropped: MaxHistoryMessages - maxKeptTurns + 1,
+		},
+		{
+			name:        "SummaryIsNotCounted",
+			history:     append([]Message{SummaryMessage("earlier")}, history...),
+			budget:      1000,
+			wantKept:    4,
+			wantDropped: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, dropped := FitHistory(defaultModel, tt.history, tt.budget)
+
+			if len(kept) != tt.wantKept {
+				t.Errorf("kept %d messages, want %d", len(kept), tt.wantKept)
+			}
+			if len(dropped) != tt.wantDropped {
+				t.Errorf("dropped %d messages, want %d", len(dropped), tt.wantDropped)
+			}
+			if len(kept) > 0 && kept[0].Role != roleUser {
+				t.Errorf("kept history starts with %q, want %q", kept[0].Role, roleUser)
+			}
+		})
+	}
+}
+
+func manyTurns(n int) []Message {
+	turns := make([]Message, n)
+	for i := range turns {
+		turns[i] = Message{Role: roleUser, Content: "hi"}
+		if i%2 == 1 {
+			turns[i].Role = "assistant"
+		}
+	}
+	return turns
+}
+
+func TestSplitSummary(t *testing.T) {
+	turns := []Message{{Role: "user", Content: "hi"}}
+
+	summary, rest := SplitSummary(WithSummary("we talked about Go", turns))
+	if summary != "we talked about Go" {
+		t.Errorf("summary = %q, want %q", summary, "we talked about Go")
+	}
+	if len(rest) != 1 {
+		t.Errorf("rest has %d messages, want 1", len(rest))
+	}
+
+	summary, rest = SplitSummary(turns)
+	if summary != "" || len(rest) != 1 {
+		t.Errorf("SplitSummary() without summary = %q, %d messages", summary, len(rest))
+	}
+}
+
+func TestClientSummarize(t *testing.T) {
+	var got Request
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		resp := newSuccessResponse("  Alice asked about Go.  ")
+		resp.Usage = &Usage{PromptTokens: 40, CompletionTokens: 6, TotalTokens: 46}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+	client := newTestGroqClient(server.URL)
+
+	summary, usage, err := client.Summarize(context.Background(), "", "Earlier summary", []Message{
+		{Role: "user", Content: "alice: what is Go?"},
+	})
+
+	assertNoError(t, err)
+	if summary != "Alice asked about Go." {
+		t.Errorf("summary = %q, want %q", summary, "Alice asked about Go.")
+	}
+	if usage.TotalTokens != 46 {
+		t.Errorf("usage = %+v, want the summary request's tokens", usage)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Content != summarizePrompt {
+		t.Fatalf("unexpected request messages: %+v", got.Messages)
+	}
+	if !strings.Contains(got.Messages[1].Content, "Earlier summary") || !strings.Contains(got.Messages[1].Content, "what is Go?") {
+		t.Errorf("prompt = %q, want previous summary and new messages", got.Messages[1].Content)
+	}
+}
+
+func TestClientSummarizeChunksLongInput(t *testing.T) {
+	var prompts []string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		var got Request
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		prompts = append(prompts, got.Messages[1].Content)
+		_ = json.NewEncoder(w).Encode(newSuccessResponse("summary " + strings.Repeat("x", len(prompts))))
+	})
+	client := newTestGroqClient(server.URL)
+
+	summary, _, err := client.Summarize(context.Background(), "gemma2-9b-it", "", []Message{
+		{Role: "user", Content: strings.Repeat("word ", 8000)},
+		{Role: "assistant", Content: "short"},
+	})
+
+	assertNoError(t, err)
+	if len(prompts) < 2 {
+		t.Fatalf("made %d requests, want the input split into several", len(prompts))
+	}
+	limit := ContextWindow("gemma2-9b-it")
+	for i, prompt := range prompts {
+		if tokens := EstimateTokens("gemma2-9b-it", prompt); tokens > limit {
+			t.Errorf("request %d has %d tokens, over the %d window", i, tokens, limit)
+		}
+	}
+	if !strings.Contains(prompts[1], "summary x") {
+		t.Errorf("second request = %q, want the running summary carried over", prompts[1][:80])
+	}
+	if summary != "summary "+strings.Repeat("x", len(prompts)) {
+		t.Errorf("summary = %q, want the last partial", summary)
+	}
+}
@@ -0,0 +1,37 @@
+package groq
+
+import "strings"
+
+var (
+	nonChatModelHints = []string{"whisper", "tts", "guard"}
+	noToolModelHints  = []string{"llava", "saba", "allam", "compound"}
+)
+
+func DescribeModel(model string) ModelInfo {
+	return ModelInfo{
+		ID:            model,
+		ContextWindow: ContextWindow(model),
+		Vision:        IsVisionModel(model),
+		Tools:         SupportsTools(model),
+	}
+}
+
+func SupportsTools(model string) bool {
+	lower := strings.ToLower(model)
+	for _, hint := range noToolModelHints {
+		if strings.Contains(lower, hint) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Client) describeModel(m ModelInfo) ModelInfo {
+	info := DescribeModel(m.ID)
+	info.OwnedBy = m.OwnedBy
+	if m.ContextWindow > 0 {
+		info.ContextWindow = m.ContextWindow
+	}
+	info.Vision = info.Vision || m.ID == c.visionModel
+	return info
+}
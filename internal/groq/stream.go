@@ -0,0 +1,109 @@
+package groq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	streamDataPrefix = "data:"
+	streamDone       = "[DONE]"
+)
+
+type StreamHandler func(delta string)
+
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *Error `json:"error,omitempty"`
+}
+
+func (c *Client) Stream(ctx context.Context, chatReq ChatRequest, onDelta StreamHandler) (string, error) {
+	model := chatReq.Model
+	if model == "" {
+		model = c.model
+	}
+
+	data, err := json.Marshal(Request{
+		Model:    model,
+		Messages: c.requestMessages(chatReq),
+		Stream:   true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL, bytes.NewBuffer(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	c.setAuth(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		log.ErrorContext(ctx, "LLM stream request failed", "provider", c.name, "error", err)
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		apiErr := classifyError(resp, body)
+		log.WarnContext(ctx, "LLM API error", "provider", c.name, "status", resp.StatusCode, "kind", apiErr.Kind)
+		return "", apiErr
+	}
+
+	return c.readStream(resp.Body, onDelta)
+}
+
+func (c *Client) readStream(body io.Reader, onDelta StreamHandler) (string, error) {
+	var sb strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, streamDataPrefix) {
+			continue
+		}
+
+		payload := strings.TrimSpace(strings.TrimPrefix(line, streamDataPrefix))
+		if payload == streamDone {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			return sb.String(), fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return sb.String(), fmt.Errorf("%s error: %s", c.name, chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta.Content
+		sb.WriteString(delta)
+		if onDelta != nil {
+			onDelta(delta)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return sb.String(), fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,50 @@
+package groq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestClientStream(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if !req.Stream {
+			t.Error("request should enable streaming")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{"Hel", "lo", "!"} {
+			_, _ = fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", delta)
+		}
+		_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	client := newTestGroqClient(server.URL)
+
+	var deltas []string
+	got, err := client.Stream(context.Background(), ChatRequest{Prompt: "hi"}, func(delta string) {
+		deltas = append(deltas, delta)
+	})
+
+	assertNoError(t, err)
+	if got != "Hello!" {
+		t.Errorf("got %q, want %q", got, "Hello!")
+	}
+	if len(deltas) != 3 {
+		t.Errorf("got %d deltas, want 3", len(deltas))
+	}
+}
+
+func TestClientStreamHTTPError(t *testing.T) {
+	server := newGroqTestServer(t, Response{}, http.StatusInternalServerError)
+	client := newTestGroqClient(server.URL)
+
+	_, err := client.Stream(context.Background(), ChatRequest{Prompt: "hi"}, nil)
+
+	assertError(t, err, true)
+}
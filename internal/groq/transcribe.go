@@ -0,0 +1,101 @@
+package groq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTranscriptionModel = "whisper-large-v3-turbo"
+	transcriptionFormat       = "json"
+	defaultAudioFilename      = "audio.ogg"
+)
+
+type transcriptionResponse struct {
+	Text string `json:"text"`
+}
+
+func (c *Client) TranscriptionModel() string {
+	return c.audioModel
+}
+
+func (c *Client) SetTranscriptionModel(model string) {
+	c.audioModel = model
+}
+
+func (c *Client) Transcribe(ctx context.Context, audio []byte, filename, language string) (string, error) {
+	if c.audioModel == "" {
+		return "", fmt.Errorf("%s has no transcription model", c.name)
+	}
+	if filename == "" {
+		filename = defaultAudioFilename
+	}
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", fmt.Errorf("failed to write audio: %w", err)
+	}
+
+	fields := map[string]string{
+		"model":           c.audioModel,
+		"response_format": transcriptionFormat,
+	}
+	if language != "" {
+		fields["language"] = language
+	}
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return "", fmt.Errorf("failed to write form field: %w", err)
+		}
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to close form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.audioURL, &buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	c.setAuth(req)
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		log.ErrorContext(ctx, "Transcription request failed", "provider", c.name, "error", err)
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	log.DebugContext(ctx, "Transcription request completed", "provider", c.name, "status", resp.StatusCode, "duration", time.Since(start))
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := classifyError(resp, body)
+		log.WarnContext(ctx, "Transcription API error", "provider", c.name, "status", resp.StatusCode, "kind", apiErr.Kind)
+		return "", apiErr
+	}
+
+	var result transcriptionResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse transcription response: %w", err)
+	}
+
+	return strings.TrimSpace(result.Text), nil
+}
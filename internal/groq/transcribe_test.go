@@ -0,0 +1,66 @@
+package groq
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestClientTranscribe(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testAPIKey {
+			t.Error("missing or invalid authorization header")
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("failed to parse form: %v", err)
+		}
+		if got := r.FormValue("model"); got != defaultTranscriptionModel {
+			t.Errorf("model = %q, want %q", got, defaultTranscriptionModel)
+		}
+		if got := r.FormValue("language"); got != "en" {
+			t.Errorf("language = %q, want %q", got, "en")
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("missing file: %v", err)
+		}
+		defer func() { _ = file.Close() }()
+		data, _ := io.ReadAll(file)
+		if header.Filename != "voice.ogg" || string(data) != "audio-bytes" {
+			t.Errorf("file = %q (%q), want voice.ogg with audio", header.Filename, data)
+		}
+
+		_, _ = w.Write([]byte(`{"text":"  hello there  "}`))
+	})
+	client := NewClient(testAPIKey)
+	client.audioURL = server.URL
+
+	text, err := client.Transcribe(context.Background(), []byte("audio-bytes"), "voice.ogg", "en")
+
+	assertNoError(t, err)
+	if text != "hello there" {
+		t.Errorf("text = %q, want %q", text, "hello there")
+	}
+}
+
+func TestClientTranscribeError(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"message":"slow down"}}`))
+	})
+	client := NewClient(testAPIKey)
+	client.audioURL = server.URL
+
+	_, err := client.Transcribe(context.Background(), []byte("audio"), "", "")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("want %v, got %v", ErrRateLimited, err)
+	}
+
+	client.SetTranscriptionModel("")
+	if _, err := client.Transcribe(context.Background(), []byte("audio"), "", ""); err == nil {
+		t.Error("want error without transcription model")
+	}
+}
@@ -0,0 +1,83 @@
+package groq
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	defaultVisionModel = "meta-llama/llama-4-scout-17b-16e-instruct"
+	partTypeText       = "text"
+	partTypeImageURL   = "image_url"
+)
+
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+type wireMessage struct {
+	Role       string     `json:"role"`
+	Content    any        `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+var visionModelHints = []string{"vision", "llava", "llama-4", "-vl", "gpt-4o", "pixtral", "gemma3"}
+
+func IsVisionModel(model string) bool {
+	lower := strings.ToLower(model)
+	for _, hint := range visionModelHints {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) VisionModel() string {
+	return c.visionModel
+}
+
+func (c *Client) SetVisionModel(model string) {
+	c.visionModel = model
+}
+
+func (r Request) MarshalJSON() ([]byte, error) {
+	type plain Request
+	wire := struct {
+		plain
+		Messages []wireMessage `json:"messages"`
+	}{plain: plain(r)}
+
+	wire.Messages = make([]wireMessage, 0, len(r.Messages))
+	for _, msg := range r.Messages {
+		wire.Messages = append(wire.Messages, msg.wire())
+	}
+	return json.Marshal(wire)
+}
+
+func (m Message) wire() wireMessage {
+	wm := wireMessage{
+		Role:       m.Role,
+		Content:    m.Content,
+		ToolCalls:  m.ToolCalls,
+		ToolCallID: m.ToolCallID,
+	}
+	if len(m.Images) == 0 {
+		return wm
+	}
+
+	parts := make([]ContentPart, 0, len(m.Images)+1)
+	parts = append(parts, ContentPart{Type: partTypeText, Text: m.Content})
+	for _, image := range m.Images {
+		parts = append(parts, ContentPart{Type: partTypeImageURL, ImageURL: &ImageURL{URL: image}})
+	}
+	wm.Content = parts
+	return wm
+}
@@ -0,0 +1,62 @@
+package groq
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRequestMarshalJSONWithImages(t *testing.T) {
+	req := Request{
+		Model: "scout",
+		Messages: []Message{
+			{Role: "user", Content: "earlier", ImageRefs: []string{"file-1"}},
+			{Role: "user", Content: "what is this?", Images: []string{"data:image/jpeg;base64,AA=="}},
+		},
+	}
+
+	data, err := json.Marshal(req)
+	assertNoError(t, err)
+
+	var wire struct {
+		Model    string           `json:"model"`
+		Messages []map[string]any `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		t.Fatalf("failed to decode wire request: %v", err)
+	}
+
+	if wire.Model != "scout" {
+		t.Errorf("model = %q, want %q", wire.Model, "scout")
+	}
+	if _, ok := wire.Messages[0]["image_refs"]; ok {
+		t.Error("image references must not be sent to the API")
+	}
+	if wire.Messages[0]["content"] != "earlier" {
+		t.Errorf("text message content = %v, want plain string", wire.Messages[0]["content"])
+	}
+
+	parts, ok := wire.Messages[1]["content"].([]any)
+	if !ok || len(parts) != 2 {
+		t.Fatalf("image message content = %v, want two parts", wire.Messages[1]["content"])
+	}
+	image := parts[1].(map[string]any)
+	if image["type"] != "image_url" || image["image_url"].(map[string]any)["url"] != "data:image/jpeg;base64,AA==" {
+		t.Errorf("image part = %v", image)
+	}
+}
+
+func TestIsVisionModel(t *testing.T) {
+	tests := map[string]bool{
+		"meta-llama/llama-4-scout-17b-16e-instruct": true,
+		"llava:13b":               true,
+		"qwen2.5-vl":              true,
+		"llama-3.3-70b-versatile": false,
+		"gemma2-9b-it":            false,
+	}
+
+	for model, want := range tests {
+		if got := IsVisionModel(model); got != want {
+			t.Errorf("IsVisionModel(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
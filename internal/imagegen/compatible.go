@@ -0,0 +1,128 @@
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	imagesPath          = "/images/generations"
+	responseFormatB64   = "b64_json"
+	maxErrorDetailBytes = 300
+)
+
+type Compatible struct {
+	name       string
+	apiKey     string
+	model      string
+	endpoint   string
+	httpClient *http.Client
+}
+
+type imagesRequest struct {
+	Model          string `json:"model,omitempty"`
+	Prompt         string `json:"prompt"`
+	N              int    `json:"n"`
+	Size           string `json:"size"`
+	ResponseFormat string `json:"response_format"`
+}
+
+type imagesResponse struct {
+	Data []struct {
+		B64JSON string `json:"b64_json"`
+		URL     string `json:"url"`
+	} `json:"data"`
+}
+
+var errEmptyImages = errors.New("image api returned no images")
+
+func NewCompatible(name, baseURL, apiKey, model string, timeout time.Duration) *Compatible {
+	return &Compatible{
+		name:       name,
+		apiKey:     apiKey,
+		model:      model,
+		endpoint:   strings.TrimRight(baseURL, "/") + imagesPath,
+		httpClient: newHTTPClient(timeout),
+	}
+}
+
+func (c *Compatible) Name() string {
+	return c.name
+}
+
+func (c *Compatible) Generate(ctx context.Context, prompt string, opts Options) (*Image, error) {
+	data, err := json.Marshal(imagesRequest{
+		Model:          c.model,
+		Prompt:         prompt,
+		N:              1,
+		Size:           opts.Size(),
+		ResponseFormat: responseFormatB64,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2*maxImageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	log.DebugContext(ctx, "Image API request completed", "provider", c.name, "status", resp.StatusCode, "duration", time.Since(start))
+
+	if resp.StatusCode != http.StatusOK {
+		detail := string(body)
+		if len(detail) > maxErrorDetailBytes {
+			detail = detail[:maxErrorDetailBytes]
+		}
+		log.WarnContext(ctx, "Image API error", "provider", c.name, "status", resp.StatusCode, "detail", detail)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	var parsed imagesResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(parsed.Data) == 0 {
+		return nil, errEmptyImages
+	}
+
+	if encoded := parsed.Data[0].B64JSON; encoded != "" {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+		return validateImage(decoded)
+	}
+	if parsed.Data[0].URL == "" {
+		return nil, errEmptyImages
+	}
+
+	imageReq, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.Data[0].URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create download request: %w", err)
+	}
+	return download(c.httpClient, imageReq)
+}
@@ -0,0 +1,114 @@
+package imagegen
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompatibleGenerate(t *testing.T) {
+	var got imagesRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != imagesPath {
+			t.Errorf("path = %q, want %q", r.URL.Path, imagesPath)
+		}
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"data":[{"b64_json":"` + base64.StdEncoding.EncodeToString(testPNG) + `"}]}`))
+	}))
+	defer server.Close()
+
+	g := NewCompatible("openai", server.URL+"/", "sk-test", "dall-e-3", time.Second)
+	img, err := g.Generate(context.Background(), "a cat", Options{Width: 1792, Height: 1024})
+
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if string(img.Data) != string(testPNG) {
+		t.Errorf("image data = %q, want decoded PNG", img.Data)
+	}
+	want := imagesRequest{Model: "dall-e-3", Prompt: "a cat", N: 1, Size: "1792x1024", ResponseFormat: responseFormatB64}
+	if got != want || auth != "Bearer sk-test" {
+		t.Errorf("request = %+v with auth %q, want %+v", got, auth, want)
+	}
+}
+
+func TestCompatibleGenerateDownloadsURL(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/files/cat.png" {
+			_, _ = w.Write(testPNG)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"url":"` + server.URL + `/files/cat.png"}]}`))
+	}))
+	defer server.Close()
+
+	img, err := NewCompatible("local", server.URL, "", "", time.Second).Generate(context.Background(), "a cat", DefaultOptions())
+
+	if err != nil || string(img.Data) != string(testPNG) {
+		t.Errorf("Generate() = %v, %v, want downloaded PNG", img, err)
+	}
+}
+
+func TestCompatibleGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr func(error) bool
+	}{
+		{
+			name:    "ServerError",
+			status:  http.StatusServiceUnavailable,
+			body:    `{"error":{"message":"overloaded"}}`,
+			wantErr: func(err error) bool { return isRetryable(err) },
+		},
+		{
+			name:    "Empty",
+			status:  http.StatusOK,
+			body:    `{"data":[]}`,
+			wantErr: func(err error) bool { return errors.Is(err, errEmptyImages) },
+		},
+		{
+			name:    "NotImage",
+			status:  http.StatusOK,
+			body:    `{"data":[{"b64_json":"` + base64.StdEncoding.EncodeToString([]byte("hello")) + `"}]}`,
+			wantErr: func(err error) bool { return errors.Is(err, ErrNotImage) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := NewCompatible("local", server.URL, "", "", time.Second).Generate(context.Background(), "a cat", DefaultOptions())
+			if err == nil || !tt.wantErr(err) {
+				t.Errorf("Generate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestPollinationsImageURL(t *testing.T) {
+	p := NewPollinations("", time.Second)
+
+	got := p.imageURL("a cat & dog", Options{Width: 1792, Height: 1024})
+
+	for _, want := range []string{"https://image.pollinations.ai/prompt/a+cat+%26+dog?", "width=1792", "height=1024", "nologo=true", "enhance=true", "seed="} {
+		if !strings.Contains(got, want) {
+			t.Errorf("imageURL() = %q, should contain %q", got, want)
+		}
+	}
+}
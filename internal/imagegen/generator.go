@@ -0,0 +1,229 @@
+package imagegen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"got/pkg/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AspectSquare = "square"
+	AspectWide   = "wide"
+	AspectTall   = "tall"
+
+	defaultTimeout     = 60 * time.Second
+	defaultMaxAttempts = 2
+	defaultRetryDelay  = time.Second
+	maxImageBytes      = 10 << 20
+	minSide            = 256
+	maxSide            = 2048
+)
+
+type Generator interface {
+	Name() string
+	Generate(ctx context.Context, prompt string, opts Options) (*Image, error)
+}
+
+type Options struct {
+	Width  int
+	Height int
+}
+
+type Image struct {
+	Data        []byte
+	ContentType string
+	Provider    string
+}
+
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+type Chain struct {
+	generators  []Generator
+	maxAttempts int
+	retryDelay  time.Duration
+	sleep       func(ctx context.Context, d time.Duration) error
+}
+
+var (
+	ErrNotImage     = errors.New("response is not an image")
+	ErrTooLarge     = errors.New("image is too large")
+	ErrNoGenerators = errors.New("no image generators configured")
+
+	aspects = map[string]Options{
+		AspectSquare: {Width: 1024, Height: 1024},
+		AspectWide:   {Width: 1792, Height: 1024},
+		AspectTall:   {Width: 1024, Height: 1792},
+	}
+
+	log = logger.For("imagegen")
+)
+
+func NewChain(generators ...Generator) *Chain {
+	return &Chain{
+		generators:  generators,
+		maxAttempts: defaultMaxAttempts,
+		retryDelay:  defaultRetryDelay,
+		sleep:       sleepContext,
+	}
+}
+
+func (c *Chain) SetRetryPolicy(maxAttempts int, retryDelay time.Duration) {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	if retryDelay <= 0 {
+		retryDelay = defaultRetryDelay
+	}
+	c.maxAttempts = maxAttempts
+	c.retryDelay = retryDelay
+}
+
+func (c *Chain) Generate(ctx context.Context, prompt string, opts Options) (*Image, error) {
+	if opts.Width == 0 || opts.Height == 0 {
+		opts = DefaultOptions()
+	}
+
+	lastErr := ErrNoGenerators
+	for _, g := range c.generators {
+		img, err := c.generateWithRetry(ctx, g, prompt, opts)
+		if err == nil {
+			img.Provider = g.Name()
+			return img, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		lastErr = err
+		log.WarnContext(ctx, "Image generation failed, trying next provider", "provider", g.Name(), "error", err)
+	}
+	return nil, lastErr
+}
+
+func (c *Chain) generateWithRetry(ctx context.Context, g Generator, prompt string, opts Options) (*Image, error) {
+	for attempt := 0; ; attempt++ {
+		img, err := g.Generate(ctx, prompt, opts)
+		if err == nil || !isRetryable(err) || ctx.Err() != nil || attempt+1 >= c.maxAttempts {
+			return img, err
+		}
+
+		wait := c.retryDelay << attempt
+		log.InfoContext(ctx, "Retrying image generation", "provider", g.Name(), "attempt", attempt+1, "wait", wait, "error", err)
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func DefaultOptions() Options {
+	return aspects[AspectSquare]
+}
+
+func AspectOptions(name string) (Options, bool) {
+	opts, ok := aspects[strings.ToLower(name)]
+	return opts, ok
+}
+
+func ParseSize(value string) (Options, bool) {
+	w, h, ok := strings.Cut(strings.ToLower(value), "x")
+	if !ok {
+		return Options{}, false
+	}
+	width, err := strconv.Atoi(w)
+	if err != nil {
+		return Options{}, false
+	}
+	height, err := strconv.Atoi(h)
+	if err != nil {
+		return Options{}, false
+	}
+	if width < minSide || width > maxSide || height < minSide || height > maxSide {
+		return Options{}, false
+	}
+	return Options{Width: width, Height: height}, true
+}
+
+func (o Options) Size() string {
+	return fmt.Sprintf("%dx%d", o.Width, o.Height)
+}
+
+func (e *StatusError) Error() string {
+	return "image api error: " + e.Status
+}
+
+func (img *Image) Extension() string {
+	switch img.ContentType {
+	case "image/png":
+		return "png"
+	case "image/webp":
+		return "webp"
+	case "image/gif":
+		return "gif"
+	default:
+		return "jpg"
+	}
+}
+
+func download(httpClient *http.Client, req *http.Request) (*Image, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return validateImage(data)
+}
+
+func validateImage(data []byte) (*Image, error) {
+	if len(data) > maxImageBytes {
+		return nil, ErrTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	if len(data) == 0 || !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("%w: %s", ErrNotImage, contentType)
+	}
+	return &Image{Data: data, ContentType: contentType}, nil
+}
+
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	return !errors.Is(err, ErrTooLarge)
+}
+
+func newHTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
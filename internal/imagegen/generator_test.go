@@ -0,0 +1,118 @@
+package imagegen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\n fake image data")
+
+type fakeGenerator struct {
+	name  string
+	errs  []error
+	calls int
+}
+
+func (f *fakeGenerator) Name() string {
+	return f.name
+}
+
+func (f *fakeGenerator) Generate(ctx context.Context, prompt string, opts Options) (*Image, error) {
+	f.calls++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return &Image{Data: testPNG, ContentType: "image/png"}, nil
+}
+
+func newTestChain(generators ...Generator) *Chain {
+	chain := NewChain(generators...)
+	chain.SetRetryPolicy(3, time.Millisecond)
+	chain.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	return chain
+}
+
+func TestChainRetriesTransientErrors(t *testing.T) {
+	primary := &fakeGenerator{name: "primary", errs: []error{&StatusError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}, ErrNotImage}}
+	fallback := &fakeGenerator{name: "fallback"}
+
+	img, err := newTestChain(primary, fallback).Generate(context.Background(), "a cat", Options{})
+
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if img.Provider != "primary" || primary.calls != 3 || fallback.calls != 0 {
+		t.Errorf("provider = %q, calls = %d/%d, want primary after 3 attempts", img.Provider, primary.calls, fallback.calls)
+	}
+}
+
+func TestChainFallsBack(t *testing.T) {
+	primary := &fakeGenerator{name: "primary", errs: []error{&StatusError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"}}}
+	fallback := &fakeGenerator{name: "fallback"}
+
+	img, err := newTestChain(primary, fallback).Generate(context.Background(), "a cat", Options{})
+
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if img.Provider != "fallback" || primary.calls != 1 {
+		t.Errorf("provider = %q after %d primary calls, want fallback without retrying a 400", img.Provider, primary.calls)
+	}
+}
+
+func TestChainReturnsLastError(t *testing.T) {
+	tooLarge := &fakeGenerator{name: "only", errs: []error{ErrTooLarge}}
+
+	_, err := newTestChain(tooLarge).Generate(context.Background(), "a cat", Options{})
+
+	if !errors.Is(err, ErrTooLarge) || tooLarge.calls != 1 {
+		t.Errorf("Generate() error = %v after %d calls, want ErrTooLarge without retry", err, tooLarge.calls)
+	}
+	if _, err := newTestChain().Generate(context.Background(), "a cat", Options{}); !errors.Is(err, ErrNoGenerators) {
+		t.Errorf("empty chain error = %v, want ErrNoGenerators", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   Options
+		wantOK bool
+	}{
+		{value: "768x512", want: Options{Width: 768, Height: 512}, wantOK: true},
+		{value: "1024X1024", want: Options{Width: 1024, Height: 1024}, wantOK: true},
+		{value: "100x100", wantOK: false},
+		{value: "4096x1024", wantOK: false},
+		{value: "wide", wantOK: false},
+		{value: "axb", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseSize(tt.value)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("ParseSize(%q) = %+v, %v, want %+v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestValidateImage(t *testing.T) {
+	img, err := validateImage(testPNG)
+	if err != nil || img.ContentType != "image/png" || img.Extension() != "png" {
+		t.Errorf("validateImage(png) = %+v, %v, want png image", img, err)
+	}
+
+	if _, err := validateImage([]byte("<html>rate limited</html>")); !errors.Is(err, ErrNotImage) {
+		t.Errorf("validateImage(html) error = %v, want ErrNotImage", err)
+	}
+	if _, err := validateImage(nil); !errors.Is(err, ErrNotImage) {
+		t.Errorf("validateImage(nil) error = %v, want ErrNotImage", err)
+	}
+	if _, err := validateImage(make([]byte, maxImageBytes+1)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("validateImage(oversized) error = %v, want ErrTooLarge", err)
+	}
+}
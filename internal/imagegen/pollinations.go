@@ -0,0 +1,61 @@
+package imagegen
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	PollinationsName    = "pollinations"
+	pollinationsBaseURL = "https://image.pollinations.ai/prompt/"
+)
+
+type Pollinations struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func NewPollinations(baseURL string, timeout time.Duration) *Pollinations {
+	if baseURL == "" {
+		baseURL = pollinationsBaseURL
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return &Pollinations{
+		httpClient: newHTTPClient(timeout),
+		baseURL:    baseURL,
+	}
+}
+
+func (p *Pollinations) Name() string {
+	return PollinationsName
+}
+
+func (p *Pollinations) Generate(ctx context.Context, prompt string, opts Options) (*Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.imageURL(prompt, opts), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	start := time.Now()
+	img, err := download(p.httpClient, req)
+	log.DebugContext(ctx, "Pollinations request completed", "size", opts.Size(), "duration", time.Since(start), "error", err)
+	return img, err
+}
+
+func (p *Pollinations) imageURL(prompt string, opts Options) string {
+	seed := time.Now().UnixNano() % 1000000
+	return fmt.Sprintf(
+		"%s%s?width=%d&height=%d&seed=%d&nologo=true&enhance=true",
+		p.baseURL,
+		url.QueryEscape(prompt),
+		opts.Width,
+		opts.Height,
+		seed,
+	)
+}
@@ -0,0 +1,165 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"got/internal/groq"
+	"sync"
+	"time"
+)
+
+const fallbackModelsTTL = time.Minute
+
+type ModelCache interface {
+	GetModels(ctx context.Context, provider string) ([]groq.ModelInfo, error)
+	SaveModels(ctx context.Context, provider string, models []groq.ModelInfo, ttl time.Duration) error
+}
+
+type fetchedModels struct {
+	models  []groq.ModelInfo
+	expires time.Time
+}
+
+type modelMemo struct {
+	mu     sync.Mutex
+	models map[string]fetchedModels
+}
+
+type ModelInfo struct {
+	Ref           string
+	Provider      string
+	ID            string
+	ContextWindow int
+	Vision        bool
+	Tools         bool
+}
+
+func (r *Registry) SetModelCache(cache ModelCache, ttl time.Duration) {
+	r.cache = cache
+	r.modelsTTL = ttl
+}
+
+func (r *Registry) Catalog(ctx context.Context) []ModelInfo {
+	var catalog []ModelInfo
+	for _, p := range r.Providers() {
+		for _, m := range r.providerModels(ctx, p) {
+			catalog = append(catalog, r.modelInfo(p, m))
+		}
+	}
+	return catalog
+}
+
+func (r *Registry) Lookup(ctx context.Context, ref string) (ModelInfo, bool) {
+	p, model := r.Resolve(ref)
+	if p == nil {
+		return ModelInfo{}, false
+	}
+	for _, m := range r.providerModels(ctx, p) {
+		if m.ID == model {
+			return r.modelInfo(p, m), true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+func (r *Registry) Describe(ctx context.Context, ref string) ModelInfo {
+	p, model := r.Resolve(ref)
+	if model == "" {
+		model = p.Model()
+	}
+	return r.describe(ctx, p, model)
+}
+
+func (r *Registry) describe(ctx context.Context, p Provider, model string) ModelInfo {
+	for _, m := range r.providerModels(ctx, p) {
+		if m.ID == model {
+			return r.modelInfo(p, m)
+		}
+	}
+	return r.modelInfo(p, groq.DescribeModel(model))
+}
+
+func (r *Registry) Models(ctx context.Context) []string {
+	var refs []string
+	for _, m := range r.Catalog(ctx) {
+		refs = append(refs, m.Ref)
+	}
+	return refs
+}
+
+func (r *Registry) ValidateModel(ctx context.Context, ref string) error {
+	if _, ok := r.Lookup(ctx, ref); !ok {
+		return fmt.Errorf("invalid model: %s", ref)
+	}
+	return nil
+}
+
+func (r *Registry) modelInfo(p Provider, m groq.ModelInfo) ModelInfo {
+	return ModelInfo{
+		Ref:           r.Ref(p.Name(), m.ID),
+		Provider:      p.Name(),
+		ID:            m.ID,
+		ContextWindow: m.ContextWindow,
+		Vision:        m.Vision || m.ID == p.VisionModel(),
+		Tools:         m.Tools,
+	}
+}
+
+func (r *Registry) providerModels(ctx context.Context, p Provider) []groq.ModelInfo {
+	if r.cache == nil {
+		return r.memo.get(p.Name(), func() ([]groq.ModelInfo, time.Duration) { return r.fetchModels(ctx, p) })
+	}
+
+	cached, err := r.cache.GetModels(ctx, p.Name())
+	if err == nil && len(cached) > 0 {
+		return cached
+	}
+	if err != nil {
+		log.DebugContext(ctx, "Failed to read cached models", "provider", p.Name(), "error", err)
+	}
+
+	models, ttl := r.fetchModels(ctx, p)
+	if err := r.cache.SaveModels(ctx, p.Name(), models, ttl); err != nil {
+		log.WarnContext(ctx, "Failed to cache models", "provider", p.Name(), "error", err)
+	}
+	return models
+}
+
+func (r *Registry) fetchModels(ctx context.Context, p Provider) ([]groq.ModelInfo, time.Duration) {
+	models, err := p.FetchModels(ctx)
+	if err != nil || len(models) == 0 {
+		if err != nil {
+			log.DebugContext(ctx, "Failed to fetch models, using static list", "provider", p.Name(), "error", err)
+		}
+		if r.modelsTTL > 0 {
+			return staticModels(p), min(r.modelsTTL, fallbackModelsTTL)
+		}
+		return staticModels(p), fallbackModelsTTL
+	}
+	return models, r.modelsTTL
+}
+
+func (m *modelMemo) get(provider string, fetch func() ([]groq.ModelInfo, time.Duration)) []groq.ModelInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if cached, ok := m.models[provider]; ok && time.Now().Before(cached.expires) {
+		return cached.models
+	}
+	models, ttl := fetch()
+	if ttl <= 0 {
+		return models
+	}
+	if m.models == nil {
+		m.models = make(map[string]fetchedModels)
+	}
+	m.models[provider] = fetchedModels{models: models, expires: time.Now().Add(ttl)}
+	return models
+}
+
+func staticModels(p Provider) []groq.ModelInfo {
+	var models []groq.ModelInfo
+	for _, model := range p.ListModels() {
+		models = append(models, groq.DescribeModel(model))
+	}
+	return models
+}
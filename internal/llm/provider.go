@@ -0,0 +1,248 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"got/internal/groq"
+	"got/pkg/logger"
+	"strings"
+	"time"
+)
+
+const refSeparator = ":"
+
+type Provider interface {
+	Name() string
+	Model() string
+	Complete(ctx context.Context, req groq.ChatRequest) (string, error)
+	Stream(ctx context.Context, req groq.ChatRequest, onDelta groq.StreamHandler) (string, error)
+	Summarize(ctx context.Context, model, previous string, messages []groq.Message) (string, groq.Usage, error)
+	ListModels() []string
+	FetchModels(ctx context.Context) ([]groq.ModelInfo, error)
+	VisionModel() string
+	TranscriptionModel() string
+	Transcribe(ctx context.Context, audio []byte, filename, language string) (string, error)
+}
+
+type Registry struct {
+	providers   map[string]Provider
+	order       []string
+	defaultName string
+	fallbacks   []string
+	cache       ModelCache
+	modelsTTL   time.Duration
+	memo        modelMemo
+}
+
+type Result struct {
+	Content  string
+	Ref      string
+	Fallback bool
+	Usage    groq.Usage
+	History  []groq.Message
+	Failed   []Attempt
+}
+
+type Attempt struct {
+	Ref   string
+	Usage groq.Usage
+}
+
+type HistoryFitter func(ctx context.Context, p Provider, info ModelInfo, history []groq.Message) []groq.Message
+
+var (
+	ErrNoTranscriber = errors.New("no provider supports transcription")
+
+	log = logger.For("llm")
+)
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider, len(providers))}
+	for _, p := range providers {
+		r.Add(p)
+	}
+	return r
+}
+
+func (r *Registry) Add(p Provider) {
+	if _, exists := r.providers[p.Name()]; !exists {
+		r.order = append(r.order, p.Name())
+	}
+	r.providers[p.Name()] = p
+	if r.defaultName == "" {
+		r.defaultName = p.Name()
+	}
+}
+
+func (r *Registry) SetDefault(name string) error {
+	if _, ok := r.providers[name]; !ok {
+		return fmt.Errorf("unknown provider: %s", name)
+	}
+	r.defaultName = name
+	return nil
+}
+
+func (r *Registry) SetFallbacks(refs []string) {
+	r.fallbacks = append([]string(nil), refs...)
+}
+
+func (r *Registry) Complete(ctx context.Context, ref string, req groq.ChatRequest) (Result, error) {
+	return r.CompleteWithHistory(ctx, ref, req, nil)
+}
+
+func (r *Registry) CompleteWithHistory(ctx context.Context, ref string, req groq.ChatRequest, fit HistoryFitter) (Result, error) {
+	var usage groq.Usage
+	onUsage := req.OnUsage
+	req.OnUsage = func(u groq.Usage) {
+		usage = usage.Add(u)
+		if onUsage != nil {
+			onUsage(u)
+		}
+	}
+
+	toolsRan := false
+	if execute := req.ExecuteTool; execute != nil {
+		req.ExecuteTool = func(ctx context.Context, call groq.ToolCall) (string, error) {
+			toolsRan = true
+			return execute(ctx, call)
+		}
+	}
+
+	history := req.History
+	var failed []Attempt
+	var lastErr error
+	for i, candidate := range r.candidates(ref) {
+		p, model := r.Resolve(candidate)
+		if model == "" {
+			model = p.Model()
+			candidate = r.Ref(p.Name(), model)
+		}
+		info := r.describe(ctx, p, model)
+		if len(req.Images) > 0 && !info.Vision {
+			continue
+		}
+		attempt := req
+		attempt.Model = model
+		if !info.Tools {
+			attempt.Tools, attempt.ExecuteTool = nil, nil
+		}
+		if fit != nil {
+			attempt.History = fit(ctx, p, info, history)
+		}
+
+		usage = groq.Usage{}
+		content, err := p.Complete(ctx, attempt)
+		if err == nil {
+			return Result{Content: content, Ref: candidate, Fallback: i > 0, Usage: usage, History: attempt.History, Failed: failed}, nil
+		}
+		if usage != (groq.Usage{}) {
+			failed = append(failed, Attempt{Ref: candidate, Usage: usage})
+		}
+
+		lastErr = err
+		if !groq.ShouldFallback(err) {
+			break
+		}
+		if toolsRan {
+			log.WarnContext(ctx, "LLM request failed after running tools, not retrying on another model", "provider", p.Name(), "model", model, "error", err)
+			break
+		}
+		log.WarnContext(ctx, "LLM request failed, trying next model", "provider", p.Name(), "model", model, "error", err)
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no model available for %s", ref)
+	}
+	return Result{Failed: failed}, lastErr
+}
+
+func (r *Registry) VisionRef(ctx context.Context, ref string) (string, bool) {
+	p, model := r.Resolve(ref)
+	if model != "" && r.describe(ctx, p, model).Vision {
+		return ref, true
+	}
+	if vision := p.VisionModel(); vision != "" {
+		return r.Ref(p.Name(), vision), true
+	}
+
+	for _, other := range r.Providers() {
+		if vision := other.VisionModel(); vision != "" {
+			return r.Ref(other.Name(), vision), true
+		}
+	}
+	return "", false
+}
+
+func (r *Registry) Transcribe(ctx context.Context, audio []byte, filename, language string) (string, error) {
+	p, ok := r.transcriber()
+	if !ok {
+		return "", ErrNoTranscriber
+	}
+	return p.Transcribe(ctx, audio, filename, language)
+}
+
+func (r *Registry) CanTranscribe() bool {
+	_, ok := r.transcriber()
+	return ok
+}
+
+func (r *Registry) Default() Provider {
+	return r.providers[r.defaultName]
+}
+
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+func (r *Registry) Providers() []Provider {
+	providers := make([]Provider, 0, len(r.order))
+	for _, name := range r.order {
+		providers = append(providers, r.providers[name])
+	}
+	return providers
+}
+
+func (r *Registry) Len() int {
+	return len(r.order)
+}
+
+func (r *Registry) Resolve(ref string) (Provider, string) {
+	if name, model, ok := strings.Cut(ref, refSeparator); ok {
+		if p, exists := r.providers[name]; exists {
+			return p, model
+		}
+	}
+	return r.Default(), ref
+}
+
+func (r *Registry) Ref(provider, model string) string {
+	if len(r.order) <= 1 {
+		return model
+	}
+	return provider + refSeparator + model
+}
+
+func (r *Registry) candidates(ref string) []string {
+	candidates := []string{ref}
+	seen := map[string]bool{ref: true}
+	for _, fallback := range r.fallbacks {
+		if !seen[fallback] {
+			seen[fallback] = true
+			candidates = append(candidates, fallback)
+		}
+	}
+	return candidates
+}
+
+func (r *Registry) transcriber() (Provider, bool) {
+	if p := r.Default(); p != nil && p.TranscriptionModel() != "" {
+		return p, true
+	}
+	for _, p := range r.Providers() {
+		if p.TranscriptionModel() != "" {
+			return p, true
+		}
+	}
+	return nil, false
+}
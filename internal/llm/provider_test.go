@@ -0,0 +1,554 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"got/internal/groq"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type mockProvider struct {
+	name    string
+	static  []string
+	fetched []string
+	err     error
+	vision  string
+	audio   string
+	model   string
+	fetches int
+}
+
+func (m *mockProvider) Name() string { return m.name }
+
+func (m *mockProvider) Model() string { return m.model }
+
+func (m *mockProvider) Complete(ctx context.Context, req groq.ChatRequest) (string, error) {
+	if req.OnUsage != nil {
+		req.OnUsage(groq.Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12})
+	}
+	return m.name + ":" + req.Model, nil
+}
+
+func (m *mockProvider) Stream(ctx context.Context, req groq.ChatRequest, onDelta groq.StreamHandler) (string, error) {
+	return m.Complete(ctx, req)
+}
+
+func (m *mockProvider) Summarize(ctx context.Context, model, previous string, messages []groq.Message) (string, groq.Usage, error) {
+	return previous, groq.Usage{}, nil
+}
+
+func (m *mockProvider) ListModels() []string { return m.static }
+
+func (m *mockProvider) FetchModels(ctx context.Context) ([]groq.ModelInfo, error) {
+	m.fetches++
+	var models []groq.ModelInfo
+	for _, id := range m.fetched {
+		models = append(models, groq.ModelInfo{ID: id, ContextWindow: 32768, Tools: true})
+	}
+	return models, m.err
+}
+
+func (m *mockProvider) VisionModel() string { return m.vision }
+
+func (m *mockProvider) TranscriptionModel() string { return m.audio }
+
+func (m *mockProvider) Transcribe(ctx context.Context, audio []byte, filename, language string) (string, error) {
+	return m.name + ":" + string(audio), nil
+}
+
+func TestRegistryResolve(t *testing.T) {
+	registry := NewRegistry(
+		&mockProvider{name: "groq"},
+		&mockProvider{name: "ollama"},
+	)
+
+	tests := []struct {
+		name         string
+		ref          string
+		wantProvider string
+		wantModel    string
+	}{
+		{
+			name:         "Empty",
+			ref:          "",
+			wantProvider: "groq",
+			wantModel:    "",
+		},
+		{
+			name:         "BareModel",
+			ref:          "llama-3.3-70b-versatile",
+			wantProvider: "groq",
+			wantModel:    "llama-3.3-70b-versatile",
+		},
+		{
+			name:         "QualifiedModel",
+			ref:          "ollama:llama3.1",
+			wantProvider: "ollama",
+			wantModel:    "llama3.1",
+		},
+		{
+			name:         "ModelWithTag",
+			ref:          "ollama:llama3.1:8b",
+			wantProvider: "ollama",
+			wantModel:    "llama3.1:8b",
+		},
+		{
+			name:         "UnknownPrefix",
+			ref:          "qwen2:7b",
+			wantProvider: "groq",
+			wantModel:    "qwen2:7b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, model := registry.Resolve(tt.ref)
+			if provider.Name() != tt.wantProvider {
+				t.Errorf("provider = %q, want %q", provider.Name(), tt.wantProvider)
+			}
+			if model != tt.wantModel {
+				t.Errorf("model = %q, want %q", model, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestRegistryModels(t *testing.T) {
+	registry := NewRegistry(
+		&mockProvider{name: "groq", static: []string{"static-a"}, err: errors.New("offline")},
+		&mockProvider{name: "ollama", static: []string{"unused"}, fetched: []string{"llama3.1", "qwen2"}},
+	)
+
+	got := registry.Models(context.Background())
+	want := []string{"groq:static-a", "ollama:llama3.1", "ollama:qwen2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Models() = %v, want %v", got, want)
+	}
+}
+
+func TestRegistrySingleProviderUsesBareNames(t *testing.T) {
+	registry := NewRegistry(&mockProvider{name: "groq", static: []string{"llama"}})
+
+	got := registry.Models(context.Background())
+	if !reflect.DeepEqual(got, []string{"llama"}) {
+		t.Errorf("Models() = %v, want [llama]", got)
+	}
+}
+
+func TestRegistryValidateModel(t *testing.T) {
+	registry := NewRegistry(
+		&mockProvider{name: "groq", static: []string{"llama"}},
+		&mockProvider{name: "ollama", fetched: []string{"qwen2"}},
+	)
+
+	if err := registry.ValidateModel(context.Background(), "ollama:qwen2"); err != nil {
+		t.Errorf("ValidateModel(ollama:qwen2) error = %v", err)
+	}
+	if err := registry.ValidateModel(context.Background(), "llama"); err != nil {
+		t.Errorf("ValidateModel(llama) error = %v", err)
+	}
+	if err := registry.ValidateModel(context.Background(), "ollama:llama"); err == nil {
+		t.Error("ValidateModel(ollama:llama) should fail")
+	}
+}
+
+type mockModelCache struct {
+	models map[string][]groq.ModelInfo
+	ttl    time.Duration
+}
+
+func (m *mockModelCache) GetModels(ctx context.Context, provider string) ([]groq.ModelInfo, error) {
+	return m.models[provider], nil
+}
+
+func (m *mockModelCache) SaveModels(ctx context.Context, provider string, models []groq.ModelInfo, ttl time.Duration) error {
+	m.models[provider] = models
+	m.ttl = ttl
+	return nil
+}
+
+func TestRegistryCatalogUsesCache(t *testing.T) {
+	cache := &mockModelCache{models: map[string][]groq.ModelInfo{
+		"groq": {{ID: "cached", ContextWindow: 8192}},
+	}}
+	registry := NewRegistry(
+		&mockProvider{name: "groq", fetched: []string{"fresh"}},
+		&mockProvider{name: "ollama", fetched: []string{"llava"}, vision: "llava"},
+	)
+	registry.SetModelCache(cache, time.Hour)
+
+	got := registry.Catalog(context.Background())
+	want := []ModelInfo{
+		{Ref: "groq:cached", Provider: "groq", ID: "cached", ContextWindow: 8192},
+		{Ref: "ollama:llava", Provider: "ollama", ID: "llava", ContextWindow: 32768, Vision: true, Tools: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Catalog() = %+v, want %+v", got, want)
+	}
+	if len(cache.models["ollama"]) != 1 || cache.ttl != time.Hour {
+		t.Errorf("fetched models not cached: %+v, ttl %v", cache.models["ollama"], cache.ttl)
+	}
+}
+
+func TestRegistryCatalogCachesStaticFallbackBriefly(t *testing.T) {
+	cache := &mockModelCache{models: map[string][]groq.ModelInfo{}}
+	registry := NewRegistry(&mockProvider{name: "groq", static: []string{"gemma2-9b-it"}, err: errors.New("offline")})
+	registry.SetModelCache(cache, time.Hour)
+
+	info, ok := registry.Lookup(context.Background(), "gemma2-9b-it")
+	if !ok {
+		t.Fatal("Lookup(gemma2-9b-it) should find the static model")
+	}
+	if info.ContextWindow != 8192 || !info.Tools || info.Vision {
+		t.Errorf("Lookup() = %+v, want static metadata", info)
+	}
+	if len(cache.models["groq"]) != 1 || cache.ttl != fallbackModelsTTL {
+		t.Errorf("static fallback cached as %+v, ttl %v, want one model for %v", cache.models["groq"], cache.ttl, fallbackModelsTTL)
+	}
+}
+
+func TestRegistryMemoRemembersFailedFetch(t *testing.T) {
+	provider := &mockProvider{name: "groq", static: []string{"gemma2-9b-it"}, err: errors.New("offline")}
+	registry := NewRegistry(provider)
+	registry.SetModelCache(nil, time.Hour)
+
+	registry.Describe(context.Background(), "gemma2-9b-it")
+	registry.Describe(context.Background(), "gemma2-9b-it")
+
+	if provider.fetches != 1 {
+		t.Errorf("FetchModels called %d times, want 1 while the fallback is remembered", provider.fetches)
+	}
+}
+
+func TestRegistrySetDefault(t *testing.T) {
+	registry := NewRegistry(&mockProvider{name: "groq"}, &mockProvider{name: "ollama"})
+
+	if err := registry.SetDefault("ollama"); err != nil {
+		t.Fatalf("SetDefault() error = %v", err)
+	}
+	if registry.Default().Name() != "ollama" {
+		t.Errorf("Default() = %q, want %q", registry.Default().Name(), "ollama")
+	}
+	if err := registry.SetDefault("missing"); err == nil {
+		t.Error("SetDefault(missing) should fail")
+	}
+}
+
+type failingProvider struct {
+	mockProvider
+	errs map[string]error
+}
+
+func (f *failingProvider) Complete(ctx context.Context, req groq.ChatRequest) (string, error) {
+	if err := f.errs[req.Model]; err != nil {
+		return "", err
+	}
+	return "answer from " + req.Model, nil
+}
+
+func TestRegistryCompleteFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         map[string]error
+		wantContent  string
+		wantRef      string
+		wantFallback bool
+		wantErr      error
+	}{
+		{
+			name:        "PrimaryAnswers",
+			errs:        map[string]error{},
+			wantContent: "answer from big",
+			wantRef:     "big",
+		},
+		{
+			name:         "FallsBackOnRateLimit",
+			errs:         map[string]error{"big": groq.ErrRateLimited},
+			wantContent:  "answer from small",
+			wantRef:      "small",
+			wantFallback: true,
+		},
+		{
+			name:    "StopsOnContextTooLong",
+			errs:    map[string]error{"big": groq.ErrContextTooLong},
+			wantErr: groq.ErrContextTooLong,
+		},
+		{
+			name:    "AllFail",
+			errs:    map[string]error{"big": groq.ErrOverloaded, "small": groq.ErrModelUnavailable},
+			wantErr: groq.ErrModelUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(&failingProvider{mockProvider: mockProvider{name: "groq"}, errs: tt.errs})
+			registry.SetFallbacks([]string{"small", "big"})
+
+			got, err := registry.Complete(context.Background(), "big", groq.ChatRequest{Prompt: "hi"})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if got.Content != tt.wantContent || got.Ref != tt.wantRef || got.Fallback != tt.wantFallback {
+				t.Errorf("Complete() = %+v, want content %q ref %q fallback %v", got, tt.wantContent, tt.wantRef, tt.wantFallback)
+			}
+		})
+	}
+}
+
+type usageFailingProvider struct {
+	mockProvider
+}
+
+func (p *usageFailingProvider) Complete(ctx context.Context, req groq.ChatRequest) (string, error) {
+	req.OnUsage(groq.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6})
+	if req.Model == "big" {
+		return "", groq.ErrOverloaded
+	}
+	return "answer from " + req.Model, nil
+}
+
+func TestRegistryCompleteRecordsUsagePerRef(t *testing.T) {
+	registry := NewRegistry(&usageFailingProvider{mockProvider{name: "groq"}})
+	registry.SetFallbacks([]string{"small"})
+
+	got, err := registry.Complete(context.Background(), "big", groq.ChatRequest{Prompt: "hi"})
+
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if got.Ref != "small" || got.Usage.TotalTokens != 6 {
+		t.Errorf("Result = %q with %+v, want only the fallback's usage", got.Ref, got.Usage)
+	}
+	want := []Attempt{{Ref: "big", Usage: groq.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6}}}
+	if !reflect.DeepEqual(got.Failed, want) {
+		t.Errorf("Failed = %+v, want %+v", got.Failed, want)
+	}
+}
+
+type toolCallingProvider struct {
+	mockProvider
+}
+
+func (p *toolCallingProvider) Complete(ctx context.Context, req groq.ChatRequest) (string, error) {
+	if req.Model == "small" {
+		return "answer from small", nil
+	}
+	if _, err := req.ExecuteTool(ctx, groq.ToolCall{ID: "call_1"}); err != nil {
+		return "", err
+	}
+	return "", groq.ErrOverloaded
+}
+
+func TestRegistryCompleteDoesNotFallBackAfterTools(t *testing.T) {
+	registry := NewRegistry(&toolCallingProvider{mockProvider{name: "groq"}})
+	registry.SetFallbacks([]string{"small"})
+	executed := 0
+
+	_, err := registry.Complete(context.Background(), "big", groq.ChatRequest{
+		Prompt: "remind me",
+		ExecuteTool: func(ctx context.Context, call groq.ToolCall) (string, error) {
+			executed++
+			return "ok", nil
+		},
+	})
+
+	if !errors.Is(err, groq.ErrOverloaded) {
+		t.Errorf("error = %v, want the primary's error without falling back", err)
+	}
+	if executed != 1 {
+		t.Errorf("tool executed %d times, want 1", executed)
+	}
+}
+
+func TestRegistryCompleteWithHistoryRefitsPerCandidate(t *testing.T) {
+	registry := NewRegistry(&failingProvider{mockProvider: mockProvider{name: "groq"}, errs: map[string]error{"big": groq.ErrRateLimited}})
+	registry.SetFallbacks([]string{"small"})
+	history := []groq.Message{{Role: "user", Content: "one"}, {Role: "assistant", Content: "two"}}
+	var fitted []string
+
+	got, err := registry.CompleteWithHistory(context.Background(), "big", groq.ChatRequest{Prompt: "hi", History: history},
+		func(ctx context.Context, p Provider, info ModelInfo, history []groq.Message) []groq.Message {
+			fitted = append(fitted, info.ID)
+			if info.ID == "small" {
+				return history[1:]
+			}
+			return history
+		})
+
+	if err != nil {
+		t.Fatalf("CompleteWithHistory() error = %v", err)
+	}
+	if !reflect.DeepEqual(fitted, []string{"big", "small"}) {
+		t.Errorf("fitted for %v, want every candidate", fitted)
+	}
+	if !reflect.DeepEqual(got.History, history[1:]) {
+		t.Errorf("History = %+v, want the fallback's fitted history", got.History)
+	}
+}
+
+func TestRegistryVisionRef(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers []Provider
+		ref       string
+		want      string
+		wantOK    bool
+	}{
+		{
+			name:      "SelectedModelHasVision",
+			providers: []Provider{&mockProvider{name: "groq", vision: "scout"}},
+			ref:       "meta-llama/llama-4-maverick",
+			want:      "meta-llama/llama-4-maverick",
+			wantOK:    true,
+		},
+		{
+			name:      "ProviderVisionModel",
+			providers: []Provider{&mockProvider{name: "groq", vision: "scout"}},
+			ref:       "llama-3.3-70b-versatile",
+			want:      "scout",
+			wantOK:    true,
+		},
+		{
+			name: "OtherProviderVisionModel",
+			providers: []Provider{
+				&mockProvider{name: "groq"},
+				&mockProvider{name: "ollama", vision: "llava"},
+			},
+			ref:    "llama-3.3-70b-versatile",
+			want:   "ollama:llava",
+			wantOK: true,
+		},
+		{
+			name:      "NoVisionModel",
+			providers: []Provider{&mockProvider{name: "groq"}},
+			ref:       "llama-3.3-70b-versatile",
+			wantOK:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NewRegistry(tt.providers...).VisionRef(context.Background(), tt.ref)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("VisionRef(%q) = %q, %v, want %q, %v", tt.ref, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRegistryCompleteSkipsTextOnlyModelsForImages(t *testing.T) {
+	registry := NewRegistry(&failingProvider{mockProvider: mockProvider{name: "groq", vision: "scout"}})
+	registry.SetFallbacks([]string{"small", "scout"})
+
+	got, err := registry.Complete(context.Background(), "big", groq.ChatRequest{Prompt: "what is this?", Images: []string{"data:image/jpeg;base64,AA=="}})
+
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if got.Ref != "scout" {
+		t.Errorf("Ref = %q, want %q", got.Ref, "scout")
+	}
+}
+
+type recordingProvider struct {
+	mockProvider
+	requests []groq.ChatRequest
+	fetches  int
+}
+
+func (p *recordingProvider) Complete(ctx context.Context, req groq.ChatRequest) (string, error) {
+	p.requests = append(p.requests, req)
+	return p.mockProvider.Complete(ctx, req)
+}
+
+func (p *recordingProvider) FetchModels(ctx context.Context) ([]groq.ModelInfo, error) {
+	p.fetches++
+	return []groq.ModelInfo{{ID: "big", ContextWindow: 65536, Tools: true}, {ID: "plain", ContextWindow: 4096}}, nil
+}
+
+func TestRegistryCompleteUsesCatalogMetadata(t *testing.T) {
+	provider := &recordingProvider{mockProvider: mockProvider{name: "groq"}}
+	registry := NewRegistry(provider)
+	registry.SetModelCache(nil, time.Hour)
+	req := groq.ChatRequest{
+		Prompt: "hi",
+		Tools:  []groq.Tool{{Type: "function"}},
+		ExecuteTool: func(ctx context.Context, call groq.ToolCall) (string, error) {
+			return "", nil
+		},
+	}
+	var windows []int
+	fit := func(ctx context.Context, p Provider, info ModelInfo, history []groq.Message) []groq.Message {
+		windows = append(windows, info.ContextWindow)
+		return history
+	}
+
+	for _, ref := range []string{"big", "plain"} {
+		if _, err := registry.CompleteWithHistory(context.Background(), ref, req, fit); err != nil {
+			t.Fatalf("CompleteWithHistory(%q) error = %v", ref, err)
+		}
+	}
+
+	if !reflect.DeepEqual(windows, []int{65536, 4096}) {
+		t.Errorf("fitted with windows %v, want the catalog's", windows)
+	}
+	if len(provider.requests[0].Tools) != 1 || provider.requests[1].Tools != nil || provider.requests[1].ExecuteTool != nil {
+		t.Errorf("tools sent = %v, %v, want tools only for the model that supports them", provider.requests[0].Tools, provider.requests[1].Tools)
+	}
+	if provider.fetches != 1 {
+		t.Errorf("fetched models %d times, want once within the TTL", provider.fetches)
+	}
+}
+
+func TestRegistryTranscribe(t *testing.T) {
+	registry := NewRegistry(&mockProvider{name: "ollama"}, &mockProvider{name: "groq", audio: "whisper"})
+
+	got, err := registry.Transcribe(context.Background(), []byte("voice"), "voice.ogg", "")
+	if err != nil {
+		t.Fatalf("Transcribe() error = %v", err)
+	}
+	if got != "groq:voice" {
+		t.Errorf("Transcribe() = %q, want %q", got, "groq:voice")
+	}
+
+	registry = NewRegistry(&mockProvider{name: "ollama"})
+	if registry.CanTranscribe() {
+		t.Error("CanTranscribe() = true without a transcription model")
+	}
+	if _, err := registry.Transcribe(context.Background(), []byte("voice"), "", ""); !errors.Is(err, ErrNoTranscriber) {
+		t.Errorf("want %v, got %v", ErrNoTranscriber, err)
+	}
+}
+
+func TestRegistryCompleteUsageAndDefaultModel(t *testing.T) {
+	registry := NewRegistry(&mockProvider{name: "groq", model: "llama"})
+	calls := 0
+
+	got, err := registry.Complete(context.Background(), "", groq.ChatRequest{
+		Prompt:  "hi",
+		OnUsage: func(groq.Usage) { calls++ },
+	})
+
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if got.Usage.PromptTokens != 10 || got.Usage.CompletionTokens != 2 || got.Usage.TotalTokens != 12 {
+		t.Errorf("Usage = %+v, want 10 prompt and 2 completion tokens", got.Usage)
+	}
+	if calls != 1 {
+		t.Errorf("caller's OnUsage called %d times, want 1", calls)
+	}
+	if got.Ref != "llama" {
+		t.Errorf("Ref = %q, want provider's default model %q", got.Ref, "llama")
+	}
+}
@@ -0,0 +1,52 @@
+package moderation
+
+import (
+	"context"
+	"got/internal/groq"
+	"got/internal/llm"
+	"strings"
+)
+
+const (
+	unsafeVerdict    = "UNSAFE"
+	classifierPrompt = "You are a content moderator for family-friendly Telegram group chats. " +
+		"Decide whether the text from the user is acceptable to post there. " +
+		"Reply with exactly SAFE, or with UNSAFE: <category> where the category is one of sexual, violence, hate, harassment, self-harm or illegal."
+)
+
+type Classification struct {
+	Flagged  bool
+	Category string
+	Result   llm.Result
+}
+
+type LLMClassifier struct {
+	gpt   *llm.Registry
+	model string
+}
+
+func NewLLMClassifier(gpt *llm.Registry, model string) *LLMClassifier {
+	return &LLMClassifier{gpt: gpt, model: model}
+}
+
+func (c *LLMClassifier) Classify(ctx context.Context, text string) (Classification, error) {
+	result, err := c.gpt.Complete(ctx, c.model, groq.ChatRequest{
+		SystemPrompt: classifierPrompt,
+		Prompt:       text,
+	})
+	if err != nil {
+		return Classification{Result: result}, err
+	}
+	flagged, category := parseClassification(result.Content)
+	return Classification{Flagged: flagged, Category: category, Result: result}, nil
+}
+
+func parseClassification(content string) (bool, string) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(strings.ToUpper(content), unsafeVerdict) {
+		return false, ""
+	}
+	category := strings.TrimLeft(content[len(unsafeVerdict):], " :-")
+	category, _, _ = strings.Cut(category, "\n")
+	return true, strings.ToLower(strings.TrimSpace(category))
+}
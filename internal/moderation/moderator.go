@@ -0,0 +1,137 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"got/internal/app/model"
+	"got/internal/llm"
+	"got/pkg/logger"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	RuleKeyword    = "keyword"
+	RulePattern    = "pattern"
+	RuleClassifier = "classifier"
+
+	classifierUnavailable = "unavailable"
+)
+
+type Classifier interface {
+	Classify(ctx context.Context, text string) (Classification, error)
+}
+
+type Verdict struct {
+	Action string
+	Rule   string
+	Detail string
+}
+
+type Moderator struct {
+	keywords   []string
+	patterns   []*regexp.Regexp
+	classifier Classifier
+	failOpen   bool
+}
+
+var (
+	errClassifierSkipped = errors.New("classifier skipped")
+
+	log = logger.For("moderation")
+)
+
+func New(keywords, patterns []string, classifier Classifier) (*Moderator, error) {
+	m := &Moderator{classifier: classifier}
+	for _, kw := range keywords {
+		if kw = strings.ToLower(strings.TrimSpace(kw)); kw != "" {
+			m.keywords = append(m.keywords, kw)
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid moderation pattern %q: %w", p, err)
+		}
+		m.patterns = append(m.patterns, re)
+	}
+	return m, nil
+}
+
+func (m *Moderator) SetFailOpen(failOpen bool) {
+	m.failOpen = failOpen
+}
+
+func (m *Moderator) HasClassifier() bool {
+	return m != nil && m.classifier != nil
+}
+
+func (v Verdict) Allowed() bool {
+	return v.Action == ""
+}
+
+func (m *Moderator) Check(ctx context.Context, text string, classify bool) (Verdict, llm.Result) {
+	if m == nil || strings.TrimSpace(text) == "" {
+		return Verdict{}, llm.Result{}
+	}
+
+	lower := strings.ToLower(text)
+	for _, kw := range m.keywords {
+		if containsWord(lower, kw) {
+			return Verdict{Action: model.ModerationActionBlock, Rule: RuleKeyword, Detail: kw}, llm.Result{}
+		}
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(text) {
+			return Verdict{Action: model.ModerationActionBlock, Rule: RulePattern, Detail: re.String()}, llm.Result{}
+		}
+	}
+
+	if m.classifier == nil {
+		return Verdict{}, llm.Result{}
+	}
+	if !classify {
+		return m.unclassified(ctx, errClassifierSkipped), llm.Result{}
+	}
+	classification, err := m.classifier.Classify(ctx, text)
+	if err != nil {
+		return m.unclassified(ctx, err), classification.Result
+	}
+	if classification.Flagged {
+		return Verdict{Action: model.ModerationActionFlag, Rule: RuleClassifier, Detail: classification.Category}, classification.Result
+	}
+	return Verdict{}, classification.Result
+}
+
+func (m *Moderator) unclassified(ctx context.Context, err error) Verdict {
+	if m.failOpen {
+		log.WarnContext(ctx, "Moderation classifier unavailable, allowing content", "error", err)
+		return Verdict{}
+	}
+	log.WarnContext(ctx, "Moderation classifier unavailable, withholding content", "error", err)
+	return Verdict{Action: model.ModerationActionFlag, Rule: RuleClassifier, Detail: classifierUnavailable}
+}
+
+func containsWord(text, word string) bool {
+	for start := 0; start < len(text); {
+		i := strings.Index(text[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[i+len(word):])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		start = i + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
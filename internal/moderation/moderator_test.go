@@ -0,0 +1,142 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"got/internal/app/model"
+	"got/internal/groq"
+	"got/internal/llm"
+	"testing"
+)
+
+type fakeClassifier struct {
+	flagged  bool
+	category string
+	err      error
+	calls    int
+}
+
+func (f *fakeClassifier) Classify(ctx context.Context, text string) (Classification, error) {
+	f.calls++
+	result := llm.Result{Ref: "guard", Usage: groq.Usage{PromptTokens: 20, CompletionTokens: 2, TotalTokens: 22}}
+	return Classification{Flagged: f.flagged, Category: f.category, Result: result}, f.err
+}
+
+func TestModeratorCheck(t *testing.T) {
+	m, err := New([]string{" Scam ", "казино", ""}, []string{`\b\d{4}-\d{4}-\d{4}-\d{4}\b`}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want Verdict
+	}{
+		{name: "Clean", text: "hello there", want: Verdict{}},
+		{name: "Keyword", text: "This is a SCAM!", want: Verdict{Action: model.ModerationActionBlock, Rule: RuleKeyword, Detail: "scam"}},
+		{name: "KeywordInsideWord", text: "scampi for dinner", want: Verdict{}},
+		{name: "CyrillicKeyword", text: "Лучшее казино, заходи", want: Verdict{Action: model.ModerationActionBlock, Rule: RuleKeyword, Detail: "казино"}},
+		{name: "CyrillicInsideWord", text: "казинобар", want: Verdict{}},
+		{name: "Pattern", text: "card 1234-5678-9012-3456", want: Verdict{Action: model.ModerationActionBlock, Rule: RulePattern, Detail: `\b\d{4}-\d{4}-\d{4}-\d{4}\b`}},
+		{name: "Empty", text: "  ", want: Verdict{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := m.Check(context.Background(), tt.text, true); got != tt.want {
+				t.Errorf("Check(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModeratorClassifier(t *testing.T) {
+	t.Run("Flagged", func(t *testing.T) {
+		classifier := &fakeClassifier{flagged: true, category: "violence"}
+		m, _ := New(nil, nil, classifier)
+
+		got, result := m.Check(context.Background(), "some text", true)
+
+		want := Verdict{Action: model.ModerationActionFlag, Rule: RuleClassifier, Detail: "violence"}
+		if got != want {
+			t.Errorf("Check() = %+v, want %+v", got, want)
+		}
+		if result.Ref != "guard" || result.Usage.TotalTokens != 22 {
+			t.Errorf("result = %+v, want the classifier's usage", result)
+		}
+	})
+
+	t.Run("FailsClosedByDefault", func(t *testing.T) {
+		m, _ := New(nil, nil, &fakeClassifier{err: errors.New("timeout")})
+
+		if got, _ := m.Check(context.Background(), "some text", true); got.Allowed() || got.Rule != RuleClassifier {
+			t.Errorf("Check() = %+v, want withheld on classifier error", got)
+		}
+	})
+
+	t.Run("FailsOpen", func(t *testing.T) {
+		m, _ := New(nil, nil, &fakeClassifier{err: errors.New("timeout")})
+		m.SetFailOpen(true)
+
+		if got, _ := m.Check(context.Background(), "some text", true); !got.Allowed() {
+			t.Errorf("Check() = %+v, want allowed on classifier error", got)
+		}
+	})
+
+	t.Run("SkippedClassifier", func(t *testing.T) {
+		classifier := &fakeClassifier{}
+		m, _ := New(nil, nil, classifier)
+
+		got, _ := m.Check(context.Background(), "some text", false)
+
+		if got.Allowed() || classifier.calls != 0 {
+			t.Errorf("Check() = %+v with %d classifier calls, want withheld without calling the classifier", got, classifier.calls)
+		}
+	})
+
+	t.Run("LocalRulesFirst", func(t *testing.T) {
+		classifier := &fakeClassifier{flagged: true}
+		m, _ := New([]string{"scam"}, nil, classifier)
+
+		got, _ := m.Check(context.Background(), "scam", true)
+
+		if got.Rule != RuleKeyword || classifier.calls != 0 {
+			t.Errorf("Check() = %+v with %d classifier calls, want keyword block without classifier", got, classifier.calls)
+		}
+	})
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	if _, err := New(nil, []string{"("}, nil); err == nil {
+		t.Error("New() error = nil, want error for invalid pattern")
+	}
+}
+
+func TestNilModeratorAllows(t *testing.T) {
+	var m *Moderator
+	if got, _ := m.Check(context.Background(), "anything", true); !got.Allowed() {
+		t.Errorf("nil Check() = %+v, want allowed", got)
+	}
+}
+
+func TestParseClassification(t *testing.T) {
+	tests := []struct {
+		content      string
+		wantFlagged  bool
+		wantCategory string
+	}{
+		{content: "SAFE", wantFlagged: false},
+		{content: " safe\n", wantFlagged: false},
+		{content: "UNSAFE: Hate", wantFlagged: true, wantCategory: "hate"},
+		{content: "unsafe - violence\nbecause of threats", wantFlagged: true, wantCategory: "violence"},
+		{content: "UNSAFE", wantFlagged: true, wantCategory: ""},
+	}
+
+	for _, tt := range tests {
+		flagged, category := parseClassification(tt.content)
+		if flagged != tt.wantFlagged || category != tt.wantCategory {
+			t.Errorf("parseClassification(%q) = %v, %q, want %v, %q", tt.content, flagged, category, tt.wantFlagged, tt.wantCategory)
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package redis
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"got/internal/groq"
+	"io"
 	"net"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,28 +18,50 @@ const (
 	defaultTimeout  = 5 * time.Second
 	historyTTL      = 24 * time.Hour
 	adminSessionTTL = 12 * time.Hour
-	maxHistoryLen   = 10
+	answerLockTTL   = 2 * time.Minute
+	maxHistoryLen   = groq.MaxHistoryMessages
+	maxHistoryScope = 100
 	historyKeyFmt   = "gpt:history:%d"
+	scopedKeyFmt    = "gpt:history:%d:%s"
+	scopesKeyFmt    = "gpt:history:%d:scopes"
+	threadKeyFmt    = "gpt:thread:%d:%d"
+	latestThreadFmt = "gpt:thread:%d:latest"
+	answerKeyFmt    = "gpt:answer:%d:%d"
+	answerLockFmt   = "gpt:answer:%d:%d:lock"
 	modelKeyFmt     = "gpt:model:%d"
 	adminKeyFmt     = "admin:session:%d"
+	modelsKeyFmt    = "llm:models:%s"
 	commandSet      = "*3\r\n$3\r\nSET\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n"
+	commandSetNX    = "*6\r\n$3\r\nSET\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n$2\r\nNX\r\n$2\r\nEX\r\n$%d\r\n%d\r\n"
 	commandGet      = "*2\r\n$3\r\nGET\r\n$%d\r\n%s\r\n"
 	commandExpire   = "*3\r\n$6\r\nEXPIRE\r\n$%d\r\n%s\r\n$%d\r\n%d\r\n"
+	commandDel      = "*%d\r\n$3\r\nDEL\r\n"
+	commandArg      = "$%d\r\n%s\r\n"
 	responseOK      = "+OK"
 	responseNil     = "$-1"
 	responseBulk    = '$'
+	responseInt     = ':'
 )
 
 type Client struct {
 	addr string
 }
 
+type Answer struct {
+	UserID    int64    `json:"user_id"`
+	Scope     string   `json:"scope"`
+	Prompt    string   `json:"prompt"`
+	ImageRefs []string `json:"image_refs,omitempty"`
+	Response  string   `json:"response"`
+	Model     string   `json:"model"`
+}
+
 func NewClient(addr string) *Client {
 	return &Client{addr: addr}
 }
 
-func (c *Client) GetHistory(ctx context.Context, chatID int64) ([]groq.Message, error) {
-	key := c.historyKey(chatID)
+func (c *Client) GetHistory(ctx context.Context, chatID int64, scope string) ([]groq.Message, error) {
+	key := c.scopedHistoryKey(chatID, scope)
 
 	data, err := c.get(ctx, key)
 	if err != nil {
@@ -52,23 +79,96 @@ func (c *Client) GetHistory(ctx context.Context, chatID int64) ([]groq.Message,
 	return history, nil
 }
 
-func (c *Client) SaveHistory(ctx context.Context, chatID int64, history []groq.Message) error {
-	if len(history) > maxHistoryLen*2 {
-		history = history[len(history)-maxHistoryLen*2:]
-	}
+func (c *Client) SaveHistory(ctx context.Context, chatID int64, scope string, history []groq.Message) error {
+	history = trimHistory(history)
 
 	data, err := json.Marshal(history)
 	if err != nil {
 		return fmt.Errorf("failed to marshal history: %w", err)
 	}
 
-	key := c.historyKey(chatID)
-	return c.setWithTTL(ctx, key, string(data), historyTTL)
+	key := c.scopedHistoryKey(chatID, scope)
+	if err := c.setWithTTL(ctx, key, string(data), historyTTL); err != nil {
+		return err
+	}
+	if scope == "" {
+		return nil
+	}
+	return c.addHistoryScope(ctx, chatID, scope)
+}
+
+func (c *Client) ClearHistory(ctx context.Context, chatID int64, scope string) error {
+	return c.del(ctx, c.scopedHistoryKey(chatID, scope))
+}
+
+func (c *Client) ClearAllHistory(ctx context.Context, chatID int64) (int, error) {
+	scopes, err := c.historyScopes(ctx, chatID)
+	if err != nil {
+		return 0, err
+	}
+
+	keys := []string{c.historyKey(chatID), c.scopesKey(chatID), c.latestThreadKey(chatID)}
+	for _, scope := range scopes {
+		keys = append(keys, c.scopedHistoryKey(chatID, scope))
+	}
+	if err := c.del(ctx, keys...); err != nil {
+		return 0, err
+	}
+
+	return len(scopes) + 1, nil
 }
 
-func (c *Client) ClearHistory(ctx context.Context, chatID int64) error {
-	key := c.historyKey(chatID)
-	return c.set(ctx, key, "[]")
+func (c *Client) GetThreadRoot(ctx context.Context, chatID int64, messageID int) (int, error) {
+	val, err := c.get(ctx, c.threadKey(chatID, messageID))
+	if err != nil || val == "" {
+		return 0, err
+	}
+	return strconv.Atoi(val)
+}
+
+func (c *Client) SetThreadRoot(ctx context.Context, chatID int64, messageID, rootID int) error {
+	return c.setWithTTL(ctx, c.threadKey(chatID, messageID), strconv.Itoa(rootID), historyTTL)
+}
+
+func (c *Client) GetLatestThread(ctx context.Context, chatID int64) (int, error) {
+	val, err := c.get(ctx, c.latestThreadKey(chatID))
+	if err != nil || val == "" {
+		return 0, err
+	}
+	return strconv.Atoi(val)
+}
+
+func (c *Client) SetLatestThread(ctx context.Context, chatID int64, rootID int) error {
+	return c.setWithTTL(ctx, c.latestThreadKey(chatID), strconv.Itoa(rootID), historyTTL)
+}
+
+func (c *Client) GetAnswer(ctx context.Context, chatID int64, messageID int) (*Answer, error) {
+	data, err := c.get(ctx, c.answerKey(chatID, messageID))
+	if err != nil || data == "" {
+		return nil, err
+	}
+
+	var answer Answer
+	if err := json.Unmarshal([]byte(data), &answer); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal answer: %w", err)
+	}
+	return &answer, nil
+}
+
+func (c *Client) SaveAnswer(ctx context.Context, chatID int64, messageID int, answer *Answer) error {
+	data, err := json.Marshal(answer)
+	if err != nil {
+		return fmt.Errorf("failed to marshal answer: %w", err)
+	}
+	return c.setWithTTL(ctx, c.answerKey(chatID, messageID), string(data), historyTTL)
+}
+
+func (c *Client) LockAnswer(ctx context.Context, chatID int64, messageID int) (bool, error) {
+	return c.setNX(ctx, fmt.Sprintf(answerLockFmt, chatID, messageID), "1", answerLockTTL)
+}
+
+func (c *Client) UnlockAnswer(ctx context.Context, chatID int64, messageID int) error {
+	return c.del(ctx, fmt.Sprintf(answerLockFmt, chatID, messageID))
 }
 
 func (c *Client) GetModel(ctx context.Context, chatID int64) (string, error) {
@@ -98,10 +198,113 @@ func (c *Client) GetAdminSession(ctx context.Context, userID int64) (bool, error
 	return val == "1", nil
 }
 
+func (c *Client) GetModels(ctx context.Context, provider string) ([]groq.ModelInfo, error) {
+	data, err := c.get(ctx, c.modelsKey(provider))
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return nil, nil
+	}
+
+	var models []groq.ModelInfo
+	if err := json.Unmarshal([]byte(data), &models); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal models: %w", err)
+	}
+
+	return models, nil
+}
+
+func (c *Client) SaveModels(ctx context.Context, provider string, models []groq.ModelInfo, ttl time.Duration) error {
+	data, err := json.Marshal(models)
+	if err != nil {
+		return fmt.Errorf("failed to marshal models: %w", err)
+	}
+
+	key := c.modelsKey(provider)
+	if ttl <= 0 {
+		return c.set(ctx, key, string(data))
+	}
+	return c.setWithTTL(ctx, key, string(data), ttl)
+}
+
+func (c *Client) historyScopes(ctx context.Context, chatID int64) ([]string, error) {
+	data, err := c.get(ctx, c.scopesKey(chatID))
+	if err != nil || data == "" {
+		return nil, err
+	}
+
+	var scopes []string
+	if err := json.Unmarshal([]byte(data), &scopes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal history scopes: %w", err)
+	}
+	return scopes, nil
+}
+
+func (c *Client) addHistoryScope(ctx context.Context, chatID int64, scope string) error {
+	scopes, err := c.historyScopes(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if len(scopes) > 0 && scopes[len(scopes)-1] == scope {
+		return nil
+	}
+
+	data, err := json.Marshal(touchScope(scopes, scope))
+	if err != nil {
+		return fmt.Errorf("failed to marshal history scopes: %w", err)
+	}
+	return c.setWithTTL(ctx, c.scopesKey(chatID), string(data), historyTTL)
+}
+
+func touchScope(scopes []string, scope string) []string {
+	scopes = slices.DeleteFunc(scopes, func(s string) bool { return s == scope })
+	scopes = append(scopes, scope)
+	if len(scopes) > maxHistoryScope {
+		scopes = scopes[len(scopes)-maxHistoryScope:]
+	}
+	return scopes
+}
+
+func trimHistory(history []groq.Message) []groq.Message {
+	if len(history) <= maxHistoryLen {
+		return history
+	}
+
+	summary, turns := groq.SplitSummary(history)
+	if summary == "" {
+		return history[len(history)-maxHistoryLen:]
+	}
+	return groq.WithSummary(summary, turns[len(turns)-maxHistoryLen+1:])
+}
+
 func (c *Client) historyKey(chatID int64) string {
 	return fmt.Sprintf(historyKeyFmt, chatID)
 }
 
+func (c *Client) scopedHistoryKey(chatID int64, scope string) string {
+	if scope == "" {
+		return c.historyKey(chatID)
+	}
+	return fmt.Sprintf(scopedKeyFmt, chatID, scope)
+}
+
+func (c *Client) scopesKey(chatID int64) string {
+	return fmt.Sprintf(scopesKeyFmt, chatID)
+}
+
+func (c *Client) threadKey(chatID int64, messageID int) string {
+	return fmt.Sprintf(threadKeyFmt, chatID, messageID)
+}
+
+func (c *Client) latestThreadKey(chatID int64) string {
+	return fmt.Sprintf(latestThreadFmt, chatID)
+}
+
+func (c *Client) answerKey(chatID int64, messageID int) string {
+	return fmt.Sprintf(answerKeyFmt, chatID, messageID)
+}
+
 func (c *Client) modelKey(chatID int64) string {
 	return fmt.Sprintf(modelKeyFmt, chatID)
 }
@@ -110,6 +313,10 @@ func (c *Client) adminKey(userID int64) string {
 	return fmt.Sprintf(adminKeyFmt, userID)
 }
 
+func (c *Client) modelsKey(provider string) string {
+	return fmt.Sprintf(modelsKeyFmt, provider)
+}
+
 func (c *Client) get(ctx context.Context, key string) (string, error) {
 	conn, err := c.dial(ctx)
 	if err != nil {
@@ -161,6 +368,59 @@ func (c *Client) setWithTTL(ctx context.Context, key, value string, ttl time.Dur
 	return nil
 }
 
+func (c *Client) setNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	seconds := int(ttl.Seconds())
+	cmd := fmt.Sprintf(commandSetNX, len(key), key, len(value), value, len(strconv.Itoa(seconds)), seconds)
+	if _, err := conn.Write([]byte(cmd)); err != nil {
+		return false, fmt.Errorf("failed to write command: %w", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read response: %w", err)
+	}
+	switch resp := strings.TrimSuffix(line, "\r\n"); resp {
+	case responseOK:
+		return true, nil
+	case responseNil:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected response: %s", resp)
+	}
+}
+
+func (c *Client) del(ctx context.Context, keys ...string) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, commandDel, len(keys)+1)
+	for _, key := range keys {
+		fmt.Fprintf(&sb, commandArg, len(key), key)
+	}
+	if _, err := conn.Write([]byte(sb.String())); err != nil {
+		return fmt.Errorf("failed to write command: %w", err)
+	}
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp := strings.TrimSuffix(line, "\r\n"); len(resp) == 0 || resp[0] != responseInt {
+		return fmt.Errorf("unexpected response: %s", resp)
+	}
+	return nil
+}
+
 func (c *Client) dial(ctx context.Context) (net.Conn, error) {
 	var d net.Dialer
 	d.Timeout = defaultTimeout
@@ -172,26 +432,32 @@ func (c *Client) dial(ctx context.Context) (net.Conn, error) {
 }
 
 func (c *Client) readBulkString(conn net.Conn) (string, error) {
-	buf := make([]byte, 4096)
-	n, err := conn.Read(buf)
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
-	resp := string(buf[:n])
-	if len(resp) >= 3 && resp[:3] == responseNil {
+	resp := strings.TrimSuffix(line, "\r\n")
+	if resp == responseNil {
 		return "", nil
 	}
 
-	if len(resp) > 0 && resp[0] == responseBulk {
-		for i := 1; i < len(resp); i++ {
-			if resp[i] == '\r' && i+2 < len(resp) && resp[i+1] == '\n' {
-				return resp[i+2 : len(resp)-2], nil
-			}
-		}
+	if len(resp) == 0 || resp[0] != responseBulk {
+		return "", fmt.Errorf("unexpected response: %s", resp)
+	}
+
+	size, err := strconv.Atoi(resp[1:])
+	if err != nil {
+		return "", fmt.Errorf("invalid bulk length: %s", resp)
+	}
+
+	buf := make([]byte, size+2)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
 	}
 
-	return "", fmt.Errorf("unexpected response: %s", resp)
+	return string(buf[:size]), nil
 }
 
 func (c *Client) readOK(conn net.Conn) error {
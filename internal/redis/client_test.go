@@ -1,9 +1,12 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"got/internal/groq"
 	"math"
+	"net"
+	"strings"
 	"testing"
 )
 
@@ -97,6 +100,30 @@ func TestClientModelKey(t *testing.T) {
 	}
 }
 
+func TestClientModelsKey(t *testing.T) {
+	client := newTestRedisClient()
+
+	assertEqual(t, client.modelsKey("groq"), "llm:models:groq")
+	assertEqual(t, client.modelsKey("ollama"), "llm:models:ollama")
+}
+
+func TestClientScopedHistoryKey(t *testing.T) {
+	client := newTestRedisClient()
+
+	assertEqual(t, client.scopedHistoryKey(123, ""), "gpt:history:123")
+	assertEqual(t, client.scopedHistoryKey(123, "user:42"), "gpt:history:123:user:42")
+	assertEqual(t, client.scopedHistoryKey(-100, "thread:7"), "gpt:history:-100:thread:7")
+	assertEqual(t, client.scopesKey(123), "gpt:history:123:scopes")
+	assertEqual(t, client.threadKey(-100, 7), "gpt:thread:-100:7")
+}
+
+func TestClientAnswerKey(t *testing.T) {
+	client := newTestRedisClient()
+
+	assertEqual(t, client.answerKey(-100, 7), "gpt:answer:-100:7")
+	assertEqual(t, client.answerKey(123, 42), "gpt:answer:123:42")
+}
+
 func TestNewClient(t *testing.T) {
 	tests := []struct {
 		name string
@@ -147,26 +174,26 @@ func TestSaveHistoryTruncatesLongHistory(t *testing.T) {
 		},
 		{
 			name:           "atThreshold",
-			historyLen:     20,
-			expectedLen:    20,
+			historyLen:     200,
+			expectedLen:    200,
 			shouldTruncate: false,
 		},
 		{
 			name:           "justAboveThreshold",
-			historyLen:     21,
-			expectedLen:    20,
+			historyLen:     201,
+			expectedLen:    200,
 			shouldTruncate: true,
 		},
 		{
 			name:           "wellAboveThreshold",
-			historyLen:     30,
-			expectedLen:    20,
+			historyLen:     300,
+			expectedLen:    200,
 			shouldTruncate: true,
 		},
 		{
 			name:           "veryLongHistory",
-			historyLen:     100,
-			expectedLen:    20,
+			historyLen:     1000,
+			expectedLen:    200,
 			shouldTruncate: true,
 		},
 		{
@@ -187,14 +214,14 @@ func TestSaveHistoryTruncatesLongHistory(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			history := createTestHistoryWithIndex(tt.historyLen)
 
-			truncated := truncateHistory(history)
+			truncated := trimHistory(history)
 
 			if len(truncated) != tt.expectedLen {
 				t.Errorf("got length %d, want %d", len(truncated), tt.expectedLen)
 			}
 
 			if tt.shouldTruncate && tt.historyLen > 0 {
-				expectedFirstIndex := tt.historyLen - maxHistoryLen*2
+				expectedFirstIndex := tt.historyLen - maxHistoryLen
 				expectedContent := fmt.Sprintf("msg-%d", expectedFirstIndex)
 				if truncated[0].Content != expectedContent {
 					t.Errorf("first message content = %q, want %q", truncated[0].Content, expectedContent)
@@ -265,9 +292,9 @@ func TestHistoryKeyAndModelKeyDifferent(t *testing.T) {
 }
 
 func TestTruncateHistoryPreservesOrder(t *testing.T) {
-	history := createTestHistoryWithIndex(25)
+	history := createTestHistoryWithIndex(250)
 
-	truncated := truncateHistory(history)
+	truncated := trimHistory(history)
 
 	for i := 1; i < len(truncated); i++ {
 		prevContent := truncated[i-1].Content
@@ -291,7 +318,7 @@ func TestTruncateHistoryPreservesMessageContent(t *testing.T) {
 		{Role: "user", Content: "How are you?"},
 	}
 
-	truncated := truncateHistory(history)
+	truncated := trimHistory(history)
 
 	if len(truncated) != 3 {
 		t.Fatalf("expected 3 messages, got %d", len(truncated))
@@ -307,12 +334,124 @@ func TestTruncateHistoryPreservesMessageContent(t *testing.T) {
 	}
 }
 
-// truncateHistory extracts the truncation logic for unit testing
-func truncateHistory(history []groq.Message) []groq.Message {
-	if len(history) > maxHistoryLen*2 {
-		return history[len(history)-maxHistoryLen*2:]
+func TestTrimHistoryKeepsSummary(t *testing.T) {
+	history := append([]groq.Message{groq.SummaryMessage("earlier talk")}, createTestHistoryWithIndex(300)...)
+
+	trimmed := trimHistory(history)
+
+	if len(trimmed) != maxHistoryLen {
+		t.Fatalf("got length %d, want %d", len(trimmed), maxHistoryLen)
+	}
+	if summary, _ := groq.SplitSummary(trimmed); summary != "earlier talk" {
+		t.Errorf("summary = %q, want %q", summary, "earlier talk")
+	}
+	assertEqual(t, trimmed[len(trimmed)-1].Content, "msg-299")
+}
+
+func TestReadBulkStringLargeValue(t *testing.T) {
+	value := strings.Repeat("x", 20000)
+	server, conn := net.Pipe()
+	defer func() { _ = conn.Close() }()
+
+	go func() {
+		_, _ = fmt.Fprintf(server, "$%d\r\n%s\r\n", len(value), value)
+		_ = server.Close()
+	}()
+
+	got, err := newTestRedisClient().readBulkString(conn)
+	if err != nil {
+		t.Fatalf("readBulkString() error = %v", err)
 	}
-	return history
+	if got != value {
+		t.Errorf("got %d bytes, want %d", len(got), len(value))
+	}
+}
+
+func TestReadBulkStringNil(t *testing.T) {
+	server, conn := net.Pipe()
+	defer func() { _ = conn.Close() }()
+
+	go func() {
+		_, _ = fmt.Fprint(server, "$-1\r\n")
+		_ = server.Close()
+	}()
+
+	got, err := newTestRedisClient().readBulkString(conn)
+	if err != nil {
+		t.Fatalf("readBulkString() error = %v", err)
+	}
+	assertEqual(t, got, "")
+}
+
+func TestTouchScope(t *testing.T) {
+	scopes := touchScope([]string{"user:1", "user:2"}, "user:1")
+	if strings.Join(scopes, ",") != "user:2,user:1" {
+		t.Errorf("touchScope() = %v, want user:1 moved to the end", scopes)
+	}
+
+	var many []string
+	for i := range maxHistoryScope {
+		many = append(many, fmt.Sprintf("thread:%d", i))
+	}
+	scopes = touchScope(many, "thread:new")
+	if len(scopes) != maxHistoryScope || scopes[0] != "thread:1" || scopes[len(scopes)-1] != "thread:new" {
+		t.Errorf("touchScope() kept %d scopes from %q to %q, want the oldest dropped", len(scopes), scopes[0], scopes[len(scopes)-1])
+	}
+}
+
+func TestDelSendsAllKeys(t *testing.T) {
+	addr, received := serveOneReply(t, ":2\r\n")
+
+	if err := NewClient(addr).del(context.Background(), "a", "bc"); err != nil {
+		t.Fatalf("del() error = %v", err)
+	}
+	assertEqual(t, <-received, "*3\r\n$3\r\nDEL\r\n$1\r\na\r\n$2\r\nbc\r\n")
+}
+
+func TestLockAnswer(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  bool
+	}{
+		{name: "Acquired", reply: "+OK\r\n", want: true},
+		{name: "Held", reply: "$-1\r\n", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, received := serveOneReply(t, tt.reply)
+
+			got, err := NewClient(addr).LockAnswer(context.Background(), 1, 2)
+			if err != nil || got != tt.want {
+				t.Fatalf("LockAnswer() = %v, %v, want %v", got, err, tt.want)
+			}
+			assertEqual(t, <-received, "*6\r\n$3\r\nSET\r\n$19\r\ngpt:answer:1:2:lock\r\n$1\r\n1\r\n$2\r\nNX\r\n$2\r\nEX\r\n$3\r\n120\r\n")
+		})
+	}
+}
+
+func serveOneReply(t *testing.T, reply string) (string, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		buf := make([]byte, 256)
+		n, _ := conn.Read(buf)
+		received <- string(buf[:n])
+		_, _ = fmt.Fprint(conn, reply)
+	}()
+	return listener.Addr().String(), received
 }
 
 func newTestRedisClient() *Client {
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"got/internal/app/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BanRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewBanRepository(pool *pgxpool.Pool) *BanRepository {
+	return &BanRepository{pool: pool}
+}
+
+func (r *BanRepository) Save(ctx context.Context, ban *model.Ban) error {
+	query := `
+		INSERT INTO bans (target_type, target_id, reason, banned_by, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (target_type, target_id) DO UPDATE
+		SET reason = EXCLUDED.reason,
+		    banned_by = EXCLUDED.banned_by,
+		    created_at = EXCLUDED.created_at,
+		    expires_at = EXCLUDED.expires_at
+		RETURNING ban_id
+	`
+	return r.pool.QueryRow(ctx, query,
+		ban.TargetType,
+		ban.TargetID,
+		ban.Reason,
+		ban.BannedBy,
+		ban.CreatedAt,
+		ban.ExpiresAt,
+	).Scan(&ban.BanID)
+}
+
+func (r *BanRepository) Delete(ctx context.Context, targetType string, targetID int64) error {
+	query := `DELETE FROM bans WHERE target_type = $1 AND target_id = $2`
+	_, err := r.pool.Exec(ctx, query, targetType, targetID)
+	return err
+}
+
+func (r *BanRepository) FindActive(ctx context.Context, userID, chatID int64) (*model.Ban, error) {
+	query := `
+		SELECT ban_id, target_type, target_id, reason, banned_by, created_at, expires_at
+		FROM bans
+		WHERE ((target_type = 'user' AND target_id = $1) OR (target_type = 'chat' AND target_id = $2))
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		LIMIT 1
+	`
+
+	ban, err := scanBan(r.pool.QueryRow(ctx, query, userID, chatID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return ban, err
+}
+
+func (r *BanRepository) ListActive(ctx context.Context) ([]*model.Ban, error) {
+	query := `
+		SELECT ban_id, target_type, target_id, reason, banned_by, created_at, expires_at
+		FROM bans
+		WHERE expires_at IS NULL OR expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []*model.Ban
+	for rows.Next() {
+		ban, err := scanBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+
+	return bans, rows.Err()
+}
+
+func scanBan(row pgx.Row) (*model.Ban, error) {
+	var ban model.Ban
+	err := row.Scan(
+		&ban.BanID,
+		&ban.TargetType,
+		&ban.TargetID,
+		&ban.Reason,
+		&ban.BannedBy,
+		&ban.CreatedAt,
+		&ban.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"got/internal/app/model"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -67,9 +68,20 @@ func (r *ChatRepository) ListAll(ctx context.Context) ([]*model.Chat, error) {
 }
 
 func (r *ChatRepository) SetLanguage(ctx context.Context, chatID int64, language string) error {
-	query := `UPDATE chats SET language = $1 WHERE chat_id = $2`
-	_, err := r.pool.Exec(ctx, query, language, chatID)
-	return err
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `UPDATE chats SET language = $1 WHERE chat_id = $2`, language, chatID); err != nil {
+			return err
+		}
+		for _, query := range []string{
+			`UPDATE facts SET search_config = chat_search_config($1) WHERE chat_id = $1 AND search_config <> chat_search_config($1)`,
+			`UPDATE chat_messages SET search_config = chat_search_config($1) WHERE chat_id = $1 AND search_config <> chat_search_config($1)`,
+		} {
+			if _, err := tx.Exec(ctx, query, chatID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *ChatRepository) GetLanguage(ctx context.Context, chatID int64) (string, error) {
@@ -84,3 +96,60 @@ func (r *ChatRepository) GetLanguage(ctx context.Context, chatID int64) (string,
 	}
 	return lang, nil
 }
+
+func (r *ChatRepository) GetSettings(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+	query := `SELECT chat_id, persona, system_prompt, auto_transcribe, memory_scope, message_log, auto_translate, assistant_mode, lurker, lurker_chance, lurker_cooldown_seconds, lurker_quiet_until FROM chat_settings WHERE chat_id = $1`
+	var settings model.ChatSettings
+	var cooldownSeconds int64
+	var quietUntil *time.Time
+	err := r.pool.QueryRow(ctx, query, chatID).Scan(
+		&settings.ChatID,
+		&settings.Persona,
+		&settings.SystemPrompt,
+		&settings.AutoTranscribe,
+		&settings.MemoryScope,
+		&settings.MessageLog,
+		&settings.AutoTranslate,
+		&settings.AssistantMode,
+		&settings.Lurker,
+		&settings.LurkerChance,
+		&cooldownSeconds,
+		&quietUntil,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &model.ChatSettings{ChatID: chatID}, nil
+		}
+		return nil, err
+	}
+	settings.LurkerCooldown = time.Duration(cooldownSeconds) * time.Second
+	if quietUntil != nil {
+		settings.LurkerQuietUntil = *quietUntil
+	}
+	return &settings, nil
+}
+
+func (r *ChatRepository) SaveSettings(ctx context.Context, settings *model.ChatSettings) error {
+	query := `
+		INSERT INTO chat_settings (chat_id, persona, system_prompt, auto_transcribe, memory_scope, message_log, auto_translate, assistant_mode, lurker, lurker_chance, lurker_cooldown_seconds, lurker_quiet_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (chat_id) DO UPDATE
+		SET persona = EXCLUDED.persona,
+		    system_prompt = EXCLUDED.system_prompt,
+		    auto_transcribe = EXCLUDED.auto_transcribe,
+		    memory_scope = EXCLUDED.memory_scope,
+		    message_log = EXCLUDED.message_log,
+		    auto_translate = EXCLUDED.auto_translate,
+		    assistant_mode = EXCLUDED.assistant_mode,
+		    lurker = EXCLUDED.lurker,
+		    lurker_chance = EXCLUDED.lurker_chance,
+		    lurker_cooldown_seconds = EXCLUDED.lurker_cooldown_seconds,
+		    lurker_quiet_until = EXCLUDED.lurker_quiet_until
+	`
+	var quietUntil *time.Time
+	if !settings.LurkerQuietUntil.IsZero() {
+		quietUntil = &settings.LurkerQuietUntil
+	}
+	_, err := r.pool.Exec(ctx, query, settings.ChatID, settings.Persona, settings.SystemPrompt, settings.AutoTranscribe, settings.MemoryScope, settings.MessageLog, settings.AutoTranslate, settings.AssistantMode, settings.Lurker, settings.LurkerChance, int64(settings.LurkerCooldown/time.Second), quietUntil)
+	return err
+}
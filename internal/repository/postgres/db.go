@@ -2,15 +2,19 @@ package postgres
 
 import (
 	"context"
-	_ "embed"
+	"embed"
 	"fmt"
-	"log/slog"
+	"got/pkg/logger"
+	"io/fs"
+	"sort"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-//go:embed migrations/001_init.sql
-var initSQL string
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+var log = logger.For("postgres")
 
 func NewDB(ctx context.Context, connString string) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(connString)
@@ -31,11 +35,25 @@ func NewDB(ctx context.Context, connString string) (*pgxpool.Pool, error) {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	slog.Info("Connected to database")
+	log.InfoContext(ctx, "Connected to database")
 	return pool, nil
 }
 
 func runMigrations(ctx context.Context, pool *pgxpool.Pool) error {
-	_, err := pool.Exec(ctx, initSQL)
-	return err
+	files, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		sql, err := migrationsFS.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if _, err := pool.Exec(ctx, string(sql)); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", file, err)
+		}
+	}
+	return nil
 }
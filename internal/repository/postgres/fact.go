@@ -19,8 +19,8 @@ func NewFactRepository(pool *pgxpool.Pool) *FactRepository {
 
 func (r *FactRepository) Save(ctx context.Context, fact *model.Fact) error {
 	query := `
-		INSERT INTO facts (comment, chat_id)
-		VALUES ($1, $2)
+		INSERT INTO facts (comment, chat_id, search_config)
+		VALUES ($1, $2, chat_search_config($2))
 		RETURNING fact_id
 	`
 	err := r.pool.QueryRow(ctx, query, fact.Comment, fact.Chat.ChatID).Scan(&fact.ID)
@@ -81,3 +81,31 @@ func (r *FactRepository) ListByChat(ctx context.Context, chatID int64) ([]*model
 
 	return facts, nil
 }
+
+func (r *FactRepository) Search(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+	sql := `
+		WITH q AS (
+			SELECT NULLIF(replace(plainto_tsquery(chat_search_config($1), $2)::text, ' & ', ' | '), '')::tsquery AS query
+		)
+		SELECT f.fact_id, f.comment, ts_rank_cd(f.search_vector, q.query)::float8 AS rank
+		FROM facts f, q
+		WHERE f.chat_id = $1 AND f.search_vector @@ q.query
+		ORDER BY rank DESC, f.fact_id DESC
+		LIMIT $3
+	`
+	rows, err := r.pool.Query(ctx, sql, chatID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snippets []*model.KnowledgeSnippet
+	for rows.Next() {
+		snippet := model.KnowledgeSnippet{Source: model.SnippetSourceFact}
+		if err := rows.Scan(&snippet.ID, &snippet.Text, &snippet.Rank); err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, &snippet)
+	}
+	return snippets, rows.Err()
+}
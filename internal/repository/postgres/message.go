@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"got/internal/app/model"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MessageRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMessageRepository(pool *pgxpool.Pool) *MessageRepository {
+	return &MessageRepository{pool: pool}
+}
+
+func (r *MessageRepository) Save(ctx context.Context, msg *model.ChatMessage) error {
+	query := `
+		INSERT INTO chat_messages (chat_id, message_id, user_id, username, text, created_at, search_config)
+		VALUES ($1, $2, $3, $4, $5, $6, chat_search_config($1))
+		RETURNING id
+	`
+	return r.pool.QueryRow(ctx, query,
+		msg.ChatID,
+		msg.MessageID,
+		msg.UserID,
+		msg.Username,
+		msg.Text,
+		msg.CreatedAt,
+	).Scan(&msg.ID)
+}
+
+func (r *MessageRepository) ListRecent(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.ChatMessage, error) {
+	query := `
+		SELECT id, chat_id, message_id, user_id, username, text, created_at
+		FROM (
+			SELECT id, chat_id, message_id, user_id, username, text, created_at
+			FROM chat_messages
+			WHERE chat_id = $1 AND created_at >= $2
+			ORDER BY created_at DESC, id DESC
+			LIMIT $3
+		) recent
+		ORDER BY created_at, id
+	`
+	rows, err := r.pool.Query(ctx, query, chatID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*model.ChatMessage
+	for rows.Next() {
+		var msg model.ChatMessage
+		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.MessageID, &msg.UserID, &msg.Username, &msg.Text, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, rows.Err()
+}
+
+func (r *MessageRepository) DeleteByChat(ctx context.Context, chatID int64) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM chat_messages WHERE chat_id = $1`, chatID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *MessageRepository) Prune(ctx context.Context, before time.Time, maxPerChat int) (int64, error) {
+	query := `
+		DELETE FROM chat_messages
+		WHERE created_at < $1
+		   OR id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY chat_id ORDER BY created_at DESC, id DESC) AS position
+				FROM chat_messages
+			) ranked
+			WHERE position > $2
+		   )
+	`
+	tag, err := r.pool.Exec(ctx, query, before, maxPerChat)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *MessageRepository) Search(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+	sql := `
+		WITH q AS (
+			SELECT NULLIF(replace(plainto_tsquery(chat_search_config($1), $2)::text, ' & ', ' | '), '')::tsquery AS query
+		)
+		SELECT m.id, m.username, m.text, m.created_at, ts_rank_cd(m.search_vector, q.query)::float8 AS rank
+		FROM chat_messages m, q
+		WHERE m.chat_id = $1 AND m.search_vector @@ q.query
+		ORDER BY rank DESC, m.created_at DESC
+		LIMIT $3
+	`
+	rows, err := r.pool.Query(ctx, sql, chatID, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snippets []*model.KnowledgeSnippet
+	for rows.Next() {
+		snippet := model.KnowledgeSnippet{Source: model.SnippetSourceMessage}
+		if err := rows.Scan(&snippet.ID, &snippet.Username, &snippet.Text, &snippet.CreatedAt, &snippet.Rank); err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, &snippet)
+	}
+	return snippets, rows.Err()
+}
@@ -0,0 +1,15 @@
+-- +migrate Up
+
+-- Bans table (blocked users and chats, NULL expires_at means permanent)
+CREATE TABLE IF NOT EXISTS bans (
+    ban_id BIGSERIAL PRIMARY KEY,
+    target_type VARCHAR(10) NOT NULL,
+    target_id BIGINT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    banned_by BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    UNIQUE(target_type, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bans_target ON bans(target_type, target_id);
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- Command usage table (one row per command invocation)
+CREATE TABLE IF NOT EXISTS command_usage (
+    usage_id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    command VARCHAR(64) NOT NULL,
+    sub_command VARCHAR(64) NOT NULL DEFAULT '',
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    success BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_command_usage_chat ON command_usage(chat_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_command_usage_created ON command_usage(created_at);
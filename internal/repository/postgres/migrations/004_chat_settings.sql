@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- Chat settings table (per-chat GPT configuration)
+CREATE TABLE IF NOT EXISTS chat_settings (
+    chat_id BIGINT PRIMARY KEY REFERENCES chats(chat_id),
+    persona VARCHAR(64) NOT NULL DEFAULT '',
+    system_prompt TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,4 @@
+-- +migrate Up
+
+-- Per-chat automatic voice note transcription
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS auto_transcribe BOOLEAN NOT NULL DEFAULT FALSE;
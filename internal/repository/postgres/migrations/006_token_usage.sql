@@ -0,0 +1,24 @@
+-- +migrate Up
+
+-- AI token usage table (one row per completed GPT request)
+CREATE TABLE IF NOT EXISTS token_usage (
+    usage_id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    model VARCHAR(128) NOT NULL,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_usage_chat ON token_usage(chat_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_token_usage_user ON token_usage(user_id, created_at);
+
+-- Per-chat and per-user quota overrides (0 means unlimited)
+CREATE TABLE IF NOT EXISTS token_quotas (
+    target_type VARCHAR(10) NOT NULL CHECK (target_type IN ('user', 'chat')),
+    target_id BIGINT NOT NULL,
+    daily_limit BIGINT NOT NULL DEFAULT 0,
+    monthly_limit BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (target_type, target_id)
+);
@@ -0,0 +1,4 @@
+-- +migrate Up
+
+-- Per-chat GPT conversation memory scope: chat, user or thread
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS memory_scope TEXT NOT NULL DEFAULT 'chat';
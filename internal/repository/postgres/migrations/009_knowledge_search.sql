@@ -0,0 +1,34 @@
+-- +migrate Up
+
+-- Full-text search over facts and the message log, in the chat's language
+CREATE OR REPLACE FUNCTION chat_search_config(p_chat_id BIGINT) RETURNS regconfig AS $$
+    SELECT CASE (SELECT language FROM chats WHERE chat_id = p_chat_id)
+        WHEN 'en' THEN 'english'
+        WHEN 'ru' THEN 'russian'
+        WHEN 'lt' THEN 'lithuanian'
+        ELSE 'simple'
+    END::regconfig
+$$ LANGUAGE SQL STABLE;
+
+-- Rows written before this migration are backfilled once; later language changes are applied by SetLanguage
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'facts' AND column_name = 'search_config') THEN
+        ALTER TABLE facts ADD COLUMN search_config regconfig NOT NULL DEFAULT 'simple';
+        UPDATE facts SET search_config = chat_search_config(chat_id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'chat_messages' AND column_name = 'search_config') THEN
+        ALTER TABLE chat_messages ADD COLUMN search_config regconfig NOT NULL DEFAULT 'simple';
+        UPDATE chat_messages SET search_config = chat_search_config(chat_id);
+    END IF;
+END $$;
+
+ALTER TABLE facts ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector(search_config, comment)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_facts_search ON facts USING GIN (search_vector);
+
+ALTER TABLE chat_messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector(search_config, username || ' ' || text)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_chat_messages_search ON chat_messages USING GIN (search_vector);
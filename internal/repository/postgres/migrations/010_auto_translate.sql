@@ -0,0 +1,4 @@
+-- +migrate Up
+
+-- Per-chat automatic translation of messages in other languages
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS auto_translate BOOLEAN NOT NULL DEFAULT FALSE;
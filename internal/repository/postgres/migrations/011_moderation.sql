@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- Moderation interventions on GPT and image answers, for admins
+CREATE TABLE IF NOT EXISTS moderation_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    source VARCHAR(32) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    rule VARCHAR(32) NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    excerpt TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_events_created ON moderation_events(created_at DESC);
@@ -0,0 +1,4 @@
+-- +migrate Up
+
+-- Private-chat assistant mode that answers plain messages without /gpt
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS assistant_mode BOOLEAN NOT NULL DEFAULT FALSE;
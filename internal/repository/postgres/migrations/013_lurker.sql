@@ -0,0 +1,7 @@
+-- +migrate Up
+
+-- Opt-in unprompted replies in group chats; zero chance or cooldown means the configured default, a null quiet time means not silenced
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS lurker BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS lurker_chance INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS lurker_cooldown_seconds BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE chat_settings ADD COLUMN IF NOT EXISTS lurker_quiet_until TIMESTAMPTZ;
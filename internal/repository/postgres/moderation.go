@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"got/internal/app/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ModerationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewModerationRepository(pool *pgxpool.Pool) *ModerationRepository {
+	return &ModerationRepository{pool: pool}
+}
+
+func (r *ModerationRepository) Save(ctx context.Context, event *model.ModerationEvent) error {
+	query := `
+		INSERT INTO moderation_events (chat_id, user_id, source, action, rule, detail, excerpt)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING event_id, created_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		event.ChatID,
+		event.UserID,
+		event.Source,
+		event.Action,
+		event.Rule,
+		event.Detail,
+		event.Excerpt,
+	).Scan(&event.EventID, &event.CreatedAt)
+}
+
+func (r *ModerationRepository) ListRecent(ctx context.Context, limit int) ([]*model.ModerationEvent, error) {
+	query := `
+		SELECT event_id, chat_id, user_id, source, action, rule, detail, excerpt, created_at
+		FROM moderation_events
+		ORDER BY created_at DESC, event_id DESC
+		LIMIT $1
+	`
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.ModerationEvent
+	for rows.Next() {
+		var e model.ModerationEvent
+		if err := rows.Scan(&e.EventID, &e.ChatID, &e.UserID, &e.Source, &e.Action, &e.Rule, &e.Detail, &e.Excerpt, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"got/internal/app/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTokenRepository(pool *pgxpool.Pool) *TokenRepository {
+	return &TokenRepository{pool: pool}
+}
+
+func (r *TokenRepository) Save(ctx context.Context, usage *model.TokenUsage) error {
+	query := `
+		INSERT INTO token_usage (chat_id, user_id, model, prompt_tokens, completion_tokens, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING usage_id
+	`
+	return r.pool.QueryRow(ctx, query,
+		usage.ChatID,
+		usage.UserID,
+		usage.Model,
+		usage.PromptTokens,
+		usage.CompletionTokens,
+		usage.CreatedAt,
+	).Scan(&usage.UsageID)
+}
+
+func (r *TokenRepository) Totals(ctx context.Context, targetType string, targetID int64, since time.Time) (*model.TokenTotals, error) {
+	query := `
+		SELECT COALESCE(SUM(prompt_tokens), 0)::BIGINT, COALESCE(SUM(completion_tokens), 0)::BIGINT
+		FROM token_usage
+		WHERE created_at >= $3
+		  AND (($1 = 'chat' AND chat_id = $2) OR ($1 = 'user' AND user_id = $2))
+	`
+
+	var totals model.TokenTotals
+	err := r.pool.QueryRow(ctx, query, targetType, targetID, since).Scan(
+		&totals.PromptTokens,
+		&totals.CompletionTokens,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &totals, nil
+}
+
+func (r *TokenRepository) TopModels(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	query := `
+		SELECT 0, model, SUM(prompt_tokens + completion_tokens)::BIGINT AS tokens
+		FROM token_usage
+		WHERE ($1::bigint = 0 OR chat_id = $1::bigint) AND created_at >= $2
+		GROUP BY model
+		ORDER BY tokens DESC, model
+		LIMIT $3
+	`
+	return queryUsageCounts(ctx, r.pool, query, chatID, since, limit)
+}
+
+func (r *TokenRepository) TopUsers(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	query := `
+		SELECT tu.user_id, COALESCE(u.username, ''), SUM(tu.prompt_tokens + tu.completion_tokens)::BIGINT AS tokens
+		FROM token_usage tu
+		LEFT JOIN users u ON tu.user_id = u.user_id
+		WHERE ($1::bigint = 0 OR tu.chat_id = $1::bigint) AND tu.created_at >= $2 AND tu.user_id <> 0
+		GROUP BY tu.user_id, u.username
+		ORDER BY tokens DESC, tu.user_id
+		LIMIT $3
+	`
+	return queryUsageCounts(ctx, r.pool, query, chatID, since, limit)
+}
+
+func (r *TokenRepository) GetQuota(ctx context.Context, targetType string, targetID int64) (*model.TokenQuota, error) {
+	query := `
+		SELECT target_type, target_id, daily_limit, monthly_limit
+		FROM token_quotas
+		WHERE target_type = $1 AND target_id = $2
+	`
+
+	var quota model.TokenQuota
+	err := r.pool.QueryRow(ctx, query, targetType, targetID).Scan(
+		&quota.TargetType,
+		&quota.TargetID,
+		&quota.Daily,
+		&quota.Monthly,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+func (r *TokenRepository) SaveQuota(ctx context.Context, quota *model.TokenQuota) error {
+	query := `
+		INSERT INTO token_quotas (target_type, target_id, daily_limit, monthly_limit)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (target_type, target_id) DO UPDATE
+		SET daily_limit = EXCLUDED.daily_limit,
+		    monthly_limit = EXCLUDED.monthly_limit
+	`
+	_, err := r.pool.Exec(ctx, query, quota.TargetType, quota.TargetID, quota.Daily, quota.Monthly)
+	return err
+}
+
+func (r *TokenRepository) DeleteQuota(ctx context.Context, targetType string, targetID int64) error {
+	query := `DELETE FROM token_quotas WHERE target_type = $1 AND target_id = $2`
+	_, err := r.pool.Exec(ctx, query, targetType, targetID)
+	return err
+}
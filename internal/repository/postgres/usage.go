@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"got/internal/app/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UsageRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewUsageRepository(pool *pgxpool.Pool) *UsageRepository {
+	return &UsageRepository{pool: pool}
+}
+
+func (r *UsageRepository) Save(ctx context.Context, usage *model.CommandUsage) error {
+	query := `
+		INSERT INTO command_usage (chat_id, user_id, command, sub_command, latency_ms, success, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING usage_id
+	`
+	return r.pool.QueryRow(ctx, query,
+		usage.ChatID,
+		usage.UserID,
+		usage.Command,
+		usage.SubCommand,
+		usage.Latency.Milliseconds(),
+		usage.Success,
+		usage.CreatedAt,
+	).Scan(&usage.UsageID)
+}
+
+func (r *UsageRepository) Summary(ctx context.Context, chatID int64, since time.Time) (*model.UsageSummary, error) {
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE NOT success),
+		       COUNT(DISTINCT chat_id),
+		       COUNT(DISTINCT user_id) FILTER (WHERE user_id <> 0),
+		       COALESCE(AVG(latency_ms), 0)::BIGINT
+		FROM command_usage
+		WHERE ($1::bigint = 0 OR chat_id = $1::bigint) AND created_at >= $2
+	`
+
+	var summary model.UsageSummary
+	var avgLatencyMs int64
+	err := r.pool.QueryRow(ctx, query, chatID, since).Scan(
+		&summary.Total,
+		&summary.Failed,
+		&summary.Chats,
+		&summary.Users,
+		&avgLatencyMs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	summary.AvgLatency = time.Duration(avgLatencyMs) * time.Millisecond
+	return &summary, nil
+}
+
+func (r *UsageRepository) TopCommands(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	query := `
+		SELECT 0, command, COUNT(*) AS cnt
+		FROM command_usage
+		WHERE ($1::bigint = 0 OR chat_id = $1::bigint) AND created_at >= $2
+		GROUP BY command
+		ORDER BY cnt DESC, command
+		LIMIT $3
+	`
+	return queryUsageCounts(ctx, r.pool, query, chatID, since, limit)
+}
+
+func (r *UsageRepository) TopUsers(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	query := `
+		SELECT cu.user_id, COALESCE(u.username, ''), COUNT(*) AS cnt
+		FROM command_usage cu
+		LEFT JOIN users u ON cu.user_id = u.user_id
+		WHERE ($1::bigint = 0 OR cu.chat_id = $1::bigint) AND cu.created_at >= $2 AND cu.user_id <> 0
+		GROUP BY cu.user_id, u.username
+		ORDER BY cnt DESC, cu.user_id
+		LIMIT $3
+	`
+	return queryUsageCounts(ctx, r.pool, query, chatID, since, limit)
+}
+
+func (r *UsageRepository) TopChats(ctx context.Context, since time.Time, limit int) ([]*model.UsageCount, error) {
+	query := `
+		SELECT cu.chat_id, COALESCE(c.chat_name, ''), COUNT(*) AS cnt
+		FROM command_usage cu
+		LEFT JOIN chats c ON cu.chat_id = c.chat_id
+		WHERE cu.created_at >= $1
+		GROUP BY cu.chat_id, c.chat_name
+		ORDER BY cnt DESC, cu.chat_id
+		LIMIT $2
+	`
+	return queryUsageCounts(ctx, r.pool, query, since, limit)
+}
+
+func queryUsageCounts(ctx context.Context, pool *pgxpool.Pool, query string, args ...any) ([]*model.UsageCount, error) {
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*model.UsageCount
+	for rows.Next() {
+		count, err := scanUsageCount(rows)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+func scanUsageCount(row pgx.Row) (*model.UsageCount, error) {
+	var count model.UsageCount
+	if err := row.Scan(&count.ID, &count.Name, &count.Count); err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
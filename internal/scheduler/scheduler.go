@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"got/pkg/logger"
 	"log/slog"
 
 	"github.com/robfig/cron/v3"
@@ -13,11 +14,16 @@ type Job struct {
 	Func     func(ctx context.Context) error
 }
 
+type ErrorHandler func(ctx context.Context, job string, err error)
+
 type Scheduler struct {
-	cron *cron.Cron
-	jobs []Job
+	cron    *cron.Cron
+	jobs    []Job
+	onError ErrorHandler
 }
 
+var log = logger.For("scheduler")
+
 func New() *Scheduler {
 	return &Scheduler{
 		cron: cron.New(cron.WithSeconds()),
@@ -26,12 +32,16 @@ func New() *Scheduler {
 
 func (s *Scheduler) Register(job Job) error {
 	_, err := s.cron.AddFunc(job.Schedule, func() {
-		ctx := context.Background()
+		ctx := logger.WithCorrelationID(context.Background(), logger.NewCorrelationID())
+		ctx = logger.WithAttrs(ctx, slog.String("job", job.Name))
 		if err := job.Func(ctx); err != nil {
-			slog.Error("Job failed", "name", job.Name, "error", err)
+			log.ErrorContext(ctx, "Job failed", "name", job.Name, "error", err)
+			if s.onError != nil {
+				s.onError(ctx, job.Name, err)
+			}
 			return
 		}
-		slog.Info("Job completed", "name", job.Name)
+		log.InfoContext(ctx, "Job completed", "name", job.Name)
 	})
 	if err != nil {
 		return err
@@ -40,8 +50,12 @@ func (s *Scheduler) Register(job Job) error {
 	return nil
 }
 
+func (s *Scheduler) OnError(handler ErrorHandler) {
+	s.onError = handler
+}
+
 func (s *Scheduler) Start() {
-	slog.Info("Scheduler started", "jobs", len(s.jobs))
+	log.Info("Scheduler started", "jobs", len(s.jobs))
 	s.cron.Start()
 }
 
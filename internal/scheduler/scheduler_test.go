@@ -96,3 +96,35 @@ func TestSchedulerJobError(t *testing.T) {
 	time.Sleep(1500 * time.Millisecond)
 	s.Stop()
 }
+
+func TestSchedulerOnError(t *testing.T) {
+	s := New()
+
+	reported := make(chan string, 1)
+	s.OnError(func(ctx context.Context, job string, err error) {
+		select {
+		case reported <- job:
+		default:
+		}
+	})
+
+	_ = s.Register(Job{
+		Name:     "failing",
+		Schedule: "* * * * * *",
+		Func: func(ctx context.Context) error {
+			return errors.New("job error")
+		},
+	})
+
+	s.Start()
+	defer s.Stop()
+
+	select {
+	case job := <-reported:
+		if job != "failing" {
+			t.Errorf("want job %q, got %q", "failing", job)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("job error was not reported within timeout")
+	}
+}
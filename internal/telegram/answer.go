@@ -0,0 +1,228 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"got/internal/app/model"
+	"got/internal/groq"
+	"got/internal/redis"
+	"got/pkg/i18n"
+)
+
+const (
+	answerCallbackPrefix = "gpt:"
+	maxAnswerRunes       = 4096
+	continuePrompt       = "Continue your previous answer exactly where it stopped. Do not repeat what you already wrote."
+	shorterPrompt        = "Rewrite your previous answer to be much shorter while keeping the key points. Reply with the new answer only."
+)
+
+const (
+	answerActionRegenerate answerAction = "regen"
+	answerActionContinue   answerAction = "continue"
+	answerActionShorter    answerAction = "shorter"
+	answerActionModel      answerAction = "model"
+)
+
+type answerAction string
+
+var answerActions = map[answerAction]bool{
+	answerActionRegenerate: true,
+	answerActionContinue:   true,
+	answerActionShorter:    true,
+	answerActionModel:      true,
+}
+
+func (h *BotHandlers) HandleCallback(ctx context.Context, update *Update) error {
+	cq := update.CallbackQuery
+	if cq == nil || cq.Message == nil || cq.Message.Chat == nil {
+		return nil
+	}
+
+	if data, ok := strings.CutPrefix(cq.Data, assistantCallbackPrefix); ok {
+		return h.handleAssistantCallback(ctx, cq, data)
+	}
+	action, ok := strings.CutPrefix(cq.Data, answerCallbackPrefix)
+	if !ok || !answerActions[answerAction(action)] {
+		return h.client.AnswerCallbackQuery(ctx, cq.ID, "")
+	}
+	return h.handleAnswerAction(ctx, cq, answerAction(action))
+}
+
+func (h *BotHandlers) handleAnswerAction(ctx context.Context, cq *CallbackQuery, action answerAction) error {
+	chatID := cq.Message.Chat.ID
+	messageID := cq.Message.MessageID
+	t := h.getTranslator(ctx, chatID)
+
+	if h.cache == nil || h.gpt == nil {
+		return h.client.AnswerCallbackQuery(ctx, cq.ID, t.Get(i18n.KeyGptAnswerExpired))
+	}
+	answer, err := h.cache.GetAnswer(ctx, chatID, messageID)
+	if err != nil || answer == nil {
+		return h.client.AnswerCallbackQuery(ctx, cq.ID, t.Get(i18n.KeyGptAnswerExpired))
+	}
+	if cq.From == nil || cq.From.ID != answer.UserID {
+		return h.client.AnswerCallbackQuery(ctx, cq.ID, t.Get(i18n.KeyGptAnswerNotYours))
+	}
+	locked, err := h.cache.LockAnswer(ctx, chatID, messageID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to lock answer", "chat_id", chatID, "message_id", messageID, "error", err)
+		return h.client.AnswerCallbackQuery(ctx, cq.ID, t.Get(i18n.KeyGptAnswerExpired))
+	}
+	if !locked {
+		return h.client.AnswerCallbackQuery(ctx, cq.ID, t.Get(i18n.KeyGptAnswerBusy))
+	}
+	defer func() {
+		if err := h.cache.UnlockAnswer(ctx, chatID, messageID); err != nil {
+			log.WarnContext(ctx, "Failed to unlock answer", "chat_id", chatID, "message_id", messageID, "error", err)
+		}
+	}()
+
+	msg := &Message{MessageID: messageID, Chat: cq.Message.Chat, From: cq.From}
+	if blocked := h.quotaMessage(ctx, msg); blocked != "" {
+		return h.client.AnswerCallbackQuery(ctx, cq.ID, blocked)
+	}
+
+	ref := answer.Model
+	if action == answerActionModel {
+		ref = nextModelRef(h.gpt.Models(ctx), answer.Model)
+		if ref == "" {
+			return h.client.AnswerCallbackQuery(ctx, cq.ID, t.Get(i18n.KeyGptAnswerNoOtherModel))
+		}
+	}
+	if err := h.client.AnswerCallbackQuery(ctx, cq.ID, ""); err != nil {
+		log.WarnContext(ctx, "Failed to answer callback query", "chat_id", chatID, "error", err)
+	}
+
+	typing := h.startTyping(ctx, chatID, actionTyping)
+	defer typing.Stop()
+
+	images := h.answerImages(ctx, answer.ImageRefs)
+	if len(images) > 0 {
+		if visionRef, ok := h.gpt.VisionRef(ctx, ref); ok {
+			ref = visionRef
+		}
+	}
+
+	history, _ := h.cache.GetHistory(ctx, chatID, answer.Scope)
+	turn := findAnswerTurn(history, answer.Prompt, answer.Response)
+	var previous []groq.Message
+	if turn >= 0 {
+		previous = history[:turn]
+	}
+
+	systemPrompt, err := h.service.BuildSystemPrompt(ctx, chatID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to build system prompt, using default", "error", err)
+	}
+	req := groq.ChatRequest{
+		SystemPrompt: h.withKnowledge(ctx, chatID, systemPrompt, answer.Prompt),
+		History:      previous,
+		Prompt:       answer.Prompt,
+		Images:       images,
+	}
+	if action == answerActionContinue || action == answerActionShorter {
+		req.History = append(slices.Clip(previous),
+			groq.Message{Role: "user", Content: answer.Prompt, ImageRefs: answer.ImageRefs},
+			groq.Message{Role: "assistant", Content: answer.Response},
+		)
+		req.Prompt = shorterPrompt
+		if action == answerActionContinue {
+			req.Prompt = continuePrompt
+		}
+		req.Images = nil
+	}
+
+	result, err := h.gpt.Complete(ctx, ref, req)
+	if err != nil {
+		log.WarnContext(ctx, "GPT answer action failed", "action", action, "model", ref, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(gptErrorKey(err)))
+	}
+	h.recordTokenUsage(ctx, msg, result)
+
+	response := result.Content
+	if action == answerActionContinue {
+		response = strings.TrimRight(answer.Response, " \n") + "\n" + strings.TrimLeft(response, " \n")
+	}
+	if notice, allowed := h.moderate(ctx, msg, model.ModerationSourceGPT, response); !allowed {
+		return h.client.EditMessageText(ctx, chatID, messageID, notice, nil)
+	}
+
+	text := response
+	if result.Fallback || action == answerActionModel {
+		text += fmt.Sprintf(t.Get(i18n.KeyGptAnsweredBy), result.Ref)
+	}
+	if err := h.client.EditMessageText(ctx, chatID, messageID, truncateMarkdown(text, maxAnswerRunes), answerKeyboard(t)); err != nil {
+		return err
+	}
+
+	if turn >= 0 {
+		history[turn+1].Content = response
+		if err := h.cache.SaveHistory(ctx, chatID, answer.Scope, history); err != nil {
+			log.WarnContext(ctx, "Failed to update history for answer", "chat_id", chatID, "error", err)
+		}
+	}
+	answer.Response, answer.Model = response, result.Ref
+	h.saveAnswer(ctx, chatID, messageID, answer)
+	return nil
+}
+
+func (h *BotHandlers) saveAnswer(ctx context.Context, chatID int64, messageID int, answer *redis.Answer) {
+	if err := h.cache.SaveAnswer(ctx, chatID, messageID, answer); err != nil {
+		log.WarnContext(ctx, "Failed to save answer state", "chat_id", chatID, "message_id", messageID, "error", err)
+	}
+}
+
+func (h *BotHandlers) answerImages(ctx context.Context, refs []string) []string {
+	var images []string
+	for _, ref := range refs {
+		data, err := h.client.DownloadFile(ctx, ref)
+		if err != nil {
+			log.WarnContext(ctx, "Failed to download answer image", "file", ref, "error", err)
+			continue
+		}
+		images = append(images, imageDataURL(data))
+	}
+	return images
+}
+
+func answerKeyboard(t *i18n.Translator) *InlineKeyboardMarkup {
+	button := func(key i18n.Key, action answerAction) InlineKeyboardButton {
+		return InlineKeyboardButton{Text: t.Get(key), CallbackData: answerCallbackPrefix + string(action)}
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{
+		{button(i18n.KeyGptButtonRegenerate, answerActionRegenerate), button(i18n.KeyGptButtonContinue, answerActionContinue)},
+		{button(i18n.KeyGptButtonShorter, answerActionShorter), button(i18n.KeyGptButtonModel, answerActionModel)},
+	}}
+}
+
+func findAnswerTurn(history []groq.Message, prompt, response string) int {
+	for i := len(history) - 2; i >= 0; i-- {
+		if history[i].Role == "user" && history[i].Content == prompt &&
+			history[i+1].Role == "assistant" && history[i+1].Content == response {
+			return i
+		}
+	}
+	return -1
+}
+
+func nextModelRef(refs []string, current string) string {
+	if len(refs) == 0 {
+		return ""
+	}
+	next := refs[(slices.Index(refs, current)+1)%len(refs)]
+	if next == current {
+		return ""
+	}
+	return next
+}
+
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
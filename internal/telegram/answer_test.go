@@ -0,0 +1,168 @@
+package telegram
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"got/internal/groq"
+)
+
+func TestAnswerKeyboard(t *testing.T) {
+	keyboard := answerKeyboard(newTestTranslator())
+
+	var got []string
+	for _, row := range keyboard.InlineKeyboard {
+		for _, button := range row {
+			got = append(got, button.Text+"="+button.CallbackData)
+		}
+	}
+
+	want := "Regenerate=gpt:regen Continue=gpt:continue Shorter=gpt:shorter Another model=gpt:model"
+	if strings.Join(got, " ") != want {
+		t.Errorf("buttons = %q, want %q", strings.Join(got, " "), want)
+	}
+	for _, button := range got {
+		if _, data, _ := strings.Cut(button, "="); len(data) > 64 {
+			t.Errorf("callback data %q exceeds Telegram's 64 byte limit", data)
+		}
+	}
+}
+
+func TestFindAnswerTurn(t *testing.T) {
+	history := []groq.Message{
+		{Role: "user", Content: "bob: hi"},
+		{Role: "assistant", Content: "hello"},
+		{Role: "user", Content: "bob: hi"},
+		{Role: "assistant", Content: "hello again"},
+		{Role: "user", Content: "bob: thanks"},
+		{Role: "assistant", Content: "you're welcome"},
+	}
+
+	tests := []struct {
+		name     string
+		prompt   string
+		response string
+		want     int
+	}{
+		{name: "Latest", prompt: "bob: thanks", response: "you're welcome", want: 4},
+		{name: "Older", prompt: "bob: hi", response: "hello again", want: 2},
+		{name: "SamePromptFirstAnswer", prompt: "bob: hi", response: "hello", want: 0},
+		{name: "Missing", prompt: "bob: hi", response: "bye", want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findAnswerTurn(history, tt.prompt, tt.response); got != tt.want {
+				t.Errorf("findAnswerTurn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextModelRef(t *testing.T) {
+	refs := []string{"groq:a", "groq:b", "local:c"}
+
+	tests := []struct {
+		name    string
+		refs    []string
+		current string
+		want    string
+	}{
+		{name: "Next", refs: refs, current: "groq:a", want: "groq:b"},
+		{name: "WrapsAround", refs: refs, current: "local:c", want: "groq:a"},
+		{name: "UnknownStartsAtFirst", refs: refs, current: "old:x", want: "groq:a"},
+		{name: "OnlyModel", refs: []string{"groq:a"}, current: "groq:a", want: ""},
+		{name: "NoModels", current: "groq:a", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextModelRef(tt.refs, tt.current); got != tt.want {
+				t.Errorf("nextModelRef(%q) = %q, want %q", tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleCallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantText string
+	}{
+		{name: "ExpiredWithoutCache", data: "gpt:regen", wantText: "This answer can no longer be changed."},
+		{name: "UnknownAction", data: "gpt:explode", wantText: ""},
+		{name: "ForeignData", data: "poll:1", wantText: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var answered map[string]any
+			server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, answerCallbackCMD) {
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+				answered = decodeJSONPayload(t, r)
+			})
+			handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), newTestServiceForHandlers(), groq.NewClient("test-key"))
+
+			update := &Update{CallbackQuery: &CallbackQuery{
+				ID:      "cb1",
+				From:    &User{ID: 42},
+				Message: &Message{MessageID: 8, Chat: &Chat{ID: testChatID}},
+				Data:    tt.data,
+			}}
+			err := handlers.HandleCallback(context.Background(), update)
+
+			assertNoError(t, err)
+			if answered["callback_query_id"] != "cb1" {
+				t.Fatalf("callback was not answered: %v", answered)
+			}
+			if text, _ := answered["text"].(string); text != tt.wantText {
+				t.Errorf("callback text = %q, want %q", text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	if got := truncateRunes("привет", 10); got != "привет" {
+		t.Errorf("truncateRunes() = %q, want unchanged", got)
+	}
+	if got := truncateRunes("привет", 4); got != "при…" {
+		t.Errorf("truncateRunes() = %q, want %q", got, "при…")
+	}
+}
+
+func TestTruncateMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{name: "Short", text: "*bold*", limit: 10, want: "*bold*"},
+		{name: "Plain", text: "abcdefghijkl", limit: 10, want: "abcde…"},
+		{name: "OpenBold", text: "ab *cdefghijkl*", limit: 11, want: "ab *cd…*"},
+		{name: "OpenCode", text: "`abcdefghijkl`", limit: 10, want: "`abcd…`"},
+		{name: "OpenPre", text: "```\nabcdefghijkl\n```", limit: 14, want: "```\nabcde…\n```"},
+		{name: "ClosedEntity", text: "_ab_ cdefghijkl", limit: 12, want: "_ab_ cd…"},
+		{name: "OpenLink", text: "see [docs](https://example.com) now", limit: 20, want: "see …"},
+		{name: "TrailingEscape", text: `abcd\_efghijkl`, limit: 10, want: "abcd…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateMarkdown(tt.text, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateMarkdown(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+			if n := utf8.RuneCountInString(got); n > tt.limit {
+				t.Errorf("truncateMarkdown() length = %d, want at most %d", n, tt.limit)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"got/internal/app/model"
+	"got/pkg/i18n"
+)
+
+const (
+	assistantCallbackPrefix = "assistant:"
+	maxAssistantModels      = 20
+	selectedMark            = "✓ "
+)
+
+const (
+	assistantActionToggle   = "toggle"
+	assistantActionModels   = "models"
+	assistantActionPersonas = "personas"
+	assistantActionModel    = "model"
+	assistantActionPersona  = "persona"
+	assistantActionBack     = "back"
+)
+
+var errNoModelStore = errors.New("model selection requires redis")
+
+func (h *BotHandlers) HandleAssistant(ctx context.Context, update *Update) error {
+	msg := update.Message
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	if msg.Chat.Type != chatTypePrivate {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAssistantPrivateOnly))
+	}
+	if h.gpt == nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptNoKey))
+	}
+
+	switch arg := strings.ToLower(strings.TrimSpace(msg.CommandArguments())); arg {
+	case "":
+	case switchOn, switchOff:
+		enabled := arg == switchOn
+		if err := h.service.SetAssistantMode(ctx, chatID, enabled); err != nil {
+			log.ErrorContext(ctx, "Failed to save assistant mode", "chat_id", chatID, "error", err)
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAssistantError))
+		}
+		return h.client.SendMessage(ctx, chatID, t.Get(assistantModeKey(enabled)))
+	default:
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAssistantUsage))
+	}
+
+	text, keyboard, err := h.assistantMenu(ctx, chatID, assistantActionBack)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load assistant settings", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAssistantError))
+	}
+	_, err = h.client.SendMessageWithKeyboard(ctx, chatID, 0, text, keyboard)
+	return err
+}
+
+func (h *BotHandlers) handleAssistantCallback(ctx context.Context, cq *CallbackQuery, data string) error {
+	chatID := cq.Message.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+	if h.gpt == nil || cq.Message.Chat.Type != chatTypePrivate {
+		return h.client.AnswerCallbackQuery(ctx, cq.ID, "")
+	}
+
+	action, arg, _ := strings.Cut(data, ":")
+	view := assistantActionBack
+	var err error
+	switch action {
+	case assistantActionToggle:
+		var enabled bool
+		if enabled, err = h.service.IsAssistantMode(ctx, chatID); err == nil {
+			err = h.service.SetAssistantMode(ctx, chatID, !enabled)
+		}
+	case assistantActionModels, assistantActionPersonas:
+		view = action
+	case assistantActionModel:
+		err = h.selectAssistantModel(ctx, chatID, arg)
+	case assistantActionPersona:
+		err = h.selectAssistantPersona(ctx, chatID, arg)
+	}
+	if err != nil {
+		log.WarnContext(ctx, "Failed to update assistant settings", "chat_id", chatID, "action", action, "error", err)
+		return h.client.AnswerCallbackQuery(ctx, cq.ID, t.Get(i18n.KeyAssistantError))
+	}
+	if err := h.client.AnswerCallbackQuery(ctx, cq.ID, ""); err != nil {
+		log.WarnContext(ctx, "Failed to answer callback query", "chat_id", chatID, "error", err)
+	}
+
+	text, keyboard, err := h.assistantMenu(ctx, chatID, view)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load assistant settings", "chat_id", chatID, "error", err)
+		return nil
+	}
+	return h.client.EditMessageText(ctx, chatID, cq.Message.MessageID, text, keyboard)
+}
+
+func (h *BotHandlers) assistantMenu(ctx context.Context, chatID int64, view string) (string, *InlineKeyboardMarkup, error) {
+	t := h.getTranslator(ctx, chatID)
+	enabled, err := h.service.IsAssistantMode(ctx, chatID)
+	if err != nil {
+		return "", nil, err
+	}
+	persona, err := h.service.GetPersona(ctx, chatID)
+	if err != nil {
+		return "", nil, err
+	}
+	currentModel := h.currentModelRef(ctx, chatID)
+
+	state := i18n.KeyAssistantStateOff
+	if enabled {
+		state = i18n.KeyAssistantStateOn
+	}
+	text := fmt.Sprintf(t.Get(i18n.KeyAssistantMenu), t.Get(state), currentModel, persona.Name)
+
+	var rows [][]InlineKeyboardButton
+	switch view {
+	case assistantActionModels:
+		for i, m := range h.gpt.Catalog(ctx) {
+			if i == maxAssistantModels {
+				break
+			}
+			rows = append(rows, []InlineKeyboardButton{assistantButton(markSelected(m.Ref, m.Ref == currentModel), assistantActionModel, modelToken(m.Ref))})
+		}
+		rows = append(rows, []InlineKeyboardButton{assistantButton(t.Get(i18n.KeyAssistantButtonBack), assistantActionBack, "")})
+	case assistantActionPersonas:
+		for i, p := range h.service.ListPersonas() {
+			rows = append(rows, []InlineKeyboardButton{assistantButton(markSelected(p.Name, p.Name == persona.Name), assistantActionPersona, strconv.Itoa(i))})
+		}
+		rows = append(rows, []InlineKeyboardButton{assistantButton(t.Get(i18n.KeyAssistantButtonBack), assistantActionBack, "")})
+	default:
+		toggle := i18n.KeyAssistantButtonEnable
+		if enabled {
+			toggle = i18n.KeyAssistantButtonDisable
+		}
+		rows = append(rows, []InlineKeyboardButton{assistantButton(t.Get(toggle), assistantActionToggle, "")})
+		settings := []InlineKeyboardButton{assistantButton(t.Get(i18n.KeyAssistantButtonPersona), assistantActionPersonas, "")}
+		if h.cache != nil {
+			settings = append([]InlineKeyboardButton{assistantButton(t.Get(i18n.KeyAssistantButtonModel), assistantActionModels, "")}, settings...)
+		}
+		rows = append(rows, settings)
+	}
+	return text, &InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+func (h *BotHandlers) selectAssistantModel(ctx context.Context, chatID int64, arg string) error {
+	if h.cache == nil {
+		return errNoModelStore
+	}
+	ref, ok := modelByToken(h.gpt.Models(ctx), arg)
+	if !ok {
+		return fmt.Errorf("unknown model token %q", arg)
+	}
+	if err := h.gpt.ValidateModel(ctx, ref); err != nil {
+		return err
+	}
+	return h.cache.SetModel(ctx, chatID, ref)
+}
+
+func (h *BotHandlers) selectAssistantPersona(ctx context.Context, chatID int64, arg string) error {
+	personas := h.service.ListPersonas()
+	idx, err := strconv.Atoi(arg)
+	if err != nil || idx < 0 || idx >= len(personas) {
+		return fmt.Errorf("unknown persona index %q", arg)
+	}
+	_, err = h.service.SetPersona(ctx, chatID, personas[idx].Name)
+	return err
+}
+
+func (h *BotHandlers) isAssistantChat(msg *Message, settings *model.ChatSettings) bool {
+	return h.gpt != nil && msg.Chat.Type == chatTypePrivate && msg.From != nil && settings.AssistantMode
+}
+
+func (h *BotHandlers) handleAssistantMessage(ctx context.Context, msg *Message) error {
+	audio := messageAudio(msg)
+	if audio == nil {
+		prompt := messageText(msg)
+		if strings.TrimSpace(prompt) == "" {
+			return nil
+		}
+		return h.handleGPTChat(ctx, msg, prompt)
+	}
+
+	t := h.getTranslator(ctx, msg.Chat.ID)
+	if !h.canTranscribe() {
+		return h.client.SendMessage(ctx, msg.Chat.ID, t.Get(i18n.KeyTranscribeUnavailable))
+	}
+	typing := h.startTyping(ctx, msg.Chat.ID, actionTyping)
+	text, err := h.transcribe(ctx, audio)
+	typing.Stop()
+	if err != nil {
+		log.WarnContext(ctx, "Assistant transcription failed", "chat_id", msg.Chat.ID, "error", err)
+		return h.client.SendMessage(ctx, msg.Chat.ID, t.Get(transcribeErrorKey(err)))
+	}
+	return h.handleGPTChat(ctx, msg, text)
+}
+
+func (h *BotHandlers) currentModelRef(ctx context.Context, chatID int64) string {
+	if ref := h.getChatModel(ctx, chatID); ref != "" {
+		return ref
+	}
+	if p := h.gpt.Default(); p != nil {
+		return h.gpt.Ref(p.Name(), p.Model())
+	}
+	return ""
+}
+
+func assistantButton(text, action, arg string) InlineKeyboardButton {
+	data := assistantCallbackPrefix + action
+	if arg != "" {
+		data += ":" + arg
+	}
+	return InlineKeyboardButton{Text: text, CallbackData: data}
+}
+
+func modelToken(ref string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(ref))
+	return strconv.FormatUint(uint64(hash.Sum32()), 36)
+}
+
+func modelByToken(refs []string, token string) (string, bool) {
+	for _, ref := range refs {
+		if modelToken(ref) == token {
+			return ref, true
+		}
+	}
+	return "", false
+}
+
+func markSelected(label string, selected bool) string {
+	if selected {
+		return selectedMark + label
+	}
+	return label
+}
+
+func assistantModeKey(enabled bool) i18n.Key {
+	if enabled {
+		return i18n.KeyAssistantOn
+	}
+	return i18n.KeyAssistantOff
+}
@@ -0,0 +1,148 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"got/internal/app/model"
+)
+
+func TestHandleAssistant(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		chatType    string
+		wantSent    string
+		wantEnabled bool
+	}{
+		{name: "GroupChat", text: "/assistant on", chatType: "group", wantSent: "Assistant mode works in private chats only."},
+		{name: "Enable", text: "/assistant on", chatType: chatTypePrivate, wantSent: "Assistant mode on.", wantEnabled: true},
+		{name: "BadArgument", text: "/assistant maybe", chatType: chatTypePrivate, wantSent: "Usage: /assistant [on|off]"},
+		{
+			name:     "Menu",
+			text:     "/assistant",
+			chatType: chatTypePrivate,
+			wantSent: "Mode: off\nModel: llama\nPersona: default [assistant:toggle] [assistant:personas]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &model.ChatSettings{ChatID: testChatID}
+			handlers, chat := newTestLLMHandlers(t, settings, "Hi there")
+
+			update := &Update{Message: &Message{Text: tt.text, Chat: &Chat{ID: testChatID, Type: tt.chatType}, From: &User{ID: 42}}}
+			err := handlers.HandleAssistant(context.Background(), update)
+
+			assertNoError(t, err)
+			if len(chat.sent) != 1 || chat.sent[0] != tt.wantSent {
+				t.Errorf("sent = %q, want %q", chat.sent, tt.wantSent)
+			}
+			if settings.AssistantMode != tt.wantEnabled {
+				t.Errorf("assistant mode = %v, want %v", settings.AssistantMode, tt.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestHandleAssistantCallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantSent    string
+		wantEnabled bool
+		wantPersona string
+	}{
+		{
+			name:        "Toggle",
+			data:        "assistant:toggle",
+			wantSent:    "Mode: on\nModel: llama\nPersona: default [assistant:toggle] [assistant:personas]",
+			wantEnabled: true,
+		},
+		{
+			name:     "PersonaList",
+			data:     "assistant:personas",
+			wantSent: "[assistant:persona:0]",
+		},
+		{
+			name:        "SelectPersona",
+			wantSent:    "Persona: concise",
+			data:        "assistant:persona:1",
+			wantPersona: "concise",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &model.ChatSettings{ChatID: testChatID}
+			handlers, chat := newTestLLMHandlers(t, settings, "Hi there")
+
+			update := &Update{CallbackQuery: &CallbackQuery{
+				ID:      "cb1",
+				From:    &User{ID: testChatID},
+				Message: &Message{MessageID: 8, Chat: &Chat{ID: testChatID, Type: chatTypePrivate}},
+				Data:    tt.data,
+			}}
+			err := handlers.HandleCallback(context.Background(), update)
+
+			assertNoError(t, err)
+			if len(chat.sent) != 1 || !strings.Contains(chat.sent[0], tt.wantSent) {
+				t.Errorf("edited = %q, want it to contain %q", chat.sent, tt.wantSent)
+			}
+			if settings.AssistantMode != tt.wantEnabled || settings.Persona != tt.wantPersona {
+				t.Errorf("settings = %+v, want mode %v and persona %q", settings, tt.wantEnabled, tt.wantPersona)
+			}
+		})
+	}
+}
+
+func TestHandleMessageAssistantMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		chat        *Chat
+		text        string
+		wantPrompts []string
+	}{
+		{name: "PrivateEnabled", enabled: true, chat: &Chat{ID: testChatID, Type: chatTypePrivate}, text: "what is a goroutine?", wantPrompts: []string{"tomas: what is a goroutine?"}},
+		{name: "PrivateDisabled", enabled: false, chat: &Chat{ID: testChatID, Type: chatTypePrivate}, text: "what is a goroutine?"},
+		{name: "GroupEnabled", enabled: true, chat: &Chat{ID: testChatID, Type: "group"}, text: "what is a goroutine?"},
+		{name: "EmptyText", enabled: true, chat: &Chat{ID: testChatID, Type: chatTypePrivate}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &model.ChatSettings{ChatID: testChatID, AssistantMode: tt.enabled}
+			handlers, chat := newTestLLMHandlers(t, settings, "Hi there")
+
+			msg := &Message{MessageID: 5, Text: tt.text, Chat: tt.chat, From: &User{ID: 42, UserName: "tomas"}}
+			err := handlers.HandleMessage(context.Background(), &Update{Message: msg})
+
+			assertNoError(t, err)
+			if strings.Join(chat.prompts(), "|") != strings.Join(tt.wantPrompts, "|") {
+				t.Errorf("prompts = %q, want %q", chat.prompts(), tt.wantPrompts)
+			}
+			if len(tt.wantPrompts) > 0 && (len(chat.sent) != 1 || chat.sent[0] != "Hi there") {
+				t.Errorf("sent = %q, want the assistant answer", chat.sent)
+			}
+		})
+	}
+}
+
+func TestModelByToken(t *testing.T) {
+	refs := []string{"groq:llama-3.3-70b-versatile", "ollama:meta-llama/llama-4-scout-17b-16e-instruct"}
+
+	for _, ref := range refs {
+		token := modelToken(ref)
+		if len(assistantCallbackPrefix+assistantActionModel+":"+token) > 64 {
+			t.Errorf("callback data for %q is longer than 64 bytes", ref)
+		}
+		if got, ok := modelByToken(refs, token); !ok || got != ref {
+			t.Errorf("modelByToken(%q) = %q, %v, want %q", token, got, ok, ref)
+		}
+	}
+	if _, ok := modelByToken(refs, "0"); ok {
+		t.Error("modelByToken() matched an unknown token")
+	}
+}
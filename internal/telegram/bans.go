@@ -0,0 +1,163 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"got/internal/app/model"
+	"got/pkg/i18n"
+)
+
+const banTargetChatKeyword = "chat"
+
+type banTarget struct {
+	targetType string
+	targetID   int64
+}
+
+func (h *BotHandlers) handleAdminBan(ctx context.Context, msg *Message, args string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+	if isAdmin, _ := h.isAdmin(ctx, msg.From.ID); !isAdmin {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminNotLoggedIn))
+	}
+
+	target, rest, ok := parseBanTarget(strings.Fields(args), msg)
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminBanUsage))
+	}
+	if isSelfBan(target, msg) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminBanSelf))
+	}
+
+	var duration time.Duration
+	if len(rest) > 0 {
+		if d, err := ParseDuration(rest[0]); err == nil {
+			duration = d
+			rest = rest[1:]
+		}
+	}
+	reason := strings.Join(rest, " ")
+
+	ban, err := h.service.Ban(ctx, target.targetType, target.targetID, reason, msg.From.ID, duration)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to ban", "target_type", target.targetType, "target_id", target.targetID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminBanError))
+	}
+
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyAdminBanned), formatBanTargetType(t, ban.TargetType), ban.TargetID, formatBanExpiry(t, ban)))
+}
+
+func (h *BotHandlers) handleAdminUnban(ctx context.Context, msg *Message, args string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+	if isAdmin, _ := h.isAdmin(ctx, msg.From.ID); !isAdmin {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminNotLoggedIn))
+	}
+
+	target, _, ok := parseBanTarget(strings.Fields(args), msg)
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminUnbanUsage))
+	}
+
+	if err := h.service.Unban(ctx, target.targetType, target.targetID); err != nil {
+		log.ErrorContext(ctx, "Failed to unban", "target_type", target.targetType, "target_id", target.targetID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminBanError))
+	}
+
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyAdminUnbanned), formatBanTargetType(t, target.targetType), target.targetID))
+}
+
+func (h *BotHandlers) handleAdminBans(ctx context.Context, chatID, userID int64) error {
+	t := h.getTranslator(ctx, chatID)
+	if isAdmin, _ := h.isAdmin(ctx, userID); !isAdmin {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminNotLoggedIn))
+	}
+
+	bans, err := h.service.ListBans(ctx)
+	if err != nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminBanError))
+	}
+	if len(bans) == 0 {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminBansEmpty))
+	}
+
+	return h.client.SendMessage(ctx, chatID, formatBanList(t, bans))
+}
+
+func parseBanTarget(fields []string, msg *Message) (banTarget, []string, bool) {
+	if len(fields) > 0 && fields[0] == banTargetChatKeyword {
+		if len(fields) > 1 {
+			if id, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				return banTarget{targetType: model.BanTargetChat, targetID: id}, fields[2:], true
+			}
+		}
+		if msg.Chat == nil {
+			return banTarget{}, nil, false
+		}
+		return banTarget{targetType: model.BanTargetChat, targetID: msg.Chat.ID}, fields[1:], true
+	}
+
+	if len(fields) > 0 {
+		if id, err := strconv.ParseInt(fields[0], 10, 64); err == nil && id != 0 {
+			targetType := model.BanTargetUser
+			if id < 0 {
+				targetType = model.BanTargetChat
+			}
+			return banTarget{targetType: targetType, targetID: id}, fields[1:], true
+		}
+	}
+
+	if reply := msg.ReplyToMessage; reply != nil && reply.From != nil && !reply.From.IsBot {
+		return banTarget{targetType: model.BanTargetUser, targetID: reply.From.ID}, fields, true
+	}
+
+	return banTarget{}, nil, false
+}
+
+func isSelfBan(target banTarget, msg *Message) bool {
+	switch target.targetType {
+	case model.BanTargetUser:
+		return msg.From != nil && target.targetID == msg.From.ID
+	case model.BanTargetChat:
+		return msg.Chat != nil && target.targetID == msg.Chat.ID
+	}
+	return false
+}
+
+func formatBanList(t *i18n.Translator, bans []*model.Ban) string {
+	var sb strings.Builder
+	sb.WriteString(t.Get(i18n.KeyAdminBansHeader))
+	for _, b := range bans {
+		reason := ""
+		if b.Reason != "" {
+			reason = ": " + b.Reason
+		}
+		sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyAdminBanFormat), formatBanTargetType(t, b.TargetType), b.TargetID, formatBanExpiry(t, b), reason))
+	}
+	return sb.String()
+}
+
+func formatBanTargetType(t *i18n.Translator, targetType string) string {
+	if targetType == model.BanTargetChat {
+		return t.Get(i18n.KeyAdminBanTargetChat)
+	}
+	return t.Get(i18n.KeyAdminBanTargetUser)
+}
+
+func formatBanExpiry(t *i18n.Translator, ban *model.Ban) string {
+	if ban.ExpiresAt == nil {
+		return t.Get(i18n.KeyAdminBanPermanent)
+	}
+	return fmt.Sprintf(t.Get(i18n.KeyAdminBanUntil), ban.ExpiresAt.Format(time.RFC822))
+}
+
+func argsAfter(parts []string) string {
+	if len(parts) < 2 {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,121 @@
+package telegram
+
+import (
+	"got/internal/app/model"
+	"testing"
+)
+
+func TestParseBanTarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []string
+		msg      *Message
+		wantType string
+		wantID   int64
+		wantRest int
+		wantOK   bool
+	}{
+		{
+			name:     "UserID",
+			fields:   []string{"42", "1h", "spam"},
+			msg:      &Message{Chat: &Chat{ID: 1}},
+			wantType: model.BanTargetUser,
+			wantID:   42,
+			wantRest: 2,
+			wantOK:   true,
+		},
+		{
+			name:     "NegativeIDIsChat",
+			fields:   []string{"-100"},
+			msg:      &Message{Chat: &Chat{ID: 1}},
+			wantType: model.BanTargetChat,
+			wantID:   -100,
+			wantOK:   true,
+		},
+		{
+			name:     "ChatKeywordWithID",
+			fields:   []string{"chat", "-200", "flood"},
+			msg:      &Message{Chat: &Chat{ID: 1}},
+			wantType: model.BanTargetChat,
+			wantID:   -200,
+			wantRest: 1,
+			wantOK:   true,
+		},
+		{
+			name:     "ChatKeywordCurrentChat",
+			fields:   []string{"chat", "1d"},
+			msg:      &Message{Chat: &Chat{ID: -300}},
+			wantType: model.BanTargetChat,
+			wantID:   -300,
+			wantRest: 1,
+			wantOK:   true,
+		},
+		{
+			name:   "ReplyToUser",
+			fields: []string{"spam"},
+			msg: &Message{
+				Chat:           &Chat{ID: 1},
+				ReplyToMessage: &Message{From: &User{ID: 55}},
+			},
+			wantType: model.BanTargetUser,
+			wantID:   55,
+			wantRest: 1,
+			wantOK:   true,
+		},
+		{
+			name:   "ReplyToBot",
+			fields: nil,
+			msg: &Message{
+				Chat:           &Chat{ID: 1},
+				ReplyToMessage: &Message{From: &User{ID: 55, IsBot: true}},
+			},
+			wantOK: false,
+		},
+		{
+			name:   "NoTarget",
+			fields: []string{"spam"},
+			msg:    &Message{Chat: &Chat{ID: 1}},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, rest, ok := parseBanTarget(tt.fields, tt.msg)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if target.targetType != tt.wantType || target.targetID != tt.wantID {
+				t.Errorf("target = %s/%d, want %s/%d", target.targetType, target.targetID, tt.wantType, tt.wantID)
+			}
+			if len(rest) != tt.wantRest {
+				t.Errorf("rest = %v, want %d fields", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestIsSelfBan(t *testing.T) {
+	msg := &Message{From: &User{ID: 42}, Chat: &Chat{ID: -100}}
+	tests := []struct {
+		name   string
+		target banTarget
+		want   bool
+	}{
+		{name: "Caller", target: banTarget{targetType: model.BanTargetUser, targetID: 42}, want: true},
+		{name: "CurrentChat", target: banTarget{targetType: model.BanTargetChat, targetID: -100}, want: true},
+		{name: "OtherUser", target: banTarget{targetType: model.BanTargetUser, targetID: 7}},
+		{name: "OtherChat", target: banTarget{targetType: model.BanTargetChat, targetID: -200}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSelfBan(tt.target, msg); got != tt.want {
+				t.Errorf("isSelfBan(%+v) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package telegram
 
 import (
 	"context"
+	"got/internal/alert"
+	"got/pkg/logger"
 	"log/slog"
 	"time"
 )
@@ -11,18 +13,22 @@ const (
 )
 
 type Bot struct {
-	client  *Client
-	handler Handler
+	client   *Client
+	handler  Handler
+	reporter *alert.Reporter
 }
 
 type Handler interface {
 	Handle(ctx context.Context, update *Update) error
 }
 
-func NewBot(client *Client, handler Handler) *Bot {
+var log = logger.For("telegram")
+
+func NewBot(client *Client, handler Handler, reporter *alert.Reporter) *Bot {
 	return &Bot{
-		client:  client,
-		handler: handler,
+		client:   client,
+		handler:  handler,
+		reporter: reporter,
 	}
 }
 
@@ -42,9 +48,9 @@ func (b *Bot) Start(ctx context.Context) {
 }
 
 func (b *Bot) pollUpdates(ctx context.Context, offset int) int {
-	updates, err := b.client.GetUpdates(offset)
+	updates, err := b.client.GetUpdates(ctx, offset)
 	if err != nil {
-		slog.Error("Failed to get updates", "error", err)
+		log.ErrorContext(ctx, "Failed to get updates", "error", err)
 		return offset
 	}
 
@@ -59,10 +65,35 @@ func (b *Bot) pollUpdates(ctx context.Context, offset int) int {
 }
 
 func (b *Bot) processUpdate(ctx context.Context, update *Update) {
+	ctx = updateContext(ctx, update)
+	log.DebugContext(ctx, "Processing update")
+
 	if err := b.handler.Handle(ctx, update); err != nil {
-		slog.Error("Error processing update",
+		log.ErrorContext(ctx, "Error processing update",
 			"id", update.UpdateID,
 			"error", err,
 		)
+		if b.reporter != nil {
+			b.reporter.Report(ctx, newUpdateReport(update, alert.SourceUpdate, err, nil))
+		}
+	}
+}
+
+func updateContext(ctx context.Context, update *Update) context.Context {
+	ctx = logger.WithCorrelationID(ctx, logger.NewCorrelationID())
+
+	attrs := []slog.Attr{slog.Int("update_id", update.UpdateID)}
+	msg := update.Message
+	if cq := update.CallbackQuery; cq != nil && cq.Message != nil {
+		msg = &Message{From: cq.From, Chat: cq.Message.Chat}
+	}
+	if msg != nil {
+		if msg.Chat != nil {
+			attrs = append(attrs, slog.Int64("chat_id", msg.Chat.ID))
+		}
+		if msg.From != nil {
+			attrs = append(attrs, slog.Int64("user_id", msg.From.ID))
+		}
 	}
+	return logger.WithAttrs(ctx, attrs...)
 }
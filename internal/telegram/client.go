@@ -2,11 +2,14 @@ package telegram
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	neturl "net/url"
 	"time"
 )
 
@@ -22,13 +25,22 @@ const (
 	sendMediaGroupCMD = "/sendMediaGroup"
 	sendChatActionCMD = "/sendChatAction"
 	setMyCommandsCMD  = "/setMyCommands"
+	delMyCommandsCMD  = "/deleteMyCommands"
 	getStickerSetCMD  = "/getStickerSet"
+	getChatMemberCMD  = "/getChatMember"
+	getFileCMD        = "/getFile"
+	editMessageCMD    = "/editMessageText"
+	answerCallbackCMD = "/answerCallbackQuery"
+	maxDownloadSize   = 20 << 20
+	downloadFileOp    = "/file"
+	contentTypeJSON   = "application/json"
 )
 
 type Client struct {
 	token      string
 	httpClient *http.Client
 	baseURL    string
+	fileURL    string
 }
 
 type InputMediaPhoto struct {
@@ -43,11 +55,25 @@ type InputMediaAnimation struct {
 	Caption string `json:"caption,omitempty"`
 }
 
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
 type BotCommand struct {
 	Command     string `json:"command"`
 	Description string `json:"description"`
 }
 
+type BotCommandScope struct {
+	Type   string `json:"type"`
+	ChatID int64  `json:"chat_id,omitempty"`
+}
+
 type StickerSet struct {
 	Name     string           `json:"name"`
 	Title    string           `json:"title"`
@@ -67,6 +93,36 @@ type StickerSetResponse struct {
 	Description string     `json:"description,omitempty"`
 }
 
+type ChatMember struct {
+	Status string `json:"status"`
+	User   *User  `json:"user"`
+}
+
+type File struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size"`
+	FilePath     string `json:"file_path"`
+}
+
+type FileResponse struct {
+	Ok          bool   `json:"ok"`
+	Result      File   `json:"result"`
+	Description string `json:"description,omitempty"`
+}
+
+type MessageResponse struct {
+	Ok          bool    `json:"ok"`
+	Result      Message `json:"result"`
+	Description string  `json:"description,omitempty"`
+}
+
+type ChatMemberResponse struct {
+	Ok          bool       `json:"ok"`
+	Result      ChatMember `json:"result"`
+	Description string     `json:"description,omitempty"`
+}
+
 func NewClient(token string) *Client {
 	return &Client{
 		token: token,
@@ -74,13 +130,12 @@ func NewClient(token string) *Client {
 			Timeout: defaultTimeout,
 		},
 		baseURL: "https://api.telegram.org/bot" + token,
+		fileURL: "https://api.telegram.org/file/bot" + token,
 	}
 }
 
-func (c *Client) GetUpdates(offset int) ([]Update, error) {
-	url := fmt.Sprintf("%s%s?offset=%d&timeout=60", c.baseURL, getUpdatesCMD, offset)
-
-	resp, err := c.httpClient.Get(url)
+func (c *Client) GetUpdates(ctx context.Context, offset int) ([]Update, error) {
+	resp, err := c.get(ctx, getUpdatesCMD, fmt.Sprintf("offset=%d&timeout=60", offset))
 	if err != nil {
 		return nil, err
 	}
@@ -89,22 +144,77 @@ func (c *Client) GetUpdates(offset int) ([]Update, error) {
 	return c.parseUpdatesResponse(resp.Body)
 }
 
-func (c *Client) SendMessage(chatID int64, text string) error {
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	payload := map[string]any{
+		"chat_id":    chatID,
+		"text":       text,
+		"parse_mode": "Markdown",
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return c.postJSON(ctx, sendMessageCMD, data)
+}
+
+func (c *Client) SendReply(ctx context.Context, chatID int64, replyTo int, text string) (*Message, error) {
+	return c.SendMessageWithKeyboard(ctx, chatID, replyTo, text, nil)
+}
+
+func (c *Client) SendMessageWithKeyboard(ctx context.Context, chatID int64, replyTo int, text string, keyboard *InlineKeyboardMarkup) (*Message, error) {
+	payload := map[string]any{
+		"chat_id":    chatID,
+		"text":       text,
+		"parse_mode": "Markdown",
+	}
+	if keyboard != nil {
+		payload["reply_markup"] = keyboard
+	}
+	return c.sendMessage(ctx, payload, replyTo)
+}
+
+func (c *Client) SendPlainReply(ctx context.Context, chatID int64, replyTo int, text string) (*Message, error) {
+	return c.sendMessage(ctx, map[string]any{"chat_id": chatID, "text": text}, replyTo)
+}
+
+func (c *Client) EditMessageText(ctx context.Context, chatID int64, messageID int, text string, keyboard *InlineKeyboardMarkup) error {
 	payload := map[string]any{
 		"chat_id":    chatID,
+		"message_id": messageID,
 		"text":       text,
 		"parse_mode": "Markdown",
 	}
+	if keyboard != nil {
+		payload["reply_markup"] = keyboard
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return c.postJSON(ctx, editMessageCMD, data)
+}
+
+func (c *Client) AnswerCallbackQuery(ctx context.Context, callbackID string, text string) error {
+	payload := map[string]any{
+		"callback_query_id": callbackID,
+	}
+	if text != "" {
+		payload["text"] = text
+	}
 
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
-	return c.postJSON(sendMessageCMD, data)
+	return c.postJSON(ctx, answerCallbackCMD, data)
 }
 
-func (c *Client) SendPhoto(chatID int64, photoURL string, caption string) error {
+func (c *Client) SendPhoto(ctx context.Context, chatID int64, photoURL string, caption string) error {
 	payload := map[string]any{
 		"chat_id": chatID,
 		"photo":   photoURL,
@@ -116,10 +226,10 @@ func (c *Client) SendPhoto(chatID int64, photoURL string, caption string) error
 		return err
 	}
 
-	return c.postJSON(sendPhotoCMD, data)
+	return c.postJSON(ctx, sendPhotoCMD, data)
 }
 
-func (c *Client) SendSticker(chatID int64, stickerID string) error {
+func (c *Client) SendSticker(ctx context.Context, chatID int64, stickerID string) error {
 	payload := map[string]any{
 		"chat_id": chatID,
 		"sticker": stickerID,
@@ -130,10 +240,10 @@ func (c *Client) SendSticker(chatID int64, stickerID string) error {
 		return err
 	}
 
-	return c.postJSON(sendStickerCMD, data)
+	return c.postJSON(ctx, sendStickerCMD, data)
 }
 
-func (c *Client) SendMediaGroup(chatID int64, media []InputMediaPhoto) error {
+func (c *Client) SendMediaGroup(ctx context.Context, chatID int64, media []InputMediaPhoto) error {
 	payload := map[string]any{
 		"chat_id": chatID,
 		"media":   media,
@@ -144,10 +254,10 @@ func (c *Client) SendMediaGroup(chatID int64, media []InputMediaPhoto) error {
 		return err
 	}
 
-	return c.postJSON(sendMediaGroupCMD, data)
+	return c.postJSON(ctx, sendMediaGroupCMD, data)
 }
 
-func (c *Client) SendAnimation(chatID int64, animationURL string, caption string) error {
+func (c *Client) SendAnimation(ctx context.Context, chatID int64, animationURL string, caption string) error {
 	payload := map[string]any{
 		"chat_id":   chatID,
 		"animation": animationURL,
@@ -159,10 +269,10 @@ func (c *Client) SendAnimation(chatID int64, animationURL string, caption string
 		return err
 	}
 
-	return c.postJSON(sendAnimationCMD, data)
+	return c.postJSON(ctx, sendAnimationCMD, data)
 }
 
-func (c *Client) SendChatAction(chatID int64, action string) error {
+func (c *Client) SendChatAction(ctx context.Context, chatID int64, action string) error {
 	payload := map[string]any{
 		"chat_id": chatID,
 		"action":  action,
@@ -173,20 +283,41 @@ func (c *Client) SendChatAction(chatID int64, action string) error {
 		return err
 	}
 
-	return c.postJSON(sendChatActionCMD, data)
+	return c.postJSON(ctx, sendChatActionCMD, data)
+}
+
+func (c *Client) SendPhotoFile(ctx context.Context, chatID int64, photoData []byte, filename string, caption string) error {
+	return c.sendMultipartFile(ctx, chatID, sendPhotoCMD, "photo", photoData, filename, caption)
 }
 
-func (c *Client) SendVoice(chatID int64, audioData []byte, filename string) error {
-	return c.sendMultipartFile(chatID, sendVoiceCMD, "voice", audioData, filename, "")
+func (c *Client) SendVoice(ctx context.Context, chatID int64, audioData []byte, filename string) error {
+	return c.sendMultipartFile(ctx, chatID, sendVoiceCMD, "voice", audioData, filename, "")
 }
 
-func (c *Client) SendDocument(chatID int64, fileData []byte, filename string, caption string) error {
-	return c.sendMultipartFile(chatID, sendDocumentCMD, "document", fileData, filename, caption)
+func (c *Client) SendDocument(ctx context.Context, chatID int64, fileData []byte, filename string, caption string) error {
+	return c.sendMultipartFile(ctx, chatID, sendDocumentCMD, "document", fileData, filename, caption)
+}
+
+func (c *Client) SetMyCommands(ctx context.Context, commands []BotCommand) error {
+	payload := map[string]any{
+		"commands": commands,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return c.postJSON(ctx, setMyCommandsCMD, data)
 }
 
-func (c *Client) SetMyCommands(commands []BotCommand) error {
+func (c *Client) SetMyCommandsScoped(ctx context.Context, commands []BotCommand, scope BotCommandScope, languageCode string) error {
 	payload := map[string]any{
 		"commands": commands,
+		"scope":    scope,
+	}
+	if languageCode != "" {
+		payload["language_code"] = languageCode
 	}
 
 	data, err := json.Marshal(payload)
@@ -194,13 +325,27 @@ func (c *Client) SetMyCommands(commands []BotCommand) error {
 		return err
 	}
 
-	return c.postJSON(setMyCommandsCMD, data)
+	return c.postJSON(ctx, setMyCommandsCMD, data)
 }
 
-func (c *Client) GetStickerSet(name string) (*StickerSet, error) {
-	url := fmt.Sprintf("%s%s?name=%s", c.baseURL, getStickerSetCMD, name)
+func (c *Client) DeleteMyCommands(ctx context.Context, scope BotCommandScope, languageCode string) error {
+	payload := map[string]any{
+		"scope": scope,
+	}
+	if languageCode != "" {
+		payload["language_code"] = languageCode
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return c.postJSON(ctx, delMyCommandsCMD, data)
+}
 
-	resp, err := c.httpClient.Get(url)
+func (c *Client) GetStickerSet(ctx context.Context, name string) (*StickerSet, error) {
+	resp, err := c.get(ctx, getStickerSetCMD, "name="+neturl.QueryEscape(name))
 	if err != nil {
 		return nil, err
 	}
@@ -223,6 +368,76 @@ func (c *Client) GetStickerSet(name string) (*StickerSet, error) {
 	return &apiResp.Result, nil
 }
 
+func (c *Client) GetChatMember(ctx context.Context, chatID, userID int64) (*ChatMember, error) {
+	resp, err := c.get(ctx, getChatMemberCMD, fmt.Sprintf("chat_id=%d&user_id=%d", chatID, userID))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResp ChatMemberResponse
+	if err := json.Unmarshal(data, &apiResp); err != nil {
+		return nil, err
+	}
+
+	if !apiResp.Ok {
+		return nil, fmt.Errorf("chat member not found: %s", apiResp.Description)
+	}
+
+	return &apiResp.Result, nil
+}
+
+func (c *Client) GetFile(ctx context.Context, fileID string) (*File, error) {
+	resp, err := c.get(ctx, getFileCMD, "file_id="+neturl.QueryEscape(fileID))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResp FileResponse
+	if err := json.Unmarshal(data, &apiResp); err != nil {
+		return nil, err
+	}
+
+	if !apiResp.Ok {
+		return nil, fmt.Errorf("file not found: %s", apiResp.Description)
+	}
+
+	return &apiResp.Result, nil
+}
+
+func (c *Client) DownloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	file, err := c.GetFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.FileSize > maxDownloadSize {
+		return nil, fmt.Errorf("file too large: %d bytes", file.FileSize)
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, c.fileURL+"/"+file.FilePath, downloadFileOp, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize))
+}
+
 func (c *Client) parseUpdatesResponse(body io.Reader) ([]Update, error) {
 	data, err := io.ReadAll(body)
 	if err != nil {
@@ -241,7 +456,41 @@ func (c *Client) parseUpdatesResponse(body io.Reader) ([]Update, error) {
 	return apiResp.Result, nil
 }
 
-func (c *Client) sendMultipartFile(chatID int64, endpoint string, fieldName string, fileData []byte, filename string, caption string) error {
+func (c *Client) sendMessage(ctx context.Context, payload map[string]any, replyTo int) (*Message, error) {
+	if replyTo != 0 {
+		payload["reply_to_message_id"] = replyTo
+		payload["allow_sending_without_reply"] = true
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, sendMessageCMD, contentTypeJSON, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiResp MessageResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, err
+	}
+
+	if !apiResp.Ok {
+		return nil, fmt.Errorf("failed to send reply: %s", apiResp.Description)
+	}
+
+	return &apiResp.Result, nil
+}
+
+func (c *Client) sendMultipartFile(ctx context.Context, chatID int64, endpoint string, fieldName string, fileData []byte, filename string, caption string) error {
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
 
@@ -268,11 +517,7 @@ func (c *Client) sendMultipartFile(chatID int64, endpoint string, fieldName stri
 		return err
 	}
 
-	resp, err := c.httpClient.Post(
-		c.baseURL+endpoint,
-		writer.FormDataContentType(),
-		&buf,
-	)
+	resp, err := c.post(ctx, endpoint, writer.FormDataContentType(), &buf)
 	if err != nil {
 		return err
 	}
@@ -285,12 +530,8 @@ func (c *Client) sendMultipartFile(chatID int64, endpoint string, fieldName stri
 	return nil
 }
 
-func (c *Client) postJSON(endpoint string, data []byte) error {
-	resp, err := c.httpClient.Post(
-		c.baseURL+endpoint,
-		"application/json",
-		bytes.NewBuffer(data),
-	)
+func (c *Client) postJSON(ctx context.Context, endpoint string, data []byte) error {
+	resp, err := c.post(ctx, endpoint, contentTypeJSON, bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
@@ -302,3 +543,37 @@ func (c *Client) postJSON(endpoint string, data []byte) error {
 
 	return nil
 }
+
+func (c *Client) get(ctx context.Context, endpoint, query string) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, c.baseURL+endpoint+"?"+query, endpoint, "", nil)
+}
+
+func (c *Client) post(ctx context.Context, endpoint, contentType string, body io.Reader) (*http.Response, error) {
+	return c.do(ctx, http.MethodPost, c.baseURL+endpoint, endpoint, contentType, body)
+}
+
+func (c *Client) do(ctx context.Context, method, url, op, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		log.WarnContext(ctx, "Telegram request failed", "method", op, "error", err)
+		return nil, fmt.Errorf("telegram %s request failed: %w", op, err)
+	}
+	log.DebugContext(ctx, "Telegram request completed", "method", op, "status", resp.StatusCode, "duration", time.Since(start))
+	if resp.StatusCode != http.StatusOK {
+		log.WarnContext(ctx, "Telegram API error", "method", op, "status", resp.StatusCode)
+	}
+	return resp, nil
+}
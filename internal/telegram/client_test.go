@@ -1,9 +1,12 @@
 package telegram
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -46,13 +49,31 @@ func TestClientSendMessage(t *testing.T) {
 			})
 
 			client := newTestClient(server.URL)
-			err := client.SendMessage(tt.chatID, tt.text)
+			err := client.SendMessage(context.Background(), tt.chatID, tt.text)
 
 			assertError(t, err, tt.wantErr)
 		})
 	}
 }
 
+func TestClientRequestUsesContext(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	client := newTestClient(server.URL + "/bot" + testToken)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := client.SendMessage(ctx, testChatID, "hello")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+	if err != nil && strings.Contains(err.Error(), testToken) {
+		t.Errorf("error %q leaks the bot token", err)
+	}
+}
+
 func TestClientSendPhoto(t *testing.T) {
 	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
 		payload := decodeJSONPayload(t, r)
@@ -63,7 +84,7 @@ func TestClientSendPhoto(t *testing.T) {
 	})
 
 	client := newTestClient(server.URL)
-	err := client.SendPhoto(testChatID, "https://example.com/photo.jpg", "caption text")
+	err := client.SendPhoto(context.Background(), testChatID, "https://example.com/photo.jpg", "caption text")
 
 	assertNoError(t, err)
 }
@@ -77,7 +98,7 @@ func TestClientSendSticker(t *testing.T) {
 	})
 
 	client := newTestClient(server.URL)
-	err := client.SendSticker(testChatID, "sticker-file-id")
+	err := client.SendSticker(context.Background(), testChatID, "sticker-file-id")
 
 	assertNoError(t, err)
 }
@@ -91,7 +112,7 @@ func TestClientGetUpdates(t *testing.T) {
 	server := newTestServerWithJSON(t, APIResponse{Ok: true, Result: updates})
 
 	client := newTestClient(server.URL)
-	got, err := client.GetUpdates(0)
+	got, err := client.GetUpdates(context.Background(), 0)
 
 	assertNoError(t, err)
 
@@ -108,7 +129,7 @@ func TestClientGetUpdatesError(t *testing.T) {
 	server := newTestServerWithJSON(t, APIResponse{Ok: false, Description: "Unauthorized"})
 
 	client := newTestClient(server.URL)
-	_, err := client.GetUpdates(0)
+	_, err := client.GetUpdates(context.Background(), 0)
 
 	if err == nil {
 		t.Error("expected error for unauthorized request")
@@ -210,7 +231,7 @@ func TestClientSendChatAction(t *testing.T) {
 	})
 
 	client := newTestClient(server.URL)
-	err := client.SendChatAction(testChatID, "typing")
+	err := client.SendChatAction(context.Background(), testChatID, "typing")
 
 	assertNoError(t, err)
 }
@@ -236,7 +257,7 @@ func TestClientSendMediaGroup(t *testing.T) {
 		{Type: "photo", Media: "https://example.com/1.jpg", Caption: "Photo 1"},
 		{Type: "photo", Media: "https://example.com/2.jpg", Caption: "Photo 2"},
 	}
-	err := client.SendMediaGroup(testChatID, media)
+	err := client.SendMediaGroup(context.Background(), testChatID, media)
 
 	assertNoError(t, err)
 }
@@ -251,7 +272,7 @@ func TestClientSendAnimation(t *testing.T) {
 	})
 
 	client := newTestClient(server.URL)
-	err := client.SendAnimation(testChatID, "https://example.com/anim.gif", "Funny gif")
+	err := client.SendAnimation(context.Background(), testChatID, "https://example.com/anim.gif", "Funny gif")
 
 	assertNoError(t, err)
 }
@@ -275,7 +296,7 @@ func TestClientSetMyCommands(t *testing.T) {
 		{Command: "start", Description: "Start the bot"},
 		{Command: "help", Description: "Show help"},
 	}
-	err := client.SetMyCommands(commands)
+	err := client.SetMyCommands(context.Background(), commands)
 
 	assertNoError(t, err)
 }
@@ -305,7 +326,7 @@ func TestClientSendVoice(t *testing.T) {
 	})
 
 	client := newTestClient(server.URL)
-	err := client.SendVoice(testChatID, []byte("audio data"), "audio.mp3")
+	err := client.SendVoice(context.Background(), testChatID, []byte("audio data"), "audio.mp3")
 
 	assertNoError(t, err)
 }
@@ -340,7 +361,7 @@ func TestClientSendDocument(t *testing.T) {
 	})
 
 	client := newTestClient(server.URL)
-	err := client.SendDocument(testChatID, []byte("file content"), "file.txt", "My Document")
+	err := client.SendDocument(context.Background(), testChatID, []byte("file content"), "file.txt", "My Document")
 
 	assertNoError(t, err)
 }
@@ -351,9 +372,134 @@ func TestClientSendVoiceError(t *testing.T) {
 	})
 
 	client := newTestClient(server.URL)
-	err := client.SendVoice(testChatID, []byte("audio"), "audio.mp3")
+	err := client.SendVoice(context.Background(), testChatID, []byte("audio"), "audio.mp3")
 
 	if err == nil {
 		t.Error("expected error for server error response")
 	}
 }
+
+func TestClientSendReply(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeJSONPayload(t, r)
+		if payload["reply_to_message_id"] != float64(7) {
+			t.Errorf("reply_to_message_id = %v, want 7", payload["reply_to_message_id"])
+		}
+		_ = json.NewEncoder(w).Encode(MessageResponse{Ok: true, Result: Message{MessageID: 8}})
+	})
+
+	client := newTestClient(server.URL)
+	sent, err := client.SendReply(context.Background(), testChatID, 7, "answer")
+
+	assertNoError(t, err)
+	if sent.MessageID != 8 {
+		t.Errorf("message id = %d, want 8", sent.MessageID)
+	}
+}
+
+func TestClientSendMessageWithKeyboard(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeJSONPayload(t, r)
+		if _, ok := payload["reply_to_message_id"]; ok {
+			t.Error("reply_to_message_id should be omitted without a reply")
+		}
+		markup, _ := payload["reply_markup"].(map[string]any)
+		rows, _ := markup["inline_keyboard"].([]any)
+		if len(rows) != 1 {
+			t.Errorf("reply_markup = %v, want one keyboard row", payload["reply_markup"])
+		}
+		_ = json.NewEncoder(w).Encode(MessageResponse{Ok: true, Result: Message{MessageID: 9}})
+	})
+
+	client := newTestClient(server.URL)
+	keyboard := &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{Text: "Again", CallbackData: "gpt:regen"}}}}
+	sent, err := client.SendMessageWithKeyboard(context.Background(), testChatID, 0, "answer", keyboard)
+
+	assertNoError(t, err)
+	if sent.MessageID != 9 {
+		t.Errorf("message id = %d, want 9", sent.MessageID)
+	}
+}
+
+func TestClientEditMessageText(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, editMessageCMD) {
+			t.Errorf("path = %s, want %s", r.URL.Path, editMessageCMD)
+		}
+		payload := decodeJSONPayload(t, r)
+		assertPayloadInt(t, payload, "chat_id", testChatID)
+		assertPayloadInt(t, payload, "message_id", 8)
+		assertPayloadString(t, payload, "text", "better answer")
+		if _, ok := payload["reply_markup"]; ok {
+			t.Error("reply_markup should be omitted when nil")
+		}
+	})
+
+	client := newTestClient(server.URL)
+	err := client.EditMessageText(context.Background(), testChatID, 8, "better answer", nil)
+
+	assertNoError(t, err)
+}
+
+func TestClientAnswerCallbackQuery(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeJSONPayload(t, r)
+		assertPayloadString(t, payload, "callback_query_id", "cb1")
+		assertPayloadString(t, payload, "text", "Expired")
+	})
+
+	client := newTestClient(server.URL)
+	err := client.AnswerCallbackQuery(context.Background(), "cb1", "Expired")
+
+	assertNoError(t, err)
+}
+
+func TestClientGetChatMember(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("user_id"); got != "42" {
+			t.Errorf("user_id = %q, want %q", got, "42")
+		}
+		_ = json.NewEncoder(w).Encode(ChatMemberResponse{Ok: true, Result: ChatMember{Status: "administrator"}})
+	})
+
+	client := newTestClient(server.URL)
+	member, err := client.GetChatMember(context.Background(), testChatID, 42)
+
+	assertNoError(t, err)
+	if member.Status != "administrator" {
+		t.Errorf("status = %q, want %q", member.Status, "administrator")
+	}
+}
+
+func TestClientDownloadFile(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == getFileCMD {
+			_ = json.NewEncoder(w).Encode(FileResponse{Ok: true, Result: File{FileID: "abc", FilePath: "docs/a.txt", FileSize: 5}})
+			return
+		}
+		if r.URL.Path != "/docs/a.txt" {
+			t.Errorf("path = %q, want %q", r.URL.Path, "/docs/a.txt")
+		}
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	client := newTestClient(server.URL)
+	client.fileURL = server.URL
+	data, err := client.DownloadFile(context.Background(), "abc")
+
+	assertNoError(t, err)
+	if string(data) != "hello" {
+		t.Errorf("data = %q, want %q", data, "hello")
+	}
+}
+
+func TestClientDownloadFileNotFound(t *testing.T) {
+	server := newTestServerWithJSON(t, FileResponse{Ok: false, Description: "Bad Request: invalid file_id"})
+
+	client := newTestClient(server.URL)
+	_, err := client.DownloadFile(context.Background(), "missing")
+
+	if err == nil {
+		t.Error("expected error for missing file")
+	}
+}
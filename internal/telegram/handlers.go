@@ -2,9 +2,8 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
-	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -12,6 +11,9 @@ import (
 	"got/internal/app"
 	"got/internal/app/model"
 	"got/internal/groq"
+	"got/internal/imagegen"
+	"got/internal/llm"
+	"got/internal/moderation"
 	"got/internal/redis"
 	"got/internal/tts"
 	"got/pkg/config"
@@ -21,19 +23,32 @@ import (
 const defaultSubreddit = "programmerhumor"
 
 const (
-	subCommandList   subCommand = "list"
-	subCommandAdd    subCommand = "add"
-	subCommandRemove subCommand = "remove"
-	subCommandDelete subCommand = "delete"
-	subCommandModel  subCommand = "model"
-	subCommandClear  subCommand = "clear"
-	subCommandForget subCommand = "forget"
-	subCommandAll    subCommand = "all"
-	subCommandStats  subCommand = "stats"
-	subCommandMemory subCommand = "memory"
-	subCommandImage  subCommand = "image"
-	subCommandLogin  subCommand = "login"
-	subCommandReset  subCommand = "reset"
+	subCommandList       subCommand = "list"
+	subCommandAdd        subCommand = "add"
+	subCommandRemove     subCommand = "remove"
+	subCommandDelete     subCommand = "delete"
+	subCommandModel      subCommand = "model"
+	subCommandClear      subCommand = "clear"
+	subCommandForget     subCommand = "forget"
+	subCommandAll        subCommand = "all"
+	subCommandStats      subCommand = "stats"
+	subCommandMemory     subCommand = "memory"
+	subCommandImage      subCommand = "image"
+	subCommandLogin      subCommand = "login"
+	subCommandReset      subCommand = "reset"
+	subCommandBan        subCommand = "ban"
+	subCommandUnban      subCommand = "unban"
+	subCommandBans       subCommand = "bans"
+	subCommandUsage      subCommand = "usage"
+	subCommandPersona    subCommand = "persona"
+	subCommandAuto       subCommand = "auto"
+	subCommandQuota      subCommand = "quota"
+	subCommandScope      subCommand = "scope"
+	subCommandLog        subCommand = "log"
+	subCommandSummary    subCommand = "summarize"
+	subCommandImport     subCommand = "import"
+	subCommandModeration subCommand = "moderation"
+	subCommandLurk       subCommand = "lurk"
 )
 
 const (
@@ -48,12 +63,16 @@ type subCommand string
 type BotHandlers struct {
 	client      *Client
 	service     *app.Service
-	gpt         *groq.Client
+	gpt         *llm.Registry
 	cache       *redis.Client
 	t           *i18n.Translator
 	tts         *tts.Client
 	cmds        *config.CommandsConfig
 	sentences   *SentenceProvider
+	menu        *CommandMenu
+	moderator   *moderation.Moderator
+	images      *imagegen.Chain
+	lurker      *Lurker
 	adminPass   string
 	defaultLang string
 	translators map[string]*i18n.Translator
@@ -61,7 +80,35 @@ type BotHandlers struct {
 
 var supportedLanguages = []string{"en", "ru", "lt", "ja", "be"}
 
-func NewBotHandlers(client *Client, service *app.Service, gpt *groq.Client, cache *redis.Client, t *i18n.Translator, tts *tts.Client, cmds *config.CommandsConfig, adminPass string) *BotHandlers {
+var knownSubCommands = map[subCommand]bool{
+	subCommandList:       true,
+	subCommandAdd:        true,
+	subCommandRemove:     true,
+	subCommandDelete:     true,
+	subCommandModel:      true,
+	subCommandClear:      true,
+	subCommandForget:     true,
+	subCommandAll:        true,
+	subCommandStats:      true,
+	subCommandMemory:     true,
+	subCommandImage:      true,
+	subCommandLogin:      true,
+	subCommandReset:      true,
+	subCommandBan:        true,
+	subCommandUnban:      true,
+	subCommandBans:       true,
+	subCommandUsage:      true,
+	subCommandPersona:    true,
+	subCommandAuto:       true,
+	subCommandQuota:      true,
+	subCommandScope:      true,
+	subCommandLog:        true,
+	subCommandSummary:    true,
+	subCommandModeration: true,
+	subCommandLurk:       true,
+}
+
+func NewBotHandlers(client *Client, service *app.Service, gpt *llm.Registry, cache *redis.Client, t *i18n.Translator, tts *tts.Client, cmds *config.CommandsConfig, adminPass string, menu *CommandMenu) *BotHandlers {
 	translators := make(map[string]*i18n.Translator)
 	for _, lang := range supportedLanguages {
 		translators[lang] = i18n.New(lang)
@@ -76,12 +123,17 @@ func NewBotHandlers(client *Client, service *app.Service, gpt *groq.Client, cach
 		tts:         tts,
 		cmds:        cmds,
 		sentences:   NewSentenceProvider(),
+		menu:        menu,
 		adminPass:   adminPass,
 		defaultLang: t.Lang(),
 		translators: translators,
 	}
 }
 
+func (h *BotHandlers) SetImageGenerator(images *imagegen.Chain) {
+	h.images = images
+}
+
 func (h *BotHandlers) registerChatUser(ctx context.Context, msg *Message) {
 	if msg.Chat == nil || msg.From == nil {
 		return
@@ -99,7 +151,7 @@ func (h *BotHandlers) registerChatUser(ctx context.Context, msg *Message) {
 func (h *BotHandlers) HandleStart(ctx context.Context, update *Update) error {
 	chatID := update.Message.Chat.ID
 	t := h.getTranslator(ctx, chatID)
-	return h.client.SendMessage(chatID, t.Get(i18n.KeyWelcome))
+	return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyWelcome))
 }
 
 func (h *BotHandlers) HandleHelp(ctx context.Context, update *Update) error {
@@ -114,14 +166,17 @@ func (h *BotHandlers) HandleHelp(ctx context.Context, update *Update) error {
 	}{
 		{h.cmds.Start, i18n.KeyCmdStart, nil, true},
 		{h.cmds.Help, i18n.KeyCmdHelp, nil, true},
-		{h.cmds.Gpt, i18n.KeyCmdGpt, []string{"image", "model", "memory", "clear"}, false},
+		{h.cmds.Gpt, i18n.KeyCmdGpt, []string{"image", "model", "persona", "memory", "scope", "summarize", "log", "usage", "clear"}, false},
 		{h.cmds.Meme, i18n.KeyCmdMeme, []string{"list", "add", "remove"}, false},
 		{h.cmds.Sticker, i18n.KeyCmdSticker, []string{"list", "add", "remove"}, false},
 		{h.cmds.Fact, i18n.KeyCmdFact, []string{"add"}, false},
 		{h.cmds.Tts, i18n.KeyCmdTts, nil, false},
+		{h.cmds.Transcribe, i18n.KeyCmdTranscribe, []string{"auto"}, false},
+		{h.cmds.Translate, i18n.KeyCmdTranslate, []string{"auto"}, false},
 		{h.cmds.Roulette, i18n.KeyCmdRoulette, []string{"stats", "all"}, false},
 		{h.cmds.Remind, i18n.KeyCmdRemind, []string{"list", "delete"}, false},
 		{h.cmds.Lang, i18n.KeyCmdLang, nil, false},
+		{h.cmds.Usage, i18n.KeyCmdUsage, []string{"day", "week", "month", "all"}, false},
 	}
 
 	var sb strings.Builder
@@ -138,7 +193,7 @@ func (h *BotHandlers) HandleHelp(ctx context.Context, update *Update) error {
 		sb.WriteString(fmt.Sprintf("- `/%s` — %s%s\n", c.cmd, t.Get(c.desc), subCmdsStr))
 	}
 
-	return h.client.SendMessage(chatID, sb.String())
+	return h.client.SendMessage(ctx, chatID, sb.String())
 }
 
 func (h *BotHandlers) HandleFact(ctx context.Context, update *Update) error {
@@ -149,22 +204,22 @@ func (h *BotHandlers) HandleFact(ctx context.Context, update *Update) error {
 
 	if len(parts) > 0 && subCommand(parts[0]) == subCommandAdd {
 		if len(parts) < 2 {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeyFactUsage))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyFactUsage))
 		}
 		if err := h.service.AddFact(ctx, parts[1], chatID); err != nil {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeyFactError))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyFactError))
 		}
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyFactAdded))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyFactAdded))
 	}
 
 	fact, err := h.service.GetRandomFact(ctx, chatID)
 	if err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyFactError))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyFactError))
 	}
 	if fact == nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyNoFacts))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyNoFacts))
 	}
-	return h.client.SendMessage(chatID, fmt.Sprintf(t.Get(i18n.KeyFactFormat), fact.Comment))
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyFactFormat), fact.Comment))
 }
 
 func (h *BotHandlers) HandleSticker(ctx context.Context, update *Update) error {
@@ -184,43 +239,43 @@ func (h *BotHandlers) HandleSticker(ctx context.Context, update *Update) error {
 			return h.addStickerSet(ctx, chatID, parts[1])
 		}
 		if update.Message.ReplyToMessage == nil || update.Message.ReplyToMessage.Sticker == nil {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerUsage))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerUsage))
 		}
 		sticker := update.Message.ReplyToMessage.Sticker
 		if err := h.service.AddSticker(ctx, sticker.FileID, sticker.SetName, chatID); err != nil {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerError))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerError))
 		}
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerAdded))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerAdded))
 
 	case subCommandRemove:
 		if len(parts) > 1 {
 			return h.removeStickerSet(ctx, chatID, parts[1])
 		}
 		if update.Message.ReplyToMessage == nil || update.Message.ReplyToMessage.Sticker == nil {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerRemoveUsage))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerRemoveUsage))
 		}
 		fileID := update.Message.ReplyToMessage.Sticker.FileID
 		if err := h.service.RemoveSticker(ctx, fileID, chatID); err != nil {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerError))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerError))
 		}
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerRemoved))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerRemoved))
 
 	case subCommandList:
 		stickers, err := h.service.ListStickers(ctx, chatID)
 		if err != nil {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerError))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerError))
 		}
-		return h.client.SendMessage(chatID, h.formatStickerList(t, stickers))
+		return h.client.SendMessage(ctx, chatID, h.formatStickerList(t, stickers))
 
 	default:
 		sticker, err := h.service.GetRandomSticker(ctx, chatID)
 		if err != nil {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerError))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerError))
 		}
 		if sticker == nil {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeyNoStickers))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyNoStickers))
 		}
-		return h.client.SendSticker(chatID, sticker.FileID)
+		return h.client.SendSticker(ctx, chatID, sticker.FileID)
 	}
 }
 
@@ -234,28 +289,28 @@ func (h *BotHandlers) HandleMeme(ctx context.Context, update *Update) error {
 		switch subCommand(parts[0]) {
 		case subCommandAdd:
 			if len(parts) < 2 {
-				return h.client.SendMessage(chatID, t.Get(i18n.KeyMemeUsage))
+				return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyMemeUsage))
 			}
 			if err := h.service.AddSubreddit(ctx, parts[1], chatID); err != nil {
-				return h.client.SendMessage(chatID, t.Get(i18n.KeySubredditError))
+				return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeySubredditError))
 			}
-			return h.client.SendMessage(chatID, fmt.Sprintf(t.Get(i18n.KeyMemeAdded), parts[1]))
+			return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyMemeAdded), parts[1]))
 
 		case subCommandRemove:
 			if len(parts) < 2 {
-				return h.client.SendMessage(chatID, t.Get(i18n.KeyMemeUsage))
+				return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyMemeUsage))
 			}
 			if err := h.service.RemoveSubreddit(ctx, parts[1], chatID); err != nil {
-				return h.client.SendMessage(chatID, t.Get(i18n.KeySubredditError))
+				return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeySubredditError))
 			}
-			return h.client.SendMessage(chatID, fmt.Sprintf(t.Get(i18n.KeyMemeRemoved), parts[1]))
+			return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyMemeRemoved), parts[1]))
 
 		case subCommandList:
 			subs, err := h.service.ListSubreddits(ctx, chatID)
 			if err != nil {
-				return h.client.SendMessage(chatID, t.Get(i18n.KeySubredditError))
+				return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeySubredditError))
 			}
-			return h.client.SendMessage(chatID, h.formatSubredditList(t, subs))
+			return h.client.SendMessage(ctx, chatID, h.formatSubredditList(t, subs))
 		}
 	}
 
@@ -265,7 +320,7 @@ func (h *BotHandlers) HandleMeme(ctx context.Context, update *Update) error {
 	if len(parts) > 0 {
 		if n, err := strconv.Atoi(parts[0]); err == nil {
 			if n < 1 || n > 5 {
-				return h.client.SendMessage(chatID, t.Get(i18n.KeyMemeCountInvalid))
+				return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyMemeCountInvalid))
 			}
 			count = n
 			if len(parts) > 1 {
@@ -276,7 +331,7 @@ func (h *BotHandlers) HandleMeme(ctx context.Context, update *Update) error {
 			if len(parts) > 1 {
 				if n, err := strconv.Atoi(parts[1]); err == nil {
 					if n < 1 || n > 5 {
-						return h.client.SendMessage(chatID, t.Get(i18n.KeyMemeCountInvalid))
+						return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyMemeCountInvalid))
 					}
 					count = n
 				}
@@ -290,7 +345,7 @@ func (h *BotHandlers) HandleMeme(ctx context.Context, update *Update) error {
 	} else {
 		sub, err := h.service.GetRandomSubreddit(ctx, chatID)
 		if err != nil {
-			return h.client.SendMessage(chatID, t.Get(i18n.KeySubredditError))
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeySubredditError))
 		}
 		if sub != nil {
 			subName = sub.Name
@@ -299,14 +354,14 @@ func (h *BotHandlers) HandleMeme(ctx context.Context, update *Update) error {
 		}
 	}
 
-	_ = h.client.SendChatAction(chatID, actionUploadPhoto)
+	_ = h.client.SendChatAction(ctx, chatID, actionUploadPhoto)
 
 	memes, err := h.fetchMemes(ctx, subName, count)
 	if err != nil || len(memes) == 0 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyMemeError)+subName)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyMemeError)+subName)
 	}
 
-	return h.sendMemes(chatID, memes)
+	return h.sendMemes(ctx, chatID, memes)
 }
 
 func (h *BotHandlers) HandleGPT(ctx context.Context, update *Update) error {
@@ -314,12 +369,15 @@ func (h *BotHandlers) HandleGPT(ctx context.Context, update *Update) error {
 	t := h.getTranslator(ctx, chatID)
 
 	if h.gpt == nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyGptNoKey))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptNoKey))
 	}
 
 	args := update.Message.CommandArguments()
 	if args == "" {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyGptUsage))
+		if messageAudio(update.Message.ReplyToMessage) != nil {
+			return h.handleGPTChat(ctx, update.Message, "")
+		}
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptUsage))
 	}
 
 	parts := strings.SplitN(args, " ", 2)
@@ -332,17 +390,25 @@ func (h *BotHandlers) HandleGPT(ctx context.Context, update *Update) error {
 		}
 		return h.handleGPTModels(ctx, chatID)
 	case subCommandClear, subCommandForget:
-		return h.handleGPTClear(ctx, chatID)
+		return h.handleGPTClear(ctx, update.Message, argsAfter(parts))
 	case subCommandMemory:
-		return h.handleGPTMemory(ctx, chatID)
+		return h.handleGPTMemory(ctx, update.Message, strings.ToLower(argsAfter(parts)))
+	case subCommandScope:
+		return h.handleGPTScope(ctx, update.Message, strings.ToLower(argsAfter(parts)))
+	case subCommandSummary:
+		return h.handleGPTSummarize(ctx, update.Message, argsAfter(parts))
+	case subCommandLog:
+		return h.handleGPTLog(ctx, update.Message, strings.ToLower(argsAfter(parts)))
 	case subCommandImage:
-		return h.handleGPTImage(ctx, chatID, parts)
+		return h.handleGPTImage(ctx, update.Message, parts)
+	case subCommandPersona:
+		return h.handleGPTPersona(ctx, chatID, argsAfter(parts))
+	case subCommandUsage:
+		return h.handleGPTUsage(ctx, update.Message)
+	case subCommandLurk:
+		return h.handleGPTLurk(ctx, update.Message, argsAfter(parts))
 	default:
-		username := ""
-		if update.Message.From != nil {
-			username = update.Message.From.UserName
-		}
-		return h.handleGPTChat(ctx, chatID, username, args)
+		return h.handleGPTChat(ctx, update.Message, args)
 	}
 }
 
@@ -353,7 +419,7 @@ func (h *BotHandlers) HandleRemind(ctx context.Context, update *Update) error {
 	parts := strings.SplitN(args, " ", 2)
 
 	if len(parts) == 0 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRemindUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRemindUsage))
 	}
 
 	switch subCommand(parts[0]) {
@@ -397,7 +463,7 @@ func (h *BotHandlers) HandleRoulette(ctx context.Context, update *Update) error
 		if year, err := strconv.Atoi(parts[0]); err == nil {
 			return h.handleRouletteYear(ctx, chatID, year)
 		}
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRouletteUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRouletteUsage))
 	}
 }
 
@@ -407,11 +473,11 @@ func (h *BotHandlers) HandleTTS(ctx context.Context, update *Update) error {
 	text := update.Message.CommandArguments()
 
 	if text == "" {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyTtsUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTtsUsage))
 	}
 
 	if h.tts == nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyTtsError))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTtsError))
 	}
 
 	typing := h.startTyping(ctx, chatID, actionRecordVoice)
@@ -419,10 +485,10 @@ func (h *BotHandlers) HandleTTS(ctx context.Context, update *Update) error {
 
 	audioData, err := h.tts.GenerateSpeech(ctx, text)
 	if err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyTtsError))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTtsError))
 	}
 
-	return h.client.SendVoice(chatID, audioData, "speech.mp3")
+	return h.client.SendVoice(ctx, chatID, audioData, "speech.mp3")
 }
 
 func (h *BotHandlers) HandleAdmin(ctx context.Context, update *Update) error {
@@ -432,14 +498,14 @@ func (h *BotHandlers) HandleAdmin(ctx context.Context, update *Update) error {
 	isPrivate := update.Message.Chat.Type == "private"
 
 	if h.adminPass == "" {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminNoPass))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminNoPass))
 	}
 
 	args := strings.TrimSpace(update.Message.CommandArguments())
 	parts := strings.SplitN(args, " ", 2)
 
 	if len(parts) == 0 || parts[0] == "" {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminUsage))
 	}
 
 	switch subCommand(parts[0]) {
@@ -447,8 +513,20 @@ func (h *BotHandlers) HandleAdmin(ctx context.Context, update *Update) error {
 		return h.handleAdminLogin(ctx, chatID, userID, parts, isPrivate)
 	case subCommandReset:
 		return h.handleAdminReset(ctx, chatID, userID)
+	case subCommandBan:
+		return h.handleAdminBan(ctx, update.Message, argsAfter(parts))
+	case subCommandUnban:
+		return h.handleAdminUnban(ctx, update.Message, argsAfter(parts))
+	case subCommandBans:
+		return h.handleAdminBans(ctx, chatID, userID)
+	case subCommandUsage:
+		return h.handleAdminUsage(ctx, chatID, userID, argsAfter(parts))
+	case subCommandQuota:
+		return h.handleAdminQuota(ctx, update.Message, argsAfter(parts))
+	case subCommandModeration:
+		return h.handleAdminModeration(ctx, chatID, userID)
 	default:
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminUsage))
 	}
 }
 
@@ -467,49 +545,49 @@ func (h *BotHandlers) handleRemindList(ctx context.Context, chatID int64) error
 	t := h.getTranslator(ctx, chatID)
 	reminders, err := h.service.GetPendingReminders(ctx, chatID)
 	if err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRemindListError))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRemindListError))
 	}
 	if len(reminders) == 0 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRemindNoPending))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRemindNoPending))
 	}
 
-	return h.client.SendMessage(chatID, h.formatReminders(t, reminders))
+	return h.client.SendMessage(ctx, chatID, h.formatReminders(t, reminders))
 }
 
 func (h *BotHandlers) handleRemindDelete(ctx context.Context, chatID int64, parts []string) error {
 	t := h.getTranslator(ctx, chatID)
 	if len(parts) < 2 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRemindDeleteUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRemindDeleteUsage))
 	}
 
 	reminderID, err := strconv.ParseInt(parts[1], 10, 64)
 	if err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRemindDeleteUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRemindDeleteUsage))
 	}
 
 	if err := h.service.DeleteReminder(ctx, reminderID, chatID); err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRemindDeleteError))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRemindDeleteError))
 	}
 
-	return h.client.SendMessage(chatID, t.Get(i18n.KeyRemindDeleted))
+	return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRemindDeleted))
 }
 
 func (h *BotHandlers) handleRemindAdd(ctx context.Context, chatID int64, userID int64, parts []string) error {
 	t := h.getTranslator(ctx, chatID)
 	if len(parts) < 2 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRemindUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRemindUsage))
 	}
 
 	duration, err := ParseDuration(parts[0])
 	if err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRemindInvalid))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRemindInvalid))
 	}
 
 	if err := h.service.AddReminder(ctx, chatID, userID, parts[1], duration); err != nil {
-		return h.client.SendMessage(chatID, fmt.Sprintf("Error: %v", err))
+		return h.client.SendMessage(ctx, chatID, fmt.Sprintf("Error: %v", err))
 	}
 
-	return h.client.SendMessage(chatID, fmt.Sprintf(t.Get(i18n.KeyRemindSuccess), duration))
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyRemindSuccess), duration))
 }
 
 func (h *BotHandlers) formatReminders(t *i18n.Translator, reminders []*model.Reminder) string {
@@ -527,8 +605,8 @@ func (h *BotHandlers) handleRouletteSpin(ctx context.Context, chatID int64, year
 
 	winner, err := h.service.GetTodayWinner(ctx, chatID, year)
 	if err != nil {
-		slog.Error("roulette: failed to get today winner", "chatID", chatID, "error", err)
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRouletteNoStats))
+		log.ErrorContext(ctx, "roulette: failed to get today winner", "chatID", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRouletteNoStats))
 	}
 
 	if winner != nil {
@@ -537,104 +615,116 @@ func (h *BotHandlers) handleRouletteSpin(ctx context.Context, chatID int64, year
 			name = fmt.Sprintf("User%d", winner.User.UserID)
 		}
 		msg := fmt.Sprintf(t.Get(i18n.KeyRouletteWinnerExists), alias, name, winner.Score)
-		return h.client.SendMessage(chatID, msg)
+		return h.client.SendMessage(ctx, chatID, msg)
 	}
 
 	winner, err = h.service.SelectRandomWinner(ctx, chatID, year)
 	if err != nil {
-		slog.Error("roulette: failed to select random winner", "chatID", chatID, "error", err)
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRouletteNoUsers))
+		log.ErrorContext(ctx, "roulette: failed to select random winner", "chatID", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRouletteNoUsers))
 	}
 	if winner == nil {
-		slog.Warn("roulette: no users found in chat", "chatID", chatID)
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRouletteNoUsers))
+		log.WarnContext(ctx, "roulette: no users found in chat", "chatID", chatID)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRouletteNoUsers))
 	}
 
 	winnerName := h.formatUser(winner.User)
 	fallbackMsg := fmt.Sprintf(t.Get(i18n.KeyRouletteWinnerNew), alias, winnerName)
-	return h.sentences.SendSequence(h.client, chatID, t.Lang(), alias, winnerName, fallbackMsg)
+	return h.sentences.SendSequence(ctx, h.client, chatID, t.Lang(), alias, winnerName, fallbackMsg)
 }
 
 func (h *BotHandlers) handleRouletteYear(ctx context.Context, chatID int64, year int) error {
 	t := h.getTranslator(ctx, chatID)
 	stats, err := h.service.GetStatsByYear(ctx, chatID, year)
 	if err != nil || len(stats) == 0 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRouletteNoStats))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRouletteNoStats))
 	}
 
-	return h.client.SendMessage(chatID, h.formatStats(t, stats, fmt.Sprintf(t.Get(i18n.KeyRouletteHeader), year)))
+	return h.client.SendMessage(ctx, chatID, h.formatStats(t, stats, fmt.Sprintf(t.Get(i18n.KeyRouletteHeader), year)))
 }
 
 func (h *BotHandlers) handleRouletteAll(ctx context.Context, chatID int64) error {
 	t := h.getTranslator(ctx, chatID)
 	stats, err := h.service.GetAllStats(ctx, chatID)
 	if err != nil || len(stats) == 0 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyRouletteNoStats))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyRouletteNoStats))
 	}
 
 	aggregated := h.aggregateStats(stats)
-	return h.client.SendMessage(chatID, h.formatStats(t, aggregated, t.Get(i18n.KeyRouletteHeaderAll)))
+	return h.client.SendMessage(ctx, chatID, h.formatStats(t, aggregated, t.Get(i18n.KeyRouletteHeaderAll)))
 }
 
 func (h *BotHandlers) handleGPTModels(ctx context.Context, chatID int64) error {
 	t := h.getTranslator(ctx, chatID)
-	currentModel := h.getChatModel(ctx, chatID)
-	models := h.fetchModelsWithFallback(ctx)
+	currentModel := h.currentModelRef(ctx, chatID)
 
 	var sb strings.Builder
 	sb.WriteString(t.Get(i18n.KeyGptModelsHeader))
-	for i, m := range models {
+	writeModelList(&sb, h.gpt.Catalog(ctx), currentModel)
+	sb.WriteString(t.Get(i18n.KeyGptModelsLegend))
+	return h.client.SendMessage(ctx, chatID, sb.String())
+}
+
+func writeModelList(sb *strings.Builder, catalog []llm.ModelInfo, currentModel string) {
+	for i, m := range catalog {
 		prefix := "  "
-		if m == currentModel {
+		if m.Ref == currentModel {
 			prefix = "→ "
 		}
-		sb.WriteString(fmt.Sprintf("%s%d. %s\n", prefix, i+1, m))
-	}
-	return h.client.SendMessage(chatID, sb.String())
-}
-
-func (h *BotHandlers) fetchModelsWithFallback(ctx context.Context) []string {
-	models, err := h.gpt.FetchModels(ctx)
-	if err != nil || len(models) == 0 {
-		return h.gpt.ListModels()
+		sb.WriteString(fmt.Sprintf("%s%d. %s%s\n", prefix, i+1, m.Ref, formatModelTags(m)))
 	}
-	return models
 }
 
 func (h *BotHandlers) handleGPTSetModel(ctx context.Context, chatID int64, modelInput string) error {
 	t := h.getTranslator(ctx, chatID)
-	modelName := h.resolveModelName(ctx, modelInput)
+	catalog := h.gpt.Catalog(ctx)
+	modelName := resolveModelName(catalog, modelInput)
 
-	if err := h.gpt.ValidateModel(modelName); err != nil {
-		models := h.gpt.ListModels()
+	if err := h.gpt.ValidateModel(ctx, modelName); err != nil {
 		var sb strings.Builder
 		sb.WriteString(t.Get(i18n.KeyGptModelInvalid))
-		for i, m := range models {
-			sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, m))
-		}
-		return h.client.SendMessage(chatID, sb.String())
+		writeModelList(&sb, catalog, "")
+		return h.client.SendMessage(ctx, chatID, sb.String())
 	}
 
 	if h.cache != nil {
 		_ = h.cache.SetModel(ctx, chatID, modelName)
 	}
 
-	return h.client.SendMessage(chatID, fmt.Sprintf(t.Get(i18n.KeyGptModelSet), modelName))
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyGptModelSet), modelName))
 }
 
-func (h *BotHandlers) resolveModelName(ctx context.Context, input string) string {
+func resolveModelName(catalog []llm.ModelInfo, input string) string {
 	num, err := strconv.Atoi(input)
 	if err != nil {
 		return input
 	}
 
-	models := h.fetchModelsWithFallback(ctx)
 	idx := num - 1
-	if idx < 0 || idx >= len(models) {
+	if idx < 0 || idx >= len(catalog) {
 		return input
 	}
 
-	return models[idx]
+	return catalog[idx].Ref
+}
+
+func formatModelTags(m llm.ModelInfo) string {
+	var tags []string
+	if m.ContextWindow >= 1024 {
+		tags = append(tags, fmt.Sprintf("%dk", m.ContextWindow/1024))
+	} else if m.ContextWindow > 0 {
+		tags = append(tags, strconv.Itoa(m.ContextWindow))
+	}
+	if m.Vision {
+		tags = append(tags, "👁")
+	}
+	if m.Tools {
+		tags = append(tags, "🛠")
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return " · " + strings.Join(tags, " ")
 }
 
 func (h *BotHandlers) getChatModel(ctx context.Context, chatID int64) string {
@@ -651,74 +741,162 @@ func (h *BotHandlers) startTyping(ctx context.Context, chatID int64, action stri
 	return indicator
 }
 
-func (h *BotHandlers) handleGPTClear(ctx context.Context, chatID int64) error {
+func (h *BotHandlers) handleGPTImage(ctx context.Context, msg *Message, parts []string) error {
+	chatID := msg.Chat.ID
 	t := h.getTranslator(ctx, chatID)
-	if h.cache != nil {
-		_ = h.cache.ClearHistory(ctx, chatID)
+	if len(parts) < 2 || strings.TrimSpace(parts[1]) == "" {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptImageUsage))
 	}
-	return h.client.SendMessage(chatID, t.Get(i18n.KeyGptCleared))
-}
 
-func (h *BotHandlers) handleGPTMemory(ctx context.Context, chatID int64) error {
-	t := h.getTranslator(ctx, chatID)
-	if h.cache == nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyGptMemoryNoRedis))
+	prompt, opts, ok := parseImageArgs(parts[1])
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptImageUsage))
 	}
-
-	history, err := h.cache.GetHistory(ctx, chatID)
-	if err != nil || len(history) == 0 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyGptMemoryEmpty))
+	if notice, allowed := h.moderate(ctx, msg, model.ModerationSourceImage, prompt); !allowed {
+		return h.client.SendMessage(ctx, chatID, notice)
 	}
-
-	_ = h.client.SendChatAction(chatID, actionUploadDocument)
-
-	content := formatHistoryAsText(history)
-	filename := fmt.Sprintf("chat_history_%d.txt", chatID)
-	caption := t.Get(i18n.KeyGptMemoryCaption)
-
-	return h.client.SendDocument(chatID, []byte(content), filename, caption)
-}
-
-func (h *BotHandlers) handleGPTImage(ctx context.Context, chatID int64, parts []string) error {
-	t := h.getTranslator(ctx, chatID)
-	if len(parts) < 2 || strings.TrimSpace(parts[1]) == "" {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyGptImageUsage))
+	if h.images == nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptImageError))
 	}
 
 	typing := h.startTyping(ctx, chatID, actionUploadPhoto)
 	defer typing.Stop()
 
-	prompt := strings.TrimSpace(parts[1])
-	imageURL := buildImageURL(prompt)
+	img, err := h.images.Generate(ctx, prompt, opts)
+	if err != nil {
+		log.WarnContext(ctx, "Image generation failed", "chat_id", chatID, "size", opts.Size(), "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptImageError))
+	}
 
-	return h.client.SendPhoto(chatID, imageURL, prompt)
+	return h.client.SendPhotoFile(ctx, chatID, img.Data, "image."+img.Extension(), prompt)
 }
 
-func (h *BotHandlers) handleGPTChat(ctx context.Context, chatID int64, username string, prompt string) error {
+func (h *BotHandlers) handleGPTChat(ctx context.Context, msg *Message, prompt string) error {
+	chatID := msg.Chat.ID
 	t := h.getTranslator(ctx, chatID)
+	username := ""
+	if msg.From != nil {
+		username = msg.From.UserName
+	}
+	if blocked := h.quotaMessage(ctx, msg); blocked != "" {
+		return h.client.SendMessage(ctx, chatID, blocked)
+	}
+
 	typing := h.startTyping(ctx, chatID, actionTyping)
 	defer typing.Stop()
 
-	formattedPrompt := formatPromptWithUsername(username, prompt)
-	model := h.getChatModel(ctx, chatID)
+	prompt, err := h.voicePrompt(ctx, msg, prompt)
+	if err != nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(transcribeErrorKey(err)))
+	}
+
+	chatModel := h.getChatModel(ctx, chatID)
+	image, err := h.promptImage(ctx, msg)
+	if err != nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(visionErrorKey(err)))
+	}
+
+	var images, imageRefs []string
+	if image != nil {
+		visionModel, ok := h.gpt.VisionRef(ctx, chatModel)
+		if !ok {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptVisionUnavailable))
+		}
+		chatModel = visionModel
+		images, imageRefs = []string{image.dataURL}, []string{image.ref}
+	}
+	systemPrompt, err := h.service.BuildSystemPrompt(ctx, chatID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to build system prompt, using default", "error", err)
+	}
+	systemPrompt = h.withKnowledge(ctx, chatID, systemPrompt, prompt)
 
+	scope := h.historyScope(ctx, msg)
 	var history []groq.Message
 	if h.cache != nil {
-		history, _ = h.cache.GetHistory(ctx, chatID)
+		history, _ = h.cache.GetHistory(ctx, chatID, scope.key)
+	}
+	formattedPrompt := withReplyContext(msg, history, formatPromptWithUsername(username, prompt))
+	fit := func(ctx context.Context, provider llm.Provider, info llm.ModelInfo, history []groq.Message) []groq.Message {
+		return h.compactHistory(ctx, msg, provider, info, systemPrompt, formattedPrompt, history)
 	}
 
-	response, err := h.gpt.ChatWithModel(ctx, formattedPrompt, history, model)
+	result, err := h.gpt.CompleteWithHistory(ctx, chatModel, groq.ChatRequest{
+		SystemPrompt: systemPrompt,
+		History:      history,
+		Prompt:       formattedPrompt,
+		Images:       images,
+		Tools:        chatTools(),
+		ExecuteTool:  h.toolExecutor(msg),
+	}, fit)
 	if err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyGptError))
+		log.WarnContext(ctx, "GPT request failed", "model", chatModel, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(gptErrorKey(err)))
 	}
+	h.recordTokenUsage(ctx, msg, result)
+	response, allowed := h.moderate(ctx, msg, model.ModerationSourceGPT, result.Content)
 
-	if h.cache != nil {
-		history = append(history, groq.Message{Role: "user", Content: formattedPrompt})
+	if h.cache != nil && allowed {
+		history = append(result.History, groq.Message{Role: "user", Content: formattedPrompt, ImageRefs: imageRefs})
 		history = append(history, groq.Message{Role: "assistant", Content: response})
-		_ = h.cache.SaveHistory(ctx, chatID, history)
+		_ = h.cache.SaveHistory(ctx, chatID, scope.key, history)
+	}
+
+	var keyboard *InlineKeyboardMarkup
+	if h.cache != nil && allowed {
+		keyboard = answerKeyboard(t)
+	}
+	if result.Fallback && allowed {
+		response += fmt.Sprintf(t.Get(i18n.KeyGptAnsweredBy), result.Ref)
+	}
+	sent, err := h.sendGPTReply(ctx, msg, scope, response, keyboard)
+	if err != nil || keyboard == nil {
+		return err
+	}
+	h.saveAnswer(ctx, chatID, sent.MessageID, &redis.Answer{
+		UserID:    messageUserID(msg),
+		Scope:     scope.key,
+		Prompt:    formattedPrompt,
+		ImageRefs: imageRefs,
+		Response:  result.Content,
+		Model:     result.Ref,
+	})
+	return nil
+}
+
+func gptErrorKey(err error) i18n.Key {
+	switch {
+	case errors.Is(err, groq.ErrRateLimited):
+		return i18n.KeyGptErrorRateLimit
+	case errors.Is(err, groq.ErrOverloaded):
+		return i18n.KeyGptErrorOverloaded
+	case errors.Is(err, groq.ErrContextTooLong):
+		return i18n.KeyGptErrorContext
+	case errors.Is(err, groq.ErrAuth):
+		return i18n.KeyGptErrorAuth
+	default:
+		return i18n.KeyGptError
+	}
+}
+
+func (h *BotHandlers) compactHistory(ctx context.Context, msg *Message, provider llm.Provider, info llm.ModelInfo, systemPrompt, prompt string, history []groq.Message) []groq.Message {
+	model := info.ID
+	budget := groq.HistoryBudget(model, info.ContextWindow, systemPrompt, prompt, info.Tools)
+	kept, dropped := groq.FitHistory(model, history, budget)
+	if len(dropped) == 0 {
+		return history
+	}
+
+	summary, _ := groq.SplitSummary(history)
+	updated, usage, err := provider.Summarize(ctx, model, summary, dropped)
+	h.recordUsage(ctx, msg, info.Ref, usage)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to summarize dropped history, keeping previous summary", "error", err)
+		updated = summary
 	}
 
-	return h.client.SendMessage(chatID, response)
+	log.DebugContext(ctx, "Compacted GPT history", "dropped", len(dropped), "kept", len(kept), "budget", budget)
+	return groq.WithSummary(updated, kept)
 }
 
 func (h *BotHandlers) formatUser(user *model.User) string {
@@ -757,9 +935,9 @@ func (h *BotHandlers) formatSubredditList(t *i18n.Translator, subs []*model.Subr
 
 func (h *BotHandlers) addStickerSet(ctx context.Context, chatID int64, setName string) error {
 	t := h.getTranslator(ctx, chatID)
-	stickerSet, err := h.client.GetStickerSet(setName)
+	stickerSet, err := h.client.GetStickerSet(ctx, setName)
 	if err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerSetNotFound))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerSetNotFound))
 	}
 
 	added := 0
@@ -769,19 +947,19 @@ func (h *BotHandlers) addStickerSet(ctx context.Context, chatID int64, setName s
 		}
 	}
 
-	return h.client.SendMessage(chatID, fmt.Sprintf(t.Get(i18n.KeyStickerSetAdded), stickerSet.Title, added))
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyStickerSetAdded), stickerSet.Title, added))
 }
 
 func (h *BotHandlers) removeStickerSet(ctx context.Context, chatID int64, setName string) error {
 	t := h.getTranslator(ctx, chatID)
 	removed, err := h.service.RemoveStickerSet(ctx, setName, chatID)
 	if err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerError))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerError))
 	}
 	if removed == 0 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyStickerSetNotFound))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyStickerSetNotFound))
 	}
-	return h.client.SendMessage(chatID, fmt.Sprintf(t.Get(i18n.KeyStickerSetRemoved), setName, removed))
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyStickerSetRemoved), setName, removed))
 }
 
 func (h *BotHandlers) formatStickerList(t *i18n.Translator, stickers []*model.Sticker) string {
@@ -844,7 +1022,7 @@ func (h *BotHandlers) aggregateStats(stats []*model.Stat) []*model.Stat {
 	return result
 }
 
-func (h *BotHandlers) sendMemes(chatID int64, memes []model.RedditMeme) error {
+func (h *BotHandlers) sendMemes(ctx context.Context, chatID int64, memes []model.RedditMeme) error {
 	var photos []model.RedditMeme
 	var gifs []model.RedditMeme
 
@@ -865,17 +1043,17 @@ func (h *BotHandlers) sendMemes(chatID int64, memes []model.RedditMeme) error {
 				Caption: formatMemeCaption(meme),
 			}
 		}
-		if err := h.client.SendMediaGroup(chatID, media); err != nil {
+		if err := h.client.SendMediaGroup(ctx, chatID, media); err != nil {
 			return err
 		}
 	} else if len(photos) == 1 {
-		if err := h.client.SendPhoto(chatID, photos[0].URL, formatMemeCaption(photos[0])); err != nil {
+		if err := h.client.SendPhoto(ctx, chatID, photos[0].URL, formatMemeCaption(photos[0])); err != nil {
 			return err
 		}
 	}
 
 	for _, gif := range gifs {
-		if err := h.client.SendAnimation(chatID, gif.URL, formatMemeCaption(gif)); err != nil {
+		if err := h.client.SendAnimation(ctx, chatID, gif.URL, formatMemeCaption(gif)); err != nil {
 			return err
 		}
 	}
@@ -886,38 +1064,38 @@ func (h *BotHandlers) sendMemes(chatID int64, memes []model.RedditMeme) error {
 func (h *BotHandlers) handleAdminLogin(ctx context.Context, chatID, userID int64, parts []string, isPrivate bool) error {
 	t := h.getTranslator(ctx, chatID)
 	if !isPrivate {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminDMOnly))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminDMOnly))
 	}
 
 	if len(parts) < 2 {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminUsage))
 	}
 
 	password := strings.TrimSpace(parts[1])
 	if password != h.adminPass {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminUnauthorized))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminUnauthorized))
 	}
 
 	if h.cache != nil {
 		_ = h.cache.SetAdminSession(ctx, userID, true)
 	}
 
-	return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminLoginSuccess))
+	return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminLoginSuccess))
 }
 
 func (h *BotHandlers) handleAdminReset(ctx context.Context, chatID, userID int64) error {
 	t := h.getTranslator(ctx, chatID)
 	isAdmin, _ := h.isAdmin(ctx, userID)
 	if !isAdmin {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminNotLoggedIn))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminNotLoggedIn))
 	}
 
 	year := time.Now().Year()
 	if err := h.service.ResetTodayWinner(ctx, chatID, year); err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminResetError))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminResetError))
 	}
 
-	return h.client.SendMessage(chatID, t.Get(i18n.KeyAdminResetSuccess))
+	return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminResetSuccess))
 }
 
 func (h *BotHandlers) isAdmin(ctx context.Context, userID int64) (bool, error) {
@@ -929,13 +1107,10 @@ func (h *BotHandlers) isAdmin(ctx context.Context, userID int64) (bool, error) {
 
 func (h *BotHandlers) showCurrentLanguage(ctx context.Context, chatID int64) error {
 	t := h.getTranslator(ctx, chatID)
-	lang, _ := h.service.GetChatLanguage(ctx, chatID)
-	if lang == "" {
-		lang = h.defaultLang
-	}
+	lang := h.chatLanguage(ctx, chatID)
 
 	msg := fmt.Sprintf(t.Get(i18n.KeyLangCurrent), lang) + "\n\n" + t.Get(i18n.KeyLangList)
-	return h.client.SendMessage(chatID, msg)
+	return h.client.SendMessage(ctx, chatID, msg)
 }
 
 func (h *BotHandlers) setLanguage(ctx context.Context, chatID int64, lang string) error {
@@ -943,18 +1118,32 @@ func (h *BotHandlers) setLanguage(ctx context.Context, chatID int64, lang string
 	lang = strings.ToLower(strings.TrimSpace(lang))
 
 	if !isValidLanguage(lang) {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyLangUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyLangUsage))
 	}
 
 	if err := h.service.SetChatLanguage(ctx, chatID, lang); err != nil {
-		return h.client.SendMessage(chatID, t.Get(i18n.KeyLangUsage))
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyLangUsage))
+	}
+
+	if h.menu != nil {
+		if err := h.menu.RefreshChat(ctx, chatID, lang); err != nil {
+			log.ErrorContext(ctx, "Failed to refresh chat command menu", "chat_id", chatID, "error", err)
+		}
 	}
 
 	newT := h.translators[lang]
 	if newT == nil {
 		newT = t
 	}
-	return h.client.SendMessage(chatID, fmt.Sprintf(newT.Get(i18n.KeyLangSet), lang))
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(newT.Get(i18n.KeyLangSet), lang))
+}
+
+func (h *BotHandlers) chatLanguage(ctx context.Context, chatID int64) string {
+	lang, _ := h.service.GetChatLanguage(ctx, chatID)
+	if lang == "" {
+		return h.defaultLang
+	}
+	return lang
 }
 
 func (h *BotHandlers) getTranslator(ctx context.Context, chatID int64) *i18n.Translator {
@@ -974,18 +1163,30 @@ func formatHistoryAsText(history []groq.Message) string {
 		sb.WriteString(msg.Role)
 		sb.WriteString(": ")
 		sb.WriteString(msg.Content)
+		if len(msg.ImageRefs) > 0 {
+			sb.WriteString(" [image]")
+		}
 		sb.WriteString("\n")
 	}
 	return sb.String()
 }
 
-func buildImageURL(prompt string) string {
-	seed := time.Now().UnixNano() % 1000000
-	return fmt.Sprintf(
-		"https://image.pollinations.ai/prompt/%s?width=1024&height=1024&seed=%d&nologo=true&enhance=true",
-		url.QueryEscape(prompt),
-		seed,
-	)
+func parseImageArgs(args string) (string, imagegen.Options, bool) {
+	opts := imagegen.DefaultOptions()
+	fields := strings.Fields(args)
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		flag := strings.TrimPrefix(fields[0], "--")
+		if aspect, ok := imagegen.AspectOptions(flag); ok {
+			opts = aspect
+		} else if size, ok := imagegen.ParseSize(flag); ok {
+			opts = size
+		} else {
+			return "", opts, false
+		}
+		fields = fields[1:]
+	}
+	prompt := strings.Join(fields, " ")
+	return prompt, opts, prompt != ""
 }
 
 func formatPromptWithUsername(username string, prompt string) string {
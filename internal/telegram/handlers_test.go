@@ -2,100 +2,203 @@ package telegram
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"got/internal/app"
 	"got/internal/app/model"
 	"got/internal/groq"
+	"got/internal/imagegen"
+	"got/internal/llm"
 	"got/internal/redis"
 	"got/pkg/config"
 	"got/pkg/i18n"
 )
 
+type llmRequest struct {
+	system string
+	prompt string
+}
+
+type fakeLLMChat struct {
+	requests []llmRequest
+	sent     []string
+}
+
 func newTestTranslator() *i18n.Translator {
 	return i18n.NewWithTranslations("en", map[string]string{
-		"help_header":            "*Available commands:*\n",
-		"cmd_start":              "Start the bot",
-		"cmd_help":               "Show available commands",
-		"cmd_gpt":                "Chat with AI",
-		"cmd_remind":             "Set a reminder",
-		"cmd_meme":               "Get a random meme",
-		"cmd_sticker":            "Get a random sticker",
-		"cmd_fact":               "Get a random fact",
-		"cmd_roulette":           "Daily winner roulette",
-		"cmd_tts":                "Convert text to speech",
-		"cmd_lang":               "Change chat language",
-		"welcome":                "Welcome! I am ready.",
-		"gpt_usage":              "Usage: /gpt <prompt>",
-		"gpt_no_key":             "GPT is not configured.",
-		"gpt_cleared":            "Conversation history cleared.",
-		"gpt_error":              "Failed to get AI response.",
-		"gpt_models_header":      "Available models:\n",
-		"gpt_image_usage":        "Usage: /gpt image <prompt>",
-		"gpt_model_set":          "Model set to: %s",
-		"gpt_model_invalid":      "Invalid model. Available models:\n",
-		"gpt_memory_header":      "Memory stats:\n",
-		"gpt_memory_stats":       "Messages: %d, Characters: %d",
-		"gpt_memory_empty":       "No conversation history.",
-		"gpt_memory_no_redis":    "Memory feature is not available.",
-		"tts_usage":              "Usage: /tts <text to speak>",
-		"tts_error":              "Failed to generate speech.",
-		"sticker_usage":          "Reply to a sticker with /sticker add",
-		"sticker_added":          "Sticker added!",
-		"sticker_error":          "Failed to process sticker.",
-		"sticker_remove_usage":   "Reply to a sticker with /sticker remove",
-		"sticker_removed":        "Sticker removed!",
-		"sticker_list_header":    "*Available Sticker Sets:*\n\n",
-		"no_stickers":            "No stickers saved yet.",
-		"fact_usage":             "Usage: /fact add <text>",
-		"fact_added":             "Fact added!",
-		"fact_error":             "Failed to process fact.",
-		"fact_format":            "Fun fact: %s",
-		"no_facts":               "No facts saved yet.",
-		"meme_usage":             "Usage: /meme add <subreddit>",
-		"meme_added":             "Subreddit r/%s added!",
-		"meme_removed":           "Subreddit r/%s removed!",
-		"meme_list_header":       "Saved subreddits:\n",
-		"meme_error":             "Failed to fetch meme from ",
-		"meme_count_invalid":     "Count must be between 1 and 5.",
-		"subreddit_error":        "Failed to process subreddit.",
-		"remind_usage":           "Usage: /remind <duration> <message>",
-		"remind_invalid_time":    "Invalid time format.",
-		"remind_success":         "Reminder set for %s.",
-		"remind_no_pending":      "No pending reminders.",
-		"remind_list_error":      "Failed to list reminders.",
-		"remind_header":          "*Pending reminders:*\n",
-		"remind_format":          "#%d: %s (at %s)\n",
-		"remind_delete_usage":    "Usage: /remind delete <id>",
-		"remind_deleted":         "Reminder deleted.",
-		"remind_delete_error":    "Failed to delete reminder.",
-		"roulette_usage":         "Usage: /roulette [year|all]",
-		"roulette_no_stats":      "No stats found.",
-		"roulette_no_users":      "No users registered.",
-		"roulette_alias":         "Winner",
-		"roulette_winner_exists": "Today's %s: %s with %d points!",
-		"roulette_winner_new":    "New %s: %s!",
-		"roulette_header":        "Stats for %d",
-		"roulette_header_all":    "All-time stats",
-		"roulette_footer":        "Total: %d users",
-		"roulette_user":          "%d. %s: %d points",
+		"help_header":              "*Available commands:*\n",
+		"cmd_start":                "Start the bot",
+		"cmd_help":                 "Show available commands",
+		"cmd_gpt":                  "Chat with AI",
+		"cmd_remind":               "Set a reminder",
+		"cmd_meme":                 "Get a random meme",
+		"cmd_sticker":              "Get a random sticker",
+		"cmd_fact":                 "Get a random fact",
+		"cmd_roulette":             "Daily winner roulette",
+		"cmd_tts":                  "Convert text to speech",
+		"cmd_lang":                 "Change chat language",
+		"welcome":                  "Welcome! I am ready.",
+		"gpt_usage":                "Usage: /gpt <prompt>",
+		"gpt_no_key":               "GPT is not configured.",
+		"gpt_cleared":              "Conversation history cleared.",
+		"gpt_error":                "Failed to get AI response.",
+		"gpt_models_header":        "Available models:\n",
+		"gpt_image_usage":          "Usage: /gpt image <prompt>",
+		"gpt_image_error":          "Couldn't generate the image.",
+		"gpt_model_set":            "Model set to: %s",
+		"gpt_model_invalid":        "Invalid model. Available models:\n",
+		"gpt_memory_header":        "Memory stats:\n",
+		"gpt_memory_stats":         "Messages: %d, Characters: %d",
+		"gpt_memory_empty":         "No conversation history.",
+		"gpt_thread_unknown":       "No conversation thread found.",
+		"gpt_memory_no_redis":      "Memory feature is not available.",
+		"tts_usage":                "Usage: /tts <text to speak>",
+		"tts_error":                "Failed to generate speech.",
+		"sticker_usage":            "Reply to a sticker with /sticker add",
+		"sticker_added":            "Sticker added!",
+		"sticker_error":            "Failed to process sticker.",
+		"sticker_remove_usage":     "Reply to a sticker with /sticker remove",
+		"sticker_removed":          "Sticker removed!",
+		"sticker_list_header":      "*Available Sticker Sets:*\n\n",
+		"no_stickers":              "No stickers saved yet.",
+		"fact_usage":               "Usage: /fact add <text>",
+		"fact_added":               "Fact added!",
+		"fact_error":               "Failed to process fact.",
+		"fact_format":              "Fun fact: %s",
+		"no_facts":                 "No facts saved yet.",
+		"meme_usage":               "Usage: /meme add <subreddit>",
+		"meme_added":               "Subreddit r/%s added!",
+		"meme_removed":             "Subreddit r/%s removed!",
+		"meme_list_header":         "Saved subreddits:\n",
+		"meme_error":               "Failed to fetch meme from ",
+		"meme_count_invalid":       "Count must be between 1 and 5.",
+		"subreddit_error":          "Failed to process subreddit.",
+		"remind_usage":             "Usage: /remind <duration> <message>",
+		"remind_invalid_time":      "Invalid time format.",
+		"remind_success":           "Reminder set for %s.",
+		"remind_no_pending":        "No pending reminders.",
+		"remind_list_error":        "Failed to list reminders.",
+		"remind_header":            "*Pending reminders:*\n",
+		"remind_format":            "#%d: %s (at %s)\n",
+		"remind_delete_usage":      "Usage: /remind delete <id>",
+		"remind_deleted":           "Reminder deleted.",
+		"remind_delete_error":      "Failed to delete reminder.",
+		"roulette_usage":           "Usage: /roulette [year|all]",
+		"roulette_no_stats":        "No stats found.",
+		"roulette_no_users":        "No users registered.",
+		"roulette_alias":           "Winner",
+		"roulette_winner_exists":   "Today's %s: %s with %d points!",
+		"roulette_winner_new":      "New %s: %s!",
+		"roulette_header":          "Stats for %d",
+		"roulette_header_all":      "All-time stats",
+		"roulette_footer":          "Total: %d users",
+		"roulette_user":            "%d. %s: %d points",
+		"cmd_usage":                "Command usage statistics",
+		"usage_usage":              "Usage: /usage [day|week|month|all]",
+		"usage_header":             "Command usage (%s): %d total, %d failed, %s avg\n",
+		"usage_top_commands":       "Top commands:\n",
+		"usage_top_users":          "Top users:\n",
+		"usage_item":               "%d. %s — %d\n",
+		"usage_empty":              "No commands recorded.",
+		"usage_error":              "Failed to load usage.",
+		"usage_period_week":        "last 7 days",
+		"gpt_persona_usage":        "Usage: /gpt persona [list|reset|<name>|<prompt>]",
+		"gpt_persona_current":      "Current persona: %s\n\n%s",
+		"gpt_persona_set":          "Persona set to %s.",
+		"gpt_persona_custom_set":   "Custom system prompt saved.",
+		"gpt_persona_reset":        "Persona reset to default.",
+		"gpt_persona_list_header":  "Built-in personas:\n",
+		"gpt_persona_list_item":    "- %s: %s\n",
+		"gpt_persona_error":        "Failed to update persona.",
+		"cmd_transcribe":           "Transcribe a voice message",
+		"transcribe_usage":         "Reply to a voice message with /transcribe",
+		"transcribe_result":        "🎙 %s",
+		"transcribe_empty":         "No speech found.",
+		"transcribe_error":         "Failed to transcribe.",
+		"transcribe_unavailable":   "Transcription is not available.",
+		"transcribe_too_large":     "The audio is too large.",
+		"transcribe_auto_on":       "Auto-transcription on.",
+		"transcribe_auto_off":      "Auto-transcription off.",
+		"transcribe_admin_only":    "Only admins can change auto-transcription.",
+		"cmd_translate":            "Translate a message",
+		"translate_usage":          "Reply to a message with /translate",
+		"translate_result":         "🌐 %s → %s\n%s",
+		"translate_same":           "The text is already in %s.",
+		"translate_error":          "Failed to translate.",
+		"translate_auto_on":        "Auto-translation on.",
+		"translate_auto_off":       "Auto-translation off.",
+		"translate_admin_only":     "Only admins can change auto-translation.",
+		"assistant_usage":          "Usage: /assistant [on|off]",
+		"assistant_private_only":   "Assistant mode works in private chats only.",
+		"assistant_on":             "Assistant mode on.",
+		"assistant_off":            "Assistant mode off.",
+		"assistant_error":          "Failed to update assistant settings.",
+		"assistant_menu":           "Mode: %s\nModel: %s\nPersona: %s",
+		"assistant_state_on":       "on",
+		"assistant_state_off":      "off",
+		"assistant_button_enable":  "Turn on",
+		"assistant_button_disable": "Turn off",
+		"assistant_button_model":   "Model",
+		"assistant_button_persona": "Persona",
+		"assistant_button_back":    "Back",
+		"gpt_summarize_usage":      "Usage: /gpt summarize [N | since 2h]",
+		"gpt_log_usage":            "Usage: /gpt log [on|off]",
+		"gpt_summarize_disabled":   "Message log is off.",
+		"gpt_summarize_result":     "Summary of %d messages:\n%s",
+		"gpt_log_on":               "Message log is on.",
+		"gpt_lurk_usage":           "Usage: /gpt lurk [on|off | chance N | cooldown D | quiet D]",
+		"gpt_lurk_on":              "Lurker on.",
+		"gpt_lurk_off":             "Lurker off.",
+		"gpt_lurk_settings":        "Chance %d%%, cooldown %s.",
+		"gpt_lurk_quiet":           "Quiet for %s.",
+		"gpt_lurk_quiet_left":      "Quiet for another %s.",
+		"gpt_lurk_admin_only":      "Only admins can change lurker mode.",
+		"gpt_lurk_error":           "Failed to update lurker settings.",
+		"gpt_lurk_unavailable":     "Lurker mode is not available.",
+		"gpt_scope_current":        "Memory scope: %s",
+		"gpt_scope_usage":          "Usage: /gpt scope <chat, user, thread>",
+		"gpt_scope_set":            "Memory scope set to %s.",
+		"gpt_scope_admin_only":     "Only chat admins can change the memory scope.",
+		"gpt_clear_all_admin_only": "Only chat admins can clear every conversation.",
+		"gpt_quota_user_day":       "Daily limit reached (%d/%d tokens).",
+		"gpt_quota_chat_month":     "Chat monthly limit reached (%d/%d tokens).",
+		"gpt_tokens_header":        "AI token usage:\n",
+		"gpt_tokens_chat":          "Chat: today %s, month %s\n",
+		"gpt_tokens_user":          "You: today %s, month %s\n",
+		"gpt_tokens_top_models":    "Models:\n",
+		"gpt_tokens_top_users":     "Users:\n",
+		"quota_unlimited":          "no limit",
+		"gpt_button_regenerate":    "Regenerate",
+		"gpt_button_continue":      "Continue",
+		"gpt_button_shorter":       "Shorter",
+		"gpt_button_model":         "Another model",
+		"gpt_answer_expired":       "This answer can no longer be changed.",
+		"gpt_moderation_blocked":   "The answer was withheld.",
+		"admin_moderation_header":  "Moderation log:\n",
+		"admin_moderation_format":  "- %s: %s %s in chat %d, user %d (%s)\n  %s\n",
 	})
 }
 
 func newTestCommandsConfig() *config.CommandsConfig {
 	return &config.CommandsConfig{
-		Start:    "start",
-		Help:     "help",
-		Gpt:      "gpt",
-		Remind:   "remind",
-		Meme:     "meme",
-		Sticker:  "sticker",
-		Fact:     "fact",
-		Roulette: "roulette",
-		Tts:      "tts",
-		Lang:     "lang",
+		Start:      "start",
+		Help:       "help",
+		Gpt:        "gpt",
+		Remind:     "remind",
+		Meme:       "meme",
+		Sticker:    "sticker",
+		Fact:       "fact",
+		Roulette:   "roulette",
+		Tts:        "tts",
+		Lang:       "lang",
+		Usage:      "usage",
+		Transcribe: "transcribe",
+		Translate:  "translate",
 	}
 }
 
@@ -187,7 +290,10 @@ func TestHandleHelpAllCommandsListed(t *testing.T) {
 		"/fact",
 		"/roulette",
 		"/tts",
+		"/transcribe",
+		"/translate",
 		"/lang",
+		"/usage",
 	}
 
 	for _, cmd := range expectedCommands {
@@ -243,7 +349,10 @@ func TestHandleHelpCommandDescriptions(t *testing.T) {
 		"Get a random fact",
 		"Daily winner roulette",
 		"Convert text to speech",
+		"Transcribe a voice message",
+		"Translate a message",
 		"Change chat language",
+		"Command usage statistics",
 	}
 
 	for _, desc := range expectedDescriptions {
@@ -253,33 +362,123 @@ func TestHandleHelpCommandDescriptions(t *testing.T) {
 	}
 }
 
+func newMockService(repos app.Repositories) *app.Service {
+	if repos.Chats == nil {
+		repos.Chats = &mockChatRepo{}
+	}
+	if repos.Users == nil {
+		repos.Users = &mockUserRepo{}
+	}
+	if repos.Reminders == nil {
+		repos.Reminders = &mockReminderRepo{}
+	}
+	if repos.Facts == nil {
+		repos.Facts = &mockFactRepo{}
+	}
+	if repos.Stickers == nil {
+		repos.Stickers = &mockStickerRepo{}
+	}
+	if repos.Subreddits == nil {
+		repos.Subreddits = &mockSubredditRepo{}
+	}
+	if repos.Stats == nil {
+		repos.Stats = &mockStatRepo{}
+	}
+	if repos.Bans == nil {
+		repos.Bans = &mockBanRepo{}
+	}
+	if repos.Usage == nil {
+		repos.Usage = &mockUsageRepo{}
+	}
+	if repos.Tokens == nil {
+		repos.Tokens = &mockTokenRepo{}
+	}
+	if repos.Messages == nil {
+		repos.Messages = &mockMessageRepo{}
+	}
+	if repos.Moderation == nil {
+		repos.Moderation = &mockModerationRepo{}
+	}
+	return app.NewService(repos)
+}
+
 func newTestServiceForHandlers() *app.Service {
-	return app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	return newMockService(app.Repositories{})
 }
 
 func newTestBotHandlers(client *Client, svc *app.Service) *BotHandlers {
-	return NewBotHandlers(client, svc, nil, nil, newTestTranslator(), nil, newTestCommandsConfig(), "")
+	return NewBotHandlers(client, svc, nil, nil, newTestTranslator(), nil, newTestCommandsConfig(), "", nil)
 }
 
 func newTestBotHandlersWithGPT(client *Client, svc *app.Service, gpt *groq.Client) *BotHandlers {
 	return &BotHandlers{
 		client:  client,
 		service: svc,
-		gpt:     gpt,
+		gpt:     llm.NewRegistry(gpt),
 		cache:   nil,
 		t:       newTestTranslator(),
 		tts:     nil,
 	}
 }
 
+func newTestLLMHandlers(t *testing.T, settings *model.ChatSettings, llmReply string) (*BotHandlers, *fakeLLMChat) {
+	t.Helper()
+	chat := &fakeLLMChat{}
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/models"):
+			http.NotFound(w, r)
+		case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+			var req groq.Request
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			chat.requests = append(chat.requests, llmRequest{system: req.Messages[0].Content, prompt: req.Messages[len(req.Messages)-1].Content})
+			_ = json.NewEncoder(w).Encode(groq.Response{Choices: []groq.Choice{{Message: groq.Message{Role: "assistant", Content: llmReply}}}})
+		case r.URL.Path == getChatMemberCMD:
+			_ = json.NewEncoder(w).Encode(ChatMemberResponse{Ok: true, Result: ChatMember{Status: memberStatusAdministrator}})
+		case strings.HasSuffix(r.URL.Path, sendMessageCMD), strings.HasSuffix(r.URL.Path, editMessageCMD):
+			chat.sent = append(chat.sent, sentWithButtons(decodeJSONPayload(t, r)))
+			_ = json.NewEncoder(w).Encode(MessageResponse{Ok: true, Result: Message{MessageID: 99}})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": true})
+		}
+	})
+
+	chats := &mockChatRepo{
+		getSettingsFunc: func(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+			copied := *settings
+			copied.ChatID = chatID
+			return &copied, nil
+		},
+		saveSettingsFunc: func(ctx context.Context, saved *model.ChatSettings) error {
+			*settings = *saved
+			return nil
+		},
+	}
+	svc := newMockService(app.Repositories{Chats: chats})
+	gpt := groq.NewCompatibleClient("local", server.URL, "", "llama", []string{"llama"})
+	return newTestBotHandlersWithGPT(newTestClient(server.URL), svc, gpt), chat
+}
+
+func sentWithButtons(payload map[string]any) string {
+	text := payload["text"].(string)
+	if markup, ok := payload["reply_markup"].(map[string]any); ok {
+		for _, row := range markup["inline_keyboard"].([]any) {
+			for _, button := range row.([]any) {
+				text += " [" + button.(map[string]any)["callback_data"].(string) + "]"
+			}
+		}
+	}
+	return text
+}
+
+func (c *fakeLLMChat) prompts() []string {
+	var prompts []string
+	for _, req := range c.requests {
+		prompts = append(prompts, req.prompt)
+	}
+	return prompts
+}
+
 func TestHandleTTSNoText(t *testing.T) {
 	var sentMessage string
 	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
@@ -451,15 +650,7 @@ func TestHandleRemindSuccess(t *testing.T) {
 			return nil
 		},
 	}
-	svc := app.NewService(
-		mockChat,
-		mockUser,
-		mockReminder,
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Chats: mockChat, Users: mockUser, Reminders: mockReminder})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -494,15 +685,7 @@ func TestHandleRemindListEmpty(t *testing.T) {
 			return []*model.Reminder{}, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		mockReminder,
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Reminders: mockReminder})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -540,15 +723,7 @@ func TestHandleRemindListWithReminders(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		mockReminder,
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Reminders: mockReminder})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -611,15 +786,7 @@ func TestHandleRemindDeleteSuccess(t *testing.T) {
 			return nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		mockReminder,
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Reminders: mockReminder})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -654,15 +821,7 @@ func TestHandleRouletteNoUsers(t *testing.T) {
 			return nil, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		mockUser,
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		mockStat,
-	)
+	svc := newMockService(app.Repositories{Users: mockUser, Stats: mockStat})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -705,15 +864,7 @@ func TestHandleRouletteWithWinner(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		mockStat,
-	)
+	svc := newMockService(app.Repositories{Stats: mockStat})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -751,15 +902,7 @@ func TestHandleRouletteYear(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		mockStat,
-	)
+	svc := newMockService(app.Repositories{Stats: mockStat})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -797,15 +940,7 @@ func TestHandleRouletteAll(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		mockStat,
-	)
+	svc := newMockService(app.Repositories{Stats: mockStat})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -908,27 +1043,35 @@ func TestHandleGPTImageNoPrompt(t *testing.T) {
 }
 
 func TestHandleGPTImageSuccess(t *testing.T) {
-	var photoURL string
-	var caption string
+	png := []byte("\x89PNG\r\n\x1a\n fake image data")
+	var imagePath, imageQuery, filename, caption string
+	var uploaded []byte
 	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
-		payload := decodeJSONPayload(t, r)
-		if url, ok := payload["photo"].(string); ok {
-			photoURL = url
-		}
-		if cap, ok := payload["caption"].(string); ok {
-			caption = cap
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/prompt/"):
+			imagePath, imageQuery = r.URL.Path, r.URL.RawQuery
+			_, _ = w.Write(png)
+		case strings.HasSuffix(r.URL.Path, sendPhotoCMD):
+			if err := r.ParseMultipartForm(10 << 20); err != nil {
+				t.Fatalf("failed to parse multipart form: %v", err)
+			}
+			caption = r.FormValue("caption")
+			file, header, err := r.FormFile("photo")
+			if err != nil {
+				t.Fatalf("failed to get photo: %v", err)
+			}
+			defer func() { _ = file.Close() }()
+			filename = header.Filename
+			uploaded, _ = io.ReadAll(file)
 		}
-		w.WriteHeader(http.StatusOK)
 	})
 
-	client := newTestClient(server.URL)
-	svc := newTestServiceForHandlers()
-	gpt := groq.NewClient("test-key")
-	handlers := newTestBotHandlersWithGPT(client, svc, gpt)
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), newTestServiceForHandlers(), groq.NewClient("test-key"))
+	handlers.SetImageGenerator(imagegen.NewChain(imagegen.NewPollinations(server.URL+"/prompt/", time.Second)))
 
 	update := &Update{
 		Message: &Message{
-			Text: "/gpt image a cute cat",
+			Text: "/gpt image --wide a cute cat",
 			Chat: &Chat{ID: 123},
 		},
 	}
@@ -938,20 +1081,44 @@ func TestHandleGPTImageSuccess(t *testing.T) {
 		t.Fatalf("HandleGPT() error = %v", err)
 	}
 
-	if !strings.Contains(photoURL, "image.pollinations.ai") {
-		t.Errorf("expected pollinations URL, got: %s", photoURL)
-	}
-	if !strings.Contains(photoURL, "width=1024") {
-		t.Errorf("expected width param in URL, got: %s", photoURL)
+	if imagePath != "/prompt/a+cute+cat" || !strings.Contains(imageQuery, "width=1792&height=1024") {
+		t.Errorf("image request = %s?%s, want wide prompt URL", imagePath, imageQuery)
 	}
-	if !strings.Contains(photoURL, "nologo=true") {
-		t.Errorf("expected nologo param in URL, got: %s", photoURL)
+	if string(uploaded) != string(png) || filename != "image.png" {
+		t.Errorf("uploaded %q as %q, want generated PNG", uploaded, filename)
 	}
 	if caption != "a cute cat" {
 		t.Errorf("expected caption 'a cute cat', got: %s", caption)
 	}
 }
 
+func TestHandleGPTImageError(t *testing.T) {
+	var sentMessage string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/prompt/"):
+			_, _ = w.Write([]byte("<html>queue full</html>"))
+		case strings.HasSuffix(r.URL.Path, sendPhotoCMD):
+			t.Error("photo should not be sent when generation fails")
+		case strings.HasSuffix(r.URL.Path, sendMessageCMD):
+			sentMessage = decodeJSONPayload(t, r)["text"].(string)
+		}
+	})
+
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), newTestServiceForHandlers(), groq.NewClient("test-key"))
+	chain := imagegen.NewChain(imagegen.NewPollinations(server.URL+"/prompt/", time.Second))
+	chain.SetRetryPolicy(1, time.Millisecond)
+	handlers.SetImageGenerator(chain)
+
+	update := &Update{Message: &Message{Text: "/gpt image a cute cat", Chat: &Chat{ID: 123}}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if sentMessage != "Couldn't generate the image." {
+		t.Errorf("sent = %q, want image error", sentMessage)
+	}
+}
+
 func TestFormatPromptWithUsername(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1042,15 +1209,7 @@ func TestHandleFactNoFacts(t *testing.T) {
 			return nil, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		&mockReminderRepo{},
-		mockFact,
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Facts: mockFact})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -1084,15 +1243,7 @@ func TestHandleFactWithFact(t *testing.T) {
 			return &model.Fact{Comment: "The sky is blue"}, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		&mockReminderRepo{},
-		mockFact,
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Facts: mockFact})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -1155,15 +1306,7 @@ func TestHandleStickerNoStickers(t *testing.T) {
 			return nil, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		mockSticker,
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Stickers: mockSticker})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -1200,15 +1343,7 @@ func TestHandleStickerList(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		mockSticker,
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Stickers: mockSticker})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -1274,15 +1409,7 @@ func TestHandleMemeListSubreddits(t *testing.T) {
 			return []*model.Subreddit{{Name: "funny"}, {Name: "memes"}}, nil
 		},
 	}
-	svc := app.NewService(
-		&mockChatRepo{},
-		&mockUserRepo{},
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		mockSub,
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Subreddits: mockSub})
 	handlers := newTestBotHandlers(client, svc)
 
 	update := &Update{
@@ -1416,41 +1543,33 @@ func TestIsAnimatedURL(t *testing.T) {
 	}
 }
 
-func TestBuildImageURL(t *testing.T) {
+func TestParseImageArgs(t *testing.T) {
 	tests := []struct {
-		name         string
-		prompt       string
-		wantContains []string
+		name       string
+		args       string
+		wantPrompt string
+		wantOpts   imagegen.Options
+		wantOK     bool
 	}{
-		{
-			name:   "simplePrompt",
-			prompt: "a cat",
-			wantContains: []string{
-				"https://image.pollinations.ai/prompt/a+cat",
-				"width=1024",
-				"height=1024",
-				"nologo=true",
-				"enhance=true",
-				"seed=",
-			},
-		},
-		{
-			name:   "promptWithSpecialChars",
-			prompt: "a cat & dog",
-			wantContains: []string{
-				"a+cat+%26+dog",
-				"width=1024",
-			},
-		},
+		{name: "Default", args: "a cat", wantPrompt: "a cat", wantOpts: imagegen.Options{Width: 1024, Height: 1024}, wantOK: true},
+		{name: "Wide", args: "--wide a cat", wantPrompt: "a cat", wantOpts: imagegen.Options{Width: 1792, Height: 1024}, wantOK: true},
+		{name: "Tall", args: "--TALL a cat", wantPrompt: "a cat", wantOpts: imagegen.Options{Width: 1024, Height: 1792}, wantOK: true},
+		{name: "Size", args: "--768x512 a cat", wantPrompt: "a cat", wantOpts: imagegen.Options{Width: 768, Height: 512}, wantOK: true},
+		{name: "LastFlagWins", args: "--wide --square a cat", wantPrompt: "a cat", wantOpts: imagegen.Options{Width: 1024, Height: 1024}, wantOK: true},
+		{name: "FlagInsidePrompt", args: "a cat --wide", wantPrompt: "a cat --wide", wantOpts: imagegen.Options{Width: 1024, Height: 1024}, wantOK: true},
+		{name: "UnknownFlag", args: "--huge a cat", wantOK: false},
+		{name: "SizeOutOfRange", args: "--9000x100 a cat", wantOK: false},
+		{name: "OnlyFlags", args: "--wide", wantOK: false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := buildImageURL(tt.prompt)
-			for _, want := range tt.wantContains {
-				if !strings.Contains(got, want) {
-					t.Errorf("buildImageURL() = %q, should contain %q", got, want)
-				}
+			prompt, opts, ok := parseImageArgs(tt.args)
+			if ok != tt.wantOK {
+				t.Fatalf("parseImageArgs(%q) ok = %v, want %v", tt.args, ok, tt.wantOK)
+			}
+			if ok && (prompt != tt.wantPrompt || opts != tt.wantOpts) {
+				t.Errorf("parseImageArgs(%q) = %q, %+v, want %q, %+v", tt.args, prompt, opts, tt.wantPrompt, tt.wantOpts)
 			}
 		})
 	}
@@ -1460,7 +1579,7 @@ func newTestBotHandlersWithGPTAndCache(client *Client, svc *app.Service, gpt *gr
 	return &BotHandlers{
 		client:  client,
 		service: svc,
-		gpt:     gpt,
+		gpt:     llm.NewRegistry(gpt),
 		cache:   cache,
 		t:       newTestTranslator(),
 		tts:     nil,
@@ -1569,6 +1688,30 @@ func TestHandleGPTModels(t *testing.T) {
 			t.Errorf("expected model %q in response, got: %s", model, sentMessage)
 		}
 	}
+	if !strings.Contains(sentMessage, "→ 1. llama-3.3-70b-versatile · 128k 🛠") {
+		t.Errorf("expected default model marked with metadata, got: %s", sentMessage)
+	}
+}
+
+func TestResolveModelName(t *testing.T) {
+	catalog := []llm.ModelInfo{{Ref: "groq:llama"}, {Ref: "local:qwen2"}}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "2", want: "local:qwen2"},
+		{input: "1", want: "groq:llama"},
+		{input: "3", want: "3"},
+		{input: "0", want: "0"},
+		{input: "local:qwen2", want: "local:qwen2"},
+	}
+
+	for _, tt := range tests {
+		if got := resolveModelName(catalog, tt.input); got != tt.want {
+			t.Errorf("resolveModelName(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
 }
 
 func TestRegisterChatUser(t *testing.T) {
@@ -1634,15 +1777,7 @@ func TestRegisterChatUser(t *testing.T) {
 				},
 			}
 
-			svc := app.NewService(
-				mockChat,
-				mockUser,
-				&mockReminderRepo{},
-				&mockFactRepo{},
-				&mockStickerRepo{},
-				&mockSubredditRepo{},
-				&mockStatRepo{},
-			)
+			svc := newMockService(app.Repositories{Chats: mockChat, Users: mockUser})
 
 			server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
@@ -1679,15 +1814,7 @@ func TestRouletteRegistersUser(t *testing.T) {
 		},
 	}
 
-	svc := app.NewService(
-		mockChat,
-		mockUser,
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	svc := newMockService(app.Repositories{Chats: mockChat, Users: mockUser})
 
 	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -1712,3 +1839,25 @@ func TestRouletteRegistersUser(t *testing.T) {
 		t.Error("expected user to be saved on /roulette")
 	}
 }
+
+func TestGPTErrorKey(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want i18n.Key
+	}{
+		{name: "RateLimited", err: &groq.APIError{Kind: groq.ErrRateLimited}, want: i18n.KeyGptErrorRateLimit},
+		{name: "Overloaded", err: groq.ErrOverloaded, want: i18n.KeyGptErrorOverloaded},
+		{name: "ContextTooLong", err: groq.ErrContextTooLong, want: i18n.KeyGptErrorContext},
+		{name: "Auth", err: groq.ErrAuth, want: i18n.KeyGptErrorAuth},
+		{name: "Unknown", err: context.DeadlineExceeded, want: i18n.KeyGptError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gptErrorKey(tt.err); got != tt.want {
+				t.Errorf("gptErrorKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"got/internal/app/model"
+)
+
+const (
+	knowledgeSnippets     = 5
+	maxSnippetRunes       = 300
+	snippetTimeFormat     = "2006-01-02 15:04"
+	knowledgePromptHeader = "Notes retrieved from this chat's saved facts and message history that may be relevant. " +
+		"Use them only if they help answer, and cite the ones you use by number, like [1]."
+)
+
+func (h *BotHandlers) withKnowledge(ctx context.Context, chatID int64, systemPrompt, prompt string) string {
+	snippets, err := h.service.SearchKnowledge(ctx, chatID, prompt, knowledgeSnippets)
+	if err != nil {
+		log.WarnContext(ctx, "Knowledge search failed, answering without it", "chat_id", chatID, "error", err)
+		return systemPrompt
+	}
+	if len(snippets) == 0 {
+		return systemPrompt
+	}
+
+	log.DebugContext(ctx, "Injected chat knowledge", "chat_id", chatID, "snippets", len(snippets))
+	return systemPrompt + "\n\n" + formatKnowledge(snippets)
+}
+
+func formatKnowledge(snippets []*model.KnowledgeSnippet) string {
+	var b strings.Builder
+	b.WriteString(knowledgePromptHeader)
+	for i, s := range snippets {
+		text := strings.Join(strings.Fields(s.Text), " ")
+		if runes := []rune(text); len(runes) > maxSnippetRunes {
+			text = string(runes[:maxSnippetRunes]) + "…"
+		}
+
+		if s.Source == model.SnippetSourceFact {
+			fmt.Fprintf(&b, "\n[%d] fact #%d: %s", i+1, s.ID, text)
+			continue
+		}
+		name := s.Username
+		if name == "" {
+			name = "someone"
+		}
+		fmt.Fprintf(&b, "\n[%d] %s, %s: %s", i+1, name, s.CreatedAt.Format(snippetTimeFormat), text)
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+)
+
+func TestFormatKnowledge(t *testing.T) {
+	snippets := []*model.KnowledgeSnippet{
+		{Source: model.SnippetSourceMessage, ID: 9, Username: "tomas", Text: "the server\nruns out of disk every Friday", CreatedAt: time.Date(2024, 5, 3, 18, 4, 0, 0, time.UTC)},
+		{Source: model.SnippetSourceFact, ID: 3, Text: strings.Repeat("x", maxSnippetRunes+10)},
+	}
+
+	got := formatKnowledge(snippets)
+
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3 || lines[0] != knowledgePromptHeader {
+		t.Fatalf("formatKnowledge() = %q, want header and two snippets", got)
+	}
+	if lines[1] != "[1] tomas, 2024-05-03 18:04: the server runs out of disk every Friday" {
+		t.Errorf("message snippet = %q", lines[1])
+	}
+	if lines[2] != "[2] fact #3: "+strings.Repeat("x", maxSnippetRunes)+"…" {
+		t.Errorf("fact snippet = %q, want truncated fact", lines[2])
+	}
+}
+
+func TestHandleGPTChatInjectsKnowledge(t *testing.T) {
+	var systemPrompt string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			var req groq.Request
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if len(req.Messages) > 0 && req.Messages[0].Role == "system" {
+				systemPrompt = req.Messages[0].Content
+			}
+			_ = json.NewEncoder(w).Encode(groq.Response{
+				Choices: []groq.Choice{{Message: groq.Message{Role: "assistant", Content: "He said it is full [1]."}}},
+			})
+		}
+	})
+
+	var gotQuery string
+	facts := &mockFactRepo{
+		searchFunc: func(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+			gotQuery = query
+			return []*model.KnowledgeSnippet{{Source: model.SnippetSourceFact, ID: 3, Text: "Tomas runs the server", Rank: 0.4}}, nil
+		},
+	}
+	svc := newMockService(app.Repositories{Facts: facts})
+	gpt := groq.NewCompatibleClient("local", server.URL, "", "llama", []string{"llama"})
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), svc, gpt)
+
+	update := &Update{Message: &Message{Text: "/gpt what did Tomas say about the server?", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if gotQuery != "what did Tomas say about the server?" {
+		t.Errorf("search query = %q, want the raw prompt", gotQuery)
+	}
+	if !strings.Contains(systemPrompt, "[1] fact #3: Tomas runs the server") {
+		t.Errorf("system prompt = %q, want injected fact", systemPrompt)
+	}
+}
@@ -0,0 +1,244 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+	"got/pkg/i18n"
+)
+
+const (
+	lurkerPass     = "PASS"
+	lurkerSelfName = "you"
+	lurkerPrompt   = "You are also a regular member of this group chat, and nobody has addressed you directly. " +
+		"Read the recent messages below. If you have something short, natural and relevant to add, reply with only that message, " +
+		"in the language of the conversation and without a name prefix. Your own earlier messages are marked as \"" + lurkerSelfName + "\". " +
+		"If there is nothing worth saying, reply with " + lurkerPass + "."
+)
+
+const (
+	lurkArgChance   = "chance"
+	lurkArgCooldown = "cooldown"
+	lurkArgQuiet    = "quiet"
+)
+
+type Lurker struct {
+	size  int
+	now   func() time.Time
+	roll  func() int
+	mu    sync.Mutex
+	chats map[int64]*lurkerChat
+}
+
+type lurkerChat struct {
+	recent    []string
+	lastReply time.Time
+}
+
+func NewLurker(contextSize int) *Lurker {
+	return &Lurker{
+		size:  contextSize,
+		now:   time.Now,
+		roll:  func() int { return rand.IntN(100) },
+		chats: make(map[int64]*lurkerChat),
+	}
+}
+
+func (l *Lurker) observe(chatID int64, line string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	chat := l.chat(chatID)
+	chat.recent = append(chat.recent, line)
+	if len(chat.recent) > l.size {
+		chat.recent = chat.recent[len(chat.recent)-l.size:]
+	}
+	return append([]string(nil), chat.recent...)
+}
+
+func (l *Lurker) admit(chatID int64, settings model.LurkerSettings) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	chat := l.chat(chatID)
+	now := l.now()
+	if now.Before(settings.QuietUntil) || now.Sub(chat.lastReply) < settings.Cooldown {
+		return false
+	}
+	if l.roll() >= settings.Chance {
+		return false
+	}
+	chat.lastReply = now
+	return true
+}
+
+func (l *Lurker) forget(chatID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.chats, chatID)
+}
+
+func (l *Lurker) chat(chatID int64) *lurkerChat {
+	chat, ok := l.chats[chatID]
+	if !ok {
+		chat = &lurkerChat{}
+		l.chats[chatID] = chat
+	}
+	return chat
+}
+
+func (h *BotHandlers) SetLurker(lurker *Lurker) {
+	h.lurker = lurker
+}
+
+func (h *BotHandlers) lurk(ctx context.Context, msg *Message, settings model.LurkerSettings) {
+	if h.lurker == nil || h.gpt == nil || msg.Chat.Type == chatTypePrivate || msg.From == nil || msg.From.IsBot {
+		return
+	}
+	text := strings.TrimSpace(messageText(msg))
+	if text == "" || strings.HasPrefix(text, "/") {
+		return
+	}
+
+	chatID := msg.Chat.ID
+	if !settings.Enabled {
+		return
+	}
+	lines := h.lurker.observe(chatID, formatPromptWithUsername(userName(msg.From), text))
+	if !h.lurker.admit(chatID, settings) {
+		return
+	}
+	if blocked := h.quotaMessage(ctx, msg); blocked != "" {
+		return
+	}
+
+	systemPrompt, err := h.service.BuildSystemPrompt(ctx, chatID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to build system prompt, using default", "error", err)
+	}
+	result, err := h.gpt.Complete(ctx, h.getChatModel(ctx, chatID), groq.ChatRequest{
+		SystemPrompt: systemPrompt + "\n\n" + lurkerPrompt,
+		Prompt:       strings.Join(lines, "\n"),
+	})
+	if err != nil {
+		log.WarnContext(ctx, "Lurker request failed", "chat_id", chatID, "error", err)
+		return
+	}
+	h.recordTokenUsage(ctx, msg, result)
+
+	reply := strings.TrimSpace(result.Content)
+	if reply == "" || strings.EqualFold(strings.Trim(reply, ". "), lurkerPass) {
+		log.DebugContext(ctx, "Lurker chose not to reply", "chat_id", chatID)
+		return
+	}
+	if _, allowed := h.moderate(ctx, msg, model.ModerationSourceGPT, reply); !allowed {
+		return
+	}
+
+	h.lurker.observe(chatID, formatPromptWithUsername(lurkerSelfName, reply))
+	if err := h.client.SendMessage(ctx, chatID, reply); err != nil {
+		log.WarnContext(ctx, "Failed to send lurker reply", "chat_id", chatID, "error", err)
+	}
+}
+
+func (h *BotHandlers) handleGPTLurk(ctx context.Context, msg *Message, args string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+	if h.lurker == nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLurkUnavailable))
+	}
+
+	fields := strings.Fields(strings.ToLower(args))
+	if len(fields) == 0 {
+		return h.showLurker(ctx, chatID)
+	}
+	if !h.canManageChat(ctx, msg) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLurkAdminOnly))
+	}
+
+	var err error
+	switch {
+	case len(fields) == 1 && (fields[0] == switchOn || fields[0] == switchOff):
+		enabled := fields[0] == switchOn
+		if err = h.service.SetLurker(ctx, chatID, enabled); err == nil {
+			if !enabled {
+				h.lurker.forget(chatID)
+			}
+			return h.showLurker(ctx, chatID)
+		}
+	case len(fields) == 2 && fields[0] == lurkArgChance:
+		chance, convErr := strconv.Atoi(strings.TrimSuffix(fields[1], "%"))
+		if convErr != nil {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLurkUsage))
+		}
+		if err = h.service.SetLurkerChance(ctx, chatID, chance); err == nil {
+			return h.showLurker(ctx, chatID)
+		}
+	case len(fields) == 2 && fields[0] == lurkArgCooldown:
+		cooldown, parseErr := ParseDuration(fields[1])
+		if parseErr != nil {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLurkUsage))
+		}
+		if err = h.service.SetLurkerCooldown(ctx, chatID, cooldown); err == nil {
+			return h.showLurker(ctx, chatID)
+		}
+	case len(fields) == 2 && fields[0] == lurkArgQuiet:
+		quiet, parseErr := ParseDuration(fields[1])
+		if parseErr != nil || quiet <= 0 {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLurkUsage))
+		}
+		if err = h.service.SetLurkerQuiet(ctx, chatID, h.lurker.now().Add(quiet)); err == nil {
+			return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyGptLurkQuiet), formatLurkDuration(quiet)))
+		}
+	default:
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLurkUsage))
+	}
+
+	if errors.Is(err, app.ErrInvalidLurkerChance) || errors.Is(err, app.ErrInvalidLurkerCooldown) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLurkUsage))
+	}
+	log.ErrorContext(ctx, "Failed to save lurker settings", "chat_id", chatID, "error", err)
+	return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLurkError))
+}
+
+func (h *BotHandlers) showLurker(ctx context.Context, chatID int64) error {
+	t := h.getTranslator(ctx, chatID)
+	settings, err := h.service.GetLurkerSettings(ctx, chatID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load lurker settings", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLurkError))
+	}
+
+	text := t.Get(lurkModeKey(settings.Enabled)) + "\n" + fmt.Sprintf(t.Get(i18n.KeyGptLurkSettings), settings.Chance, formatLurkDuration(settings.Cooldown))
+	if left := settings.QuietUntil.Sub(h.lurker.now()); settings.Enabled && left > 0 {
+		text += "\n" + fmt.Sprintf(t.Get(i18n.KeyGptLurkQuietLeft), formatLurkDuration(left))
+	}
+	return h.client.SendMessage(ctx, chatID, text)
+}
+
+func lurkModeKey(enabled bool) i18n.Key {
+	if enabled {
+		return i18n.KeyGptLurkOn
+	}
+	return i18n.KeyGptLurkOff
+}
+
+func formatLurkDuration(d time.Duration) string {
+	s := d.Round(time.Second).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,167 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"got/internal/app/model"
+)
+
+var lurkStart = time.Date(2024, 5, 3, 18, 0, 0, 0, time.UTC)
+
+func newTestLurker(start time.Time, rolls ...int) (*Lurker, *time.Time) {
+	now := start
+	lurker := NewLurker(3)
+	lurker.now = func() time.Time { return now }
+	lurker.roll = func() int {
+		roll := rolls[0]
+		rolls = rolls[1:]
+		return roll
+	}
+	return lurker, &now
+}
+
+func newTestLurkerHandlers(t *testing.T, settings *model.ChatSettings, lurker *Lurker, llmReply string) (*BotHandlers, *fakeLLMChat) {
+	t.Helper()
+	handlers, chat := newTestLLMHandlers(t, settings, llmReply)
+	handlers.service.SetLurkerDefaults(5, 30*time.Minute)
+	handlers.SetLurker(lurker)
+	return handlers, chat
+}
+
+func TestLurkerAdmit(t *testing.T) {
+	start := time.Date(2024, 5, 3, 18, 0, 0, 0, time.UTC)
+	settings := model.LurkerSettings{Enabled: true, Chance: 10, Cooldown: 30 * time.Minute}
+	lurker, now := newTestLurker(start, 50, 5, 0, 0)
+
+	if lurker.admit(testChatID, settings) {
+		t.Error("admit() = true for a roll above the chance")
+	}
+	if !lurker.admit(testChatID, settings) {
+		t.Error("admit() = false for a roll below the chance")
+	}
+	*now = start.Add(10 * time.Minute)
+	if lurker.admit(testChatID, settings) {
+		t.Error("admit() = true within the cooldown")
+	}
+	*now = start.Add(31 * time.Minute)
+	settings.QuietUntil = start.Add(time.Hour)
+	if lurker.admit(testChatID, settings) {
+		t.Error("admit() = true while quiet")
+	}
+	*now = start.Add(2 * time.Hour)
+	if !lurker.admit(testChatID, settings) {
+		t.Error("admit() = false after the quiet period")
+	}
+}
+
+func TestLurkerObserveKeepsRecent(t *testing.T) {
+	lurker := NewLurker(3)
+
+	for _, line := range []string{"a: 1", "b: 2", "a: 3"} {
+		lurker.observe(testChatID, line)
+	}
+	got := lurker.observe(testChatID, "b: 4")
+
+	if strings.Join(got, "|") != "b: 2|a: 3|b: 4" {
+		t.Errorf("observe() = %q, want the last three lines", got)
+	}
+}
+
+func TestHandleMessageLurker(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		chatType    string
+		llmReply    string
+		wantPrompts int
+		wantSent    []string
+	}{
+		{name: "Replies", enabled: true, chatType: "group", llmReply: "Friday deploys again?", wantPrompts: 1, wantSent: []string{"Friday deploys again?"}},
+		{name: "Passes", enabled: true, chatType: "group", llmReply: "PASS.", wantPrompts: 1},
+		{name: "Disabled", enabled: false, chatType: "group"},
+		{name: "PrivateChat", enabled: true, chatType: chatTypePrivate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &model.ChatSettings{ChatID: testChatID, Lurker: tt.enabled}
+			lurker, _ := newTestLurker(time.Now(), 0)
+			handlers, chat := newTestLurkerHandlers(t, settings, lurker, tt.llmReply)
+			lurker.observe(testChatID, "alice: the deploy broke")
+
+			msg := &Message{MessageID: 5, Text: "who deployed on Friday?", Chat: &Chat{ID: testChatID, Type: tt.chatType}, From: &User{ID: 42, UserName: "tomas"}}
+			err := handlers.HandleMessage(context.Background(), &Update{Message: msg})
+
+			assertNoError(t, err)
+			if len(chat.requests) != tt.wantPrompts {
+				t.Fatalf("made %d LLM requests, want %d", len(chat.requests), tt.wantPrompts)
+			}
+			if tt.wantPrompts > 0 && chat.requests[0].prompt != "alice: the deploy broke\ntomas: who deployed on Friday?" {
+				t.Errorf("prompt = %q, want recent messages", chat.requests[0].prompt)
+			}
+			if strings.Join(chat.sent, "|") != strings.Join(tt.wantSent, "|") {
+				t.Errorf("sent = %q, want %q", chat.sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestHandleGPTLurk(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		settings model.ChatSettings
+		wantSent string
+		want     model.ChatSettings
+	}{
+		{name: "Status", text: "/gpt lurk", wantSent: "Lurker off.\nChance 5%, cooldown 30m."},
+		{name: "Enable", text: "/gpt lurk on", wantSent: "Lurker on.\nChance 5%, cooldown 30m.", want: model.ChatSettings{Lurker: true}},
+		{name: "Chance", text: "/gpt lurk chance 15%", wantSent: "Lurker off.\nChance 15%, cooldown 30m.", want: model.ChatSettings{LurkerChance: 15}},
+		{name: "Cooldown", text: "/gpt lurk cooldown 1h30m", wantSent: "Lurker off.\nChance 5%, cooldown 1h30m.", want: model.ChatSettings{LurkerCooldown: 90 * time.Minute}},
+		{name: "InvalidChance", text: "/gpt lurk chance 0", wantSent: "Usage: /gpt lurk [on|off | chance N | cooldown D | quiet D]"},
+		{name: "Quiet", text: "/gpt lurk quiet 2h", wantSent: "Quiet for 2h.", want: model.ChatSettings{LurkerQuietUntil: lurkStart.Add(2 * time.Hour)}},
+		{name: "QuietStatus", text: "/gpt lurk", settings: model.ChatSettings{Lurker: true, LurkerQuietUntil: lurkStart.Add(time.Hour)}, wantSent: "Lurker on.\nChance 5%, cooldown 30m.\nQuiet for another 1h.", want: model.ChatSettings{Lurker: true, LurkerQuietUntil: lurkStart.Add(time.Hour)}},
+		{name: "DisableClearsQuiet", text: "/gpt lurk off", settings: model.ChatSettings{Lurker: true, LurkerQuietUntil: lurkStart.Add(time.Hour)}, wantSent: "Lurker off.\nChance 5%, cooldown 30m."},
+		{name: "Unknown", text: "/gpt lurk loudly", wantSent: "Usage: /gpt lurk [on|off | chance N | cooldown D | quiet D]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &tt.settings
+			settings.ChatID = testChatID
+			lurker, _ := newTestLurker(lurkStart)
+			handlers, chat := newTestLurkerHandlers(t, settings, lurker, "")
+
+			update := &Update{Message: &Message{Text: tt.text, Chat: &Chat{ID: testChatID, Type: "group"}, From: &User{ID: 42}}}
+			err := handlers.HandleGPT(context.Background(), update)
+
+			assertNoError(t, err)
+			if len(chat.sent) != 1 || chat.sent[0] != tt.wantSent {
+				t.Errorf("sent = %q, want %q", chat.sent, tt.wantSent)
+			}
+			tt.want.ChatID = testChatID
+			if *settings != tt.want {
+				t.Errorf("settings = %+v, want %+v", *settings, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLurkDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Minute:                "30m",
+		2 * time.Hour:                   "2h",
+		90 * time.Minute:                "1h30m",
+		45 * time.Second:                "45s",
+		time.Hour + 30*time.Millisecond: "1h",
+	}
+
+	for d, want := range tests {
+		if got := formatLurkDuration(d); got != want {
+			t.Errorf("formatLurkDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package telegram
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	markdownSpecial  = "_*`["
+	markdownPre      = "```"
+	markdownEllipsis = "…"
+	maxMarkdownClose = len("\n" + markdownPre)
+)
+
+var markdownWrappers = map[string][2]string{
+	"bold":   {"*", "*"},
+	"italic": {"_", "_"},
+	"code":   {"`", "`"},
+	"pre":    {"```\n", "\n```"},
+}
+
+func messageMarkdown(msg *Message) string {
+	if msg == nil {
+		return ""
+	}
+	if msg.Text != "" {
+		return entitiesToMarkdown(msg.Text, msg.Entities)
+	}
+	return entitiesToMarkdown(msg.Caption, msg.CaptionEntities)
+}
+
+func entitiesToMarkdown(text string, entities []MessageEntity) string {
+	units := utf16.Encode([]rune(text))
+	var sb strings.Builder
+	pos := 0
+	for _, e := range entities {
+		end := e.Offset + e.Length
+		prefix, suffix, ok := markdownWrapper(e)
+		if !ok || e.Offset < pos || end > len(units) || e.Length <= 0 {
+			continue
+		}
+
+		sb.WriteString(escapeMarkdown(string(utf16.Decode(units[pos:e.Offset]))))
+		sb.WriteString(prefix + string(utf16.Decode(units[e.Offset:end])) + suffix)
+		pos = end
+	}
+	sb.WriteString(escapeMarkdown(string(utf16.Decode(units[pos:]))))
+	return sb.String()
+}
+
+func markdownWrapper(e MessageEntity) (string, string, bool) {
+	if e.Type == "text_link" && e.URL != "" {
+		return "[", "](" + e.URL + ")", true
+	}
+	w, ok := markdownWrappers[e.Type]
+	return w[0], w[1], ok
+}
+
+func truncateMarkdown(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return closeMarkdown(runes[:max(limit-utf8.RuneCountInString(markdownEllipsis)-maxMarkdownClose, 0)])
+}
+
+func closeMarkdown(runes []rune) string {
+	closer, start := "", 0
+	for i := 0; i < len(runes); i++ {
+		switch {
+		case closer == markdownPre:
+			if hasRunePrefix(runes[i:], markdownPre) {
+				closer = ""
+				i += len(markdownPre) - 1
+			}
+		case closer == "]":
+			if runes[i] == ']' && i+1 < len(runes) && runes[i+1] == '(' {
+				closer = ")"
+				i++
+			}
+		case closer != "":
+			if string(runes[i]) == closer {
+				closer = ""
+			}
+		case runes[i] == '\\':
+			if i+1 == len(runes) {
+				runes = runes[:i]
+			}
+			i++
+		case hasRunePrefix(runes[i:], markdownPre):
+			closer, start = markdownPre, i
+			i += len(markdownPre) - 1
+		case runes[i] == '[':
+			closer, start = "]", i
+		case strings.ContainsRune("*_`", runes[i]):
+			closer, start = string(runes[i]), i
+		}
+	}
+
+	switch closer {
+	case "":
+		return string(runes) + markdownEllipsis
+	case "]", ")":
+		return string(runes[:start]) + markdownEllipsis
+	case markdownPre:
+		return string(runes) + markdownEllipsis + "\n" + markdownPre
+	default:
+		return string(runes) + markdownEllipsis + closer
+	}
+}
+
+func hasRunePrefix(runes []rune, prefix string) bool {
+	n := utf8.RuneCountInString(prefix)
+	return len(runes) >= n && string(runes[:n]) == prefix
+}
+
+func escapeMarkdown(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(markdownSpecial, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func unescapeMarkdown(s string) string {
+	var sb strings.Builder
+	escaped := false
+	for _, r := range s {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		if escaped && !strings.ContainsRune(markdownSpecial, r) {
+			sb.WriteByte('\\')
+		}
+		escaped = false
+		sb.WriteRune(r)
+	}
+	if escaped {
+		sb.WriteByte('\\')
+	}
+	return sb.String()
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
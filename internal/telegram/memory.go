@@ -0,0 +1,348 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+	"got/pkg/i18n"
+)
+
+const (
+	userScopeFmt   = "user:%d"
+	threadScopeFmt = "thread:%d"
+
+	memoryFormatText     = "text"
+	memoryFormatMarkdown = "md"
+	memoryFormatJSON     = "json"
+	memoryExportVersion  = 1
+	maxMemoryImportSize  = 1 << 20
+)
+
+type memoryExport struct {
+	Version    int            `json:"version"`
+	ChatID     int64          `json:"chat_id"`
+	Scope      string         `json:"scope"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Messages   []groq.Message `json:"messages"`
+}
+
+type historyScope struct {
+	kind string
+	key  string
+	root int
+}
+
+func (h *BotHandlers) handleGPTClear(ctx context.Context, msg *Message, arg string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	if subCommand(arg) == subCommandAll {
+		return h.handleGPTClearAll(ctx, msg)
+	}
+
+	if h.cache != nil {
+		scope, ok := h.managedScope(ctx, msg)
+		if !ok {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptThreadUnknown))
+		}
+		_ = h.cache.ClearHistory(ctx, chatID, scope.key)
+	}
+	return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptCleared))
+}
+
+func (h *BotHandlers) handleGPTClearAll(ctx context.Context, msg *Message) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	if !h.canManageChat(ctx, msg) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptClearAllAdminOnly))
+	}
+	if h.cache == nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryNoRedis))
+	}
+
+	cleared, err := h.cache.ClearAllHistory(ctx, chatID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to clear chat histories", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryNoRedis))
+	}
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyGptClearedAll), cleared))
+}
+
+func (h *BotHandlers) handleGPTMemory(ctx context.Context, msg *Message, arg string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+	if h.cache == nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryNoRedis))
+	}
+	if subCommand(arg) == subCommandImport {
+		return h.handleGPTMemoryImport(ctx, msg)
+	}
+
+	format := arg
+	if format == "" {
+		format = memoryFormatText
+	}
+	if format != memoryFormatText && format != memoryFormatMarkdown && format != memoryFormatJSON {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryUsage))
+	}
+
+	scope, ok := h.managedScope(ctx, msg)
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptThreadUnknown))
+	}
+	history, err := h.cache.GetHistory(ctx, chatID, scope.key)
+	if err != nil || len(history) == 0 {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryEmpty))
+	}
+
+	_ = h.client.SendChatAction(ctx, chatID, actionUploadDocument)
+
+	var content []byte
+	switch format {
+	case memoryFormatMarkdown:
+		content = []byte(formatHistoryAsMarkdown(history))
+	case memoryFormatJSON:
+		content, _ = json.MarshalIndent(memoryExport{
+			Version:    memoryExportVersion,
+			ChatID:     chatID,
+			Scope:      scope.kind,
+			ExportedAt: time.Now().UTC(),
+			Messages:   history,
+		}, "", "  ")
+	default:
+		content = []byte(formatHistoryAsText(history))
+	}
+
+	filename := fmt.Sprintf("chat_history_%d.%s", chatID, memoryFileExtension(format))
+	caption := t.Get(i18n.KeyGptMemoryCaption)
+
+	return h.client.SendDocument(ctx, chatID, content, filename, caption)
+}
+
+func (h *BotHandlers) handleGPTMemoryImport(ctx context.Context, msg *Message) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	var doc *Document
+	if msg.ReplyToMessage != nil {
+		doc = msg.ReplyToMessage.Document
+	}
+	if doc == nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryUsage))
+	}
+	if doc.FileSize > maxMemoryImportSize {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryImportInvalid))
+	}
+
+	data, err := h.client.DownloadFile(ctx, doc.FileID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to download memory import", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryImportError))
+	}
+	history, err := parseMemoryExport(data)
+	if err != nil {
+		log.DebugContext(ctx, "Rejected memory import", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryImportInvalid))
+	}
+
+	info := h.gpt.Describe(ctx, h.getChatModel(ctx, chatID))
+	systemPrompt, err := h.service.BuildSystemPrompt(ctx, chatID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to build system prompt, using default", "error", err)
+	}
+	summary, _ := groq.SplitSummary(history)
+	kept, dropped := groq.FitHistory(info.ID, history, groq.HistoryBudget(info.ID, info.ContextWindow, systemPrompt, "", info.Tools))
+	if len(kept) == 0 && summary == "" {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryImportInvalid))
+	}
+
+	scope, ok := h.managedScope(ctx, msg)
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptThreadUnknown))
+	}
+	if err := h.cache.SaveHistory(ctx, chatID, scope.key, groq.WithSummary(summary, kept)); err != nil {
+		log.ErrorContext(ctx, "Failed to save imported memory", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptMemoryImportError))
+	}
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyGptMemoryImported), len(kept), len(dropped)))
+}
+
+func (h *BotHandlers) handleGPTScope(ctx context.Context, msg *Message, arg string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	if arg == "" {
+		scope, err := h.service.GetMemoryScope(ctx, chatID)
+		if err != nil {
+			log.WarnContext(ctx, "Failed to load memory scope", "chat_id", chatID, "error", err)
+		}
+		return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyGptScopeCurrent), scope)+"\n\n"+t.Get(i18n.KeyGptScopeUsage))
+	}
+
+	if !h.canManageChat(ctx, msg) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptScopeAdminOnly))
+	}
+
+	err := h.service.SetMemoryScope(ctx, chatID, arg)
+	if errors.Is(err, app.ErrInvalidMemoryScope) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptScopeUsage))
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to save memory scope", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptScopeError))
+	}
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyGptScopeSet), arg))
+}
+
+func (h *BotHandlers) historyScope(ctx context.Context, msg *Message) historyScope {
+	kind, err := h.service.GetMemoryScope(ctx, msg.Chat.ID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to load memory scope, using shared history", "chat_id", msg.Chat.ID, "error", err)
+	}
+
+	switch kind {
+	case model.MemoryScopeUser:
+		if msg.From != nil {
+			return historyScope{kind: kind, key: fmt.Sprintf(userScopeFmt, msg.From.ID)}
+		}
+	case model.MemoryScopeThread:
+		root := h.threadRoot(ctx, msg)
+		return historyScope{kind: kind, key: fmt.Sprintf(threadScopeFmt, root), root: root}
+	}
+	return historyScope{kind: model.MemoryScopeChat}
+}
+
+func (h *BotHandlers) managedScope(ctx context.Context, msg *Message) (historyScope, bool) {
+	scope := h.historyScope(ctx, msg)
+	if scope.kind != model.MemoryScopeThread {
+		return scope, true
+	}
+
+	root := 0
+	if reply := msg.ReplyToMessage; reply != nil {
+		root = h.knownThreadRoot(ctx, msg.Chat.ID, reply.MessageID)
+	}
+	if root == 0 && h.cache != nil {
+		root, _ = h.cache.GetLatestThread(ctx, msg.Chat.ID)
+	}
+	if root == 0 {
+		return historyScope{}, false
+	}
+	return historyScope{kind: scope.kind, key: fmt.Sprintf(threadScopeFmt, root), root: root}, true
+}
+
+func (h *BotHandlers) threadRoot(ctx context.Context, msg *Message) int {
+	reply := msg.ReplyToMessage
+	if reply == nil {
+		return msg.MessageID
+	}
+	if root := h.knownThreadRoot(ctx, msg.Chat.ID, reply.MessageID); root != 0 {
+		return root
+	}
+	return reply.MessageID
+}
+
+func (h *BotHandlers) knownThreadRoot(ctx context.Context, chatID int64, messageID int) int {
+	if h.cache == nil {
+		return 0
+	}
+	root, err := h.cache.GetThreadRoot(ctx, chatID, messageID)
+	if err != nil {
+		return 0
+	}
+	return root
+}
+
+func (h *BotHandlers) sendGPTReply(ctx context.Context, msg *Message, scope historyScope, response string, keyboard *InlineKeyboardMarkup) (*Message, error) {
+	chatID := msg.Chat.ID
+	response = truncateMarkdown(response, maxAnswerRunes)
+	threaded := scope.kind == model.MemoryScopeThread && h.cache != nil
+	if !threaded && keyboard == nil {
+		return nil, h.client.SendMessage(ctx, chatID, response)
+	}
+
+	replyTo := 0
+	if threaded {
+		replyTo = msg.MessageID
+	}
+	sent, err := h.client.SendMessageWithKeyboard(ctx, chatID, replyTo, response, keyboard)
+	if err != nil || !threaded {
+		return sent, err
+	}
+	for _, id := range []int{msg.MessageID, sent.MessageID} {
+		if err := h.cache.SetThreadRoot(ctx, chatID, id, scope.root); err != nil {
+			log.WarnContext(ctx, "Failed to remember thread root", "chat_id", chatID, "error", err)
+		}
+	}
+	if err := h.cache.SetLatestThread(ctx, chatID, scope.root); err != nil {
+		log.WarnContext(ctx, "Failed to remember latest thread", "chat_id", chatID, "error", err)
+	}
+	return sent, nil
+}
+
+func parseMemoryExport(data []byte) ([]groq.Message, error) {
+	var export memoryExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+	if export.Version != memoryExportVersion {
+		return nil, fmt.Errorf("unsupported version %d", export.Version)
+	}
+	if len(export.Messages) == 0 {
+		return nil, errors.New("no messages")
+	}
+
+	history := make([]groq.Message, 0, len(export.Messages))
+	for i, m := range export.Messages {
+		switch {
+		case m.Role == "system" && i == 0:
+			if summary, _ := groq.SplitSummary(export.Messages[:1]); summary == "" {
+				return nil, errors.New("system message is not a summary")
+			}
+		case m.Role != "user" && m.Role != "assistant":
+			return nil, fmt.Errorf("message %d has unsupported role %q", i, m.Role)
+		}
+		if strings.TrimSpace(m.Content) == "" && len(m.ImageRefs) == 0 {
+			return nil, fmt.Errorf("message %d is empty", i)
+		}
+		history = append(history, groq.Message{Role: m.Role, Content: m.Content, ImageRefs: m.ImageRefs})
+	}
+	return history, nil
+}
+
+func formatHistoryAsMarkdown(history []groq.Message) string {
+	var sb strings.Builder
+	sb.WriteString("# Chat history\n")
+	summary, turns := groq.SplitSummary(history)
+	if summary != "" {
+		sb.WriteString("\n## Summary\n\n")
+		sb.WriteString(summary)
+		sb.WriteString("\n")
+	}
+	for _, msg := range turns {
+		sb.WriteString("\n## ")
+		sb.WriteString(msg.Role)
+		sb.WriteString("\n\n")
+		sb.WriteString(msg.Content)
+		if len(msg.ImageRefs) > 0 {
+			sb.WriteString("\n\n_[image]_")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func memoryFileExtension(format string) string {
+	if format == memoryFormatText {
+		return "txt"
+	}
+	return format
+}
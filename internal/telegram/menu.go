@@ -0,0 +1,153 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+
+	"got/internal/app/model"
+	"got/pkg/config"
+	"got/pkg/i18n"
+)
+
+const (
+	scopeDefault         = "default"
+	scopeAllPrivateChats = "all_private_chats"
+	scopeAllGroupChats   = "all_group_chats"
+	scopeAllChatAdmins   = "all_chat_administrators"
+	scopeChat            = "chat"
+	scopeChatAdmins      = "chat_administrators"
+)
+
+const (
+	menuDefault menuScope = 1 << iota
+	menuPrivate
+	menuGroup
+	menuAdmins
+)
+
+type menuScope int
+
+type menuEntry struct {
+	cmd    string
+	desc   i18n.Key
+	scopes menuScope
+}
+
+type CommandMenu struct {
+	client      *Client
+	cfg         *config.Config
+	defaultLang string
+	translators map[string]*i18n.Translator
+}
+
+func NewCommandMenu(client *Client, cfg *config.Config, defaultLang string) *CommandMenu {
+	translators := make(map[string]*i18n.Translator)
+	for _, lang := range i18n.Languages() {
+		translators[lang] = i18n.New(lang)
+	}
+	if _, ok := translators[defaultLang]; !ok {
+		translators[defaultLang] = i18n.New(defaultLang)
+	}
+
+	return &CommandMenu{
+		client:      client,
+		cfg:         cfg,
+		defaultLang: defaultLang,
+		translators: translators,
+	}
+}
+
+func (m *CommandMenu) Register(ctx context.Context) error {
+	scopes := []struct {
+		scope    BotCommandScope
+		audience menuScope
+	}{
+		{BotCommandScope{Type: scopeDefault}, menuDefault},
+		{BotCommandScope{Type: scopeAllPrivateChats}, menuPrivate},
+		{BotCommandScope{Type: scopeAllGroupChats}, menuGroup},
+		{BotCommandScope{Type: scopeAllChatAdmins}, menuAdmins},
+	}
+
+	var errs []error
+	for _, s := range scopes {
+		if err := m.client.SetMyCommandsScoped(ctx, m.commands(m.translators[m.defaultLang], s.audience), s.scope, ""); err != nil {
+			errs = append(errs, err)
+		}
+		for lang, t := range m.translators {
+			if err := m.client.SetMyCommandsScoped(ctx, m.commands(t, s.audience), s.scope, lang); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	log.InfoContext(ctx, "Bot command menus registered", "languages", len(m.translators), "scopes", len(scopes), "failed", len(errs))
+	return errors.Join(errs...)
+}
+
+func (m *CommandMenu) SyncChats(ctx context.Context, chats []*model.Chat) {
+	for _, chat := range chats {
+		if chat.Language == "" || chat.Language == m.defaultLang {
+			continue
+		}
+		if err := m.RefreshChat(ctx, chat.ChatID, chat.Language); err != nil {
+			log.ErrorContext(ctx, "Failed to sync chat command menu", "chat_id", chat.ChatID, "error", err)
+		}
+	}
+}
+
+func (m *CommandMenu) RefreshChat(ctx context.Context, chatID int64, lang string) error {
+	log.DebugContext(ctx, "Refreshing chat command menu", "chat_id", chatID, "language", lang)
+	chatScope := BotCommandScope{Type: scopeChat, ChatID: chatID}
+	adminScope := BotCommandScope{Type: scopeChatAdmins, ChatID: chatID}
+	isGroup := chatID < 0
+
+	t, ok := m.translators[lang]
+	if lang == "" || lang == m.defaultLang || !ok {
+		if isGroup {
+			if err := m.client.DeleteMyCommands(ctx, adminScope, ""); err != nil {
+				return err
+			}
+		}
+		return m.client.DeleteMyCommands(ctx, chatScope, "")
+	}
+
+	if !isGroup {
+		return m.client.SetMyCommandsScoped(ctx, m.commands(t, menuPrivate), chatScope, "")
+	}
+
+	if err := m.client.SetMyCommandsScoped(ctx, m.commands(t, menuGroup), chatScope, ""); err != nil {
+		return err
+	}
+	return m.client.SetMyCommandsScoped(ctx, m.commands(t, menuAdmins), adminScope, "")
+}
+
+func (m *CommandMenu) commands(t *i18n.Translator, audience menuScope) []BotCommand {
+	cmds := &m.cfg.Commands
+	all := menuDefault | menuPrivate | menuGroup | menuAdmins
+	entries := []menuEntry{
+		{cmds.Start, i18n.KeyCmdStart, menuDefault | menuPrivate},
+		{cmds.Help, i18n.KeyCmdHelp, all},
+		{cmds.Gpt, i18n.KeyCmdGpt, all},
+		{cmds.Remind, i18n.KeyCmdRemind, all},
+		{cmds.Meme, i18n.KeyCmdMeme, all},
+		{cmds.Sticker, i18n.KeyCmdSticker, all},
+		{cmds.Fact, i18n.KeyCmdFact, all},
+		{cmds.Roulette, i18n.KeyCmdRoulette, all},
+		{cmds.Tts, i18n.KeyCmdTts, all},
+		{cmds.Transcribe, i18n.KeyCmdTranscribe, all},
+		{cmds.Translate, i18n.KeyCmdTranslate, all},
+		{cmds.Assistant, i18n.KeyCmdAssistant, menuPrivate},
+		{cmds.Lang, i18n.KeyCmdLang, menuDefault | menuPrivate | menuAdmins},
+		{cmds.Usage, i18n.KeyCmdUsage, all},
+		{cmds.Admin, i18n.KeyCmdAdmin, menuPrivate},
+	}
+
+	var commands []BotCommand
+	for _, e := range entries {
+		if e.scopes&audience == 0 || m.cfg.IsDisabled(e.cmd) {
+			continue
+		}
+		commands = append(commands, BotCommand{Command: e.cmd, Description: t.Get(e.desc)})
+	}
+	return commands
+}
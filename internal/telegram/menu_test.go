@@ -0,0 +1,172 @@
+package telegram
+
+import (
+	"context"
+	"got/internal/app/model"
+	"got/pkg/config"
+	"got/pkg/i18n"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type menuCall struct {
+	method   string
+	scope    string
+	chatID   int64
+	language string
+	commands []string
+}
+
+func newTestCommandMenu(t *testing.T, disabled map[string]bool) (*CommandMenu, *[]menuCall) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var calls []menuCall
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeJSONPayload(t, r)
+		call := menuCall{method: r.URL.Path}
+		if scope, ok := payload["scope"].(map[string]any); ok {
+			call.scope, _ = scope["type"].(string)
+			if id, ok := scope["chat_id"].(float64); ok {
+				call.chatID = int64(id)
+			}
+		}
+		call.language, _ = payload["language_code"].(string)
+		if cmds, ok := payload["commands"].([]any); ok {
+			for _, c := range cmds {
+				call.commands = append(call.commands, c.(map[string]any)["command"].(string))
+			}
+		}
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	cfg := &config.Config{Commands: *newTestCommandsConfig(), DisabledCommands: disabled}
+	cfg.Commands.Admin = "admin"
+	menu := &CommandMenu{
+		client:      newTestClient(server.URL),
+		cfg:         cfg,
+		defaultLang: "en",
+		translators: map[string]*i18n.Translator{
+			"en": newTestTranslator(),
+			"ru": i18n.NewWithTranslations("ru", map[string]string{"cmd_gpt": "Чат с ИИ"}),
+		},
+	}
+	return menu, &calls
+}
+
+func TestCommandMenuRegister(t *testing.T) {
+	menu, calls := newTestCommandMenu(t, map[string]bool{"meme": true})
+
+	if err := menu.Register(context.Background()); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// 4 scopes x (no language + en + ru)
+	if len(*calls) != 12 {
+		t.Fatalf("got %d setMyCommands calls, want 12", len(*calls))
+	}
+
+	for _, c := range *calls {
+		joined := strings.Join(c.commands, ",")
+		if strings.Contains(joined, "meme") {
+			t.Errorf("disabled command registered in scope %s: %s", c.scope, joined)
+		}
+		switch c.scope {
+		case scopeAllGroupChats:
+			if strings.Contains(joined, "start") || strings.Contains(joined, "admin") || strings.Contains(joined, "lang") {
+				t.Errorf("group menu has private-only commands: %s", joined)
+			}
+		case scopeAllChatAdmins:
+			if !strings.Contains(joined, "lang") {
+				t.Errorf("admin menu missing lang: %s", joined)
+			}
+		case scopeAllPrivateChats:
+			if !strings.Contains(joined, "admin") {
+				t.Errorf("private menu missing admin: %s", joined)
+			}
+		}
+	}
+}
+
+func TestCommandMenuRefreshChat(t *testing.T) {
+	tests := []struct {
+		name       string
+		chatID     int64
+		lang       string
+		wantCalls  []string
+		wantScopes []string
+	}{
+		{
+			name:       "GroupWithLanguage",
+			chatID:     -100,
+			lang:       "ru",
+			wantCalls:  []string{setMyCommandsCMD, setMyCommandsCMD},
+			wantScopes: []string{scopeChat, scopeChatAdmins},
+		},
+		{
+			name:       "PrivateWithLanguage",
+			chatID:     42,
+			lang:       "ru",
+			wantCalls:  []string{setMyCommandsCMD},
+			wantScopes: []string{scopeChat},
+		},
+		{
+			name:       "DefaultLanguage",
+			chatID:     42,
+			lang:       "en",
+			wantCalls:  []string{delMyCommandsCMD},
+			wantScopes: []string{scopeChat},
+		},
+		{
+			name:       "GroupReset",
+			chatID:     -100,
+			lang:       "",
+			wantCalls:  []string{delMyCommandsCMD, delMyCommandsCMD},
+			wantScopes: []string{scopeChatAdmins, scopeChat},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			menu, calls := newTestCommandMenu(t, nil)
+
+			if err := menu.RefreshChat(context.Background(), tt.chatID, tt.lang); err != nil {
+				t.Fatalf("RefreshChat() error = %v", err)
+			}
+
+			if len(*calls) != len(tt.wantCalls) {
+				t.Fatalf("got %d calls, want %d", len(*calls), len(tt.wantCalls))
+			}
+			for i, c := range *calls {
+				if c.method != tt.wantCalls[i] || c.scope != tt.wantScopes[i] {
+					t.Errorf("call %d = %s %s, want %s %s", i, c.method, c.scope, tt.wantCalls[i], tt.wantScopes[i])
+				}
+				if c.chatID != tt.chatID {
+					t.Errorf("call %d chat_id = %d, want %d", i, c.chatID, tt.chatID)
+				}
+				if c.language != "" {
+					t.Errorf("call %d should not set language_code, got %q", i, c.language)
+				}
+			}
+		})
+	}
+}
+
+func TestCommandMenuSyncChatsSkipsDefault(t *testing.T) {
+	menu, calls := newTestCommandMenu(t, nil)
+
+	menu.SyncChats(context.Background(), []*model.Chat{
+		{ChatID: -1},
+		{ChatID: 2, Language: "ru"},
+		{ChatID: 3, Language: "en"},
+	})
+
+	if len(*calls) != 1 || (*calls)[0].chatID != 2 {
+		t.Errorf("want a single refresh for chat 2, got %+v", *calls)
+	}
+}
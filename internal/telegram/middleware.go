@@ -2,9 +2,12 @@ package telegram
 
 import (
 	"context"
+	"fmt"
+	"got/internal/alert"
 	"got/internal/app"
 	"got/internal/app/model"
-	"log/slog"
+	"runtime/debug"
+	"time"
 )
 
 type Middleware func(HandlerFunc) HandlerFunc
@@ -14,6 +17,11 @@ type AutoRegisterMiddleware struct {
 	next    Handler
 }
 
+type BanFilterMiddleware struct {
+	service *app.Service
+	next    Handler
+}
+
 func WithLogging(next HandlerFunc) HandlerFunc {
 	return func(ctx context.Context, update *Update) error {
 		if update.Message != nil {
@@ -24,7 +32,7 @@ func WithLogging(next HandlerFunc) HandlerFunc {
 					username = update.Message.From.FirstName
 				}
 			}
-			slog.Info("User command received",
+			log.InfoContext(ctx, "User command received",
 				"user", username,
 				"command", update.Message.Command(),
 			)
@@ -34,16 +42,64 @@ func WithLogging(next HandlerFunc) HandlerFunc {
 }
 
 func WithRecover(next HandlerFunc) HandlerFunc {
-	return func(ctx context.Context, update *Update) error {
-		defer func() {
-			if r := recover(); r != nil {
-				slog.Error("Panic recovered", "error", r)
-			}
-		}()
-		return next(ctx, update)
+	return WithReportedRecover(nil)(next)
+}
+
+func WithReportedRecover(reporter *alert.Reporter) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *Update) error {
+			defer func() {
+				if r := recover(); r != nil {
+					log.ErrorContext(ctx, "Panic recovered", "error", r)
+					if reporter != nil {
+						reporter.Report(ctx, newUpdateReport(update, alert.SourcePanic, fmt.Errorf("panic: %v", r), debug.Stack()))
+					}
+				}
+			}()
+			return next(ctx, update)
+		}
+	}
+}
+
+func NewBanFilterMiddleware(service *app.Service, next Handler) *BanFilterMiddleware {
+	return &BanFilterMiddleware{
+		service: service,
+		next:    next,
 	}
 }
 
+func (m *BanFilterMiddleware) Handle(ctx context.Context, update *Update) error {
+	if update.Message != nil && m.isBlocked(ctx, update.Message) {
+		log.DebugContext(ctx, "Dropping update from banned sender")
+		return nil
+	}
+	if cq := update.CallbackQuery; cq != nil && cq.Message != nil && m.isBlocked(ctx, &Message{From: cq.From, Chat: cq.Message.Chat}) {
+		log.DebugContext(ctx, "Dropping callback from banned sender")
+		return nil
+	}
+	return m.next.Handle(ctx, update)
+}
+
+func (m *BanFilterMiddleware) isBlocked(ctx context.Context, msg *Message) bool {
+	var userID, chatID int64
+	if msg.From != nil {
+		userID = msg.From.ID
+	}
+	if msg.Chat != nil {
+		chatID = msg.Chat.ID
+	}
+	if userID == 0 && chatID == 0 {
+		return false
+	}
+
+	blocked, err := m.service.IsBlocked(ctx, userID, chatID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to check ban list", "error", err)
+		return false
+	}
+	return blocked
+}
+
 func NewAutoRegisterMiddleware(service *app.Service, next Handler) *AutoRegisterMiddleware {
 	return &AutoRegisterMiddleware{
 		service: service,
@@ -65,7 +121,7 @@ func (m *AutoRegisterMiddleware) registerChatAndUser(ctx context.Context, msg *M
 			ChatName: m.getChatName(msg.Chat),
 		}
 		if err := m.service.RegisterChat(ctx, chat); err != nil {
-			slog.Error("Failed to register chat", "chat_id", chat.ChatID, "error", err)
+			log.ErrorContext(ctx, "Failed to register chat", "chat_id", chat.ChatID, "error", err)
 		}
 	}
 
@@ -75,7 +131,7 @@ func (m *AutoRegisterMiddleware) registerChatAndUser(ctx context.Context, msg *M
 			Username: m.getUsername(msg.From),
 		}
 		if err := m.service.RegisterUser(ctx, user, msg.Chat.ID); err != nil {
-			slog.Error("Failed to register user", "user_id", user.UserID, "error", err)
+			log.ErrorContext(ctx, "Failed to register user", "user_id", user.UserID, "error", err)
 		}
 	}
 }
@@ -93,3 +149,66 @@ func (m *AutoRegisterMiddleware) getUsername(user *User) string {
 	}
 	return user.FirstName
 }
+
+func newUpdateReport(update *Update, source string, err error, stack []byte) alert.Report {
+	report := alert.Report{
+		Source: source,
+		Err:    err,
+		Stack:  stack,
+	}
+	if update == nil || update.Message == nil {
+		return report
+	}
+
+	msg := update.Message
+	report.Command = msg.Command()
+	if msg.Chat != nil {
+		report.ChatID = msg.Chat.ID
+	}
+	if msg.From != nil {
+		report.UserID = msg.From.ID
+		report.Username = msg.From.UserName
+		if report.Username == "" {
+			report.Username = msg.From.FirstName
+		}
+	}
+	return report
+}
+
+func WithUsageTracking(service *app.Service) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, update *Update) (err error) {
+			start := time.Now()
+			success := false
+			defer func() {
+				recordUsage(ctx, service, update, time.Since(start), success)
+			}()
+
+			err = next(ctx, update)
+			success = err == nil
+			return err
+		}
+	}
+}
+
+func recordUsage(ctx context.Context, service *app.Service, update *Update, latency time.Duration, success bool) {
+	msg := update.Message
+	if msg == nil || msg.Chat == nil {
+		return
+	}
+
+	usage := &model.CommandUsage{
+		ChatID:     msg.Chat.ID,
+		Command:    msg.Command(),
+		SubCommand: usageSubCommand(msg.CommandArguments()),
+		Latency:    latency,
+		Success:    success,
+	}
+	if msg.From != nil {
+		usage.UserID = msg.From.ID
+	}
+
+	if err := service.RecordCommandUsage(ctx, usage); err != nil {
+		log.ErrorContext(ctx, "Failed to record command usage", "command", usage.Command, "error", err)
+	}
+}
@@ -3,14 +3,21 @@ package telegram
 import (
 	"context"
 	"errors"
+	"got/internal/alert"
 	"got/internal/app"
 	"got/internal/app/model"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 type mockChatRepo struct {
-	saveFunc func(ctx context.Context, chat *model.Chat) error
-	getFunc  func(ctx context.Context, chatID int64) (*model.Chat, error)
+	saveFunc         func(ctx context.Context, chat *model.Chat) error
+	getFunc          func(ctx context.Context, chatID int64) (*model.Chat, error)
+	getLanguageFunc  func(ctx context.Context, chatID int64) (string, error)
+	getSettingsFunc  func(ctx context.Context, chatID int64) (*model.ChatSettings, error)
+	saveSettingsFunc func(ctx context.Context, settings *model.ChatSettings) error
 }
 
 type mockUserRepo struct {
@@ -29,6 +36,7 @@ type mockFactRepo struct {
 	saveFunc            func(ctx context.Context, f *model.Fact) error
 	getRandomByChatFunc func(ctx context.Context, chatID int64) (*model.Fact, error)
 	listByChatFunc      func(ctx context.Context, chatID int64) ([]*model.Fact, error)
+	searchFunc          func(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error)
 }
 
 type mockStickerRepo struct {
@@ -56,6 +64,44 @@ type mockStatRepo struct {
 	updateFunc             func(ctx context.Context, statID int64, score int64, isWinner bool) error
 }
 
+type mockBanRepo struct {
+	saveFunc       func(ctx context.Context, ban *model.Ban) error
+	deleteFunc     func(ctx context.Context, targetType string, targetID int64) error
+	findActiveFunc func(ctx context.Context, userID, chatID int64) (*model.Ban, error)
+	listActiveFunc func(ctx context.Context) ([]*model.Ban, error)
+}
+
+type mockUsageRepo struct {
+	saveFunc        func(ctx context.Context, usage *model.CommandUsage) error
+	summaryFunc     func(ctx context.Context, chatID int64, since time.Time) (*model.UsageSummary, error)
+	topCommandsFunc func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	topUsersFunc    func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	topChatsFunc    func(ctx context.Context, since time.Time, limit int) ([]*model.UsageCount, error)
+}
+
+type mockTokenRepo struct {
+	saveFunc        func(ctx context.Context, usage *model.TokenUsage) error
+	totalsFunc      func(ctx context.Context, targetType string, targetID int64, since time.Time) (*model.TokenTotals, error)
+	topModelsFunc   func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	topUsersFunc    func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error)
+	getQuotaFunc    func(ctx context.Context, targetType string, targetID int64) (*model.TokenQuota, error)
+	saveQuotaFunc   func(ctx context.Context, quota *model.TokenQuota) error
+	deleteQuotaFunc func(ctx context.Context, targetType string, targetID int64) error
+}
+
+type mockMessageRepo struct {
+	saveFunc         func(ctx context.Context, msg *model.ChatMessage) error
+	listRecentFunc   func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.ChatMessage, error)
+	deleteByChatFunc func(ctx context.Context, chatID int64) (int64, error)
+	pruneFunc        func(ctx context.Context, before time.Time, maxPerChat int) (int64, error)
+	searchFunc       func(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error)
+}
+
+type mockModerationRepo struct {
+	saveFunc       func(ctx context.Context, event *model.ModerationEvent) error
+	listRecentFunc func(ctx context.Context, limit int) ([]*model.ModerationEvent, error)
+}
+
 type mockHandler struct {
 	called bool
 	err    error
@@ -84,9 +130,26 @@ func (m *mockChatRepo) SetLanguage(ctx context.Context, chatID int64, language s
 }
 
 func (m *mockChatRepo) GetLanguage(ctx context.Context, chatID int64) (string, error) {
+	if m.getLanguageFunc != nil {
+		return m.getLanguageFunc(ctx, chatID)
+	}
 	return "", nil
 }
 
+func (m *mockChatRepo) GetSettings(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+	if m.getSettingsFunc != nil {
+		return m.getSettingsFunc(ctx, chatID)
+	}
+	return &model.ChatSettings{ChatID: chatID}, nil
+}
+
+func (m *mockChatRepo) SaveSettings(ctx context.Context, settings *model.ChatSettings) error {
+	if m.saveSettingsFunc != nil {
+		return m.saveSettingsFunc(ctx, settings)
+	}
+	return nil
+}
+
 func (m *mockUserRepo) Save(ctx context.Context, user *model.User) error {
 	if m.saveFunc != nil {
 		return m.saveFunc(ctx, user)
@@ -153,6 +216,12 @@ func (m *mockFactRepo) ListByChat(ctx context.Context, chatID int64) ([]*model.F
 	}
 	return nil, nil
 }
+func (m *mockFactRepo) Search(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+	if m.searchFunc != nil {
+		return m.searchFunc(ctx, chatID, query, limit)
+	}
+	return nil, nil
+}
 
 func (m *mockStickerRepo) Save(ctx context.Context, s *model.Sticker) error {
 	if m.saveFunc != nil {
@@ -255,21 +324,41 @@ func (m *mockStatRepo) Update(ctx context.Context, statID int64, score int64, is
 	return nil
 }
 
+func (m *mockBanRepo) Save(ctx context.Context, ban *model.Ban) error {
+	if m.saveFunc != nil {
+		return m.saveFunc(ctx, ban)
+	}
+	return nil
+}
+
+func (m *mockBanRepo) Delete(ctx context.Context, targetType string, targetID int64) error {
+	if m.deleteFunc != nil {
+		return m.deleteFunc(ctx, targetType, targetID)
+	}
+	return nil
+}
+
+func (m *mockBanRepo) FindActive(ctx context.Context, userID, chatID int64) (*model.Ban, error) {
+	if m.findActiveFunc != nil {
+		return m.findActiveFunc(ctx, userID, chatID)
+	}
+	return nil, nil
+}
+
+func (m *mockBanRepo) ListActive(ctx context.Context) ([]*model.Ban, error) {
+	if m.listActiveFunc != nil {
+		return m.listActiveFunc(ctx)
+	}
+	return nil, nil
+}
+
 func (m *mockHandler) Handle(ctx context.Context, update *Update) error {
 	m.called = true
 	return m.err
 }
 
 func newTestService(chatRepo *mockChatRepo, userRepo *mockUserRepo) *app.Service {
-	return app.NewService(
-		chatRepo,
-		userRepo,
-		&mockReminderRepo{},
-		&mockFactRepo{},
-		&mockStickerRepo{},
-		&mockSubredditRepo{},
-		&mockStatRepo{},
-	)
+	return newMockService(app.Repositories{Chats: chatRepo, Users: userRepo})
 }
 
 func TestAutoRegisterMiddlewareHandle(t *testing.T) {
@@ -424,3 +513,338 @@ func TestWithRecover(t *testing.T) {
 		t.Errorf("unexpected error after recover: %v", err)
 	}
 }
+
+func TestWithReportedRecover(t *testing.T) {
+	var sentMessage string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeJSONPayload(t, r)
+		sentMessage = payload["text"].(string)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	reporter := alert.NewReporter(newTestClient(server.URL), -100, time.Minute)
+	handler := WithReportedRecover(reporter)(func(ctx context.Context, update *Update) error {
+		panic("test panic")
+	})
+
+	update := &Update{
+		Message: &Message{
+			Text: "/gpt hello",
+			Chat: &Chat{ID: 123},
+			From: &User{ID: 456, UserName: "testuser"},
+		},
+	}
+
+	err := handler(context.Background(), update)
+
+	if err != nil {
+		t.Errorf("unexpected error after recover: %v", err)
+	}
+	for _, want := range []string{"panic: test panic", "`gpt`", "`123`", "`testuser`"} {
+		if !strings.Contains(sentMessage, want) {
+			t.Errorf("report should contain %q, got: %s", want, sentMessage)
+		}
+	}
+}
+
+func TestBanFilterMiddlewareHandle(t *testing.T) {
+	tests := []struct {
+		name           string
+		update         *Update
+		findErr        error
+		wantNextCalled bool
+	}{
+		{
+			name:           "NilMessage",
+			update:         &Update{},
+			wantNextCalled: true,
+		},
+		{
+			name: "AllowedUser",
+			update: &Update{Message: &Message{
+				Chat: &Chat{ID: 1},
+				From: &User{ID: 7},
+			}},
+			wantNextCalled: true,
+		},
+		{
+			name: "BannedUser",
+			update: &Update{Message: &Message{
+				Chat: &Chat{ID: 1},
+				From: &User{ID: 42},
+			}},
+			wantNextCalled: false,
+		},
+		{
+			name: "BannedChat",
+			update: &Update{Message: &Message{
+				Chat: &Chat{ID: -100},
+				From: &User{ID: 7},
+			}},
+			wantNextCalled: false,
+		},
+		{
+			name: "BannedUserCallback",
+			update: &Update{CallbackQuery: &CallbackQuery{
+				From:    &User{ID: 42},
+				Message: &Message{Chat: &Chat{ID: 1}},
+			}},
+			wantNextCalled: false,
+		},
+		{
+			name: "LookupErrorFailsOpen",
+			update: &Update{Message: &Message{
+				Chat: &Chat{ID: 1},
+				From: &User{ID: 42},
+			}},
+			findErr:        errors.New("db down"),
+			wantNextCalled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			banRepo := &mockBanRepo{
+				findActiveFunc: func(ctx context.Context, userID, chatID int64) (*model.Ban, error) {
+					if tt.findErr != nil {
+						return nil, tt.findErr
+					}
+					if userID == 42 || chatID == -100 {
+						return &model.Ban{}, nil
+					}
+					return nil, nil
+				},
+			}
+			svc := newMockService(app.Repositories{Bans: banRepo})
+			next := &mockHandler{}
+			mw := NewBanFilterMiddleware(svc, next)
+
+			if err := mw.Handle(context.Background(), tt.update); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if next.called != tt.wantNextCalled {
+				t.Errorf("next called = %v, want %v", next.called, tt.wantNextCalled)
+			}
+		})
+	}
+}
+
+func (m *mockUsageRepo) Save(ctx context.Context, usage *model.CommandUsage) error {
+	if m.saveFunc != nil {
+		return m.saveFunc(ctx, usage)
+	}
+	return nil
+}
+
+func (m *mockUsageRepo) Summary(ctx context.Context, chatID int64, since time.Time) (*model.UsageSummary, error) {
+	if m.summaryFunc != nil {
+		return m.summaryFunc(ctx, chatID, since)
+	}
+	return nil, nil
+}
+
+func (m *mockUsageRepo) TopCommands(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.topCommandsFunc != nil {
+		return m.topCommandsFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockUsageRepo) TopUsers(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.topUsersFunc != nil {
+		return m.topUsersFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockUsageRepo) TopChats(ctx context.Context, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.topChatsFunc != nil {
+		return m.topChatsFunc(ctx, since, limit)
+	}
+	return nil, nil
+}
+
+func TestWithUsageTracking(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		handlerErr  error
+		wantSub     string
+		wantSuccess bool
+	}{
+		{
+			name:        "SuccessWithSubCommand",
+			text:        "/remind list",
+			wantSub:     "list",
+			wantSuccess: true,
+		},
+		{
+			name:        "FreeTextIsNotSubCommand",
+			text:        "/gpt hello there",
+			wantSub:     "",
+			wantSuccess: true,
+		},
+		{
+			name:        "HandlerError",
+			text:        "/meme",
+			handlerErr:  errors.New("boom"),
+			wantSuccess: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recorded *model.CommandUsage
+			usageRepo := &mockUsageRepo{
+				saveFunc: func(ctx context.Context, usage *model.CommandUsage) error {
+					recorded = usage
+					return nil
+				},
+			}
+			svc := newMockService(app.Repositories{Usage: usageRepo})
+
+			handler := WithUsageTracking(svc)(func(ctx context.Context, update *Update) error {
+				return tt.handlerErr
+			})
+
+			update := &Update{Message: &Message{
+				Text: tt.text,
+				Chat: &Chat{ID: 1},
+				From: &User{ID: 7},
+			}}
+			_ = handler(context.Background(), update)
+
+			if recorded == nil {
+				t.Fatal("usage was not recorded")
+			}
+			if recorded.ChatID != 1 || recorded.UserID != 7 {
+				t.Errorf("recorded chat/user = %d/%d, want 1/7", recorded.ChatID, recorded.UserID)
+			}
+			if recorded.SubCommand != tt.wantSub {
+				t.Errorf("sub command = %q, want %q", recorded.SubCommand, tt.wantSub)
+			}
+			if recorded.Success != tt.wantSuccess {
+				t.Errorf("success = %v, want %v", recorded.Success, tt.wantSuccess)
+			}
+		})
+	}
+}
+
+func TestWithUsageTrackingRecordsPanic(t *testing.T) {
+	var recorded *model.CommandUsage
+	usageRepo := &mockUsageRepo{
+		saveFunc: func(ctx context.Context, usage *model.CommandUsage) error {
+			recorded = usage
+			return nil
+		},
+	}
+	svc := newMockService(app.Repositories{Usage: usageRepo})
+
+	handler := WithRecover(WithUsageTracking(svc)(func(ctx context.Context, update *Update) error {
+		panic("boom")
+	}))
+
+	_ = handler(context.Background(), &Update{Message: &Message{Text: "/fact", Chat: &Chat{ID: 1}}})
+
+	if recorded == nil || recorded.Success {
+		t.Errorf("want failed usage recorded, got %+v", recorded)
+	}
+}
+
+func (m *mockTokenRepo) Save(ctx context.Context, usage *model.TokenUsage) error {
+	if m.saveFunc != nil {
+		return m.saveFunc(ctx, usage)
+	}
+	return nil
+}
+
+func (m *mockTokenRepo) Totals(ctx context.Context, targetType string, targetID int64, since time.Time) (*model.TokenTotals, error) {
+	if m.totalsFunc != nil {
+		return m.totalsFunc(ctx, targetType, targetID, since)
+	}
+	return &model.TokenTotals{}, nil
+}
+
+func (m *mockTokenRepo) TopModels(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.topModelsFunc != nil {
+		return m.topModelsFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockTokenRepo) TopUsers(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+	if m.topUsersFunc != nil {
+		return m.topUsersFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockTokenRepo) GetQuota(ctx context.Context, targetType string, targetID int64) (*model.TokenQuota, error) {
+	if m.getQuotaFunc != nil {
+		return m.getQuotaFunc(ctx, targetType, targetID)
+	}
+	return nil, nil
+}
+
+func (m *mockTokenRepo) SaveQuota(ctx context.Context, quota *model.TokenQuota) error {
+	if m.saveQuotaFunc != nil {
+		return m.saveQuotaFunc(ctx, quota)
+	}
+	return nil
+}
+
+func (m *mockTokenRepo) DeleteQuota(ctx context.Context, targetType string, targetID int64) error {
+	if m.deleteQuotaFunc != nil {
+		return m.deleteQuotaFunc(ctx, targetType, targetID)
+	}
+	return nil
+}
+
+func (m *mockMessageRepo) Save(ctx context.Context, msg *model.ChatMessage) error {
+	if m.saveFunc != nil {
+		return m.saveFunc(ctx, msg)
+	}
+	return nil
+}
+
+func (m *mockMessageRepo) ListRecent(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.ChatMessage, error) {
+	if m.listRecentFunc != nil {
+		return m.listRecentFunc(ctx, chatID, since, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockMessageRepo) DeleteByChat(ctx context.Context, chatID int64) (int64, error) {
+	if m.deleteByChatFunc != nil {
+		return m.deleteByChatFunc(ctx, chatID)
+	}
+	return 0, nil
+}
+
+func (m *mockMessageRepo) Prune(ctx context.Context, before time.Time, maxPerChat int) (int64, error) {
+	if m.pruneFunc != nil {
+		return m.pruneFunc(ctx, before, maxPerChat)
+	}
+	return 0, nil
+}
+
+func (m *mockMessageRepo) Search(ctx context.Context, chatID int64, query string, limit int) ([]*model.KnowledgeSnippet, error) {
+	if m.searchFunc != nil {
+		return m.searchFunc(ctx, chatID, query, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockModerationRepo) Save(ctx context.Context, event *model.ModerationEvent) error {
+	if m.saveFunc != nil {
+		return m.saveFunc(ctx, event)
+	}
+	return nil
+}
+
+func (m *mockModerationRepo) ListRecent(ctx context.Context, limit int) ([]*model.ModerationEvent, error) {
+	if m.listRecentFunc != nil {
+		return m.listRecentFunc(ctx, limit)
+	}
+	return nil, nil
+}
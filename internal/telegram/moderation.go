@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"got/internal/app/model"
+	"got/internal/moderation"
+	"got/pkg/i18n"
+)
+
+const (
+	moderationExcerptRunes = 200
+	moderationListLimit    = 20
+)
+
+func (h *BotHandlers) SetModerator(m *moderation.Moderator) {
+	h.moderator = m
+}
+
+func (h *BotHandlers) moderate(ctx context.Context, msg *Message, source, text string) (string, bool) {
+	classify := h.moderator.HasClassifier() && h.quotaMessage(ctx, msg) == ""
+	verdict, usage := h.moderator.Check(ctx, text, classify)
+	h.recordTokenUsage(ctx, msg, usage)
+	if verdict.Allowed() {
+		return text, true
+	}
+
+	chatID := msg.Chat.ID
+	log.WarnContext(ctx, "Moderation withheld a response", "chat_id", chatID, "source", source, "action", verdict.Action, "rule", verdict.Rule, "detail", verdict.Detail)
+	event := &model.ModerationEvent{
+		ChatID:  chatID,
+		UserID:  messageUserID(msg),
+		Source:  source,
+		Action:  verdict.Action,
+		Rule:    verdict.Rule,
+		Detail:  verdict.Detail,
+		Excerpt: moderationExcerpt(text),
+	}
+	if err := h.service.RecordModeration(ctx, event); err != nil {
+		log.ErrorContext(ctx, "Failed to record moderation event", "chat_id", chatID, "error", err)
+	}
+
+	t := h.getTranslator(ctx, chatID)
+	return t.Get(moderationNoticeKey(verdict.Action)), false
+}
+
+func (h *BotHandlers) handleAdminModeration(ctx context.Context, chatID, userID int64) error {
+	t := h.getTranslator(ctx, chatID)
+	if isAdmin, _ := h.isAdmin(ctx, userID); !isAdmin {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminNotLoggedIn))
+	}
+
+	events, err := h.service.ListModerationEvents(ctx, moderationListLimit)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to list moderation events", "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminModerationError))
+	}
+	if len(events) == 0 {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminModerationEmpty))
+	}
+	return h.client.SendMessage(ctx, chatID, formatModerationEvents(t, events))
+}
+
+func formatModerationEvents(t *i18n.Translator, events []*model.ModerationEvent) string {
+	var sb strings.Builder
+	sb.WriteString(t.Get(i18n.KeyAdminModerationHeader))
+	for _, e := range events {
+		rule := e.Rule
+		if e.Detail != "" {
+			rule += ": " + e.Detail
+		}
+		sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyAdminModerationFormat),
+			e.CreatedAt.Format(time.RFC822), e.Action, e.Source, e.ChatID, e.UserID,
+			strings.ReplaceAll(rule, "`", "'"), strings.ReplaceAll(e.Excerpt, "`", "'")))
+	}
+	return sb.String()
+}
+
+func moderationExcerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > moderationExcerptRunes {
+		return string(runes[:moderationExcerptRunes]) + "…"
+	}
+	return text
+}
+
+func moderationNoticeKey(action string) i18n.Key {
+	if action == model.ModerationActionFlag {
+		return i18n.KeyGptModerationFlagged
+	}
+	return i18n.KeyGptModerationBlocked
+}
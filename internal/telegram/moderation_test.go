@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+	"got/internal/moderation"
+)
+
+func newTestModerator(t *testing.T, keywords ...string) *moderation.Moderator {
+	t.Helper()
+	m, err := moderation.New(keywords, nil, nil)
+	if err != nil {
+		t.Fatalf("moderation.New() error = %v", err)
+	}
+	return m
+}
+
+func TestHandleGPTChatModeratesAnswer(t *testing.T) {
+	var sent []string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+			_ = json.NewEncoder(w).Encode(groq.Response{
+				Choices: []groq.Choice{{Message: groq.Message{Role: "assistant", Content: "Join my casino today!"}}},
+			})
+		case strings.HasSuffix(r.URL.Path, sendMessageCMD):
+			sent = append(sent, decodeJSONPayload(t, r)["text"].(string))
+		}
+	})
+
+	var event *model.ModerationEvent
+	moderationRepo := &mockModerationRepo{
+		saveFunc: func(ctx context.Context, e *model.ModerationEvent) error {
+			event = e
+			return nil
+		},
+	}
+	svc := newMockService(app.Repositories{Moderation: moderationRepo})
+	gpt := groq.NewCompatibleClient("local", server.URL, "", "llama", []string{"llama"})
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), svc, gpt)
+	handlers.SetModerator(newTestModerator(t, "casino"))
+
+	update := &Update{Message: &Message{Text: "/gpt advertise something", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if len(sent) != 1 || sent[0] != "The answer was withheld." {
+		t.Errorf("sent = %q, want moderation notice", sent)
+	}
+	if event == nil {
+		t.Fatal("moderation event was not recorded")
+	}
+	want := model.ModerationEvent{ChatID: testChatID, UserID: 42, Source: model.ModerationSourceGPT, Action: model.ModerationActionBlock, Rule: moderation.RuleKeyword, Detail: "casino", Excerpt: "Join my casino today!"}
+	if *event != want {
+		t.Errorf("event = %+v, want %+v", *event, want)
+	}
+}
+
+func TestHandleGPTImageModeratesPrompt(t *testing.T) {
+	var sent []string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, sendMessageCMD) {
+			sent = append(sent, decodeJSONPayload(t, r)["text"].(string))
+			return
+		}
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	var event *model.ModerationEvent
+	moderationRepo := &mockModerationRepo{
+		saveFunc: func(ctx context.Context, e *model.ModerationEvent) error {
+			event = e
+			return nil
+		},
+	}
+	svc := newMockService(app.Repositories{Moderation: moderationRepo})
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), svc, groq.NewClient("test-key"))
+	handlers.SetModerator(newTestModerator(t, "casino"))
+
+	update := &Update{Message: &Message{Text: "/gpt image a neon casino sign", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if len(sent) != 1 || sent[0] != "The answer was withheld." {
+		t.Errorf("sent = %q, want moderation notice", sent)
+	}
+	if event == nil || event.Source != model.ModerationSourceImage {
+		t.Errorf("event = %+v, want image moderation event", event)
+	}
+}
+
+func TestFormatModerationEvents(t *testing.T) {
+	events := []*model.ModerationEvent{
+		{ChatID: -100, UserID: 7, Source: model.ModerationSourceGPT, Action: model.ModerationActionFlag, Rule: moderation.RuleClassifier, Detail: "hate", Excerpt: "some `quoted` text", CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	}
+
+	got := formatModerationEvents(newTestTranslator(), events)
+
+	want := "Moderation log:\n- 01 May 24 12:00 UTC: flag gpt in chat -100, user 7 (classifier: hate)\n  some 'quoted' text\n"
+	if got != want {
+		t.Errorf("formatModerationEvents() = %q, want %q", got, want)
+	}
+}
+
+func TestModerationExcerpt(t *testing.T) {
+	long := strings.Repeat("я", moderationExcerptRunes+5)
+
+	if got := moderationExcerpt("a\n\nb   c"); got != "a b c" {
+		t.Errorf("moderationExcerpt() = %q, want collapsed whitespace", got)
+	}
+	if got := moderationExcerpt(long); got != strings.Repeat("я", moderationExcerptRunes)+"…" {
+		t.Errorf("moderationExcerpt() = %q, want truncated excerpt", got)
+	}
+}
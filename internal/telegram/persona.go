@@ -0,0 +1,64 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/pkg/i18n"
+)
+
+func (h *BotHandlers) handleGPTPersona(ctx context.Context, chatID int64, args string) error {
+	t := h.getTranslator(ctx, chatID)
+
+	switch subCommand(strings.ToLower(args)) {
+	case "":
+		return h.showPersona(ctx, chatID)
+	case subCommandList:
+		return h.client.SendMessage(ctx, chatID, formatPersonaList(t, h.service.ListPersonas()))
+	case subCommandReset:
+		if err := h.service.ResetPersona(ctx, chatID); err != nil {
+			log.ErrorContext(ctx, "Failed to reset persona", "error", err)
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptPersonaError))
+		}
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptPersonaReset))
+	}
+
+	persona, err := h.service.SetPersona(ctx, chatID, args)
+	if errors.Is(err, app.ErrSystemPromptTooLong) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptPersonaTooLong))
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to set persona", "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptPersonaError))
+	}
+
+	if persona.Name == model.PersonaCustom {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptPersonaCustomSet))
+	}
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyGptPersonaSet), persona.Name))
+}
+
+func (h *BotHandlers) showPersona(ctx context.Context, chatID int64) error {
+	t := h.getTranslator(ctx, chatID)
+	persona, err := h.service.GetPersona(ctx, chatID)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load persona", "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptPersonaError))
+	}
+
+	msg := fmt.Sprintf(t.Get(i18n.KeyGptPersonaCurrent), persona.Name, persona.Prompt) + "\n\n" + t.Get(i18n.KeyGptPersonaUsage)
+	return h.client.SendMessage(ctx, chatID, msg)
+}
+
+func formatPersonaList(t *i18n.Translator, personas []model.Persona) string {
+	var sb strings.Builder
+	sb.WriteString(t.Get(i18n.KeyGptPersonaListHeader))
+	for _, p := range personas {
+		sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyGptPersonaListItem), p.Name, p.Prompt))
+	}
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package telegram
+
+import (
+	"context"
+	"got/internal/app"
+	"got/internal/app/model"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHandleGPTPersona(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        string
+		wantMessage string
+		wantSaved   *model.ChatSettings
+	}{
+		{
+			name:        "Show",
+			args:        "",
+			wantMessage: "default",
+		},
+		{
+			name:        "List",
+			args:        "list",
+			wantMessage: "pirate",
+		},
+		{
+			name:        "SetBuiltin",
+			args:        "teacher",
+			wantMessage: "teacher",
+			wantSaved:   &model.ChatSettings{ChatID: 123, Persona: "teacher"},
+		},
+		{
+			name:        "SetCustom",
+			args:        "You are a grumpy cat.",
+			wantMessage: "Custom system prompt saved.",
+			wantSaved:   &model.ChatSettings{ChatID: 123, Persona: model.PersonaCustom, SystemPrompt: "You are a grumpy cat."},
+		},
+		{
+			name:        "Reset",
+			args:        "reset",
+			wantMessage: "Persona reset to default.",
+			wantSaved:   &model.ChatSettings{ChatID: 123},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentMessage string
+			server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+				payload := decodeJSONPayload(t, r)
+				sentMessage = payload["text"].(string)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			var saved *model.ChatSettings
+			chatRepo := &mockChatRepo{
+				saveSettingsFunc: func(ctx context.Context, settings *model.ChatSettings) error {
+					saved = settings
+					return nil
+				},
+			}
+			svc := newMockService(app.Repositories{Chats: chatRepo})
+			handlers := newTestBotHandlers(newTestClient(server.URL), svc)
+
+			if err := handlers.handleGPTPersona(context.Background(), 123, tt.args); err != nil {
+				t.Fatalf("handleGPTPersona() error = %v", err)
+			}
+
+			if !strings.Contains(sentMessage, tt.wantMessage) {
+				t.Errorf("message %q does not contain %q", sentMessage, tt.wantMessage)
+			}
+			if tt.wantSaved != nil && (saved == nil || *saved != *tt.wantSaved) {
+				t.Errorf("saved settings = %+v, want %+v", saved, tt.wantSaved)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+	"got/internal/llm"
+	"got/pkg/i18n"
+)
+
+const quotaResetKeyword = "reset"
+
+func (h *BotHandlers) handleGPTUsage(ctx context.Context, msg *Message) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	report, err := h.service.GetTokenUsageReport(ctx, chatID, messageUserID(msg), time.Now())
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load token usage", "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptTokensError))
+	}
+
+	return h.client.SendMessage(ctx, chatID, formatTokenReport(t, report))
+}
+
+func (h *BotHandlers) handleAdminQuota(ctx context.Context, msg *Message, args string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+	if isAdmin, _ := h.isAdmin(ctx, msg.From.ID); !isAdmin {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminNotLoggedIn))
+	}
+
+	target, rest, ok := parseBanTarget(strings.Fields(args), msg)
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminQuotaUsage))
+	}
+	targetName := formatBanTargetType(t, target.targetType)
+
+	switch {
+	case len(rest) == 0:
+		quota, overridden, err := h.service.GetTokenQuota(ctx, target.targetType, target.targetID)
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to load token quota", "target_type", target.targetType, "target_id", target.targetID, "error", err)
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminQuotaError))
+		}
+		suffix := ""
+		if !overridden {
+			suffix = t.Get(i18n.KeyAdminQuotaDefault)
+		}
+		return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyAdminQuotaShow), targetName, target.targetID, formatQuotaLimit(t, quota.Daily), formatQuotaLimit(t, quota.Monthly), suffix))
+	case len(rest) == 1 && strings.ToLower(rest[0]) == quotaResetKeyword:
+		if err := h.service.ResetTokenQuota(ctx, target.targetType, target.targetID); err != nil {
+			log.ErrorContext(ctx, "Failed to reset token quota", "target_type", target.targetType, "target_id", target.targetID, "error", err)
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminQuotaError))
+		}
+		return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyAdminQuotaReset), targetName, target.targetID))
+	case len(rest) == 2:
+		daily, errDaily := strconv.ParseInt(rest[0], 10, 64)
+		monthly, errMonthly := strconv.ParseInt(rest[1], 10, 64)
+		if errDaily != nil || errMonthly != nil {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminQuotaUsage))
+		}
+
+		quota, err := h.service.SetTokenQuota(ctx, target.targetType, target.targetID, daily, monthly)
+		if errors.Is(err, app.ErrInvalidQuota) {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminQuotaUsage))
+		}
+		if err != nil {
+			log.ErrorContext(ctx, "Failed to save token quota", "target_type", target.targetType, "target_id", target.targetID, "error", err)
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminQuotaError))
+		}
+		return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyAdminQuotaSet), targetName, quota.TargetID, formatQuotaLimit(t, quota.Daily), formatQuotaLimit(t, quota.Monthly)))
+	default:
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminQuotaUsage))
+	}
+}
+
+func (h *BotHandlers) quotaMessage(ctx context.Context, msg *Message) string {
+	err := h.service.CheckTokenQuota(ctx, msg.Chat.ID, messageUserID(msg), time.Now())
+
+	var exceeded *app.QuotaExceededError
+	if !errors.As(err, &exceeded) {
+		if err != nil {
+			log.WarnContext(ctx, "Failed to check token quota, allowing request", "error", err)
+		}
+		return ""
+	}
+
+	log.InfoContext(ctx, "Token quota exceeded", "chat_id", msg.Chat.ID, "target_type", exceeded.TargetType, "period", exceeded.Period)
+	t := h.getTranslator(ctx, msg.Chat.ID)
+	return fmt.Sprintf(t.Get(quotaExceededKey(exceeded)), exceeded.Used, exceeded.Limit)
+}
+
+func (h *BotHandlers) recordTokenUsage(ctx context.Context, msg *Message, result llm.Result) {
+	for _, attempt := range result.Failed {
+		h.recordUsage(ctx, msg, attempt.Ref, attempt.Usage)
+	}
+	h.recordUsage(ctx, msg, result.Ref, result.Usage)
+}
+
+func (h *BotHandlers) recordUsage(ctx context.Context, msg *Message, ref string, tokens groq.Usage) {
+	if tokens.PromptTokens == 0 && tokens.CompletionTokens == 0 {
+		return
+	}
+
+	usage := &model.TokenUsage{
+		ChatID:           msg.Chat.ID,
+		UserID:           messageUserID(msg),
+		Model:            ref,
+		PromptTokens:     int64(tokens.PromptTokens),
+		CompletionTokens: int64(tokens.CompletionTokens),
+	}
+	if err := h.service.RecordTokenUsage(ctx, usage); err != nil {
+		log.WarnContext(ctx, "Failed to record token usage", "error", err)
+	}
+}
+
+func quotaExceededKey(e *app.QuotaExceededError) i18n.Key {
+	switch {
+	case e.TargetType == model.QuotaTargetChat && e.Period == model.QuotaPeriodMonth:
+		return i18n.KeyGptQuotaChatMonth
+	case e.TargetType == model.QuotaTargetChat:
+		return i18n.KeyGptQuotaChatDay
+	case e.Period == model.QuotaPeriodMonth:
+		return i18n.KeyGptQuotaUserMonth
+	default:
+		return i18n.KeyGptQuotaUserDay
+	}
+}
+
+func formatTokenReport(t *i18n.Translator, report *model.TokenUsageReport) string {
+	var sb strings.Builder
+	sb.WriteString(t.Get(i18n.KeyGptTokensHeader))
+	sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyGptTokensChat), formatTokenUsage(t, report.ChatDay), formatTokenUsage(t, report.ChatMonth)))
+	sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyGptTokensUser), formatTokenUsage(t, report.UserDay), formatTokenUsage(t, report.UserMonth)))
+
+	if len(report.Models) > 0 {
+		sb.WriteString(t.Get(i18n.KeyGptTokensTopModels))
+		for i, m := range report.Models {
+			sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyUsageItem), i+1, "`"+m.Name+"`", m.Count))
+		}
+	}
+
+	if len(report.Users) > 0 {
+		sb.WriteString(t.Get(i18n.KeyGptTokensTopUsers))
+		for i, u := range report.Users {
+			sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyUsageItem), i+1, usageCountName(u, "User"), u.Count))
+		}
+	}
+
+	return sb.String()
+}
+
+func formatTokenUsage(t *i18n.Translator, usage model.TokenLimitUsage) string {
+	if usage.Limit <= 0 {
+		return fmt.Sprintf("%d (%s)", usage.Used, t.Get(i18n.KeyQuotaUnlimited))
+	}
+	return fmt.Sprintf("%d/%d", usage.Used, usage.Limit)
+}
+
+func formatQuotaLimit(t *i18n.Translator, limit int64) string {
+	if limit <= 0 {
+		return t.Get(i18n.KeyQuotaUnlimited)
+	}
+	return strconv.FormatInt(limit, 10)
+}
+
+func messageUserID(msg *Message) int64 {
+	if msg.From == nil {
+		return 0
+	}
+	return msg.From.ID
+}
@@ -0,0 +1,115 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+)
+
+func TestHandleGPTQuotaExceeded(t *testing.T) {
+	var sent []string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, sendMessageCMD):
+			sent = append(sent, decodeJSONPayload(t, r)["text"].(string))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	tokens := &mockTokenRepo{
+		totalsFunc: func(ctx context.Context, targetType string, targetID int64, since time.Time) (*model.TokenTotals, error) {
+			return &model.TokenTotals{PromptTokens: 900, CompletionTokens: 200}, nil
+		},
+	}
+	svc := newTestServiceWithTokens(tokens)
+	svc.SetDefaultTokenQuota(model.QuotaTargetUser, 1000, 0)
+
+	gpt := groq.NewCompatibleClient("local", server.URL, "", "llama", []string{"llama"})
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), svc, gpt)
+
+	update := &Update{Message: &Message{Text: "/gpt hello", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if len(sent) != 1 || sent[0] != "Daily limit reached (1100/1000 tokens)." {
+		t.Errorf("sent = %q, want quota message", sent)
+	}
+}
+
+func TestHandleGPTRecordsTokenUsage(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			_ = json.NewEncoder(w).Encode(groq.Response{
+				Choices: []groq.Choice{{Message: groq.Message{Role: "assistant", Content: "Hi!"}}},
+				Usage:   &groq.Usage{PromptTokens: 120, CompletionTokens: 8, TotalTokens: 128},
+			})
+		}
+	})
+
+	var recorded *model.TokenUsage
+	tokens := &mockTokenRepo{
+		saveFunc: func(ctx context.Context, usage *model.TokenUsage) error {
+			recorded = usage
+			return nil
+		},
+	}
+	gpt := groq.NewCompatibleClient("local", server.URL, "", "llama", []string{"llama"})
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), newTestServiceWithTokens(tokens), gpt)
+
+	update := &Update{Message: &Message{Text: "/gpt hello", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if recorded == nil {
+		t.Fatal("token usage was not recorded")
+	}
+	if recorded.ChatID != testChatID || recorded.UserID != 42 || recorded.Model != "llama" {
+		t.Errorf("recorded = %+v, want chat %d user 42 model llama", recorded, testChatID)
+	}
+	if recorded.PromptTokens != 120 || recorded.CompletionTokens != 8 {
+		t.Errorf("recorded tokens = %d/%d, want 120/8", recorded.PromptTokens, recorded.CompletionTokens)
+	}
+}
+
+func TestFormatTokenReport(t *testing.T) {
+	report := &model.TokenUsageReport{
+		ChatDay:   model.TokenLimitUsage{Used: 500, Limit: 1000},
+		ChatMonth: model.TokenLimitUsage{Used: 7000},
+		UserDay:   model.TokenLimitUsage{Used: 100},
+		UserMonth: model.TokenLimitUsage{Used: 900, Limit: 50000},
+		Models:    []*model.UsageCount{{Name: "llama", Count: 7000}},
+		Users:     []*model.UsageCount{{ID: 42, Name: "alice", Count: 900}},
+	}
+
+	got := formatTokenReport(newTestTranslator(), report)
+
+	for _, want := range []string{
+		"Chat: today 500/1000, month 7000 (no limit)",
+		"You: today 100 (no limit), month 900/50000",
+		"`llama`",
+		"alice",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestQuotaExceededKey(t *testing.T) {
+	err := &app.QuotaExceededError{TargetType: model.QuotaTargetChat, Period: model.QuotaPeriodMonth, Used: 10, Limit: 5}
+	if got := quotaExceededKey(err); got != "gpt_quota_chat_month" {
+		t.Errorf("quotaExceededKey() = %q, want gpt_quota_chat_month", got)
+	}
+}
+
+func newTestServiceWithTokens(tokens *mockTokenRepo) *app.Service {
+	return newMockService(app.Repositories{Tokens: tokens})
+}
@@ -0,0 +1,87 @@
+package telegram
+
+import (
+	"strings"
+
+	"got/internal/groq"
+)
+
+const (
+	maxReplyContextRunes = 2000
+	replyContextHeader   = "Context from the message being replied to:"
+)
+
+func replyContext(msg *Message, history []groq.Message) string {
+	if msg == nil || msg.ReplyToMessage == nil {
+		return ""
+	}
+	reply := msg.ReplyToMessage
+	text := strings.TrimSpace(messageText(reply))
+	known := (reply.From != nil && reply.From.IsBot) || inHistory(history, text)
+
+	var lines []string
+	if origin := forwardOriginName(reply.ForwardOrigin); origin != "" && !known {
+		lines = append(lines, "Forwarded from: "+origin)
+	}
+	if text != "" && !known {
+		lines = append(lines, "Text: "+truncateRunes(text, maxReplyContextRunes))
+	}
+	if msg.Quote != nil && strings.TrimSpace(msg.Quote.Text) != "" {
+		lines = append(lines, "Quoted fragment: "+strings.TrimSpace(msg.Quote.Text))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	if author := userName(reply.From); author != "" && !known {
+		lines = append([]string{"Author: " + author}, lines...)
+	}
+	return replyContextHeader + "\n" + strings.Join(lines, "\n")
+}
+
+func withReplyContext(msg *Message, history []groq.Message, prompt string) string {
+	quoted := replyContext(msg, history)
+	if quoted == "" {
+		return prompt
+	}
+	return quoted + "\n\n" + prompt
+}
+
+func inHistory(history []groq.Message, text string) bool {
+	if text == "" {
+		return false
+	}
+	for _, m := range history {
+		if strings.Contains(m.Content, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func forwardOriginName(origin *MessageOrigin) string {
+	if origin == nil {
+		return ""
+	}
+	if name := userName(origin.SenderUser); name != "" {
+		return name
+	}
+	if origin.SenderUserName != "" {
+		return origin.SenderUserName
+	}
+	for _, chat := range []*Chat{origin.Chat, origin.SenderChat} {
+		if chat != nil && chat.Title != "" {
+			return chat.Title
+		}
+	}
+	return ""
+}
+
+func userName(user *User) string {
+	if user == nil {
+		return ""
+	}
+	if user.UserName != "" {
+		return user.UserName
+	}
+	return user.FirstName
+}
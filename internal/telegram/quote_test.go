@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"got/internal/groq"
+)
+
+func TestReplyContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     *Message
+		history []groq.Message
+		want    string
+	}{
+		{
+			name: "No reply",
+			msg:  &Message{Text: "/gpt hi"},
+			want: "",
+		},
+		{
+			name: "Reply with text",
+			msg:  &Message{ReplyToMessage: &Message{Text: "the build is red", From: &User{UserName: "alice"}}},
+			want: replyContextHeader + "\nAuthor: alice\nText: the build is red",
+		},
+		{
+			name: "Caption with forward and quote",
+			msg: &Message{
+				Quote: &TextQuote{Text: " 40% faster "},
+				ReplyToMessage: &Message{
+					Caption:       "Release notes: 40% faster startup",
+					From:          &User{FirstName: "Bob"},
+					ForwardOrigin: &MessageOrigin{Type: "channel", Chat: &Chat{Title: "Go News"}},
+				},
+			},
+			want: replyContextHeader + "\nAuthor: Bob\nForwarded from: Go News\nText: Release notes: 40% faster startup\nQuoted fragment: 40% faster",
+		},
+		{
+			name: "Hidden forward sender",
+			msg:  &Message{ReplyToMessage: &Message{Text: "hello", ForwardOrigin: &MessageOrigin{Type: "hidden_user", SenderUserName: "Carol"}}},
+			want: replyContextHeader + "\nForwarded from: Carol\nText: hello",
+		},
+		{
+			name: "Reply to the bot keeps only the quote",
+			msg: &Message{
+				Quote:          &TextQuote{Text: "tests fail"},
+				ReplyToMessage: &Message{Text: "It means the tests fail.", From: &User{UserName: "gotbot", IsBot: true}},
+			},
+			want: replyContextHeader + "\nQuoted fragment: tests fail",
+		},
+		{
+			name:    "Reply already in history",
+			msg:     &Message{ReplyToMessage: &Message{Text: "the build is red", From: &User{UserName: "alice"}}},
+			history: []groq.Message{{Role: "user", Content: "alice: the build is red"}},
+			want:    "",
+		},
+		{
+			name: "Sticker without text",
+			msg:  &Message{ReplyToMessage: &Message{Sticker: &Sticker{FileID: "s"}, From: &User{UserName: "alice"}}},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replyContext(tt.msg, tt.history); got != tt.want {
+				t.Errorf("replyContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplyContextTruncatesLongText(t *testing.T) {
+	msg := &Message{ReplyToMessage: &Message{Text: strings.Repeat("a", maxReplyContextRunes+50)}}
+
+	got := replyContext(msg, nil)
+
+	if !strings.HasSuffix(got, strings.Repeat("a", maxReplyContextRunes-1)+"…") {
+		t.Errorf("replyContext() length = %d, want text truncated to %d runes", len([]rune(got)), maxReplyContextRunes)
+	}
+}
+
+func TestHandleGPTChatIncludesReplyContext(t *testing.T) {
+	var prompt string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			var req groq.Request
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			prompt = req.Messages[len(req.Messages)-1].Content
+			_ = json.NewEncoder(w).Encode(groq.Response{
+				Choices: []groq.Choice{{Message: groq.Message{Role: "assistant", Content: "It means the tests fail."}}},
+			})
+		}
+	})
+
+	gpt := groq.NewCompatibleClient("local", server.URL, "", "llama", []string{"llama"})
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), newTestServiceForHandlers(), gpt)
+
+	update := &Update{Message: &Message{
+		Text:           "/gpt explain this",
+		Chat:           &Chat{ID: testChatID},
+		From:           &User{ID: 42, UserName: "tomas"},
+		ReplyToMessage: &Message{Text: "the build is red", From: &User{UserName: "alice"}},
+	}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	want := replyContextHeader + "\nAuthor: alice\nText: the build is red\n\ntomas: explain this"
+	if prompt != want {
+		t.Errorf("prompt = %q, want %q", prompt, want)
+	}
+}
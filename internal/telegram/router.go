@@ -2,11 +2,12 @@ package telegram
 
 import (
 	"context"
-	"log/slog"
 )
 
 type Router struct {
 	handlers map[string]HandlerFunc
+	fallback HandlerFunc
+	callback HandlerFunc
 }
 
 func NewRouter() *Router {
@@ -19,13 +20,30 @@ func (r *Router) Register(command string, handler HandlerFunc) {
 	r.handlers[command] = handler
 }
 
+func (r *Router) SetFallback(handler HandlerFunc) {
+	r.fallback = handler
+}
+
+func (r *Router) SetCallbackHandler(handler HandlerFunc) {
+	r.callback = handler
+}
+
 func (r *Router) Handle(ctx context.Context, update *Update) error {
+	if update.CallbackQuery != nil {
+		if r.callback != nil {
+			return r.callback(ctx, update)
+		}
+		return nil
+	}
 	if update.Message == nil {
 		return nil
 	}
 
 	cmd := update.Message.Command()
 	if cmd == "" {
+		if r.fallback != nil {
+			return r.fallback(ctx, update)
+		}
 		return nil
 	}
 
@@ -37,6 +55,6 @@ func (r *Router) executeCommand(ctx context.Context, cmd string, update *Update)
 		return handler(ctx, update)
 	}
 
-	slog.Info("Unknown command", "command", cmd)
+	log.InfoContext(ctx, "Unknown command", "command", cmd)
 	return nil
 }
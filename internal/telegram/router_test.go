@@ -91,3 +91,52 @@ func TestRouterHandle(t *testing.T) {
 		})
 	}
 }
+
+func TestRouterFallback(t *testing.T) {
+	var handled []string
+	r := NewRouter()
+	r.Register("start", func(ctx context.Context, update *Update) error {
+		handled = append(handled, "start")
+		return nil
+	})
+	r.SetFallback(func(ctx context.Context, update *Update) error {
+		handled = append(handled, "fallback")
+		return nil
+	})
+
+	for _, text := range []string{"/start", "just text", "/unknown"} {
+		if err := r.Handle(context.Background(), &Update{Message: &Message{Text: text}}); err != nil {
+			t.Fatalf("Router.Handle(%q) error = %v", text, err)
+		}
+	}
+
+	if len(handled) != 2 || handled[0] != "start" || handled[1] != "fallback" {
+		t.Errorf("handled = %v, want [start fallback]", handled)
+	}
+}
+
+func TestRouterCallback(t *testing.T) {
+	var handled []string
+	r := NewRouter()
+	r.SetFallback(func(ctx context.Context, update *Update) error {
+		handled = append(handled, "fallback")
+		return nil
+	})
+
+	update := &Update{CallbackQuery: &CallbackQuery{ID: "1", Data: "gpt:regen"}}
+	if err := r.Handle(context.Background(), update); err != nil {
+		t.Fatalf("Router.Handle() without callback handler error = %v", err)
+	}
+
+	r.SetCallbackHandler(func(ctx context.Context, update *Update) error {
+		handled = append(handled, update.CallbackQuery.Data)
+		return nil
+	})
+	if err := r.Handle(context.Background(), update); err != nil {
+		t.Fatalf("Router.Handle() error = %v", err)
+	}
+
+	if len(handled) != 1 || handled[0] != "gpt:regen" {
+		t.Errorf("handled = %v, want only the callback handler", handled)
+	}
+}
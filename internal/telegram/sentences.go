@@ -1,9 +1,9 @@
 package telegram
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
 	"math/rand"
 	"os"
 	"strings"
@@ -41,13 +41,13 @@ func NewSentenceProviderFromFile(path string) *SentenceProvider {
 
 	data, err := os.ReadFile(path)
 	if err != nil {
-		slog.Warn("Failed to load sentences file, roulette animation disabled", "path", path, "error", err)
+		log.Warn("Failed to load sentences file, roulette animation disabled", "path", path, "error", err)
 		return provider
 	}
 
 	var multiLang map[string]SentencesFile
 	if err := json.Unmarshal(data, &multiLang); err != nil {
-		slog.Error("Failed to parse sentences file", "path", path, "error", err)
+		log.Error("Failed to parse sentences file", "path", path, "error", err)
 		return provider
 	}
 
@@ -55,7 +55,7 @@ func NewSentenceProviderFromFile(path string) *SentenceProvider {
 		provider.languages[lang] = file.Groups
 	}
 
-	slog.Info("Loaded roulette sentences", "languages", len(provider.languages))
+	log.Info("Loaded roulette sentences", "languages", len(provider.languages))
 	return provider
 }
 
@@ -88,16 +88,16 @@ func RandomDelay() time.Duration {
 	return randomDelay()
 }
 
-func (p *SentenceProvider) SendSequence(client *Client, chatID int64, lang, alias, winnerName, fallbackMsg string) error {
+func (p *SentenceProvider) SendSequence(ctx context.Context, client *Client, chatID int64, lang, alias, winnerName, fallbackMsg string) error {
 	sentences := p.GetRandomGroup(lang)
 	if len(sentences) == 0 {
-		return client.SendMessage(chatID, fallbackMsg)
+		return client.SendMessage(ctx, chatID, fallbackMsg)
 	}
 
 	for _, sentence := range sentences {
 		time.Sleep(randomDelay())
 		msg := FormatSentence(sentence, alias, winnerName)
-		if err := client.SendMessage(chatID, msg); err != nil {
+		if err := client.SendMessage(ctx, chatID, msg); err != nil {
 			return err
 		}
 	}
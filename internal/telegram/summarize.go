@@ -0,0 +1,232 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"got/internal/app/model"
+	"got/internal/groq"
+	"got/pkg/i18n"
+)
+
+const (
+	defaultSummarizeMessages = 200
+	maxSummarizeMessages     = 1000
+	maxSummaryChunkTokens    = 6000
+	minSummaryChunkTokens    = 512
+	summaryReplyTokens       = 1024
+	maxSummaryRounds         = 3
+	summarizeSince           = "since"
+	summaryLineFormat        = "[%s] %s: %s"
+	summarizeChunkPrompt     = "You summarize a part of a Telegram group chat log. " +
+		"List the main topics, decisions, open questions and who said what in a few short bullet points. " +
+		"Write in the language most messages are written in. Reply with the summary only."
+	summarizeReducePrompt = "You merge partial summaries of consecutive parts of a Telegram group chat into one summary. " +
+		"Keep it short, group related points, keep names and decisions, and drop repetition. " +
+		"Write in the language of the partial summaries. Reply with the summary only."
+)
+
+func (h *BotHandlers) handleGPTLog(ctx context.Context, msg *Message, arg string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	if arg == "" {
+		enabled, err := h.service.IsMessageLogEnabled(ctx, chatID)
+		if err != nil {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLogError))
+		}
+		return h.client.SendMessage(ctx, chatID, t.Get(messageLogKey(enabled)))
+	}
+
+	if arg != switchOn && arg != switchOff {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLogUsage))
+	}
+	if !h.canManageChat(ctx, msg) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLogAdminOnly))
+	}
+
+	enabled := arg == switchOn
+	if err := h.service.SetMessageLog(ctx, chatID, enabled); err != nil {
+		log.ErrorContext(ctx, "Failed to save message log setting", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLogError))
+	}
+	return h.client.SendMessage(ctx, chatID, t.Get(messageLogKey(enabled)))
+}
+
+func (h *BotHandlers) handleGPTSummarize(ctx context.Context, msg *Message, arg string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	limit, since, ok := parseSummarizeArgs(arg, time.Now())
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptSummarizeUsage))
+	}
+
+	enabled, err := h.service.IsMessageLogEnabled(ctx, chatID)
+	if err != nil || !enabled {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptSummarizeDisabled))
+	}
+	if blocked := h.quotaMessage(ctx, msg); blocked != "" {
+		return h.client.SendMessage(ctx, chatID, blocked)
+	}
+
+	messages, err := h.service.RecentMessages(ctx, chatID, since, limit)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load message log", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptLogError))
+	}
+	if len(messages) == 0 {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptSummarizeEmpty))
+	}
+
+	typing := h.startTyping(ctx, chatID, actionTyping)
+	defer typing.Stop()
+
+	summary, err := h.summarizeLines(ctx, msg, h.getChatModel(ctx, chatID), formatLogLines(messages))
+	if err != nil {
+		log.WarnContext(ctx, "Chat summary failed", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(gptErrorKey(err)))
+	}
+	if notice, allowed := h.moderate(ctx, msg, model.ModerationSourceGPT, summary); !allowed {
+		return h.client.SendMessage(ctx, chatID, notice)
+	}
+	header := fmt.Sprintf(t.Get(i18n.KeyGptSummarizeResult), len(messages), "")
+	summary = truncateMarkdown(summary, maxAnswerRunes-utf8.RuneCountInString(header))
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyGptSummarizeResult), len(messages), summary))
+}
+
+func (h *BotHandlers) summarizeLines(ctx context.Context, msg *Message, ref string, lines []string) (string, error) {
+	p, model := h.gpt.Resolve(ref)
+	if model == "" {
+		model = p.Model()
+	}
+	budget := h.summaryBudget(ctx, h.gpt.Ref(p.Name(), model), model)
+
+	prompt := summarizeChunkPrompt
+	for round := 0; ; round++ {
+		var partials []string
+		for _, chunk := range chunkLines(model, lines, budget) {
+			result, err := h.gpt.Complete(ctx, ref, groq.ChatRequest{
+				SystemPrompt: prompt,
+				Prompt:       strings.Join(chunk, "\n"),
+			})
+			if err != nil {
+				return "", err
+			}
+			h.recordTokenUsage(ctx, msg, result)
+			partials = append(partials, strings.TrimSpace(result.Content))
+		}
+
+		if len(partials) == 1 || round+1 >= maxSummaryRounds {
+			return strings.Join(partials, "\n\n"), nil
+		}
+		lines, prompt = partials, summarizeReducePrompt
+	}
+}
+
+func (h *BotHandlers) summaryBudget(ctx context.Context, ref, model string) int {
+	budget := h.gpt.Describe(ctx, ref).ContextWindow - summaryReplyTokens - groq.EstimateTokens(model, summarizeReducePrompt)
+	return max(min(budget, maxSummaryChunkTokens), minSummaryChunkTokens)
+}
+
+func chunkLines(model string, lines []string, budget int) [][]string {
+	var chunks [][]string
+	var current []string
+	used := 0
+	for _, line := range splitLongLines(lines, budget) {
+		cost := groq.EstimateTokens(model, line) + 1
+		if len(current) > 0 && used+cost > budget {
+			chunks = append(chunks, current)
+			current, used = nil, 0
+		}
+		current = append(current, line)
+		used += cost
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+func splitLongLines(lines []string, maxRunes int) []string {
+	var split []string
+	for _, line := range lines {
+		runes := []rune(line)
+		for len(runes) > maxRunes {
+			split = append(split, string(runes[:maxRunes]))
+			runes = runes[maxRunes:]
+		}
+		split = append(split, string(runes))
+	}
+	return split
+}
+
+func parseSummarizeArgs(arg string, now time.Time) (int, time.Time, bool) {
+	fields := strings.Fields(strings.ToLower(arg))
+	switch {
+	case len(fields) == 0:
+		return defaultSummarizeMessages, time.Time{}, true
+	case len(fields) == 1:
+		n, err := strconv.Atoi(fields[0])
+		if err != nil || n <= 0 {
+			return 0, time.Time{}, false
+		}
+		return min(n, maxSummarizeMessages), time.Time{}, true
+	case len(fields) == 2 && fields[0] == summarizeSince:
+		d, err := ParseDuration(fields[1])
+		if err != nil || d <= 0 {
+			return 0, time.Time{}, false
+		}
+		return maxSummarizeMessages, now.Add(-d), true
+	default:
+		return 0, time.Time{}, false
+	}
+}
+
+func formatLogLines(messages []*model.ChatMessage) []string {
+	lines := make([]string, 0, len(messages))
+	for _, m := range messages {
+		name := m.Username
+		if name == "" {
+			name = fmt.Sprintf("User%d", m.UserID)
+		}
+		lines = append(lines, fmt.Sprintf(summaryLineFormat, m.CreatedAt.Format("15:04"), name, m.Text))
+	}
+	return lines
+}
+
+func (h *BotHandlers) logMessage(ctx context.Context, msg *Message, settings *model.ChatSettings) {
+	if !settings.MessageLog || msg.From == nil || msg.From.IsBot {
+		return
+	}
+	text := messageText(msg)
+	if text == "" || strings.HasPrefix(text, "/") {
+		return
+	}
+
+	username := msg.From.UserName
+	if username == "" {
+		username = msg.From.FirstName
+	}
+	err := h.service.LogMessage(ctx, settings, &model.ChatMessage{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.MessageID,
+		UserID:    msg.From.ID,
+		Username:  username,
+		Text:      text,
+	})
+	if err != nil {
+		log.WarnContext(ctx, "Failed to log chat message", "chat_id", msg.Chat.ID, "error", err)
+	}
+}
+
+func messageLogKey(enabled bool) i18n.Key {
+	if enabled {
+		return i18n.KeyGptLogOn
+	}
+	return i18n.KeyGptLogOff
+}
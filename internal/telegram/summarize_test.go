@@ -0,0 +1,214 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+)
+
+func newTestServiceWithMessageLog(enabled bool, messages *mockMessageRepo) *app.Service {
+	chats := &mockChatRepo{
+		getSettingsFunc: func(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+			return &model.ChatSettings{ChatID: chatID, MessageLog: enabled}, nil
+		},
+	}
+	return newMockService(app.Repositories{Chats: chats, Messages: messages})
+}
+
+func TestParseSummarizeArgs(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		arg       string
+		wantLimit int
+		wantSince time.Time
+		wantOK    bool
+	}{
+		{name: "Default", arg: "", wantLimit: defaultSummarizeMessages, wantOK: true},
+		{name: "Count", arg: "50", wantLimit: 50, wantOK: true},
+		{name: "CountCapped", arg: "5000", wantLimit: maxSummarizeMessages, wantOK: true},
+		{name: "Since", arg: "since 2h", wantLimit: maxSummarizeMessages, wantSince: now.Add(-2 * time.Hour), wantOK: true},
+		{name: "SinceDays", arg: "Since 1d", wantLimit: maxSummarizeMessages, wantSince: now.Add(-24 * time.Hour), wantOK: true},
+		{name: "Zero", arg: "0", wantOK: false},
+		{name: "Garbage", arg: "everything", wantOK: false},
+		{name: "SinceWithoutDuration", arg: "since", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, since, ok := parseSummarizeArgs(tt.arg, now)
+			if ok != tt.wantOK || limit != tt.wantLimit || !since.Equal(tt.wantSince) {
+				t.Errorf("parseSummarizeArgs(%q) = %d, %v, %v, want %d, %v, %v", tt.arg, limit, since, ok, tt.wantLimit, tt.wantSince, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestChunkLines(t *testing.T) {
+	lines := []string{strings.Repeat("a", 400), strings.Repeat("b", 400), "short", strings.Repeat("c", 2000)}
+
+	chunks := chunkLines("llama", lines, 150)
+
+	var joined []string
+	for i, chunk := range chunks {
+		used := 0
+		for _, line := range chunk {
+			used += groq.EstimateTokens("llama", line) + 1
+		}
+		if used > 150 {
+			t.Errorf("chunk %d uses %d tokens, over the 150 budget", i, used)
+		}
+		joined = append(joined, strings.Join(chunk, ""))
+	}
+	if got := strings.Join(joined, ""); got != strings.Join(lines, "") {
+		t.Error("chunks should keep every line in order")
+	}
+}
+
+func TestHandleGPTSummarize(t *testing.T) {
+	var sent []string
+	var prompts []string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+			var req groq.Request
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			prompts = append(prompts, req.Messages[len(req.Messages)-1].Content)
+			_ = json.NewEncoder(w).Encode(groq.Response{
+				Choices: []groq.Choice{{Message: groq.Message{Role: "assistant", Content: "Alice planned a trip."}}},
+			})
+		case strings.HasSuffix(r.URL.Path, sendMessageCMD):
+			sent = append(sent, decodeJSONPayload(t, r)["text"].(string))
+		}
+	})
+
+	var gotLimit int
+	messages := &mockMessageRepo{
+		listRecentFunc: func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.ChatMessage, error) {
+			gotLimit = limit
+			return []*model.ChatMessage{
+				{UserID: 1, Username: "alice", Text: "let's go to Vilnius", CreatedAt: time.Date(2024, 5, 1, 9, 5, 0, 0, time.UTC)},
+				{UserID: 2, Text: "sounds good", CreatedAt: time.Date(2024, 5, 1, 9, 6, 0, 0, time.UTC)},
+			}, nil
+		},
+	}
+	gpt := groq.NewCompatibleClient("local", server.URL, "", "llama", []string{"llama"})
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), newTestServiceWithMessageLog(true, messages), gpt)
+
+	update := &Update{Message: &Message{Text: "/gpt summarize 30", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if gotLimit != 30 {
+		t.Errorf("limit = %d, want 30", gotLimit)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "[09:05] alice: let's go to Vilnius\n[09:06] User2: sounds good") {
+		t.Errorf("prompts = %q, want formatted log lines", prompts)
+	}
+	if len(sent) != 1 || sent[0] != "Summary of 2 messages:\nAlice planned a trip." {
+		t.Errorf("sent = %q, want summary", sent)
+	}
+}
+
+func TestHandleGPTSummarizeDisabled(t *testing.T) {
+	var sent string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		sent = decodeJSONPayload(t, r)["text"].(string)
+	})
+
+	messages := &mockMessageRepo{
+		listRecentFunc: func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.ChatMessage, error) {
+			t.Error("message log should not be read when disabled")
+			return nil, nil
+		},
+	}
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), newTestServiceWithMessageLog(false, messages), groq.NewClient("test-key"))
+
+	update := &Update{Message: &Message{Text: "/gpt summarize", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if sent != "Message log is off." {
+		t.Errorf("sent = %q, want disabled message", sent)
+	}
+}
+
+func TestHandleGPTLogBadArgument(t *testing.T) {
+	var sent string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		sent = decodeJSONPayload(t, r)["text"].(string)
+	})
+	handlers := newTestBotHandlersWithGPT(newTestClient(server.URL), newTestServiceWithMessageLog(true, &mockMessageRepo{}), groq.NewClient("test-key"))
+
+	update := &Update{Message: &Message{Text: "/gpt log maybe", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}}
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if sent != "Usage: /gpt log [on|off]" {
+		t.Errorf("sent = %q, want log usage", sent)
+	}
+}
+
+func TestHandleMessageLogsText(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		msg       *Message
+		wantSaved string
+	}{
+		{
+			name:      "Enabled",
+			enabled:   true,
+			msg:       &Message{MessageID: 7, Text: "hello", Chat: &Chat{ID: testChatID}, From: &User{ID: 42, UserName: "bob"}},
+			wantSaved: "hello",
+		},
+		{
+			name:      "Caption",
+			enabled:   true,
+			msg:       &Message{Caption: "look", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}},
+			wantSaved: "look",
+		},
+		{
+			name:    "DisabledByDefault",
+			enabled: false,
+			msg:     &Message{Text: "hello", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}},
+		},
+		{
+			name:    "SkipsBots",
+			enabled: true,
+			msg:     &Message{Text: "beep", Chat: &Chat{ID: testChatID}, From: &User{ID: 9, IsBot: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *model.ChatMessage
+			messages := &mockMessageRepo{
+				saveFunc: func(ctx context.Context, msg *model.ChatMessage) error {
+					saved = msg
+					return nil
+				},
+			}
+			handlers := newTestBotHandlers(newTestClient("http://unused"), newTestServiceWithMessageLog(tt.enabled, messages))
+
+			err := handlers.HandleMessage(context.Background(), &Update{Message: tt.msg})
+
+			assertNoError(t, err)
+			got := ""
+			if saved != nil {
+				got = saved.Text
+			}
+			if got != tt.wantSaved {
+				t.Errorf("saved text = %q, want %q", got, tt.wantSaved)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"got/internal/groq"
+)
+
+const (
+	toolSetReminder   = "set_reminder"
+	toolListReminders = "list_reminders"
+	toolRandomFact    = "get_random_fact"
+	toolAddFact       = "add_fact"
+	toolSendMeme      = "send_meme"
+	toolAddSubreddit  = "add_subreddit"
+
+	memberStatusCreator       = "creator"
+	memberStatusAdministrator = "administrator"
+	chatTypePrivate           = "private"
+)
+
+type chatTool struct {
+	description string
+	parameters  map[string]any
+	adminOnly   bool
+	run         func(h *BotHandlers, ctx context.Context, msg *Message, args json.RawMessage) (string, error)
+}
+
+var errToolPermission = errors.New("permission denied: only chat administrators can do this")
+
+var chatToolRegistry = map[string]chatTool{
+	toolSetReminder: {
+		description: "Schedule a reminder in the current chat.",
+		parameters: objectSchema(map[string]any{
+			"duration": stringProperty("How long from now, e.g. 30m, 2h, 1d, 1d2h."),
+			"message":  stringProperty("What to remind about."),
+		}, "duration", "message"),
+		run: runSetReminder,
+	},
+	toolListReminders: {
+		description: "List pending reminders in the current chat.",
+		parameters:  objectSchema(nil),
+		run:         runListReminders,
+	},
+	toolRandomFact: {
+		description: "Get a random fact saved in the current chat.",
+		parameters:  objectSchema(nil),
+		run:         runRandomFact,
+	},
+	toolAddFact: {
+		description: "Save a new fact for the current chat.",
+		parameters: objectSchema(map[string]any{
+			"text": stringProperty("The fact to save."),
+		}, "text"),
+		run: runAddFact,
+	},
+	toolSendMeme: {
+		description: "Send a random meme to the chat, optionally from a specific subreddit.",
+		parameters: objectSchema(map[string]any{
+			"subreddit": stringProperty("Subreddit name without the r/ prefix. Optional."),
+		}),
+		run: runSendMeme,
+	},
+	toolAddSubreddit: {
+		description: "Add a subreddit to the chat's meme sources.",
+		parameters: objectSchema(map[string]any{
+			"name": stringProperty("Subreddit name without the r/ prefix."),
+		}, "name"),
+		adminOnly: true,
+		run:       runAddSubreddit,
+	},
+}
+
+func chatTools() []groq.Tool {
+	names := make([]string, 0, len(chatToolRegistry))
+	for name := range chatToolRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tools := make([]groq.Tool, 0, len(names))
+	for _, name := range names {
+		tool := chatToolRegistry[name]
+		tools = append(tools, groq.NewFunctionTool(name, tool.description, tool.parameters))
+	}
+	return tools
+}
+
+func (h *BotHandlers) toolExecutor(msg *Message) groq.ToolExecutor {
+	return func(ctx context.Context, call groq.ToolCall) (string, error) {
+		tool, ok := chatToolRegistry[call.Function.Name]
+		if !ok {
+			return "", fmt.Errorf("unknown tool: %s", call.Function.Name)
+		}
+
+		if tool.adminOnly && !h.canManageChat(ctx, msg) {
+			return "", errToolPermission
+		}
+
+		args := json.RawMessage(call.Function.Arguments)
+		if len(args) == 0 {
+			args = json.RawMessage("{}")
+		}
+
+		log.InfoContext(ctx, "Running GPT tool", "tool", call.Function.Name)
+		return tool.run(h, ctx, msg, args)
+	}
+}
+
+func (h *BotHandlers) canManageChat(ctx context.Context, msg *Message) bool {
+	if msg.Chat.Type == chatTypePrivate {
+		return true
+	}
+	if msg.From == nil {
+		return false
+	}
+	if isAdmin, _ := h.isAdmin(ctx, msg.From.ID); isAdmin {
+		return true
+	}
+
+	member, err := h.client.GetChatMember(ctx, msg.Chat.ID, msg.From.ID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to check chat member status", "error", err)
+		return false
+	}
+	return member.Status == memberStatusCreator || member.Status == memberStatusAdministrator
+}
+
+func runSetReminder(h *BotHandlers, ctx context.Context, msg *Message, raw json.RawMessage) (string, error) {
+	var args struct {
+		Duration string `json:"duration"`
+		Message  string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(args.Message) == "" {
+		return "", errors.New("message is required")
+	}
+
+	duration, err := ParseDuration(strings.ReplaceAll(args.Duration, " ", ""))
+	if err != nil {
+		return "", fmt.Errorf("invalid duration %q", args.Duration)
+	}
+
+	var userID int64
+	if msg.From != nil {
+		userID = msg.From.ID
+	}
+	if err := h.service.AddReminder(ctx, msg.Chat.ID, userID, args.Message, duration); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("reminder set in %s: %s", duration, args.Message), nil
+}
+
+func runListReminders(h *BotHandlers, ctx context.Context, msg *Message, _ json.RawMessage) (string, error) {
+	reminders, err := h.service.GetPendingReminders(ctx, msg.Chat.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(reminders) == 0 {
+		return "no pending reminders", nil
+	}
+
+	var sb strings.Builder
+	for _, r := range reminders {
+		sb.WriteString(fmt.Sprintf("#%d at %s: %s\n", r.ReminderID, r.RemindAt.Format("2006-01-02 15:04 MST"), r.Message))
+	}
+	return sb.String(), nil
+}
+
+func runRandomFact(h *BotHandlers, ctx context.Context, msg *Message, _ json.RawMessage) (string, error) {
+	fact, err := h.service.GetRandomFact(ctx, msg.Chat.ID)
+	if err != nil {
+		return "", err
+	}
+	if fact == nil {
+		return "no facts saved in this chat", nil
+	}
+	return fact.Comment, nil
+}
+
+func runAddFact(h *BotHandlers, ctx context.Context, msg *Message, raw json.RawMessage) (string, error) {
+	var args struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(args.Text) == "" {
+		return "", errors.New("text is required")
+	}
+
+	if err := h.service.AddFact(ctx, args.Text, msg.Chat.ID); err != nil {
+		return "", err
+	}
+	return "fact saved", nil
+}
+
+func runSendMeme(h *BotHandlers, ctx context.Context, msg *Message, raw json.RawMessage) (string, error) {
+	var args struct {
+		Subreddit string `json:"subreddit"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", err
+	}
+
+	subName := strings.TrimPrefix(strings.TrimSpace(args.Subreddit), "r/")
+	if subName == "" {
+		sub, err := h.service.GetRandomSubreddit(ctx, msg.Chat.ID)
+		if err != nil {
+			return "", err
+		}
+		subName = defaultSubreddit
+		if sub != nil {
+			subName = sub.Name
+		}
+	}
+
+	memes, err := h.fetchMemes(ctx, subName, 1)
+	if err != nil || len(memes) == 0 {
+		return "", fmt.Errorf("no memes found in r/%s", subName)
+	}
+	if err := h.sendMemes(ctx, msg.Chat.ID, memes); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sent meme %q from r/%s", memes[0].Title, subName), nil
+}
+
+func runAddSubreddit(h *BotHandlers, ctx context.Context, msg *Message, raw json.RawMessage) (string, error) {
+	var args struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &args); err != nil {
+		return "", err
+	}
+
+	name := strings.TrimPrefix(strings.TrimSpace(args.Name), "r/")
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+
+	if err := h.service.AddSubreddit(ctx, name, msg.Chat.ID); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("r/%s added", name), nil
+}
+
+func objectSchema(properties map[string]any, required ...string) map[string]any {
+	if properties == nil {
+		properties = map[string]any{}
+	}
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func stringProperty(description string) map[string]any {
+	return map[string]any{
+		"type":        "string",
+		"description": description,
+	}
+}
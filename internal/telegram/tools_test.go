@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+	"strings"
+	"testing"
+)
+
+func TestChatTools(t *testing.T) {
+	tools := chatTools()
+
+	if len(tools) != len(chatToolRegistry) {
+		t.Fatalf("got %d tools, want %d", len(tools), len(chatToolRegistry))
+	}
+	for i, tool := range tools {
+		if tool.Type != "function" {
+			t.Errorf("tool[%d].Type = %q, want %q", i, tool.Type, "function")
+		}
+		if i > 0 && tools[i-1].Function.Name > tool.Function.Name {
+			t.Errorf("tools not sorted: %q before %q", tools[i-1].Function.Name, tool.Function.Name)
+		}
+	}
+}
+
+func TestToolExecutorSetReminder(t *testing.T) {
+	var saved *model.Reminder
+	svc := newMockService(app.Repositories{
+		Chats: &mockChatRepo{
+			getFunc: func(ctx context.Context, chatID int64) (*model.Chat, error) {
+				return &model.Chat{ChatID: chatID}, nil
+			},
+		},
+		Users: &mockUserRepo{
+			getFunc: func(ctx context.Context, userID int64) (*model.User, error) {
+				return &model.User{UserID: userID}, nil
+			},
+		},
+		Reminders: &mockReminderRepo{
+			saveFunc: func(ctx context.Context, r *model.Reminder) error {
+				saved = r
+				return nil
+			},
+		},
+	})
+	handlers := newTestBotHandlers(newTestClient("http://unused"), svc)
+	msg := &Message{Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}
+
+	result, err := handlers.toolExecutor(msg)(context.Background(), toolCall(toolSetReminder, map[string]string{
+		"duration": "2h",
+		"message":  "call mom",
+	}))
+
+	assertNoError(t, err)
+	if saved == nil {
+		t.Fatal("expected reminder to be saved")
+	}
+	if saved.Chat.ChatID != testChatID || saved.User.UserID != 42 || saved.Message != "call mom" {
+		t.Errorf("saved reminder = %+v", saved)
+	}
+	if !strings.Contains(result, "call mom") {
+		t.Errorf("result = %q, want it to mention the reminder", result)
+	}
+}
+
+func TestToolExecutorInvalidArguments(t *testing.T) {
+	handlers := newTestBotHandlers(newTestClient("http://unused"), newTestServiceForHandlers())
+	msg := &Message{Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}
+
+	_, err := handlers.toolExecutor(msg)(context.Background(), toolCall(toolSetReminder, map[string]string{
+		"duration": "soon",
+		"message":  "call mom",
+	}))
+
+	if err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
+
+func TestToolExecutorUnknownTool(t *testing.T) {
+	handlers := newTestBotHandlers(newTestClient("http://unused"), newTestServiceForHandlers())
+	msg := &Message{Chat: &Chat{ID: testChatID}, From: &User{ID: 42}}
+
+	_, err := handlers.toolExecutor(msg)(context.Background(), toolCall("rm_rf", nil))
+
+	if err == nil {
+		t.Error("expected error for unknown tool")
+	}
+}
+
+func TestToolExecutorAddSubredditPermissions(t *testing.T) {
+	tests := []struct {
+		name      string
+		chatType  string
+		status    string
+		wantSaved bool
+	}{
+		{
+			name:      "PrivateChat",
+			chatType:  "private",
+			wantSaved: true,
+		},
+		{
+			name:      "GroupAdministrator",
+			chatType:  "group",
+			status:    memberStatusAdministrator,
+			wantSaved: true,
+		},
+		{
+			name:      "GroupCreator",
+			chatType:  "supergroup",
+			status:    memberStatusCreator,
+			wantSaved: true,
+		},
+		{
+			name:      "GroupMember",
+			chatType:  "group",
+			status:    "member",
+			wantSaved: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServerWithJSON(t, ChatMemberResponse{Ok: true, Result: ChatMember{Status: tt.status}})
+
+			saved := false
+			svc := newMockService(app.Repositories{
+				Subreddits: &mockSubredditRepo{
+					saveFunc: func(ctx context.Context, s *model.Subreddit) error {
+						saved = true
+						return nil
+					},
+				},
+			})
+			handlers := newTestBotHandlers(newTestClient(server.URL), svc)
+			msg := &Message{Chat: &Chat{ID: -100, Type: tt.chatType}, From: &User{ID: 42}}
+
+			_, err := handlers.toolExecutor(msg)(context.Background(), toolCall(toolAddSubreddit, map[string]string{"name": "r/golang"}))
+
+			if saved != tt.wantSaved {
+				t.Errorf("saved = %v, want %v", saved, tt.wantSaved)
+			}
+			if !tt.wantSaved && !errors.Is(err, errToolPermission) {
+				t.Errorf("error = %v, want %v", err, errToolPermission)
+			}
+		})
+	}
+}
+
+func toolCall(name string, args map[string]string) groq.ToolCall {
+	raw, _ := json.Marshal(args)
+	return groq.ToolCall{
+		ID:       "call_1",
+		Type:     "function",
+		Function: groq.FunctionCall{Name: name, Arguments: string(raw)},
+	}
+}
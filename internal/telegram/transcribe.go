@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"got/internal/app/model"
+	"got/pkg/i18n"
+)
+
+const (
+	defaultVoiceFilename = "voice.ogg"
+	defaultAudioFilename = "audio.mp3"
+	voicePromptFormat    = "%s\n\nVoice message transcript: %s"
+	switchOn             = "on"
+	switchOff            = "off"
+)
+
+var (
+	errAudioTooLarge    = errors.New("audio too large")
+	errEmptyTranscript  = errors.New("empty transcript")
+	errNoTranscriptions = errors.New("transcription unavailable")
+)
+
+type audioFile struct {
+	fileID   string
+	filename string
+	size     int64
+}
+
+func (h *BotHandlers) HandleTranscribe(ctx context.Context, update *Update) error {
+	msg := update.Message
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	if !h.canTranscribe() {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranscribeUnavailable))
+	}
+
+	parts := strings.SplitN(msg.CommandArguments(), " ", 2)
+	if subCommand(strings.ToLower(parts[0])) == subCommandAuto {
+		return h.handleTranscribeAuto(ctx, msg, strings.ToLower(argsAfter(parts)))
+	}
+
+	audio := messageAudio(msg.ReplyToMessage)
+	if audio == nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranscribeUsage))
+	}
+
+	typing := h.startTyping(ctx, chatID, actionTyping)
+	defer typing.Stop()
+
+	text, err := h.transcribe(ctx, audio)
+	if err != nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(transcribeErrorKey(err)))
+	}
+	return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyTranscribeResult), text))
+}
+
+func (h *BotHandlers) HandleMessage(ctx context.Context, update *Update) error {
+	msg := update.Message
+	settings, err := h.service.GetChatSettings(ctx, msg.Chat.ID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to load chat settings", "chat_id", msg.Chat.ID, "error", err)
+		return nil
+	}
+
+	h.logMessage(ctx, msg, settings)
+	if h.isAssistantChat(msg, settings) {
+		return h.handleAssistantMessage(ctx, msg)
+	}
+	if msg.Voice == nil {
+		err = h.autoTranslate(ctx, msg, settings)
+	} else {
+		err = h.autoTranscribe(ctx, msg, settings)
+	}
+	h.lurk(ctx, msg, h.service.LurkerSettings(settings))
+	return err
+}
+
+func (h *BotHandlers) autoTranscribe(ctx context.Context, msg *Message, settings *model.ChatSettings) error {
+	if !h.canTranscribe() || !settings.AutoTranscribe {
+		return nil
+	}
+
+	text, err := h.transcribe(ctx, messageAudio(msg))
+	if err != nil {
+		log.WarnContext(ctx, "Automatic transcription failed", "chat_id", msg.Chat.ID, "error", err)
+		return nil
+	}
+
+	t := h.getTranslator(ctx, msg.Chat.ID)
+	return h.client.SendMessage(ctx, msg.Chat.ID, fmt.Sprintf(t.Get(i18n.KeyTranscribeResult), text))
+}
+
+func (h *BotHandlers) handleTranscribeAuto(ctx context.Context, msg *Message, arg string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	if arg == "" {
+		enabled, err := h.service.IsAutoTranscribe(ctx, chatID)
+		if err != nil {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranscribeError))
+		}
+		return h.client.SendMessage(ctx, chatID, t.Get(autoTranscribeKey(enabled)))
+	}
+
+	if arg != switchOn && arg != switchOff {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranscribeUsage))
+	}
+	if !h.canManageChat(ctx, msg) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranscribeAdminOnly))
+	}
+
+	enabled := arg == switchOn
+	if err := h.service.SetAutoTranscribe(ctx, chatID, enabled); err != nil {
+		log.ErrorContext(ctx, "Failed to save auto-transcribe setting", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranscribeError))
+	}
+	return h.client.SendMessage(ctx, chatID, t.Get(autoTranscribeKey(enabled)))
+}
+
+func (h *BotHandlers) voicePrompt(ctx context.Context, msg *Message, prompt string) (string, error) {
+	audio := messageAudio(msg.ReplyToMessage)
+	if audio == nil {
+		return prompt, nil
+	}
+	if !h.canTranscribe() {
+		return "", errNoTranscriptions
+	}
+
+	text, err := h.transcribe(ctx, audio)
+	if err != nil {
+		return "", err
+	}
+	if prompt == "" {
+		return text, nil
+	}
+	return fmt.Sprintf(voicePromptFormat, prompt, text), nil
+}
+
+func (h *BotHandlers) transcribe(ctx context.Context, audio *audioFile) (string, error) {
+	if audio.size > maxDownloadSize {
+		return "", errAudioTooLarge
+	}
+
+	data, err := h.client.DownloadFile(ctx, audio.fileID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to download audio", "file", audio.fileID, "error", err)
+		return "", err
+	}
+
+	text, err := h.gpt.Transcribe(ctx, data, audio.filename, "")
+	if err != nil {
+		log.WarnContext(ctx, "Transcription failed", "file", audio.fileID, "error", err)
+		return "", err
+	}
+	if text == "" {
+		return "", errEmptyTranscript
+	}
+	return text, nil
+}
+
+func (h *BotHandlers) canTranscribe() bool {
+	return h.gpt != nil && h.gpt.CanTranscribe()
+}
+
+func messageAudio(msg *Message) *audioFile {
+	switch {
+	case msg == nil:
+		return nil
+	case msg.Voice != nil:
+		return &audioFile{fileID: msg.Voice.FileID, filename: defaultVoiceFilename, size: msg.Voice.FileSize}
+	case msg.Audio != nil:
+		filename := msg.Audio.FileName
+		if filename == "" {
+			filename = defaultAudioFilename
+		}
+		return &audioFile{fileID: msg.Audio.FileID, filename: filename, size: msg.Audio.FileSize}
+	default:
+		return nil
+	}
+}
+
+func autoTranscribeKey(enabled bool) i18n.Key {
+	if enabled {
+		return i18n.KeyTranscribeAutoOn
+	}
+	return i18n.KeyTranscribeAutoOff
+}
+
+func transcribeErrorKey(err error) i18n.Key {
+	switch {
+	case errors.Is(err, errAudioTooLarge):
+		return i18n.KeyTranscribeTooLarge
+	case errors.Is(err, errEmptyTranscript):
+		return i18n.KeyTranscribeEmpty
+	case errors.Is(err, errNoTranscriptions):
+		return i18n.KeyTranscribeUnavailable
+	default:
+		return i18n.KeyTranscribeError
+	}
+}
@@ -0,0 +1,277 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+)
+
+func TestMessageAudio(t *testing.T) {
+	tests := []struct {
+		name         string
+		msg          *Message
+		wantFileID   string
+		wantFilename string
+	}{
+		{name: "NilMessage", msg: nil},
+		{name: "TextMessage", msg: &Message{Text: "hello"}},
+		{
+			name:         "Voice",
+			msg:          &Message{Voice: &Voice{FileID: "voice-1"}},
+			wantFileID:   "voice-1",
+			wantFilename: defaultVoiceFilename,
+		},
+		{
+			name:         "AudioWithName",
+			msg:          &Message{Audio: &Audio{FileID: "audio-1", FileName: "song.m4a"}},
+			wantFileID:   "audio-1",
+			wantFilename: "song.m4a",
+		},
+		{
+			name:         "AudioWithoutName",
+			msg:          &Message{Audio: &Audio{FileID: "audio-2"}},
+			wantFileID:   "audio-2",
+			wantFilename: defaultAudioFilename,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := messageAudio(tt.msg)
+			if tt.wantFileID == "" {
+				if got != nil {
+					t.Errorf("messageAudio() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.fileID != tt.wantFileID || got.filename != tt.wantFilename {
+				t.Errorf("messageAudio() = %+v, want %s (%s)", got, tt.wantFileID, tt.wantFilename)
+			}
+		})
+	}
+}
+
+func TestHandleTranscribe(t *testing.T) {
+	var sent []string
+	server := newTranscribeTestServer(t, "  hello from a voice note  ", &sent)
+	handlers := newTestTranscribeHandlers(server, newTestServiceForHandlers())
+
+	update := &Update{Message: &Message{
+		Text:           "/transcribe",
+		Chat:           &Chat{ID: testChatID},
+		ReplyToMessage: &Message{Voice: &Voice{FileID: "voice-1", FileSize: 1024}},
+	}}
+
+	err := handlers.HandleTranscribe(context.Background(), update)
+
+	assertNoError(t, err)
+	if len(sent) != 1 || sent[0] != "🎙 hello from a voice note" {
+		t.Errorf("sent = %q, want transcript", sent)
+	}
+}
+
+func TestHandleTranscribeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		withGPT bool
+		reply   *Message
+		want    string
+	}{
+		{
+			name:    "NoProvider",
+			withGPT: false,
+			reply:   &Message{Voice: &Voice{FileID: "voice-1"}},
+			want:    "Transcription is not available.",
+		},
+		{
+			name:    "NoReply",
+			withGPT: true,
+			want:    "Reply to a voice message with /transcribe",
+		},
+		{
+			name:    "TooLarge",
+			withGPT: true,
+			reply:   &Message{Audio: &Audio{FileID: "audio-1", FileSize: maxDownloadSize + 1}},
+			want:    "The audio is too large.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			server := newTranscribeTestServer(t, "unused", &sent)
+			handlers := newTestBotHandlers(newTestClient(server.URL), newTestServiceForHandlers())
+			if tt.withGPT {
+				handlers = newTestTranscribeHandlers(server, newTestServiceForHandlers())
+			}
+
+			update := &Update{Message: &Message{Text: "/transcribe", Chat: &Chat{ID: testChatID}, ReplyToMessage: tt.reply}}
+			err := handlers.HandleTranscribe(context.Background(), update)
+
+			assertNoError(t, err)
+			if len(sent) != 1 || sent[0] != tt.want {
+				t.Errorf("sent = %q, want %q", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleTranscribeAuto(t *testing.T) {
+	var saved *model.ChatSettings
+	chats := &mockChatRepo{
+		saveSettingsFunc: func(ctx context.Context, settings *model.ChatSettings) error {
+			saved = settings
+			return nil
+		},
+	}
+	svc := newMockService(app.Repositories{Chats: chats})
+
+	var sent []string
+	server := newTranscribeTestServer(t, "unused", &sent)
+	handlers := newTestTranscribeHandlers(server, svc)
+
+	update := &Update{Message: &Message{
+		Text: "/transcribe auto on",
+		Chat: &Chat{ID: testChatID, Type: chatTypePrivate},
+		From: &User{ID: 42},
+	}}
+
+	err := handlers.HandleTranscribe(context.Background(), update)
+
+	assertNoError(t, err)
+	if saved == nil || !saved.AutoTranscribe {
+		t.Errorf("saved settings = %+v, want auto_transcribe enabled", saved)
+	}
+	if len(sent) != 1 || sent[0] != "Auto-transcription on." {
+		t.Errorf("sent = %q, want confirmation", sent)
+	}
+}
+
+func TestHandleMessageAutoTranscribe(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		msg      *Message
+		wantSent int
+	}{
+		{
+			name:     "Enabled",
+			enabled:  true,
+			msg:      &Message{Chat: &Chat{ID: testChatID}, Voice: &Voice{FileID: "voice-1"}},
+			wantSent: 1,
+		},
+		{
+			name:     "Disabled",
+			enabled:  false,
+			msg:      &Message{Chat: &Chat{ID: testChatID}, Voice: &Voice{FileID: "voice-1"}},
+			wantSent: 0,
+		},
+		{
+			name:     "NotVoice",
+			enabled:  true,
+			msg:      &Message{Chat: &Chat{ID: testChatID}, Text: "hello"},
+			wantSent: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chats := &mockChatRepo{
+				getSettingsFunc: func(ctx context.Context, chatID int64) (*model.ChatSettings, error) {
+					return &model.ChatSettings{ChatID: chatID, AutoTranscribe: tt.enabled}, nil
+				},
+			}
+			svc := newMockService(app.Repositories{Chats: chats})
+
+			var sent []string
+			server := newTranscribeTestServer(t, "auto transcript", &sent)
+			handlers := newTestTranscribeHandlers(server, svc)
+
+			err := handlers.HandleMessage(context.Background(), &Update{Message: tt.msg})
+
+			assertNoError(t, err)
+			if len(sent) != tt.wantSent {
+				t.Errorf("sent %d messages (%q), want %d", len(sent), sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestHandleGPTVoicePrompt(t *testing.T) {
+	var sent []string
+	var prompt string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+			var req groq.Request
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			prompt = req.Messages[len(req.Messages)-1].Content
+			_ = json.NewEncoder(w).Encode(groq.Response{Choices: []groq.Choice{{Message: groq.Message{Role: "assistant", Content: "Sure."}}}})
+		case strings.HasSuffix(r.URL.Path, "/models"):
+			http.NotFound(w, r)
+		default:
+			serveTranscribeRequest(t, w, r, "remind me to buy milk", &sent)
+		}
+	})
+	handlers := newTestTranscribeHandlers(server, newTestServiceForHandlers())
+
+	update := &Update{Message: &Message{
+		Text:           "/gpt",
+		Chat:           &Chat{ID: testChatID},
+		From:           &User{ID: 42, UserName: "alice"},
+		ReplyToMessage: &Message{Voice: &Voice{FileID: "voice-1"}},
+	}}
+
+	err := handlers.HandleGPT(context.Background(), update)
+
+	assertNoError(t, err)
+	if !strings.Contains(prompt, "remind me to buy milk") {
+		t.Errorf("prompt = %q, want voice transcript", prompt)
+	}
+	if len(sent) != 1 || sent[0] != "Sure." {
+		t.Errorf("sent = %q, want GPT answer", sent)
+	}
+}
+
+func newTestTranscribeHandlers(server *httptest.Server, svc *app.Service) *BotHandlers {
+	client := newTestClient(server.URL)
+	client.fileURL = server.URL
+
+	gpt := groq.NewCompatibleClient("local", server.URL, "", "llama", []string{"llama"})
+	gpt.SetTranscriptionModel("whisper-1")
+	return newTestBotHandlersWithGPT(client, svc, gpt)
+}
+
+func newTranscribeTestServer(t *testing.T, transcript string, sent *[]string) *httptest.Server {
+	t.Helper()
+	return newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		serveTranscribeRequest(t, w, r, transcript, sent)
+	})
+}
+
+func serveTranscribeRequest(t *testing.T, w http.ResponseWriter, r *http.Request, transcript string, sent *[]string) {
+	t.Helper()
+	switch {
+	case strings.HasSuffix(r.URL.Path, getFileCMD):
+		_ = json.NewEncoder(w).Encode(FileResponse{Ok: true, Result: File{FileID: r.URL.Query().Get("file_id"), FilePath: "voice/file_1.oga"}})
+	case strings.HasSuffix(r.URL.Path, "/voice/file_1.oga"):
+		_, _ = w.Write([]byte("OggS-audio"))
+	case strings.HasSuffix(r.URL.Path, "/audio/transcriptions"):
+		if got := r.FormValue("model"); got != "whisper-1" {
+			t.Errorf("model = %q, want %q", got, "whisper-1")
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"text": transcript})
+	case strings.HasSuffix(r.URL.Path, sendMessageCMD):
+		*sent = append(*sent, decodeJSONPayload(t, r)["text"].(string))
+	case strings.HasSuffix(r.URL.Path, sendChatActionCMD):
+	default:
+		t.Errorf("unexpected request %s", r.URL.Path)
+	}
+}
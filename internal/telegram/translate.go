@@ -0,0 +1,355 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"got/internal/app"
+	"got/internal/app/model"
+	"got/internal/groq"
+	"got/pkg/i18n"
+)
+
+const (
+	minAutoTranslateRunes = 12
+	defaultTranslateLang  = "en"
+	unknownLanguage       = "?"
+	lithuanianLetters     = "ąčęėįšųūž"
+	targetSeparator       = ":"
+	minEnglishShare       = 0.2
+	translatePrompt       = "You translate messages from a Telegram group chat into %s. " +
+		"Detect the source language yourself. Keep Markdown formatting, @mentions, hashtags, links, code and emoji exactly as they are, and do not translate people's names. " +
+		"On the first line write only the ISO 639-1 code of the source language, then write the translation on the following lines. Do not add explanations."
+)
+
+var errEmptyTranslation = errors.New("empty translation")
+
+var languageScripts = map[string]string{
+	"en": scriptLatin,
+	"lt": scriptLatin,
+	"ru": scriptCyrillic,
+	"be": scriptCyrillic,
+	"ja": scriptJapanese,
+}
+
+var englishWords = map[string]bool{
+	"the": true, "a": true, "an": true, "and": true, "or": true, "but": true, "is": true, "are": true,
+	"was": true, "were": true, "be": true, "to": true, "of": true, "in": true, "on": true, "at": true,
+	"for": true, "with": true, "it": true, "this": true, "that": true, "i": true, "you": true, "we": true,
+	"they": true, "he": true, "she": true, "not": true, "do": true, "have": true, "has": true, "what": true,
+	"my": true, "your": true, "again": true, "just": true, "can": true, "will": true, "so": true, "if": true,
+}
+
+const (
+	scriptLatin    = "latin"
+	scriptCyrillic = "cyrillic"
+	scriptJapanese = "japanese"
+)
+
+type translation struct {
+	source string
+	target string
+	text   string
+}
+
+func (h *BotHandlers) HandleTranslate(ctx context.Context, update *Update) error {
+	msg := update.Message
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	if h.gpt == nil {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyGptNoKey))
+	}
+
+	args := strings.TrimSpace(msg.CommandArguments())
+	parts := strings.SplitN(args, " ", 2)
+	if subCommand(strings.ToLower(parts[0])) == subCommandAuto {
+		return h.handleTranslateAuto(ctx, msg, strings.ToLower(argsAfter(parts)))
+	}
+
+	target, text, ok := parseTranslateArgs(args, msg.ReplyToMessage != nil)
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranslateUsage))
+	}
+	if target == "" {
+		target = h.translateTarget(ctx, chatID)
+	}
+	if text != "" {
+		text = argumentMarkdown(msg, text)
+	} else {
+		text = messageMarkdown(msg.ReplyToMessage)
+	}
+	if text == "" {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranslateUsage))
+	}
+	if blocked := h.quotaMessage(ctx, msg); blocked != "" {
+		return h.client.SendMessage(ctx, chatID, blocked)
+	}
+
+	typing := h.startTyping(ctx, chatID, actionTyping)
+	defer typing.Stop()
+
+	result, err := h.translate(ctx, msg, text, target)
+	if err != nil {
+		log.WarnContext(ctx, "Translation failed", "chat_id", chatID, "target", target, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(translateErrorKey(err)))
+	}
+	if result.source == target {
+		return h.client.SendMessage(ctx, chatID, fmt.Sprintf(t.Get(i18n.KeyTranslateSame), app.LanguageNames[target]))
+	}
+	if notice, allowed := h.moderate(ctx, msg, model.ModerationSourceGPT, result.text); !allowed {
+		return h.client.SendMessage(ctx, chatID, notice)
+	}
+	return h.sendTranslation(ctx, chatID, 0, fmt.Sprintf(t.Get(i18n.KeyTranslateResult), result.source, result.target, result.text))
+}
+
+func (h *BotHandlers) handleTranslateAuto(ctx context.Context, msg *Message, arg string) error {
+	chatID := msg.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	if arg == "" {
+		enabled, err := h.service.IsAutoTranslate(ctx, chatID)
+		if err != nil {
+			return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranslateError))
+		}
+		return h.client.SendMessage(ctx, chatID, t.Get(autoTranslateKey(enabled)))
+	}
+
+	if arg != switchOn && arg != switchOff {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranslateUsage))
+	}
+	if !h.canManageChat(ctx, msg) {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranslateAdminOnly))
+	}
+
+	enabled := arg == switchOn
+	if err := h.service.SetAutoTranslate(ctx, chatID, enabled); err != nil {
+		log.ErrorContext(ctx, "Failed to save auto-translate setting", "chat_id", chatID, "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyTranslateError))
+	}
+	return h.client.SendMessage(ctx, chatID, t.Get(autoTranslateKey(enabled)))
+}
+
+func (h *BotHandlers) autoTranslate(ctx context.Context, msg *Message, settings *model.ChatSettings) error {
+	chatID := msg.Chat.ID
+	text := messageText(msg)
+	if h.gpt == nil || !settings.AutoTranslate || msg.From == nil || msg.From.IsBot || utf8.RuneCountInString(text) < minAutoTranslateRunes {
+		return nil
+	}
+	target := h.translateTarget(ctx, chatID)
+	if !needsTranslation(text, target) {
+		return nil
+	}
+	if blocked := h.quotaMessage(ctx, msg); blocked != "" {
+		return nil
+	}
+
+	result, err := h.translate(ctx, msg, messageMarkdown(msg), target)
+	if err != nil {
+		log.WarnContext(ctx, "Automatic translation failed", "chat_id", chatID, "error", err)
+		return nil
+	}
+	if result.source == target {
+		return nil
+	}
+	if _, allowed := h.moderate(ctx, msg, model.ModerationSourceGPT, result.text); !allowed {
+		return nil
+	}
+
+	t := h.getTranslator(ctx, chatID)
+	return h.sendTranslation(ctx, chatID, msg.MessageID, fmt.Sprintf(t.Get(i18n.KeyTranslateResult), result.source, result.target, result.text))
+}
+
+func (h *BotHandlers) sendTranslation(ctx context.Context, chatID int64, replyTo int, text string) error {
+	_, err := h.client.SendReply(ctx, chatID, replyTo, text)
+	if err == nil {
+		return nil
+	}
+	log.DebugContext(ctx, "Translation is not valid Markdown, sending plain text", "chat_id", chatID, "error", err)
+	_, err = h.client.SendPlainReply(ctx, chatID, replyTo, unescapeMarkdown(text))
+	return err
+}
+
+func (h *BotHandlers) translate(ctx context.Context, msg *Message, text, target string) (*translation, error) {
+	result, err := h.gpt.Complete(ctx, h.getChatModel(ctx, msg.Chat.ID), groq.ChatRequest{
+		SystemPrompt: fmt.Sprintf(translatePrompt, app.LanguageNames[target]),
+		Prompt:       text,
+	})
+	if err != nil {
+		return nil, err
+	}
+	h.recordTokenUsage(ctx, msg, result)
+
+	source, translated := parseTranslation(result.Content)
+	if translated == "" {
+		return nil, errEmptyTranslation
+	}
+	return &translation{source: source, target: target, text: translated}, nil
+}
+
+func (h *BotHandlers) translateTarget(ctx context.Context, chatID int64) string {
+	if lang := h.chatLanguage(ctx, chatID); app.LanguageNames[lang] != "" {
+		return lang
+	}
+	return defaultTranslateLang
+}
+
+func parseTranslateArgs(args string, reply bool) (string, string, bool) {
+	first, rest, _ := strings.Cut(args, " ")
+	if lang, found := strings.CutSuffix(strings.ToLower(first), targetSeparator); found {
+		if app.LanguageNames[lang] == "" {
+			return "", "", false
+		}
+		return lang, strings.TrimSpace(rest), true
+	}
+	if lang := strings.ToLower(args); app.LanguageNames[lang] != "" {
+		return lang, "", reply
+	}
+	return "", args, true
+}
+
+func argumentMarkdown(msg *Message, text string) string {
+	if !strings.HasSuffix(msg.Text, text) {
+		return escapeMarkdown(text)
+	}
+	start := utf16Len(msg.Text) - utf16Len(text)
+	var entities []MessageEntity
+	for _, e := range msg.Entities {
+		if e.Offset >= start {
+			e.Offset -= start
+			entities = append(entities, e)
+		}
+	}
+	return entitiesToMarkdown(text, entities)
+}
+
+func needsTranslation(text, target string) bool {
+	if source := detectLanguage(text); source != "" {
+		return source != target
+	}
+	script := textScript(text)
+	return script != "" && script != languageScripts[target]
+}
+
+func parseTranslation(content string) (string, string) {
+	content = strings.TrimSpace(content)
+	first, rest, found := strings.Cut(content, "\n")
+	code := strings.ToLower(strings.Trim(strings.TrimSpace(first), "[]()*`:."))
+	if !found || !isLanguageCode(code) {
+		return unknownLanguage, content
+	}
+	return code, strings.TrimSpace(rest)
+}
+
+func isLanguageCode(s string) bool {
+	if len(s) < 2 || len(s) > 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func detectLanguage(text string) string {
+	var lithuanian, russian, belarusian bool
+	for _, r := range strings.ToLower(text) {
+		switch r {
+		case 'ў', 'і':
+			belarusian = true
+		case 'и', 'щ', 'ъ':
+			russian = true
+		default:
+			lithuanian = lithuanian || strings.ContainsRune(lithuanianLetters, r)
+		}
+	}
+
+	switch textScript(text) {
+	case scriptJapanese:
+		return "ja"
+	case scriptCyrillic:
+		if belarusian == russian {
+			return ""
+		}
+		if belarusian {
+			return "be"
+		}
+		return "ru"
+	case scriptLatin:
+		if lithuanian {
+			return "lt"
+		}
+		if looksEnglish(text) {
+			return "en"
+		}
+	}
+	return ""
+}
+
+func textScript(text string) string {
+	var latin, cyrillic, japanese int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han):
+			japanese++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	switch {
+	case japanese > 0 && japanese >= cyrillic && japanese >= latin:
+		return scriptJapanese
+	case cyrillic > 0 && cyrillic >= latin:
+		return scriptCyrillic
+	case latin > 0:
+		return scriptLatin
+	default:
+		return ""
+	}
+}
+
+func looksEnglish(text string) bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	hits := 0
+	for _, word := range words {
+		if englishWords[word] {
+			hits++
+		}
+	}
+	return hits > 0 && float64(hits) >= minEnglishShare*float64(len(words))
+}
+
+func messageText(msg *Message) string {
+	if msg == nil {
+		return ""
+	}
+	if msg.Text != "" {
+		return msg.Text
+	}
+	return msg.Caption
+}
+
+func autoTranslateKey(enabled bool) i18n.Key {
+	if enabled {
+		return i18n.KeyTranslateAutoOn
+	}
+	return i18n.KeyTranslateAutoOff
+}
+
+func translateErrorKey(err error) i18n.Key {
+	if errors.Is(err, errEmptyTranslation) {
+		return i18n.KeyTranslateError
+	}
+	return gptErrorKey(err)
+}
@@ -0,0 +1,231 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"got/internal/app/model"
+)
+
+func TestHandleTranslate(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		reply      *Message
+		llmReply   string
+		wantPrompt string
+		wantTarget string
+		wantSent   string
+	}{
+		{
+			name:       "ReplyToChatLanguage",
+			text:       "/translate",
+			reply:      &Message{Text: "Привет, все! @tom_as", Entities: []MessageEntity{{Type: "bold", Offset: 8, Length: 3}}},
+			llmReply:   "ru\nHi, *everyone*! @tom\\_as",
+			wantPrompt: "Привет, *все*! @tom\\_as",
+			wantTarget: "English",
+			wantSent:   "🌐 ru → en\nHi, *everyone*! @tom\\_as",
+		},
+		{
+			name:       "InlineTextWithTarget",
+			text:       "/translate lt: good morning",
+			llmReply:   "en\nLabas rytas",
+			wantPrompt: "good morning",
+			wantTarget: "Lithuanian",
+			wantSent:   "🌐 en → lt\nLabas rytas",
+		},
+		{
+			name:       "AlreadyInTarget",
+			text:       "/translate good morning",
+			llmReply:   "en\ngood morning",
+			wantPrompt: "good morning",
+			wantTarget: "English",
+			wantSent:   "The text is already in English.",
+		},
+		{
+			name:       "LanguageCodeIsPartOfText",
+			text:       "/translate be careful",
+			llmReply:   "en\nbe careful",
+			wantPrompt: "be careful",
+			wantTarget: "English",
+			wantSent:   "The text is already in English.",
+		},
+		{
+			name:     "UnknownTarget",
+			text:     "/translate xx: hello",
+			wantSent: "Reply to a message with /translate",
+		},
+		{
+			name:     "NoText",
+			text:     "/translate",
+			wantSent: "Reply to a message with /translate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers, chat := newTestLLMHandlers(t, &model.ChatSettings{}, tt.llmReply)
+
+			update := &Update{Message: &Message{Text: tt.text, Chat: &Chat{ID: testChatID}, From: &User{ID: 42}, ReplyToMessage: tt.reply}}
+			err := handlers.HandleTranslate(context.Background(), update)
+
+			assertNoError(t, err)
+			if tt.wantPrompt != "" {
+				if len(chat.requests) != 1 || chat.requests[0].prompt != tt.wantPrompt || !strings.Contains(chat.requests[0].system, tt.wantTarget) {
+					t.Errorf("requests = %+v, want prompt %q into %s", chat.requests, tt.wantPrompt, tt.wantTarget)
+				}
+			}
+			if len(chat.sent) != 1 || chat.sent[0] != tt.wantSent {
+				t.Errorf("sent = %q, want %q", chat.sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestHandleMessageAutoTranslate(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		msg      *Message
+		wantSent []string
+	}{
+		{
+			name:     "OtherLanguage",
+			enabled:  true,
+			msg:      &Message{MessageID: 5, Text: "Сервер опять упал, видимо диск", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}},
+			wantSent: []string{"🌐 ru → en\nThe server went down again today"},
+		},
+		{
+			name:    "ChatLanguage",
+			enabled: true,
+			msg:     &Message{Text: "The server went down again", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}},
+		},
+		{
+			name:    "TooShort",
+			enabled: true,
+			msg:     &Message{Text: "Привет", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}},
+		},
+		{
+			name:    "Disabled",
+			enabled: false,
+			msg:     &Message{Text: "Сервер опять упал, видимо диск", Chat: &Chat{ID: testChatID}, From: &User{ID: 42}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers, chat := newTestLLMHandlers(t, &model.ChatSettings{AutoTranslate: tt.enabled}, "ru\nThe server went down again today")
+
+			err := handlers.HandleMessage(context.Background(), &Update{Message: tt.msg})
+
+			assertNoError(t, err)
+			if len(chat.requests) != len(tt.wantSent) {
+				t.Errorf("made %d LLM requests, want %d", len(chat.requests), len(tt.wantSent))
+			}
+			if strings.Join(chat.sent, "|") != strings.Join(tt.wantSent, "|") {
+				t.Errorf("sent = %q, want %q", chat.sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"the server is down", "en"},
+		{"el servidor se cayó otra vez", ""},
+		{"serveris vel nukrito", ""},
+		{"serveris vėl nukrito", "lt"},
+		{"сервер опять упал, видимо", "ru"},
+		{"сервер зноў упаў, і ўсё", "be"},
+		{"サーバーがまた落ちた", "ja"},
+		{"сервер упал", ""},
+		{"👍 123", ""},
+	}
+
+	for _, tt := range tests {
+		if got := detectLanguage(tt.text); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNeedsTranslation(t *testing.T) {
+	tests := []struct {
+		text   string
+		target string
+		want   bool
+	}{
+		{"el servidor se cayó otra vez", "ru", true},
+		{"el servidor se cayó otra vez", "en", false},
+		{"the server is down again", "en", false},
+		{"сервер опять упал, видимо", "en", true},
+	}
+
+	for _, tt := range tests {
+		if got := needsTranslation(tt.text, tt.target); got != tt.want {
+			t.Errorf("needsTranslation(%q, %q) = %v, want %v", tt.text, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestSendTranslationFallsBackToPlainText(t *testing.T) {
+	var payloads []map[string]any
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeJSONPayload(t, r)
+		payloads = append(payloads, payload)
+		if payload["parse_mode"] != nil {
+			_ = json.NewEncoder(w).Encode(MessageResponse{Ok: false, Description: "can't parse entities"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(MessageResponse{Ok: true, Result: Message{MessageID: 99}})
+	})
+	handlers := newTestBotHandlers(newTestClient(server.URL), newTestServiceForHandlers())
+
+	err := handlers.sendTranslation(context.Background(), testChatID, 5, "hi @tom\\_as *x")
+
+	assertNoError(t, err)
+	if len(payloads) != 2 || payloads[1]["text"] != "hi @tom_as *x" || payloads[1]["reply_to_message_id"] != float64(5) {
+		t.Errorf("payloads = %v, want a plain-text retry", payloads)
+	}
+}
+
+func TestEntitiesToMarkdown(t *testing.T) {
+	text := "👋 see docs and run_me now"
+	entities := []MessageEntity{
+		{Type: "text_link", Offset: 7, Length: 4, URL: "https://example.com"},
+		{Type: "code", Offset: 16, Length: 6},
+	}
+
+	got := entitiesToMarkdown(text, entities)
+
+	want := "👋 see [docs](https://example.com) and `run_me` now"
+	if got != want {
+		t.Errorf("entitiesToMarkdown() = %q, want %q", got, want)
+	}
+}
+
+func TestParseTranslation(t *testing.T) {
+	tests := []struct {
+		content    string
+		wantSource string
+		wantText   string
+	}{
+		{"ru\nHello", "ru", "Hello"},
+		{"**EN:**\nLine one\nLine two", "en", "Line one\nLine two"},
+		{"Just a translation", unknownLanguage, "Just a translation"},
+		{"Hello there\nsecond line", unknownLanguage, "Hello there\nsecond line"},
+	}
+
+	for _, tt := range tests {
+		source, text := parseTranslation(tt.content)
+		if source != tt.wantSource || text != tt.wantText {
+			t.Errorf("parseTranslation(%q) = %q, %q, want %q, %q", tt.content, source, text, tt.wantSource, tt.wantText)
+		}
+	}
+}
@@ -1,17 +1,53 @@
 package telegram
 
 type Update struct {
-	UpdateID int      `json:"update_id"`
-	Message  *Message `json:"message"`
+	UpdateID      int            `json:"update_id"`
+	Message       *Message       `json:"message"`
+	CallbackQuery *CallbackQuery `json:"callback_query"`
 }
 
 type Message struct {
-	MessageID      int      `json:"message_id"`
-	From           *User    `json:"from"`
-	Chat           *Chat    `json:"chat"`
-	Text           string   `json:"text"`
-	ReplyToMessage *Message `json:"reply_to_message"`
-	Sticker        *Sticker `json:"sticker"`
+	MessageID       int             `json:"message_id"`
+	From            *User           `json:"from"`
+	Chat            *Chat           `json:"chat"`
+	Text            string          `json:"text"`
+	Entities        []MessageEntity `json:"entities"`
+	ReplyToMessage  *Message        `json:"reply_to_message"`
+	Sticker         *Sticker        `json:"sticker"`
+	Photo           []PhotoSize     `json:"photo"`
+	Caption         string          `json:"caption"`
+	CaptionEntities []MessageEntity `json:"caption_entities"`
+	Voice           *Voice          `json:"voice"`
+	Audio           *Audio          `json:"audio"`
+	Document        *Document       `json:"document"`
+	Quote           *TextQuote      `json:"quote"`
+	ForwardOrigin   *MessageOrigin  `json:"forward_origin"`
+}
+
+type MessageEntity struct {
+	Type   string `json:"type"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	URL    string `json:"url"`
+}
+
+type TextQuote struct {
+	Text string `json:"text"`
+}
+
+type MessageOrigin struct {
+	Type           string `json:"type"`
+	SenderUser     *User  `json:"sender_user"`
+	SenderUserName string `json:"sender_user_name"`
+	SenderChat     *Chat  `json:"sender_chat"`
+	Chat           *Chat  `json:"chat"`
+}
+
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	From    *User    `json:"from"`
+	Message *Message `json:"message"`
+	Data    string   `json:"data"`
 }
 
 type User struct {
@@ -33,6 +69,39 @@ type Sticker struct {
 	SetName      string `json:"set_name"`
 }
 
+type PhotoSize struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	FileSize     int64  `json:"file_size"`
+}
+
+type Voice struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Duration     int    `json:"duration"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
+type Audio struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	Duration     int    `json:"duration"`
+	FileName     string `json:"file_name"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
+type Document struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileName     string `json:"file_name"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
 type APIResponse struct {
 	Ok          bool     `json:"ok"`
 	Result      []Update `json:"result,omitempty"`
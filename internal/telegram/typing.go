@@ -30,7 +30,7 @@ func (t *TypingIndicator) Stop() {
 }
 
 func (t *TypingIndicator) run(ctx context.Context, action string) {
-	_ = t.client.SendChatAction(t.chatID, action)
+	_ = t.client.SendChatAction(ctx, t.chatID, action)
 
 	ticker := time.NewTicker(typingInterval)
 	defer ticker.Stop()
@@ -42,7 +42,7 @@ func (t *TypingIndicator) run(ctx context.Context, action string) {
 		case <-t.done:
 			return
 		case <-ticker.C:
-			_ = t.client.SendChatAction(t.chatID, action)
+			_ = t.client.SendChatAction(ctx, t.chatID, action)
 		}
 	}
 }
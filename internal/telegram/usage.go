@@ -0,0 +1,146 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"got/internal/app/model"
+	"got/pkg/i18n"
+)
+
+const (
+	usagePeriodDay   = "day"
+	usagePeriodWeek  = "week"
+	usagePeriodMonth = "month"
+	usagePeriodAll   = "all"
+)
+
+var usagePeriods = map[string]struct {
+	duration time.Duration
+	label    i18n.Key
+}{
+	usagePeriodDay:   {24 * time.Hour, i18n.KeyUsagePeriodDay},
+	usagePeriodWeek:  {7 * 24 * time.Hour, i18n.KeyUsagePeriodWeek},
+	usagePeriodMonth: {30 * 24 * time.Hour, i18n.KeyUsagePeriodMonth},
+	usagePeriodAll:   {0, i18n.KeyUsagePeriodAll},
+}
+
+func (h *BotHandlers) HandleUsage(ctx context.Context, update *Update) error {
+	chatID := update.Message.Chat.ID
+	t := h.getTranslator(ctx, chatID)
+
+	since, label, ok := parseUsagePeriod(t, update.Message.CommandArguments(), time.Now())
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyUsageUsage))
+	}
+
+	report, err := h.service.GetChatUsage(ctx, chatID, since)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load command usage", "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyUsageError))
+	}
+	if report.Summary.Total == 0 {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyUsageEmpty))
+	}
+
+	return h.client.SendMessage(ctx, chatID, formatUsageReport(t, report, label, false))
+}
+
+func (h *BotHandlers) handleAdminUsage(ctx context.Context, chatID, userID int64, args string) error {
+	t := h.getTranslator(ctx, chatID)
+	if isAdmin, _ := h.isAdmin(ctx, userID); !isAdmin {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyAdminNotLoggedIn))
+	}
+
+	since, label, ok := parseUsagePeriod(t, args, time.Now())
+	if !ok {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyUsageUsage))
+	}
+
+	report, err := h.service.GetGlobalUsage(ctx, since)
+	if err != nil {
+		log.ErrorContext(ctx, "Failed to load global command usage", "error", err)
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyUsageError))
+	}
+	if report.Summary.Total == 0 {
+		return h.client.SendMessage(ctx, chatID, t.Get(i18n.KeyUsageEmpty))
+	}
+
+	return h.client.SendMessage(ctx, chatID, formatUsageReport(t, report, label, true))
+}
+
+func parseUsagePeriod(t *i18n.Translator, args string, now time.Time) (time.Time, string, bool) {
+	arg := strings.ToLower(strings.TrimSpace(args))
+	if arg == "" {
+		arg = usagePeriodWeek
+	}
+
+	if period, ok := usagePeriods[arg]; ok {
+		if period.duration == 0 {
+			return time.Time{}, t.Get(period.label), true
+		}
+		return now.Add(-period.duration), t.Get(period.label), true
+	}
+
+	d, err := ParseDuration(arg)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return now.Add(-d), arg, true
+}
+
+func formatUsageReport(t *i18n.Translator, report *model.UsageReport, label string, global bool) string {
+	var sb strings.Builder
+	s := report.Summary
+	avgLatency := s.AvgLatency.Round(time.Millisecond).String()
+
+	if global {
+		sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyUsageGlobalHeader), label, s.Total, s.Failed, s.Chats, s.Users, avgLatency))
+	} else {
+		sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyUsageHeader), label, s.Total, s.Failed, avgLatency))
+	}
+
+	if len(report.Commands) > 0 {
+		sb.WriteString(t.Get(i18n.KeyUsageTopCommands))
+		for i, c := range report.Commands {
+			sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyUsageItem), i+1, "`/"+c.Name+"`", c.Count))
+		}
+	}
+
+	if len(report.Users) > 0 {
+		sb.WriteString(t.Get(i18n.KeyUsageTopUsers))
+		for i, u := range report.Users {
+			sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyUsageItem), i+1, usageCountName(u, "User"), u.Count))
+		}
+	}
+
+	if len(report.Chats) > 0 {
+		sb.WriteString(t.Get(i18n.KeyUsageTopChats))
+		for i, c := range report.Chats {
+			sb.WriteString(fmt.Sprintf(t.Get(i18n.KeyUsageItem), i+1, usageCountName(c, "Chat"), c.Count))
+		}
+	}
+
+	return sb.String()
+}
+
+func usageCountName(c *model.UsageCount, fallbackPrefix string) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s%d", fallbackPrefix, c.ID)
+}
+
+func usageSubCommand(args string) string {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return ""
+	}
+	sub := subCommand(strings.ToLower(fields[0]))
+	if !knownSubCommands[sub] {
+		return ""
+	}
+	return string(sub)
+}
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"context"
+	"got/internal/app"
+	"got/internal/app/model"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseUsagePeriod(t *testing.T) {
+	tr := newTestTranslator()
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		args      string
+		wantSince time.Time
+		wantOK    bool
+	}{
+		{name: "DefaultWeek", args: "", wantSince: now.Add(-7 * 24 * time.Hour), wantOK: true},
+		{name: "Day", args: "day", wantSince: now.Add(-24 * time.Hour), wantOK: true},
+		{name: "Month", args: "MONTH", wantSince: now.Add(-30 * 24 * time.Hour), wantOK: true},
+		{name: "All", args: "all", wantSince: time.Time{}, wantOK: true},
+		{name: "CustomDuration", args: "3d", wantSince: now.Add(-72 * time.Hour), wantOK: true},
+		{name: "Invalid", args: "yesterday", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, _, ok := parseUsagePeriod(tr, tt.args, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !since.Equal(tt.wantSince) {
+				t.Errorf("since = %v, want %v", since, tt.wantSince)
+			}
+		})
+	}
+}
+
+func TestHandleUsage(t *testing.T) {
+	var sentMessage string
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		payload := decodeJSONPayload(t, r)
+		sentMessage = payload["text"].(string)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	usageRepo := &mockUsageRepo{
+		summaryFunc: func(ctx context.Context, chatID int64, since time.Time) (*model.UsageSummary, error) {
+			return &model.UsageSummary{Total: 12, Failed: 1, AvgLatency: 150 * time.Millisecond}, nil
+		},
+		topCommandsFunc: func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+			return []*model.UsageCount{{Name: "gpt", Count: 9}, {Name: "meme", Count: 3}}, nil
+		},
+		topUsersFunc: func(ctx context.Context, chatID int64, since time.Time, limit int) ([]*model.UsageCount, error) {
+			return []*model.UsageCount{{ID: 7, Name: "alice", Count: 10}, {ID: 8, Count: 2}}, nil
+		},
+	}
+	svc := newMockService(app.Repositories{Usage: usageRepo})
+	handlers := newTestBotHandlers(newTestClient(server.URL), svc)
+
+	update := &Update{Message: &Message{Text: "/usage", Chat: &Chat{ID: 123}}}
+	if err := handlers.HandleUsage(context.Background(), update); err != nil {
+		t.Fatalf("HandleUsage() error = %v", err)
+	}
+
+	for _, want := range []string{"last 7 days", "`/gpt`", "alice", "User8", "150ms"} {
+		if !strings.Contains(sentMessage, want) {
+			t.Errorf("usage report missing %q:\n%s", want, sentMessage)
+		}
+	}
+}
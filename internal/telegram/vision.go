@@ -0,0 +1,84 @@
+package telegram
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+
+	"got/pkg/i18n"
+)
+
+const (
+	maxVisionImageSize = 3 << 20
+	imageMimePrefix    = "image/"
+	defaultImageMime   = "image/jpeg"
+)
+
+var errImageTooLarge = errors.New("image too large")
+
+type promptImage struct {
+	dataURL string
+	ref     string
+}
+
+func (h *BotHandlers) promptImage(ctx context.Context, msg *Message) (*promptImage, error) {
+	photo := selectPhoto(messagePhoto(msg), maxVisionImageSize)
+	if photo == nil {
+		if len(messagePhoto(msg)) > 0 {
+			return nil, errImageTooLarge
+		}
+		return nil, nil
+	}
+
+	data, err := h.client.DownloadFile(ctx, photo.FileID)
+	if err != nil {
+		log.WarnContext(ctx, "Failed to download photo", "file", photo.FileID, "error", err)
+		return nil, err
+	}
+	if len(data) > maxVisionImageSize {
+		return nil, errImageTooLarge
+	}
+
+	return &promptImage{dataURL: imageDataURL(data), ref: photo.FileID}, nil
+}
+
+func visionErrorKey(err error) i18n.Key {
+	if errors.Is(err, errImageTooLarge) {
+		return i18n.KeyGptVisionTooLarge
+	}
+	return i18n.KeyGptVisionDownloadError
+}
+
+func messagePhoto(msg *Message) []PhotoSize {
+	if len(msg.Photo) > 0 {
+		return msg.Photo
+	}
+	if msg.ReplyToMessage != nil {
+		return msg.ReplyToMessage.Photo
+	}
+	return nil
+}
+
+func selectPhoto(sizes []PhotoSize, limit int64) *PhotoSize {
+	var best *PhotoSize
+	for i := range sizes {
+		size := &sizes[i]
+		if size.FileSize > limit {
+			continue
+		}
+		if best == nil || size.Width*size.Height > best.Width*best.Height {
+			best = size
+		}
+	}
+	return best
+}
+
+func imageDataURL(data []byte) string {
+	mime := http.DetectContentType(data)
+	if !strings.HasPrefix(mime, imageMimePrefix) {
+		mime = defaultImageMime
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
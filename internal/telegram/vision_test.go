@@ -0,0 +1,119 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSelectPhoto(t *testing.T) {
+	sizes := []PhotoSize{
+		{FileID: "small", Width: 90, Height: 90, FileSize: 1000},
+		{FileID: "medium", Width: 320, Height: 320, FileSize: 20000},
+		{FileID: "large", Width: 1280, Height: 1280, FileSize: 5 << 20},
+	}
+
+	tests := []struct {
+		name  string
+		sizes []PhotoSize
+		limit int64
+		want  string
+	}{
+		{name: "LargestWithinLimit", sizes: sizes, limit: 10 << 20, want: "large"},
+		{name: "SkipsOversized", sizes: sizes, limit: maxVisionImageSize, want: "medium"},
+		{name: "NothingFits", sizes: sizes, limit: 10, want: ""},
+		{name: "NoPhoto", sizes: nil, limit: maxVisionImageSize, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectPhoto(tt.sizes, tt.limit)
+			gotID := ""
+			if got != nil {
+				gotID = got.FileID
+			}
+			if gotID != tt.want {
+				t.Errorf("selectPhoto() = %q, want %q", gotID, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageDataURL(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n0000")
+	if got := imageDataURL(png); !strings.HasPrefix(got, "data:image/png;base64,") {
+		t.Errorf("imageDataURL(png) = %q, want png data URL", got)
+	}
+	if got := imageDataURL([]byte("not an image")); !strings.HasPrefix(got, "data:image/jpeg;base64,") {
+		t.Errorf("imageDataURL(text) = %q, want jpeg fallback", got)
+	}
+}
+
+func TestPromptImage(t *testing.T) {
+	server := newTestServerWithHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, getFileCMD):
+			if got := r.URL.Query().Get("file_id"); got != "photo-big" {
+				t.Errorf("file_id = %q, want %q", got, "photo-big")
+			}
+			_ = json.NewEncoder(w).Encode(FileResponse{Ok: true, Result: File{FileID: "photo-big", FilePath: "photos/file_1.jpg"}})
+		case strings.HasSuffix(r.URL.Path, "/photos/file_1.jpg"):
+			_, _ = w.Write([]byte("\xff\xd8\xff\xe0jpegdata"))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+
+	client := newTestClient(server.URL)
+	client.fileURL = server.URL
+	handlers := newTestBotHandlers(client, newTestServiceForHandlers())
+
+	msg := &Message{
+		Chat: &Chat{ID: testChatID},
+		ReplyToMessage: &Message{Photo: []PhotoSize{
+			{FileID: "photo-small", Width: 90, Height: 90},
+			{FileID: "photo-big", Width: 800, Height: 600},
+		}},
+	}
+
+	image, err := handlers.promptImage(context.Background(), msg)
+
+	assertNoError(t, err)
+	if image == nil {
+		t.Fatal("expected image, got nil")
+	}
+	if image.ref != "photo-big" {
+		t.Errorf("ref = %q, want %q", image.ref, "photo-big")
+	}
+	if !strings.HasPrefix(image.dataURL, "data:image/jpeg;base64,") {
+		t.Errorf("dataURL = %q, want jpeg data URL", image.dataURL)
+	}
+}
+
+func TestPromptImageWithoutPhoto(t *testing.T) {
+	handlers := newTestBotHandlers(newTestClient("http://unused"), newTestServiceForHandlers())
+
+	image, err := handlers.promptImage(context.Background(), &Message{Chat: &Chat{ID: testChatID}, Text: "/gpt hi"})
+
+	assertNoError(t, err)
+	if image != nil {
+		t.Errorf("expected no image, got %+v", image)
+	}
+}
+
+func TestPromptImageTooLarge(t *testing.T) {
+	handlers := newTestBotHandlers(newTestClient("http://unused"), newTestServiceForHandlers())
+	msg := &Message{
+		Chat:  &Chat{ID: testChatID},
+		Photo: []PhotoSize{{FileID: "huge", Width: 4000, Height: 4000, FileSize: 10 << 20}},
+	}
+
+	_, err := handlers.promptImage(context.Background(), msg)
+
+	if !errors.Is(err, errImageTooLarge) {
+		t.Errorf("error = %v, want %v", err, errImageTooLarge)
+	}
+}
@@ -3,6 +3,7 @@ package tts
 import (
 	"context"
 	"fmt"
+	"got/pkg/logger"
 	"io"
 	"net/http"
 	"net/url"
@@ -21,6 +22,8 @@ type Client struct {
 	baseURL    string
 }
 
+var log = logger.For("tts")
+
 func NewClient() *Client {
 	return &Client{
 		httpClient: &http.Client{
@@ -43,12 +46,16 @@ func (c *Client) GenerateSpeech(ctx context.Context, text string) ([]byte, error
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		log.ErrorContext(ctx, "TTS request failed", "error", err)
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	log.DebugContext(ctx, "TTS request completed", "status", resp.StatusCode, "voice", c.voice, "duration", time.Since(start))
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("tts api error: %s", resp.Status)
 	}
@@ -3,30 +3,54 @@ package config
 import (
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
 )
 
 const (
-	defaultRedisAddr    = "localhost:6379"
-	defaultLanguage     = "en"
-	defaultConfigPath   = "config.yaml"
-	defaultWinnerReset  = "0 0 0 * * *"
-	defaultAutoRoulette = "0 0 11 * * *"
-
-	defaultCmdStart    = "start"
-	defaultCmdHelp     = "help"
-	defaultCmdGpt      = "gpt"
-	defaultCmdRemind   = "remind"
-	defaultCmdMeme     = "meme"
-	defaultCmdSticker  = "sticker"
-	defaultCmdFact     = "fact"
-	defaultCmdRoulette = "roulette"
-	defaultCmdTts      = "tts"
-	defaultCmdAdmin    = "admin"
-	defaultCmdLang     = "lang"
+	defaultRedisAddr     = "localhost:6379"
+	defaultLanguage      = "en"
+	defaultConfigPath    = "config.yaml"
+	defaultWinnerReset   = "0 0 0 * * *"
+	defaultAutoRoulette  = "0 0 11 * * *"
+	defaultPruneMessages = "0 30 * * * *"
+	defaultDedupWindow   = 10 * time.Minute
+	defaultLogFormat     = "text"
+	defaultLogLevel      = "info"
+	defaultLLMName       = "local"
+	defaultLLMAttempts   = 3
+	defaultLLMRetryDelay = 500 * time.Millisecond
+	defaultLLMModelsTTL  = time.Hour
+	defaultLogRetention  = 7 * 24 * time.Hour
+	defaultLogMaxPerChat = 5000
+	defaultImageName     = "images"
+	defaultImageProvider = "pollinations"
+	defaultImageTimeout  = 60 * time.Second
+	defaultImageAttempts = 2
+	defaultImageDelay    = time.Second
+	defaultLurkChance    = 5
+	defaultLurkCooldown  = 30 * time.Minute
+	defaultLurkContext   = 20
+
+	defaultCmdStart      = "start"
+	defaultCmdHelp       = "help"
+	defaultCmdGpt        = "gpt"
+	defaultCmdRemind     = "remind"
+	defaultCmdMeme       = "meme"
+	defaultCmdSticker    = "sticker"
+	defaultCmdFact       = "fact"
+	defaultCmdRoulette   = "roulette"
+	defaultCmdTts        = "tts"
+	defaultCmdAdmin      = "admin"
+	defaultCmdLang       = "lang"
+	defaultCmdUsage      = "usage"
+	defaultCmdTranscribe = "transcribe"
+	defaultCmdTranslate  = "translate"
+	defaultCmdAssistant  = "assistant"
 )
 
 type Config struct {
@@ -35,9 +59,17 @@ type Config struct {
 	GptKey           string
 	RedisAddr        string
 	AdminPass        string
-	Bot              BotConfig      `yaml:"bot"`
-	Schedule         ScheduleConfig `yaml:"schedule"`
-	Commands         CommandsConfig `yaml:"commands"`
+	Bot              BotConfig        `yaml:"bot"`
+	Schedule         ScheduleConfig   `yaml:"schedule"`
+	Commands         CommandsConfig   `yaml:"commands"`
+	Alerts           AlertsConfig     `yaml:"alerts"`
+	Log              LogConfig        `yaml:"log"`
+	LLM              LLMConfig        `yaml:"llm"`
+	Quotas           QuotaConfig      `yaml:"quotas"`
+	MessageLog       MessageLogConfig `yaml:"message_log"`
+	Moderation       ModerationConfig `yaml:"moderation"`
+	Image            ImageConfig      `yaml:"image"`
+	Lurker           LurkerConfig     `yaml:"lurker"`
 	DisabledCommands map[string]bool
 }
 
@@ -46,22 +78,101 @@ type BotConfig struct {
 }
 
 type ScheduleConfig struct {
-	WinnerReset  string `yaml:"winner_reset"`
-	AutoRoulette string `yaml:"auto_roulette"`
+	WinnerReset   string `yaml:"winner_reset"`
+	AutoRoulette  string `yaml:"auto_roulette"`
+	PruneMessages string `yaml:"prune_messages"`
+}
+
+type AlertsConfig struct {
+	ChatID      int64         `yaml:"chat_id"`
+	DedupWindow time.Duration `yaml:"dedup_window"`
+}
+
+type LogConfig struct {
+	Format   string            `yaml:"format"`
+	Level    string            `yaml:"level"`
+	Packages map[string]string `yaml:"packages"`
+}
+
+type LLMConfig struct {
+	DefaultProvider string              `yaml:"default_provider"`
+	Providers       []LLMProviderConfig `yaml:"providers"`
+	Fallbacks       []string            `yaml:"fallbacks"`
+	MaxAttempts     int                 `yaml:"max_attempts"`
+	RetryDelay      time.Duration       `yaml:"retry_delay"`
+	ModelsTTL       time.Duration       `yaml:"models_ttl"`
+}
+
+type LLMProviderConfig struct {
+	Name        string   `yaml:"name"`
+	BaseURL     string   `yaml:"base_url"`
+	APIKey      string   `yaml:"api_key"`
+	Model       string   `yaml:"model"`
+	VisionModel string   `yaml:"vision_model"`
+	AudioModel  string   `yaml:"audio_model"`
+	Models      []string `yaml:"models"`
+}
+
+type MessageLogConfig struct {
+	Retention  time.Duration `yaml:"retention"`
+	MaxPerChat int           `yaml:"max_per_chat"`
+}
+
+type ModerationConfig struct {
+	Enabled    bool     `yaml:"enabled"`
+	Keywords   []string `yaml:"keywords"`
+	Patterns   []string `yaml:"patterns"`
+	Classifier bool     `yaml:"classifier"`
+	FailOpen   bool     `yaml:"fail_open"`
+	Model      string   `yaml:"model"`
+}
+
+type ImageConfig struct {
+	Timeout     time.Duration         `yaml:"timeout"`
+	MaxAttempts int                   `yaml:"max_attempts"`
+	RetryDelay  time.Duration         `yaml:"retry_delay"`
+	Providers   []ImageProviderConfig `yaml:"providers"`
+}
+
+type ImageProviderConfig struct {
+	Name    string `yaml:"name"`
+	BaseURL string `yaml:"base_url"`
+	APIKey  string `yaml:"api_key"`
+	Model   string `yaml:"model"`
+}
+
+type LurkerConfig struct {
+	Chance   int           `yaml:"chance"`
+	Cooldown time.Duration `yaml:"cooldown"`
+	Context  int           `yaml:"context"`
+}
+
+type QuotaConfig struct {
+	Chat QuotaLimits `yaml:"chat"`
+	User QuotaLimits `yaml:"user"`
+}
+
+type QuotaLimits struct {
+	Daily   int64 `yaml:"daily"`
+	Monthly int64 `yaml:"monthly"`
 }
 
 type CommandsConfig struct {
-	Start    string `yaml:"start"`
-	Help     string `yaml:"help"`
-	Gpt      string `yaml:"gpt"`
-	Remind   string `yaml:"remind"`
-	Meme     string `yaml:"meme"`
-	Sticker  string `yaml:"sticker"`
-	Fact     string `yaml:"fact"`
-	Roulette string `yaml:"roulette"`
-	Tts      string `yaml:"tts"`
-	Admin    string `yaml:"admin"`
-	Lang     string `yaml:"lang"`
+	Start      string `yaml:"start"`
+	Help       string `yaml:"help"`
+	Gpt        string `yaml:"gpt"`
+	Remind     string `yaml:"remind"`
+	Meme       string `yaml:"meme"`
+	Sticker    string `yaml:"sticker"`
+	Fact       string `yaml:"fact"`
+	Roulette   string `yaml:"roulette"`
+	Tts        string `yaml:"tts"`
+	Admin      string `yaml:"admin"`
+	Lang       string `yaml:"lang"`
+	Usage      string `yaml:"usage"`
+	Transcribe string `yaml:"transcribe"`
+	Translate  string `yaml:"translate"`
+	Assistant  string `yaml:"assistant"`
 }
 
 func Load() *Config {
@@ -130,10 +241,26 @@ func applyEnvOverrides(cfg *Config) {
 		cfg.Schedule.AutoRoulette = defaultAutoRoulette
 	}
 
+	if schedule := os.Getenv("SCHEDULE_PRUNE_MESSAGES"); schedule != "" {
+		cfg.Schedule.PruneMessages = schedule
+	}
+	if cfg.Schedule.PruneMessages == "" {
+		cfg.Schedule.PruneMessages = defaultPruneMessages
+	}
+
 	if pass := os.Getenv("ADMIN_PASS"); pass != "" {
 		cfg.AdminPass = pass
 	}
 
+	applyAlertOverrides(cfg)
+	applyLogOverrides(cfg)
+	applyLLMOverrides(cfg)
+	applyQuotaOverrides(cfg)
+	applyMessageLogOverrides(cfg)
+	applyModerationOverrides(cfg)
+	applyImageOverrides(cfg)
+	applyLurkerOverrides(cfg)
+
 	applyCommandOverrides(cfg)
 	applyDisabledCommands(cfg)
 }
@@ -150,6 +277,243 @@ func applyCommandOverrides(cfg *Config) {
 	cfg.Commands.Tts = getEnvOrDefaultWithFallback("CMD_TTS", cfg.Commands.Tts, defaultCmdTts)
 	cfg.Commands.Admin = getEnvOrDefaultWithFallback("CMD_ADMIN", cfg.Commands.Admin, defaultCmdAdmin)
 	cfg.Commands.Lang = getEnvOrDefaultWithFallback("CMD_LANG", cfg.Commands.Lang, defaultCmdLang)
+	cfg.Commands.Usage = getEnvOrDefaultWithFallback("CMD_USAGE", cfg.Commands.Usage, defaultCmdUsage)
+	cfg.Commands.Transcribe = getEnvOrDefaultWithFallback("CMD_TRANSCRIBE", cfg.Commands.Transcribe, defaultCmdTranscribe)
+	cfg.Commands.Translate = getEnvOrDefaultWithFallback("CMD_TRANSLATE", cfg.Commands.Translate, defaultCmdTranslate)
+	cfg.Commands.Assistant = getEnvOrDefaultWithFallback("CMD_ASSISTANT", cfg.Commands.Assistant, defaultCmdAssistant)
+}
+
+func applyAlertOverrides(cfg *Config) {
+	if chatID := os.Getenv("ALERT_CHAT_ID"); chatID != "" {
+		if id, err := strconv.ParseInt(chatID, 10, 64); err == nil {
+			cfg.Alerts.ChatID = id
+		} else {
+			slog.Warn("Invalid ALERT_CHAT_ID, ignoring", "value", chatID)
+		}
+	}
+
+	if window := os.Getenv("ALERT_DEDUP_WINDOW"); window != "" {
+		if d, err := time.ParseDuration(window); err == nil {
+			cfg.Alerts.DedupWindow = d
+		} else {
+			slog.Warn("Invalid ALERT_DEDUP_WINDOW, ignoring", "value", window)
+		}
+	}
+	if cfg.Alerts.DedupWindow <= 0 {
+		cfg.Alerts.DedupWindow = defaultDedupWindow
+	}
+}
+
+func applyLogOverrides(cfg *Config) {
+	cfg.Log.Format = strings.ToLower(getEnvOrDefaultWithFallback("LOG_FORMAT", cfg.Log.Format, defaultLogFormat))
+	cfg.Log.Level = strings.ToLower(getEnvOrDefaultWithFallback("LOG_LEVEL", cfg.Log.Level, defaultLogLevel))
+}
+
+func applyLLMOverrides(cfg *Config) {
+	if name := os.Getenv("LLM_DEFAULT_PROVIDER"); name != "" {
+		cfg.LLM.DefaultProvider = name
+	}
+
+	if fallbacks := os.Getenv("LLM_FALLBACKS"); fallbacks != "" {
+		cfg.LLM.Fallbacks = splitList(fallbacks)
+	}
+
+	if attempts := os.Getenv("LLM_MAX_ATTEMPTS"); attempts != "" {
+		if n, err := strconv.Atoi(attempts); err == nil && n > 0 {
+			cfg.LLM.MaxAttempts = n
+		} else {
+			slog.Warn("Invalid LLM_MAX_ATTEMPTS, ignoring", "value", attempts)
+		}
+	}
+	if cfg.LLM.MaxAttempts <= 0 {
+		cfg.LLM.MaxAttempts = defaultLLMAttempts
+	}
+	if cfg.LLM.RetryDelay <= 0 {
+		cfg.LLM.RetryDelay = defaultLLMRetryDelay
+	}
+
+	if ttl := os.Getenv("LLM_MODELS_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil && d > 0 {
+			cfg.LLM.ModelsTTL = d
+		} else {
+			slog.Warn("Invalid LLM_MODELS_TTL, ignoring", "value", ttl)
+		}
+	}
+	if cfg.LLM.ModelsTTL <= 0 {
+		cfg.LLM.ModelsTTL = defaultLLMModelsTTL
+	}
+
+	baseURL := os.Getenv("LLM_BASE_URL")
+	if baseURL == "" {
+		return
+	}
+
+	provider := LLMProviderConfig{
+		Name:        getEnvOrDefault("LLM_NAME", defaultLLMName),
+		BaseURL:     baseURL,
+		APIKey:      os.Getenv("LLM_API_KEY"),
+		Model:       os.Getenv("LLM_MODEL"),
+		VisionModel: os.Getenv("LLM_VISION_MODEL"),
+		AudioModel:  os.Getenv("LLM_AUDIO_MODEL"),
+	}
+	if provider.Model != "" {
+		provider.Models = []string{provider.Model}
+	}
+
+	for i, p := range cfg.LLM.Providers {
+		if p.Name == provider.Name {
+			cfg.LLM.Providers[i] = provider
+			return
+		}
+	}
+	cfg.LLM.Providers = append(cfg.LLM.Providers, provider)
+}
+
+func applyQuotaOverrides(cfg *Config) {
+	limits := map[string]*int64{
+		"QUOTA_CHAT_DAILY":   &cfg.Quotas.Chat.Daily,
+		"QUOTA_CHAT_MONTHLY": &cfg.Quotas.Chat.Monthly,
+		"QUOTA_USER_DAILY":   &cfg.Quotas.User.Daily,
+		"QUOTA_USER_MONTHLY": &cfg.Quotas.User.Monthly,
+	}
+
+	for env, limit := range limits {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && n >= 0 {
+			*limit = n
+		} else {
+			slog.Warn("Invalid token quota, ignoring", "env", env, "value", value)
+		}
+	}
+}
+
+func applyMessageLogOverrides(cfg *Config) {
+	if retention := os.Getenv("MESSAGE_LOG_RETENTION"); retention != "" {
+		if d, err := time.ParseDuration(retention); err == nil && d > 0 {
+			cfg.MessageLog.Retention = d
+		} else {
+			slog.Warn("Invalid MESSAGE_LOG_RETENTION, ignoring", "value", retention)
+		}
+	}
+	if cfg.MessageLog.Retention <= 0 {
+		cfg.MessageLog.Retention = defaultLogRetention
+	}
+
+	if limit := os.Getenv("MESSAGE_LOG_MAX_PER_CHAT"); limit != "" {
+		if n, err := strconv.Atoi(limit); err == nil && n > 0 {
+			cfg.MessageLog.MaxPerChat = n
+		} else {
+			slog.Warn("Invalid MESSAGE_LOG_MAX_PER_CHAT, ignoring", "value", limit)
+		}
+	}
+	if cfg.MessageLog.MaxPerChat <= 0 {
+		cfg.MessageLog.MaxPerChat = defaultLogMaxPerChat
+	}
+}
+
+func applyModerationOverrides(cfg *Config) {
+	if enabled := os.Getenv("MODERATION_ENABLED"); enabled != "" {
+		cfg.Moderation.Enabled = isEnvTrue("MODERATION_ENABLED")
+	}
+	if keywords := os.Getenv("MODERATION_KEYWORDS"); keywords != "" {
+		cfg.Moderation.Keywords = splitList(keywords)
+	}
+	if classifier := os.Getenv("MODERATION_CLASSIFIER"); classifier != "" {
+		cfg.Moderation.Classifier = isEnvTrue("MODERATION_CLASSIFIER")
+	}
+	if failOpen := os.Getenv("MODERATION_FAIL_OPEN"); failOpen != "" {
+		cfg.Moderation.FailOpen = isEnvTrue("MODERATION_FAIL_OPEN")
+	}
+	if model := os.Getenv("MODERATION_MODEL"); model != "" {
+		cfg.Moderation.Model = model
+	}
+}
+
+func applyImageOverrides(cfg *Config) {
+	if timeout := os.Getenv("IMAGE_TIMEOUT"); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err == nil && d > 0 {
+			cfg.Image.Timeout = d
+		} else {
+			slog.Warn("Invalid IMAGE_TIMEOUT, ignoring", "value", timeout)
+		}
+	}
+	if cfg.Image.Timeout <= 0 {
+		cfg.Image.Timeout = defaultImageTimeout
+	}
+	if attempts := os.Getenv("IMAGE_MAX_ATTEMPTS"); attempts != "" {
+		if n, err := strconv.Atoi(attempts); err == nil && n > 0 {
+			cfg.Image.MaxAttempts = n
+		} else {
+			slog.Warn("Invalid IMAGE_MAX_ATTEMPTS, ignoring", "value", attempts)
+		}
+	}
+	if cfg.Image.MaxAttempts <= 0 {
+		cfg.Image.MaxAttempts = defaultImageAttempts
+	}
+	if cfg.Image.RetryDelay <= 0 {
+		cfg.Image.RetryDelay = defaultImageDelay
+	}
+	if len(cfg.Image.Providers) == 0 {
+		cfg.Image.Providers = []ImageProviderConfig{{Name: defaultImageProvider}}
+	}
+
+	baseURL := os.Getenv("IMAGE_BASE_URL")
+	if baseURL == "" {
+		return
+	}
+
+	provider := ImageProviderConfig{
+		Name:    getEnvOrDefault("IMAGE_NAME", defaultImageName),
+		BaseURL: baseURL,
+		APIKey:  os.Getenv("IMAGE_API_KEY"),
+		Model:   os.Getenv("IMAGE_MODEL"),
+	}
+	for i, p := range cfg.Image.Providers {
+		if p.Name == provider.Name {
+			cfg.Image.Providers[i] = provider
+			return
+		}
+	}
+	cfg.Image.Providers = append([]ImageProviderConfig{provider}, cfg.Image.Providers...)
+}
+
+func applyLurkerOverrides(cfg *Config) {
+	if chance := os.Getenv("LURKER_CHANCE"); chance != "" {
+		if n, err := strconv.Atoi(chance); err == nil && n > 0 && n <= 100 {
+			cfg.Lurker.Chance = n
+		} else {
+			slog.Warn("Invalid LURKER_CHANCE, ignoring", "value", chance)
+		}
+	}
+	if cfg.Lurker.Chance <= 0 || cfg.Lurker.Chance > 100 {
+		cfg.Lurker.Chance = defaultLurkChance
+	}
+	if cooldown := os.Getenv("LURKER_COOLDOWN"); cooldown != "" {
+		if d, err := time.ParseDuration(cooldown); err == nil && d > 0 {
+			cfg.Lurker.Cooldown = d
+		} else {
+			slog.Warn("Invalid LURKER_COOLDOWN, ignoring", "value", cooldown)
+		}
+	}
+	if cfg.Lurker.Cooldown <= 0 {
+		cfg.Lurker.Cooldown = defaultLurkCooldown
+	}
+	if cfg.Lurker.Context <= 0 {
+		cfg.Lurker.Context = defaultLurkContext
+	}
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 func getEnvOrDefaultWithFallback(envKey, yamlValue, defaultValue string) string {
@@ -166,6 +530,7 @@ func setDefaults(cfg *Config) {
 	cfg.Bot.Language = defaultLanguage
 	cfg.Schedule.WinnerReset = defaultWinnerReset
 	cfg.Schedule.AutoRoulette = defaultAutoRoulette
+	cfg.Schedule.PruneMessages = defaultPruneMessages
 	cfg.Commands.Start = defaultCmdStart
 	cfg.Commands.Help = defaultCmdHelp
 	cfg.Commands.Gpt = defaultCmdGpt
@@ -177,6 +542,10 @@ func setDefaults(cfg *Config) {
 	cfg.Commands.Tts = defaultCmdTts
 	cfg.Commands.Admin = defaultCmdAdmin
 	cfg.Commands.Lang = defaultCmdLang
+	cfg.Commands.Usage = defaultCmdUsage
+	cfg.Commands.Transcribe = defaultCmdTranscribe
+	cfg.Commands.Translate = defaultCmdTranslate
+	cfg.Commands.Assistant = defaultCmdAssistant
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -190,17 +559,21 @@ func applyDisabledCommands(cfg *Config) {
 	cfg.DisabledCommands = make(map[string]bool)
 
 	disableEnvs := map[string]string{
-		"DISABLE_CMD_START":    cfg.Commands.Start,
-		"DISABLE_CMD_HELP":     cfg.Commands.Help,
-		"DISABLE_CMD_GPT":      cfg.Commands.Gpt,
-		"DISABLE_CMD_REMIND":   cfg.Commands.Remind,
-		"DISABLE_CMD_MEME":     cfg.Commands.Meme,
-		"DISABLE_CMD_STICKER":  cfg.Commands.Sticker,
-		"DISABLE_CMD_FACT":     cfg.Commands.Fact,
-		"DISABLE_CMD_ROULETTE": cfg.Commands.Roulette,
-		"DISABLE_CMD_TTS":      cfg.Commands.Tts,
-		"DISABLE_CMD_ADMIN":    cfg.Commands.Admin,
-		"DISABLE_CMD_LANG":     cfg.Commands.Lang,
+		"DISABLE_CMD_START":      cfg.Commands.Start,
+		"DISABLE_CMD_HELP":       cfg.Commands.Help,
+		"DISABLE_CMD_GPT":        cfg.Commands.Gpt,
+		"DISABLE_CMD_REMIND":     cfg.Commands.Remind,
+		"DISABLE_CMD_MEME":       cfg.Commands.Meme,
+		"DISABLE_CMD_STICKER":    cfg.Commands.Sticker,
+		"DISABLE_CMD_FACT":       cfg.Commands.Fact,
+		"DISABLE_CMD_ROULETTE":   cfg.Commands.Roulette,
+		"DISABLE_CMD_TTS":        cfg.Commands.Tts,
+		"DISABLE_CMD_ADMIN":      cfg.Commands.Admin,
+		"DISABLE_CMD_LANG":       cfg.Commands.Lang,
+		"DISABLE_CMD_USAGE":      cfg.Commands.Usage,
+		"DISABLE_CMD_TRANSCRIBE": cfg.Commands.Transcribe,
+		"DISABLE_CMD_TRANSLATE":  cfg.Commands.Translate,
+		"DISABLE_CMD_ASSISTANT":  cfg.Commands.Assistant,
 	}
 
 	for envKey, cmdName := range disableEnvs {
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestIsEnvTrue(t *testing.T) {
@@ -110,3 +112,243 @@ func TestApplyDisabledCommandsWithCustomCommandNames(t *testing.T) {
 		t.Error("default meme command name should not be in disabled list")
 	}
 }
+
+func TestApplyAlertOverrides(t *testing.T) {
+	os.Setenv("ALERT_CHAT_ID", "-1001234")
+	os.Setenv("ALERT_DEDUP_WINDOW", "5m")
+	defer func() {
+		os.Unsetenv("ALERT_CHAT_ID")
+		os.Unsetenv("ALERT_DEDUP_WINDOW")
+	}()
+
+	cfg := &Config{}
+	applyAlertOverrides(cfg)
+
+	if cfg.Alerts.ChatID != -1001234 {
+		t.Errorf("Alerts.ChatID = %d, want %d", cfg.Alerts.ChatID, -1001234)
+	}
+	if cfg.Alerts.DedupWindow != 5*time.Minute {
+		t.Errorf("Alerts.DedupWindow = %v, want %v", cfg.Alerts.DedupWindow, 5*time.Minute)
+	}
+}
+
+func TestApplyAlertOverridesDefaultWindow(t *testing.T) {
+	cfg := &Config{}
+	applyAlertOverrides(cfg)
+
+	if cfg.Alerts.ChatID != 0 {
+		t.Errorf("Alerts.ChatID = %d, want 0", cfg.Alerts.ChatID)
+	}
+	if cfg.Alerts.DedupWindow != defaultDedupWindow {
+		t.Errorf("Alerts.DedupWindow = %v, want %v", cfg.Alerts.DedupWindow, defaultDedupWindow)
+	}
+}
+
+func TestApplyLogOverrides(t *testing.T) {
+	os.Setenv("LOG_FORMAT", "JSON")
+	defer os.Unsetenv("LOG_FORMAT")
+
+	cfg := &Config{Log: LogConfig{Level: "debug"}}
+	applyLogOverrides(cfg)
+
+	if cfg.Log.Format != "json" {
+		t.Errorf("Log.Format = %q, want %q", cfg.Log.Format, "json")
+	}
+	if cfg.Log.Level != "debug" {
+		t.Errorf("Log.Level = %q, want %q", cfg.Log.Level, "debug")
+	}
+}
+
+func TestApplyLLMOverrides(t *testing.T) {
+	os.Setenv("LLM_BASE_URL", "http://localhost:11434/v1")
+	os.Setenv("LLM_MODEL", "llama3.1")
+	os.Setenv("LLM_DEFAULT_PROVIDER", "local")
+	defer func() {
+		os.Unsetenv("LLM_BASE_URL")
+		os.Unsetenv("LLM_MODEL")
+		os.Unsetenv("LLM_DEFAULT_PROVIDER")
+	}()
+
+	cfg := &Config{LLM: LLMConfig{Providers: []LLMProviderConfig{
+		{Name: "openrouter", BaseURL: "https://openrouter.ai/api/v1"},
+	}}}
+	applyLLMOverrides(cfg)
+
+	if cfg.LLM.DefaultProvider != "local" {
+		t.Errorf("LLM.DefaultProvider = %q, want %q", cfg.LLM.DefaultProvider, "local")
+	}
+	if len(cfg.LLM.Providers) != 2 {
+		t.Fatalf("got %d providers, want 2", len(cfg.LLM.Providers))
+	}
+
+	local := cfg.LLM.Providers[1]
+	if local.Name != defaultLLMName || local.BaseURL != "http://localhost:11434/v1" || local.Model != "llama3.1" {
+		t.Errorf("env provider = %+v", local)
+	}
+	if len(local.Models) != 1 || local.Models[0] != "llama3.1" {
+		t.Errorf("env provider models = %v, want [llama3.1]", local.Models)
+	}
+}
+
+func TestApplyLLMOverridesRetryAndFallbacks(t *testing.T) {
+	os.Setenv("LLM_FALLBACKS", "llama-3.1-8b-instant, local:llama3.1 ,")
+	os.Setenv("LLM_MAX_ATTEMPTS", "5")
+	defer func() {
+		os.Unsetenv("LLM_FALLBACKS")
+		os.Unsetenv("LLM_MAX_ATTEMPTS")
+	}()
+
+	cfg := &Config{}
+	applyLLMOverrides(cfg)
+
+	want := []string{"llama-3.1-8b-instant", "local:llama3.1"}
+	if len(cfg.LLM.Fallbacks) != len(want) || cfg.LLM.Fallbacks[0] != want[0] || cfg.LLM.Fallbacks[1] != want[1] {
+		t.Errorf("LLM.Fallbacks = %v, want %v", cfg.LLM.Fallbacks, want)
+	}
+	if cfg.LLM.MaxAttempts != 5 {
+		t.Errorf("LLM.MaxAttempts = %d, want 5", cfg.LLM.MaxAttempts)
+	}
+	if cfg.LLM.RetryDelay != defaultLLMRetryDelay {
+		t.Errorf("LLM.RetryDelay = %v, want %v", cfg.LLM.RetryDelay, defaultLLMRetryDelay)
+	}
+	if cfg.LLM.ModelsTTL != defaultLLMModelsTTL {
+		t.Errorf("LLM.ModelsTTL = %v, want %v", cfg.LLM.ModelsTTL, defaultLLMModelsTTL)
+	}
+}
+
+func TestApplyLLMOverridesModelsTTL(t *testing.T) {
+	os.Setenv("LLM_MODELS_TTL", "15m")
+	defer os.Unsetenv("LLM_MODELS_TTL")
+
+	cfg := &Config{}
+	applyLLMOverrides(cfg)
+
+	if cfg.LLM.ModelsTTL != 15*time.Minute {
+		t.Errorf("LLM.ModelsTTL = %v, want 15m", cfg.LLM.ModelsTTL)
+	}
+}
+
+func TestApplyMessageLogOverrides(t *testing.T) {
+	os.Setenv("MESSAGE_LOG_RETENTION", "48h")
+	os.Setenv("MESSAGE_LOG_MAX_PER_CHAT", "-1")
+	defer func() {
+		os.Unsetenv("MESSAGE_LOG_RETENTION")
+		os.Unsetenv("MESSAGE_LOG_MAX_PER_CHAT")
+	}()
+
+	cfg := &Config{}
+	applyMessageLogOverrides(cfg)
+
+	if cfg.MessageLog.Retention != 48*time.Hour {
+		t.Errorf("MessageLog.Retention = %v, want 48h", cfg.MessageLog.Retention)
+	}
+	if cfg.MessageLog.MaxPerChat != defaultLogMaxPerChat {
+		t.Errorf("MessageLog.MaxPerChat = %d, want %d", cfg.MessageLog.MaxPerChat, defaultLogMaxPerChat)
+	}
+}
+
+func TestApplyModerationOverrides(t *testing.T) {
+	os.Setenv("MODERATION_ENABLED", "true")
+	os.Setenv("MODERATION_KEYWORDS", "casino, betting ,")
+	os.Setenv("MODERATION_FAIL_OPEN", "true")
+	defer func() {
+		os.Unsetenv("MODERATION_ENABLED")
+		os.Unsetenv("MODERATION_KEYWORDS")
+		os.Unsetenv("MODERATION_FAIL_OPEN")
+	}()
+
+	cfg := &Config{Moderation: ModerationConfig{Patterns: []string{`\bfoo\b`}, Classifier: true}}
+	applyModerationOverrides(cfg)
+
+	if !cfg.Moderation.Enabled {
+		t.Error("Moderation.Enabled = false, want true")
+	}
+	if len(cfg.Moderation.Keywords) != 2 || cfg.Moderation.Keywords[0] != "casino" || cfg.Moderation.Keywords[1] != "betting" {
+		t.Errorf("Moderation.Keywords = %q, want [casino betting]", cfg.Moderation.Keywords)
+	}
+	if !cfg.Moderation.FailOpen {
+		t.Error("Moderation.FailOpen = false, want true")
+	}
+	if !cfg.Moderation.Classifier || len(cfg.Moderation.Patterns) != 1 {
+		t.Errorf("Moderation = %+v, want YAML classifier and patterns kept", cfg.Moderation)
+	}
+}
+
+func TestApplyImageOverrides(t *testing.T) {
+	os.Setenv("IMAGE_BASE_URL", "https://api.openai.com/v1")
+	os.Setenv("IMAGE_MODEL", "dall-e-3")
+	os.Setenv("IMAGE_TIMEOUT", "soon")
+	defer func() {
+		os.Unsetenv("IMAGE_BASE_URL")
+		os.Unsetenv("IMAGE_MODEL")
+		os.Unsetenv("IMAGE_TIMEOUT")
+	}()
+
+	cfg := &Config{Image: ImageConfig{Providers: []ImageProviderConfig{{Name: "pollinations"}}}}
+	applyImageOverrides(cfg)
+
+	if cfg.Image.Timeout != defaultImageTimeout || cfg.Image.MaxAttempts != defaultImageAttempts {
+		t.Errorf("Image = %+v, want default timeout and attempts", cfg.Image)
+	}
+	want := ImageProviderConfig{Name: defaultImageName, BaseURL: "https://api.openai.com/v1", Model: "dall-e-3"}
+	if len(cfg.Image.Providers) != 2 || cfg.Image.Providers[0] != want || cfg.Image.Providers[1].Name != "pollinations" {
+		t.Errorf("Image.Providers = %+v, want %+v followed by pollinations", cfg.Image.Providers, want)
+	}
+}
+
+func TestLoadWithoutImageSection(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, defaultConfigPath), []byte("bot:\n  language: en\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	for _, baseURL := range []string{"", "https://api.openai.com/v1"} {
+		t.Setenv("IMAGE_BASE_URL", baseURL)
+
+		cfg := &Config{}
+		loadYAMLConfig(cfg)
+		applyEnvOverrides(cfg)
+
+		providers := cfg.Image.Providers
+		if len(providers) == 0 || providers[len(providers)-1].Name != defaultImageProvider {
+			t.Errorf("IMAGE_BASE_URL=%q: Image.Providers = %+v, want pollinations in the chain", baseURL, cfg.Image.Providers)
+		}
+	}
+}
+
+func TestApplyLurkerOverrides(t *testing.T) {
+	os.Setenv("LURKER_CHANCE", "150")
+	os.Setenv("LURKER_COOLDOWN", "2h")
+	defer func() {
+		os.Unsetenv("LURKER_CHANCE")
+		os.Unsetenv("LURKER_COOLDOWN")
+	}()
+
+	cfg := &Config{Lurker: LurkerConfig{Chance: 10}}
+	applyLurkerOverrides(cfg)
+
+	want := LurkerConfig{Chance: 10, Cooldown: 2 * time.Hour, Context: defaultLurkContext}
+	if cfg.Lurker != want {
+		t.Errorf("Lurker = %+v, want %+v (invalid chance ignored)", cfg.Lurker, want)
+	}
+}
+
+func TestApplyQuotaOverrides(t *testing.T) {
+	os.Setenv("QUOTA_CHAT_DAILY", "50000")
+	os.Setenv("QUOTA_USER_MONTHLY", "-5")
+	defer func() {
+		os.Unsetenv("QUOTA_CHAT_DAILY")
+		os.Unsetenv("QUOTA_USER_MONTHLY")
+	}()
+
+	cfg := &Config{Quotas: QuotaConfig{User: QuotaLimits{Monthly: 200000}}}
+	applyQuotaOverrides(cfg)
+
+	if cfg.Quotas.Chat.Daily != 50000 {
+		t.Errorf("Quotas.Chat.Daily = %d, want 50000", cfg.Quotas.Chat.Daily)
+	}
+	if cfg.Quotas.User.Monthly != 200000 {
+		t.Errorf("Quotas.User.Monthly = %d, want 200000 (invalid env ignored)", cfg.Quotas.User.Monthly)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"os"
+	"sort"
 )
 
 const (
@@ -20,6 +21,7 @@ const (
 	KeySubredditError     Key = "subreddit_error"
 	KeyGptUsage           Key = "gpt_usage"
 	KeyGptModelsHeader    Key = "gpt_models_header"
+	KeyGptModelsLegend    Key = "gpt_models_legend"
 	KeyGptCleared         Key = "gpt_cleared"
 	KeyGptError           Key = "gpt_error"
 	KeyGptNoKey           Key = "gpt_no_key"
@@ -80,16 +82,126 @@ const (
 	KeyTtsUsage Key = "tts_usage"
 	KeyTtsError Key = "tts_error"
 
+	KeyCmdTranscribe         Key = "cmd_transcribe"
+	KeyTranscribeUsage       Key = "transcribe_usage"
+	KeyTranscribeResult      Key = "transcribe_result"
+	KeyTranscribeEmpty       Key = "transcribe_empty"
+	KeyTranscribeError       Key = "transcribe_error"
+	KeyTranscribeUnavailable Key = "transcribe_unavailable"
+	KeyTranscribeTooLarge    Key = "transcribe_too_large"
+	KeyTranscribeAutoOn      Key = "transcribe_auto_on"
+	KeyTranscribeAutoOff     Key = "transcribe_auto_off"
+	KeyTranscribeAdminOnly   Key = "transcribe_admin_only"
+	KeyCmdTranslate          Key = "cmd_translate"
+	KeyTranslateUsage        Key = "translate_usage"
+	KeyTranslateResult       Key = "translate_result"
+	KeyTranslateSame         Key = "translate_same"
+	KeyTranslateError        Key = "translate_error"
+	KeyTranslateAutoOn       Key = "translate_auto_on"
+	KeyTranslateAutoOff      Key = "translate_auto_off"
+	KeyTranslateAdminOnly    Key = "translate_admin_only"
+
+	KeyCmdAssistant           Key = "cmd_assistant"
+	KeyAssistantUsage         Key = "assistant_usage"
+	KeyAssistantPrivateOnly   Key = "assistant_private_only"
+	KeyAssistantOn            Key = "assistant_on"
+	KeyAssistantOff           Key = "assistant_off"
+	KeyAssistantError         Key = "assistant_error"
+	KeyAssistantMenu          Key = "assistant_menu"
+	KeyAssistantStateOn       Key = "assistant_state_on"
+	KeyAssistantStateOff      Key = "assistant_state_off"
+	KeyAssistantButtonEnable  Key = "assistant_button_enable"
+	KeyAssistantButtonDisable Key = "assistant_button_disable"
+	KeyAssistantButtonModel   Key = "assistant_button_model"
+	KeyAssistantButtonPersona Key = "assistant_button_persona"
+	KeyAssistantButtonBack    Key = "assistant_button_back"
+
 	KeyGptImageUsage Key = "gpt_image_usage"
 	KeyGptImageError Key = "gpt_image_error"
 
-	KeyGptMemoryHeader  Key = "gpt_memory_header"
-	KeyGptMemoryStats   Key = "gpt_memory_stats"
-	KeyGptMemoryEmpty   Key = "gpt_memory_empty"
-	KeyGptMemoryNoRedis Key = "gpt_memory_no_redis"
-	KeyGptMemoryCaption Key = "gpt_memory_caption"
-	KeyGptModelSet      Key = "gpt_model_set"
-	KeyGptModelInvalid  Key = "gpt_model_invalid"
+	KeyGptMemoryHeader        Key = "gpt_memory_header"
+	KeyGptMemoryStats         Key = "gpt_memory_stats"
+	KeyGptMemoryEmpty         Key = "gpt_memory_empty"
+	KeyGptMemoryNoRedis       Key = "gpt_memory_no_redis"
+	KeyGptMemoryCaption       Key = "gpt_memory_caption"
+	KeyGptMemoryUsage         Key = "gpt_memory_usage"
+	KeyGptMemoryImportInvalid Key = "gpt_memory_import_invalid"
+	KeyGptMemoryImportError   Key = "gpt_memory_import_error"
+	KeyGptMemoryImported      Key = "gpt_memory_imported"
+	KeyGptThreadUnknown       Key = "gpt_thread_unknown"
+	KeyGptModelSet            Key = "gpt_model_set"
+	KeyGptModelInvalid        Key = "gpt_model_invalid"
+
+	KeyGptPersonaUsage      Key = "gpt_persona_usage"
+	KeyGptPersonaCurrent    Key = "gpt_persona_current"
+	KeyGptPersonaSet        Key = "gpt_persona_set"
+	KeyGptPersonaCustomSet  Key = "gpt_persona_custom_set"
+	KeyGptPersonaReset      Key = "gpt_persona_reset"
+	KeyGptPersonaListHeader Key = "gpt_persona_list_header"
+	KeyGptPersonaListItem   Key = "gpt_persona_list_item"
+	KeyGptPersonaTooLong    Key = "gpt_persona_too_long"
+	KeyGptPersonaError      Key = "gpt_persona_error"
+
+	KeyGptScopeCurrent      Key = "gpt_scope_current"
+	KeyGptScopeUsage        Key = "gpt_scope_usage"
+	KeyGptScopeSet          Key = "gpt_scope_set"
+	KeyGptScopeAdminOnly    Key = "gpt_scope_admin_only"
+	KeyGptScopeError        Key = "gpt_scope_error"
+	KeyGptClearedAll        Key = "gpt_cleared_all"
+	KeyGptClearAllAdminOnly Key = "gpt_clear_all_admin_only"
+
+	KeyGptSummarizeUsage    Key = "gpt_summarize_usage"
+	KeyGptSummarizeDisabled Key = "gpt_summarize_disabled"
+	KeyGptSummarizeEmpty    Key = "gpt_summarize_empty"
+	KeyGptSummarizeResult   Key = "gpt_summarize_result"
+	KeyGptLogOn             Key = "gpt_log_on"
+	KeyGptLogOff            Key = "gpt_log_off"
+	KeyGptLogAdminOnly      Key = "gpt_log_admin_only"
+	KeyGptLogError          Key = "gpt_log_error"
+	KeyGptLogUsage          Key = "gpt_log_usage"
+
+	KeyGptLurkUsage       Key = "gpt_lurk_usage"
+	KeyGptLurkOn          Key = "gpt_lurk_on"
+	KeyGptLurkOff         Key = "gpt_lurk_off"
+	KeyGptLurkSettings    Key = "gpt_lurk_settings"
+	KeyGptLurkQuiet       Key = "gpt_lurk_quiet"
+	KeyGptLurkQuietLeft   Key = "gpt_lurk_quiet_left"
+	KeyGptLurkAdminOnly   Key = "gpt_lurk_admin_only"
+	KeyGptLurkError       Key = "gpt_lurk_error"
+	KeyGptLurkUnavailable Key = "gpt_lurk_unavailable"
+
+	KeyGptErrorRateLimit    Key = "gpt_error_rate_limit"
+	KeyGptErrorOverloaded   Key = "gpt_error_overloaded"
+	KeyGptErrorContext      Key = "gpt_error_context"
+	KeyGptErrorAuth         Key = "gpt_error_auth"
+	KeyGptModerationBlocked Key = "gpt_moderation_blocked"
+	KeyGptModerationFlagged Key = "gpt_moderation_flagged"
+	KeyGptAnsweredBy        Key = "gpt_answered_by"
+
+	KeyGptButtonRegenerate   Key = "gpt_button_regenerate"
+	KeyGptButtonContinue     Key = "gpt_button_continue"
+	KeyGptButtonShorter      Key = "gpt_button_shorter"
+	KeyGptButtonModel        Key = "gpt_button_model"
+	KeyGptAnswerExpired      Key = "gpt_answer_expired"
+	KeyGptAnswerNotYours     Key = "gpt_answer_not_yours"
+	KeyGptAnswerNoOtherModel Key = "gpt_answer_no_other_model"
+	KeyGptAnswerBusy         Key = "gpt_answer_busy"
+
+	KeyGptQuotaUserDay    Key = "gpt_quota_user_day"
+	KeyGptQuotaUserMonth  Key = "gpt_quota_user_month"
+	KeyGptQuotaChatDay    Key = "gpt_quota_chat_day"
+	KeyGptQuotaChatMonth  Key = "gpt_quota_chat_month"
+	KeyGptTokensHeader    Key = "gpt_tokens_header"
+	KeyGptTokensChat      Key = "gpt_tokens_chat"
+	KeyGptTokensUser      Key = "gpt_tokens_user"
+	KeyGptTokensTopModels Key = "gpt_tokens_top_models"
+	KeyGptTokensTopUsers  Key = "gpt_tokens_top_users"
+	KeyGptTokensError     Key = "gpt_tokens_error"
+	KeyQuotaUnlimited     Key = "quota_unlimited"
+
+	KeyGptVisionUnavailable   Key = "gpt_vision_unavailable"
+	KeyGptVisionDownloadError Key = "gpt_vision_download_error"
+	KeyGptVisionTooLarge      Key = "gpt_vision_too_large"
 
 	KeyAdminUnauthorized Key = "admin_unauthorized"
 	KeyAdminUsage        Key = "admin_usage"
@@ -100,11 +212,51 @@ const (
 	KeyAdminNoPass       Key = "admin_no_pass"
 	KeyAdminDMOnly       Key = "admin_dm_only"
 
+	KeyAdminBanUsage         Key = "admin_ban_usage"
+	KeyAdminUnbanUsage       Key = "admin_unban_usage"
+	KeyAdminBanned           Key = "admin_banned"
+	KeyAdminUnbanned         Key = "admin_unbanned"
+	KeyAdminBanError         Key = "admin_ban_error"
+	KeyAdminBanSelf          Key = "admin_ban_self"
+	KeyAdminBansHeader       Key = "admin_bans_header"
+	KeyAdminBansEmpty        Key = "admin_bans_empty"
+	KeyAdminModerationHeader Key = "admin_moderation_header"
+	KeyAdminModerationEmpty  Key = "admin_moderation_empty"
+	KeyAdminModerationFormat Key = "admin_moderation_format"
+	KeyAdminModerationError  Key = "admin_moderation_error"
+	KeyAdminBanFormat        Key = "admin_ban_format"
+	KeyAdminBanPermanent     Key = "admin_ban_permanent"
+	KeyAdminBanUntil         Key = "admin_ban_until"
+	KeyAdminBanTargetUser    Key = "admin_ban_target_user"
+	KeyAdminBanTargetChat    Key = "admin_ban_target_chat"
+	KeyAdminQuotaUsage       Key = "admin_quota_usage"
+	KeyAdminQuotaShow        Key = "admin_quota_show"
+	KeyAdminQuotaDefault     Key = "admin_quota_default"
+	KeyAdminQuotaSet         Key = "admin_quota_set"
+	KeyAdminQuotaReset       Key = "admin_quota_reset"
+	KeyAdminQuotaError       Key = "admin_quota_error"
+
 	KeyCmdLang     Key = "cmd_lang"
 	KeyLangUsage   Key = "lang_usage"
 	KeyLangSet     Key = "lang_set"
 	KeyLangCurrent Key = "lang_current"
 	KeyLangList    Key = "lang_list"
+
+	KeyCmdUsage          Key = "cmd_usage"
+	KeyCmdAdmin          Key = "cmd_admin"
+	KeyUsageUsage        Key = "usage_usage"
+	KeyUsageHeader       Key = "usage_header"
+	KeyUsageGlobalHeader Key = "usage_global_header"
+	KeyUsageTopCommands  Key = "usage_top_commands"
+	KeyUsageTopUsers     Key = "usage_top_users"
+	KeyUsageTopChats     Key = "usage_top_chats"
+	KeyUsageItem         Key = "usage_item"
+	KeyUsageEmpty        Key = "usage_empty"
+	KeyUsageError        Key = "usage_error"
+	KeyUsagePeriodDay    Key = "usage_period_day"
+	KeyUsagePeriodWeek   Key = "usage_period_week"
+	KeyUsagePeriodMonth  Key = "usage_period_month"
+	KeyUsagePeriodAll    Key = "usage_period_all"
 )
 
 type Key string
@@ -153,6 +305,16 @@ func (t *Translator) Lang() string {
 	return t.lang
 }
 
+func Languages() []string {
+	all := loadTranslationsFile(defaultFilePath)
+	langs := make([]string, 0, len(all))
+	for lang := range all {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
 func loadTranslationsFile(path string) map[string]map[string]string {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -243,6 +243,20 @@ func TestTranslatorAllKeys(t *testing.T) {
 	}
 }
 
+func TestLanguages(t *testing.T) {
+	setupTestTranslations(t)
+
+	got := Languages()
+	want := []string{"en", "ja", "lt", "ru"}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		assertEqual(t, got[i], want[i])
+	}
+}
+
 func getAllKeys() []Key {
 	return []Key{
 		KeyWelcome,
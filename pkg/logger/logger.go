@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	PackageKey       = "package"
+	CorrelationIDKey = "correlation_id"
+
+	correlationIDBytes = 8
+)
+
+type Options struct {
+	Format   string
+	Level    string
+	Packages map[string]string
+}
+
+type contextKey struct{}
+
+type contextFields struct {
+	correlationID string
+	attrs         []slog.Attr
+}
+
+type handler struct {
+	inner    slog.Handler
+	level    slog.Level
+	packages map[string]slog.Level
+	pkg      string
+}
+
+type packageHandler struct {
+	pkg      string
+	ops      []func(slog.Handler) slog.Handler
+	resolved atomic.Pointer[resolvedHandler]
+}
+
+type resolvedHandler struct {
+	base    slog.Handler
+	handler slog.Handler
+}
+
+func Setup(opts Options) {
+	slog.SetDefault(slog.New(NewHandler(os.Stdout, opts)))
+}
+
+func NewHandler(w io.Writer, opts Options) slog.Handler {
+	level := parseLevel(opts.Level, slog.LevelInfo)
+	minLevel := level
+
+	packages := make(map[string]slog.Level, len(opts.Packages))
+	for pkg, lvl := range opts.Packages {
+		packages[pkg] = parseLevel(lvl, level)
+		if packages[pkg] < minLevel {
+			minLevel = packages[pkg]
+		}
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: minLevel}
+	var inner slog.Handler
+	if strings.ToLower(opts.Format) == FormatJSON {
+		inner = slog.NewJSONHandler(w, handlerOpts)
+	} else {
+		inner = slog.NewTextHandler(w, handlerOpts)
+	}
+
+	return &handler{
+		inner:    inner,
+		level:    level,
+		packages: packages,
+	}
+}
+
+func For(pkg string) *slog.Logger {
+	return slog.New(&packageHandler{pkg: pkg})
+}
+
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	fields := fieldsFrom(ctx)
+	fields.correlationID = id
+	return context.WithValue(ctx, contextKey{}, fields)
+}
+
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	fields := fieldsFrom(ctx)
+	fields.attrs = append(append([]slog.Attr{}, fields.attrs...), attrs...)
+	return context.WithValue(ctx, contextKey{}, fields)
+}
+
+func CorrelationID(ctx context.Context) string {
+	return fieldsFrom(ctx).correlationID
+}
+
+func NewCorrelationID() string {
+	buf := make([]byte, correlationIDBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	if lvl, ok := h.packages[h.pkg]; ok {
+		return level >= lvl
+	}
+	return level >= h.level
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	fields := fieldsFrom(ctx)
+	if fields.correlationID != "" {
+		r.AddAttrs(slog.String(CorrelationIDKey, fields.correlationID))
+	}
+	if len(fields.attrs) > 0 {
+		r.AddAttrs(fields.attrs...)
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	for _, attr := range attrs {
+		if attr.Key == PackageKey {
+			clone.pkg = attr.Value.String()
+		}
+	}
+	clone.inner = h.inner.WithAttrs(attrs)
+	return &clone
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.inner = h.inner.WithGroup(name)
+	return &clone
+}
+
+func (h *packageHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.resolve().Enabled(ctx, level)
+}
+
+func (h *packageHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.resolve().Handle(ctx, r)
+}
+
+func (h *packageHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithAttrs(attrs) })
+}
+
+func (h *packageHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(inner slog.Handler) slog.Handler { return inner.WithGroup(name) })
+}
+
+func (h *packageHandler) with(op func(slog.Handler) slog.Handler) slog.Handler {
+	ops := append(append([]func(slog.Handler) slog.Handler{}, h.ops...), op)
+	return &packageHandler{pkg: h.pkg, ops: ops}
+}
+
+func (h *packageHandler) resolve() slog.Handler {
+	base := slog.Default().Handler()
+	if cached := h.resolved.Load(); cached != nil && sameHandler(cached.base, base) {
+		return cached.handler
+	}
+
+	inner := base.WithAttrs([]slog.Attr{slog.String(PackageKey, h.pkg)})
+	for _, op := range h.ops {
+		inner = op(inner)
+	}
+	h.resolved.Store(&resolvedHandler{base: base, handler: inner})
+	return inner
+}
+
+func sameHandler(a, b slog.Handler) bool {
+	return reflect.TypeOf(a).Comparable() && a == b
+}
+
+func fieldsFrom(ctx context.Context) contextFields {
+	if ctx == nil {
+		return contextFields{}
+	}
+	if fields, ok := ctx.Value(contextKey{}).(contextFields); ok {
+		return fields
+	}
+	return contextFields{}
+}
+
+func parseLevel(value string, fallback slog.Level) slog.Level {
+	if value == "" {
+		return fallback
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return fallback
+	}
+	return level
+}
@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestHandlerAddsCorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewHandler(&buf, Options{Format: FormatJSON, Level: "info"}))
+
+	ctx := WithCorrelationID(context.Background(), "abc123")
+	ctx = WithAttrs(ctx, slog.Int64("chat_id", 42))
+	log.InfoContext(ctx, "hello")
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("failed to decode log entry: %v (%s)", err, buf.String())
+	}
+	if entry[CorrelationIDKey] != "abc123" {
+		t.Errorf("correlation_id = %v, want %q", entry[CorrelationIDKey], "abc123")
+	}
+	if entry["chat_id"] != float64(42) {
+		t.Errorf("chat_id = %v, want 42", entry["chat_id"])
+	}
+}
+
+func TestHandlerFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{"JSON", "json", `"msg":"hello"`},
+		{"JSONUppercase", "JSON", `"msg":"hello"`},
+		{"Text", "text", "msg=hello"},
+		{"DefaultText", "", "msg=hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(NewHandler(&buf, Options{Format: tt.format})).Info("hello")
+
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("output %q should contain %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerPackageLevels(t *testing.T) {
+	var buf bytes.Buffer
+	handler := NewHandler(&buf, Options{
+		Level:    "warn",
+		Packages: map[string]string{"groq": "debug", "telegram": "error"},
+	})
+	root := slog.New(handler)
+
+	tests := []struct {
+		name   string
+		log    *slog.Logger
+		level  slog.Level
+		logged bool
+	}{
+		{"RootBelowLevel", root, slog.LevelInfo, false},
+		{"RootAtLevel", root, slog.LevelWarn, true},
+		{"PackageDebugEnabled", root.With(PackageKey, "groq"), slog.LevelDebug, true},
+		{"PackageWarnSuppressed", root.With(PackageKey, "telegram"), slog.LevelWarn, false},
+		{"PackageErrorEnabled", root.With(PackageKey, "telegram"), slog.LevelError, true},
+		{"UnknownPackageUsesDefault", root.With(PackageKey, "tts"), slog.LevelInfo, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			tt.log.Log(context.Background(), tt.level, "message")
+
+			if got := buf.Len() > 0; got != tt.logged {
+				t.Errorf("logged = %v, want %v (%s)", got, tt.logged, buf.String())
+			}
+		})
+	}
+}
+
+func TestForResolvesDefaultLazily(t *testing.T) {
+	log := For("groq")
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(NewHandler(&buf, Options{Format: FormatJSON, Level: "error", Packages: map[string]string{"groq": "debug"}})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	log.With("model", "llama").DebugContext(WithCorrelationID(context.Background(), "id1"), "request")
+
+	out := buf.String()
+	for _, want := range []string{`"package":"groq"`, `"model":"llama"`, `"correlation_id":"id1"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q should contain %s", out, want)
+		}
+	}
+}
+
+func TestForCachesUntilDefaultChanges(t *testing.T) {
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var first, second bytes.Buffer
+	slog.SetDefault(slog.New(NewHandler(&first, Options{Format: FormatJSON})))
+	h := &packageHandler{pkg: "telegram"}
+	if h.resolve() != h.resolve() {
+		t.Error("resolve() should reuse the handler while the default is unchanged")
+	}
+
+	slog.SetDefault(slog.New(NewHandler(&second, Options{Format: FormatJSON, Level: "warn"})))
+	log := slog.New(h)
+	log.Info("hidden")
+	log.Warn("shown")
+
+	if first.Len() != 0 || strings.Contains(second.String(), "hidden") || !strings.Contains(second.String(), "shown") {
+		t.Errorf("after SetDefault got first %q, second %q, want only the warning in the new handler", first.String(), second.String())
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	if got := CorrelationID(context.Background()); got != "" {
+		t.Errorf("CorrelationID() = %q, want empty", got)
+	}
+
+	ctx := WithCorrelationID(context.Background(), "xyz")
+	ctx = WithAttrs(ctx, slog.String("k", "v"))
+	if got := CorrelationID(ctx); got != "xyz" {
+		t.Errorf("CorrelationID() = %q, want %q", got, "xyz")
+	}
+}
+
+func TestNewCorrelationID(t *testing.T) {
+	a, b := NewCorrelationID(), NewCorrelationID()
+	if len(a) != correlationIDBytes*2 {
+		t.Errorf("len = %d, want %d", len(a), correlationIDBytes*2)
+	}
+	if a == b {
+		t.Error("correlation IDs should be unique")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		value string
+		want  slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"error", slog.LevelError},
+		{"", slog.LevelInfo},
+		{"bogus", slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseLevel(tt.value, slog.LevelInfo); got != tt.want {
+				t.Errorf("parseLevel(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}